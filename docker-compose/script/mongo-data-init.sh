@@ -55,6 +55,90 @@ db.getCollection("config_check_states").createIndex({
     unique: true
 });
 
+// jwt 签名密钥
+db.createCollection("jwt_keys");
+db.getCollection("jwt_keys").createIndex({
+    kid: NumberInt("1")
+}, {
+    name: "unique_kid",
+    unique: true
+});
+
+// two_factor_configs，后台用户的两步验证配置
+db.createCollection("two_factor_configs");
+db.getCollection("two_factor_configs").createIndex({
+    user_id: NumberInt("1")
+}, {
+    name: "unique_user_id",
+    unique: true
+});
+
+// 后台用户，首次登录时根据 admin 配置自动创建站长
+db.createCollection("admin_users");
+db.getCollection("admin_users").createIndex({
+    username: NumberInt("1")
+}, {
+    name: "unique_username",
+    unique: true
+});
+
+// 后台登录会话，过期后自动删除
+db.createCollection("admin_sessions");
+db.getCollection("admin_sessions").createIndex({
+    user_id: NumberInt("1")
+}, {
+    name: "user_id"
+});
+db.getCollection("admin_sessions").createIndex({
+    expires_at: NumberInt("1")
+}, {
+    name: "ttl_expires_at",
+    expireAfterSeconds: 0
+});
+
+// 登录记录，保留 90 天
+db.createCollection("login_attempts");
+db.getCollection("login_attempts").createIndex({
+    created_at: NumberInt("-1")
+}, {
+    name: "ttl_created_at",
+    expireAfterSeconds: 7776000
+});
+db.getCollection("login_attempts").createIndex({
+    username: NumberInt("1"),
+    created_at: NumberInt("-1")
+}, {
+    name: "username_created_at"
+});
+db.getCollection("login_attempts").createIndex({
+    ip: NumberInt("1"),
+    created_at: NumberInt("-1")
+}, {
+    name: "ip_created_at"
+});
+
+db.createCollection("login_throttles");
+db.getCollection("login_throttles").createIndex({
+    expires_at: NumberInt("1")
+}, {
+    name: "ttl_expires_at",
+    expireAfterSeconds: 0
+});
+
+// 后台操作审计日志，过期记录由服务端按 audit_log.retention 清理
+db.createCollection("audit_logs");
+db.getCollection("audit_logs").createIndex({
+    created_at: NumberInt("-1")
+}, {
+    name: "created_at"
+});
+db.getCollection("audit_logs").createIndex({
+    user_id: NumberInt("1"),
+    created_at: NumberInt("-1")
+}, {
+    name: "user_id_created_at"
+});
+
 // 站点信息
 db.getCollection("configs").insertOne({
   "created_at": new Date(),
@@ -174,6 +258,16 @@ db.getCollection("configs").insertOne({
     created_at: new Date(),
     updated_at: new Date()
 });
+// 订阅源配置
+db.getCollection("configs").insertOne({
+    typ: "feed",
+    "props": {
+        "full_content": false,
+        "count": 20
+    },
+    created_at: new Date(),
+    updated_at: new Date()
+});
 // 支付二维码配置
 db.getCollection("configs").insertOne({
     typ: "pay",
@@ -229,7 +323,8 @@ db.getCollection("message_templates").createIndex({
 db.getCollection("message_templates").insertOne({
     name: "comment",
     title: "文章评论通知",
-    content: "您好，您在文章有新的评论，详情请前往后台进行查看。",
+    content: "您好，{{.CommenterName}} 在文章《{{.PostTitle}}》中发表了新的评论：\n\n{{.CommentContent}}\n\n详情请前往后台进行查看。",
+    html_content: '<p>您好，{{.CommenterName}} 在文章《<a href="{{.PostUrl}}">{{.PostTitle}}</a>》中发表了新的评论：</p><blockquote>{{.CommentContent}}</blockquote><p>详情请前往后台进行查看。</p>',
     created_at: new Date(),
     updated_at: new Date(),
     recipient_type: 0,
@@ -239,7 +334,8 @@ db.getCollection("message_templates").insertOne({
 db.getCollection("message_templates").insertOne({
     name: "user-comment-approval",
     title: "评论审核通过通知",
-    content: "您好，您在 %s 文章中发表的评论已通过审核。",
+    content: "您好，{{.RecipientName}}，您在文章《{{.PostTitle}}》中发表的评论已通过审核：{{.PostUrl}}",
+    html_content: '<p>您好，{{.RecipientName}}，您在文章《<a href="{{.PostUrl}}">{{.PostTitle}}</a>》中发表的评论已通过审核。</p>',
     created_at: new Date(),
     updated_at: new Date(),
     recipient_type: 1,
@@ -249,7 +345,8 @@ db.getCollection("message_templates").insertOne({
 db.getCollection("message_templates").insertOne({
     name: "user-comment-disapproval",
     title: "评论被驳回通知",
-    content: "您好，您在 %s 文章中发表的评论未通过审核，原因：%s",
+    content: "您好，{{.RecipientName}}，您在文章《{{.PostTitle}}》中发表的评论未通过审核，原因：{{.Reason}}",
+    html_content: '<p>您好，{{.RecipientName}}，您在文章《<a href="{{.PostUrl}}">{{.PostTitle}}</a>》中发表的评论未通过审核，原因：{{.Reason}}</p>',
     created_at: new Date(),
     updated_at: new Date(),
     recipient_type: 1,
@@ -259,7 +356,8 @@ db.getCollection("message_templates").insertOne({
 db.getCollection("message_templates").insertOne({
     name: "user-comment-reply",
     title: "评论被回复通知",
-    content: "您好，您在 %s 文章中发表的评论有新的回复。",
+    content: "您好，{{.RecipientName}}，{{.CommenterName}} 回复了您在文章《{{.PostTitle}}》中发表的评论：\n\n{{.CommentContent}}\n\n查看回复：{{.PostUrl}}\n\n不想再收到此类邮件？点击退订：{{.UnsubscribeUrl}}",
+    html_content: '<p>您好，{{.RecipientName}}，{{.CommenterName}} 回复了您在文章《<a href="{{.PostUrl}}">{{.PostTitle}}</a>》中发表的评论：</p><blockquote>{{.CommentContent}}</blockquote><p style="font-size:12px;color:#999">不想再收到此类邮件？<a href="{{.UnsubscribeUrl}}">退订</a></p>',
     created_at: new Date(),
     updated_at: new Date(),
     recipient_type: 1,
@@ -269,7 +367,8 @@ db.getCollection("message_templates").insertOne({
 db.getCollection("message_templates").insertOne({
     name: "friend",
     title: "友链申请通知",
-    content: "您好，您的网站有了新的友链申请，详情可前往后台查看。",
+    content: "您好，{{.FriendName}}（{{.FriendUrl}}）申请了友链，详情可前往后台查看。",
+    html_content: '<p>您好，<a href="{{.FriendUrl}}">{{.FriendName}}</a> 申请了友链，详情可前往后台查看。</p>',
     created_at: new Date(),
     updated_at: new Date(),
     active: 1,
@@ -279,7 +378,8 @@ db.getCollection("message_templates").insertOne({
 db.getCollection("message_templates").insertOne({
     name: "friend-approval",
     title: "友链申请通过通知",
-    content: "您好，您在 %s 网站里提交的友链申请已通过审核并展示在页面上。",
+    content: "您好，您在 {{.SiteName}} 提交的友链申请已通过审核并展示在页面上：{{.SiteUrl}}/friend",
+    html_content: '<p>您好，您在 <a href="{{.SiteUrl}}/friend">{{.SiteName}}</a> 提交的友链申请已通过审核并展示在页面上。</p>',
     created_at: new Date(),
     updated_at: new Date(),
     recipient_type: 1,
@@ -289,13 +389,61 @@ db.getCollection("message_templates").insertOne({
 db.getCollection("message_templates").insertOne({
     name: "friend-rejection",
     title: "友链申请不通过通知",
-    content: "您好，您在 %s 网站里提交的友链申请未通过审核，原因：%s",
+    content: "您好，您在 {{.SiteName}} 提交的友链申请未通过审核，原因：{{.Reason}}",
+    html_content: '<p>您好，您在 <a href="{{.SiteUrl}}/friend">{{.SiteName}}</a> 提交的友链申请未通过审核，原因：{{.Reason}}</p>',
     created_at: new Date(),
     updated_at: new Date(),
     recipient_type: 1,
     active: 1
 });
 
+// email_suppressions，退订了邮件通知的邮箱，_id 为小写的邮箱地址
+db.createCollection("email_suppressions");
+
+// unsubscribe_tokens，邮箱的退订令牌，_id 为随机令牌
+db.createCollection("unsubscribe_tokens");
+db.getCollection("unsubscribe_tokens").createIndex({
+    email: NumberInt("1")
+}, {
+    name: "unique_email",
+    unique: true
+});
+
+// email_outbox，待发送和已发送的邮件
+db.createCollection("email_outbox");
+db.getCollection("email_outbox").createIndex({
+    status: NumberInt("1"),
+    lease_until: NumberInt("1")
+}, {
+    name: "status_lease_until"
+});
+db.getCollection("email_outbox").createIndex({
+    created_at: NumberInt("-1")
+}, {
+    name: "created_at"
+});
+
+// notification_channels，站长通知的推送渠道（webhook、Telegram、钉钉、飞书）
+db.createCollection("notification_channels");
+
+// webhooks，向外部推送站点事件的 webhook 订阅
+db.createCollection("webhooks");
+
+// webhook_deliveries，webhook 的推送记录
+db.createCollection("webhook_deliveries");
+db.getCollection("webhook_deliveries").createIndex({
+    status: NumberInt("1"),
+    lease_until: NumberInt("1")
+}, {
+    name: "status_lease_until"
+});
+db.getCollection("webhook_deliveries").createIndex({
+    webhook_id: NumberInt("1"),
+    created_at: NumberInt("-1")
+}, {
+    name: "webhook_id_created_at"
+});
+
 // posts
 db.createCollection("posts");
 // 创建 created_at 降序索引
@@ -309,6 +457,26 @@ db.getCollection("posts").createIndex({ "tags": 1 });
 // 创建 title 文本索引
 db.getCollection("posts").createIndex({ "title": "text" });
 
+// post_revisions，文章每次发布时保存的修订
+db.createCollection("post_revisions");
+db.getCollection("post_revisions").createIndex({
+    post_id: NumberInt("1"),
+    version: NumberInt("-1")
+}, {
+    name: "unique_post_id_version",
+    unique: true
+});
+
+// post_draft，scheduled_at 只在设置了定时发布的草稿上存在
+db.createCollection("post_draft");
+db.getCollection("post_draft").createIndex({
+    scheduled_at: NumberInt("1"),
+    publish_lease_until: NumberInt("1")
+}, {
+    name: "scheduled_at_publish_lease_until",
+    sparse: true
+});
+
 // visit_logs
 db.createCollection("visit_logs");
 // 创建 created_at 降序索引
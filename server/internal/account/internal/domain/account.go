@@ -0,0 +1,49 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+type Role string
+
+const (
+	// RoleOwner 站长，拥有所有权限
+	RoleOwner Role = "owner"
+	// RoleEditor 编辑，管理文章、草稿、分类、标签和素材
+	RoleEditor Role = "editor"
+	// RoleModerator 审核员，只能管理评论
+	RoleModerator Role = "moderator"
+	// RoleViewer 访客，只读
+	RoleViewer Role = "viewer"
+)
+
+var Roles = []Role{RoleOwner, RoleEditor, RoleModerator, RoleViewer}
+
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+type AdminUser struct {
+	Id          string
+	Username    string
+	Password    string
+	Nickname    string
+	Role        Role
+	Disabled    bool
+	LastLoginAt int64
+	// TwoFactorEnabled 是否开启了两步验证，两步验证的配置由 website_config 模块维护
+	TwoFactorEnabled bool
+	CreatedAt        int64
+	UpdatedAt        int64
+}
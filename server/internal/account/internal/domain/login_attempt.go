@@ -0,0 +1,63 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+type LoginResult string
+
+const (
+	LoginResultSuccess            LoginResult = "success"
+	LoginResultInvalidCredentials LoginResult = "invalid_credentials"
+	LoginResultDisabled           LoginResult = "disabled"
+	LoginResultThrottled          LoginResult = "throttled"
+	// LoginResultTwoFactorRequired 密码正确，等待两步验证
+	LoginResultTwoFactorRequired LoginResult = "two_factor_required"
+	LoginResultTwoFactorFailed   LoginResult = "two_factor_failed"
+)
+
+// IsFailure 是否计入限流的失败次数
+func (r LoginResult) IsFailure() bool {
+	return r == LoginResultInvalidCredentials || r == LoginResultTwoFactorFailed
+}
+
+// LoginClient 发起登录的客户端信息
+type LoginClient struct {
+	Ip        string
+	UserAgent string
+}
+
+// LoginAttempt 登录记录
+type LoginAttempt struct {
+	Id        string
+	Username  string
+	Ip        string
+	UserAgent string
+	Result    LoginResult
+	CreatedAt int64
+}
+
+type LoginAttemptFilter struct {
+	Username  string
+	Ip        string
+	Result    LoginResult
+	StartTime int64
+	EndTime   int64
+}
+
+// LoginThrottle 某个 ip 或用户名的连续登录失败情况
+type LoginThrottle struct {
+	Key          string
+	Failures     int
+	LastFailedAt int64
+}
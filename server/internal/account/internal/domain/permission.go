@@ -0,0 +1,156 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import (
+	"net/http"
+	"strings"
+)
+
+type Resource string
+
+const (
+	// ResourcePost 文章、草稿、分类、标签和索引推送
+	ResourcePost Resource = "post"
+	// ResourceAsset 文件和素材
+	ResourceAsset        Resource = "asset"
+	ResourceComment      Resource = "comment"
+	ResourceFriend       Resource = "friend"
+	ResourceDataAnalysis Resource = "data_analysis"
+	ResourceConfig       Resource = "config"
+	// ResourceSensitiveConfig 邮件、jwt 密钥等敏感配置
+	ResourceSensitiveConfig Resource = "sensitive_config"
+	// ResourceBackup 备份与恢复
+	ResourceBackup Resource = "backup"
+	// ResourceUser 后台用户管理
+	ResourceUser Resource = "user"
+	// ResourceAccount 当前登录用户自身的信息，所有角色均可访问
+	ResourceAccount Resource = "account"
+	// ResourceAuditLog 审计日志
+	ResourceAuditLog Resource = "audit_log"
+)
+
+type Access int
+
+const (
+	AccessNone Access = iota
+	AccessRead
+	AccessWrite
+)
+
+type routeResource struct {
+	prefix   string
+	resource Resource
+}
+
+// routeResources 路由前缀与资源的对应关系，按前缀从长到短匹配，新增 /admin-api 路由组时需要在此登记，
+// 未登记的路由只有站长可以访问
+var routeResources = []routeResource{
+	{prefix: "/admin-api/configs/email", resource: ResourceSensitiveConfig},
+	{prefix: "/admin-api/configs/akismet", resource: ResourceSensitiveConfig},
+	{prefix: "/admin-api/configs/jwt", resource: ResourceSensitiveConfig},
+	{prefix: "/admin-api/configs", resource: ResourceConfig},
+	{prefix: "/admin-api/posts", resource: ResourcePost},
+	{prefix: "/admin-api/post-draft", resource: ResourcePost},
+	{prefix: "/admin-api/post-index", resource: ResourcePost},
+	{prefix: "/admin-api/categories", resource: ResourcePost},
+	{prefix: "/admin-api/tags", resource: ResourcePost},
+	{prefix: "/admin-api/files", resource: ResourceAsset},
+	{prefix: "/admin-api/assets", resource: ResourceAsset},
+	{prefix: "/admin-api/comments", resource: ResourceComment},
+	{prefix: "/admin-api/friends", resource: ResourceFriend},
+	{prefix: "/admin-api/data-analysis", resource: ResourceDataAnalysis},
+	{prefix: "/admin-api/backup", resource: ResourceBackup},
+	{prefix: "/admin-api/recovery", resource: ResourceBackup},
+	{prefix: "/admin-api/users", resource: ResourceUser},
+	{prefix: "/admin-api/audit-logs", resource: ResourceAuditLog},
+	{prefix: "/admin-api/account", resource: ResourceAccount},
+	{prefix: "/admin-api/logout", resource: ResourceAccount},
+}
+
+var rolePermissions = map[Role]map[Resource]Access{
+	RoleOwner: {
+		ResourcePost:            AccessWrite,
+		ResourceAsset:           AccessWrite,
+		ResourceComment:         AccessWrite,
+		ResourceFriend:          AccessWrite,
+		ResourceDataAnalysis:    AccessWrite,
+		ResourceConfig:          AccessWrite,
+		ResourceSensitiveConfig: AccessWrite,
+		ResourceBackup:          AccessWrite,
+		ResourceUser:            AccessWrite,
+		ResourceAccount:         AccessWrite,
+		ResourceAuditLog:        AccessWrite,
+	},
+	RoleEditor: {
+		ResourcePost:         AccessWrite,
+		ResourceAsset:        AccessWrite,
+		ResourceComment:      AccessRead,
+		ResourceFriend:       AccessRead,
+		ResourceDataAnalysis: AccessRead,
+		ResourceConfig:       AccessRead,
+		ResourceAccount:      AccessWrite,
+	},
+	RoleModerator: {
+		ResourceComment: AccessWrite,
+		ResourceAccount: AccessWrite,
+	},
+	RoleViewer: {
+		ResourcePost:         AccessRead,
+		ResourceAsset:        AccessRead,
+		ResourceComment:      AccessRead,
+		ResourceFriend:       AccessRead,
+		ResourceDataAnalysis: AccessRead,
+		ResourceConfig:       AccessRead,
+		ResourceAccount:      AccessWrite,
+	},
+}
+
+// ResolveResource 根据请求路径找到对应的资源
+func ResolveResource(path string) (Resource, bool) {
+	for _, rr := range routeResources {
+		if path == rr.prefix || strings.HasPrefix(path, rr.prefix+"/") {
+			return rr.resource, true
+		}
+	}
+	return "", false
+}
+
+// RequiredAccess 根据请求方法判断所需的权限，GET 和 HEAD 为只读，其余均为写
+func RequiredAccess(method string) Access {
+	if method == http.MethodGet || method == http.MethodHead {
+		return AccessRead
+	}
+	return AccessWrite
+}
+
+// Can 判断角色是否有权限以 access 访问 resource
+func (r Role) Can(resource Resource, access Access) bool {
+	return rolePermissions[r][resource] >= access
+}
+
+// CanAccessRoute 判断角色是否有权限访问指定路由，未登记的路由只有站长可以访问
+func (r Role) CanAccessRoute(method, path string) bool {
+	resource, ok := ResolveResource(path)
+	if !ok {
+		return r == RoleOwner
+	}
+	return r.Can(resource, RequiredAccess(method))
+}
+
+// Permissions 返回角色对各资源的权限
+func (r Role) Permissions() map[Resource]Access {
+	return rolePermissions[r]
+}
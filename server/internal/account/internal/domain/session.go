@@ -0,0 +1,89 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import "strings"
+
+// Session 后台登录会话，每次登录创建一个会话，刷新令牌轮换时沿用同一个会话
+type Session struct {
+	Id               string
+	UserId           string
+	RefreshTokenHash string
+	Device           string
+	UserAgent        string
+	Ip               string
+	ExpiresAt        int64
+	LastActiveAt     int64
+	RevokedAt        int64
+	CreatedAt        int64
+}
+
+func (s *Session) IsActive(now int64) bool {
+	return s.RevokedAt == 0 && s.ExpiresAt > now
+}
+
+type SessionFilter struct {
+	UserId string
+	Ip     string
+	Device string
+}
+
+// TokenPair 访问令牌和刷新令牌，刷新令牌的格式为 {会话 id}.{随机串}
+type TokenPair struct {
+	SessionId         string
+	AccessToken       string
+	AccessExpiration  int64
+	RefreshToken      string
+	RefreshExpiration int64
+}
+
+var (
+	browserKeywords = []struct{ keyword, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	osKeywords = []struct{ keyword, name string }{
+		{"Windows", "Windows"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// DeviceOf 根据 User-Agent 生成简短的设备描述，例如 Chrome on Windows
+func DeviceOf(userAgent string) string {
+	browser, os := "Unknown", "Unknown"
+	for _, kw := range browserKeywords {
+		if strings.Contains(userAgent, kw.keyword) {
+			browser = kw.name
+			break
+		}
+	}
+	for _, kw := range osKeywords {
+		if strings.Contains(userAgent, kw.keyword) {
+			os = kw.name
+			break
+		}
+	}
+	if browser == "Unknown" && os == "Unknown" {
+		return "Unknown"
+	}
+	return browser + " on " + os
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/repository/dao"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type IAdminUserRepository interface {
+	ISessionRepository
+	ILoginAttemptRepository
+	FindAll(ctx context.Context) ([]domain.AdminUser, error)
+	FindById(ctx context.Context, id string) (*domain.AdminUser, error)
+	FindByUsername(ctx context.Context, username string) (*domain.AdminUser, error)
+	Count(ctx context.Context) (int64, error)
+	CountEnabledOwners(ctx context.Context) (int64, error)
+	Create(ctx context.Context, user domain.AdminUser) (string, error)
+	Update(ctx context.Context, user domain.AdminUser) error
+	UpdatePassword(ctx context.Context, id string, password string) error
+	UpdateLastLoginAt(ctx context.Context, id string, lastLoginAt time.Time) error
+	DeleteById(ctx context.Context, id string) error
+}
+
+var _ IAdminUserRepository = (*AdminUserRepository)(nil)
+
+func NewAdminUserRepository(dao dao.IAdminUserDao) *AdminUserRepository {
+	return &AdminUserRepository{dao: dao}
+}
+
+type AdminUserRepository struct {
+	dao dao.IAdminUserDao
+}
+
+func (r *AdminUserRepository) FindAll(ctx context.Context) ([]domain.AdminUser, error) {
+	users, err := r.dao.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]domain.AdminUser, 0, len(users))
+	for _, user := range users {
+		result = append(result, r.toDomain(user))
+	}
+	return result, nil
+}
+
+func (r *AdminUserRepository) FindById(ctx context.Context, id string) (*domain.AdminUser, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := r.dao.FindById(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	result := r.toDomain(user)
+	return &result, nil
+}
+
+func (r *AdminUserRepository) FindByUsername(ctx context.Context, username string) (*domain.AdminUser, error) {
+	user, err := r.dao.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	result := r.toDomain(user)
+	return &result, nil
+}
+
+func (r *AdminUserRepository) Count(ctx context.Context) (int64, error) {
+	return r.dao.Count(ctx)
+}
+
+func (r *AdminUserRepository) CountEnabledOwners(ctx context.Context) (int64, error) {
+	return r.dao.CountEnabledByRole(ctx, string(domain.RoleOwner))
+}
+
+func (r *AdminUserRepository) Create(ctx context.Context, user domain.AdminUser) (string, error) {
+	return r.dao.Create(ctx, &dao.AdminUser{
+		Username: user.Username,
+		Password: user.Password,
+		Nickname: user.Nickname,
+		Role:     string(user.Role),
+		Disabled: user.Disabled,
+	})
+}
+
+func (r *AdminUserRepository) Update(ctx context.Context, user domain.AdminUser) error {
+	objectID, err := bson.ObjectIDFromHex(user.Id)
+	if err != nil {
+		return err
+	}
+	return r.dao.UpdateById(ctx, objectID, user.Nickname, string(user.Role), user.Disabled)
+}
+
+func (r *AdminUserRepository) UpdatePassword(ctx context.Context, id string, password string) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	return r.dao.UpdatePasswordById(ctx, objectID, password)
+}
+
+func (r *AdminUserRepository) UpdateLastLoginAt(ctx context.Context, id string, lastLoginAt time.Time) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	return r.dao.UpdateLastLoginAtById(ctx, objectID, lastLoginAt)
+}
+
+func (r *AdminUserRepository) DeleteById(ctx context.Context, id string) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	return r.dao.DeleteById(ctx, objectID)
+}
+
+func (r *AdminUserRepository) toDomain(user *dao.AdminUser) domain.AdminUser {
+	result := domain.AdminUser{
+		Id:        user.ID.Hex(),
+		Username:  user.Username,
+		Password:  user.Password,
+		Nickname:  user.Nickname,
+		Role:      domain.Role(user.Role),
+		Disabled:  user.Disabled,
+		CreatedAt: user.CreatedAt.Unix(),
+		UpdatedAt: user.UpdatedAt.Unix(),
+	}
+	if user.LastLoginAt != nil {
+		result.LastLoginAt = user.LastLoginAt.Unix()
+	}
+	return result
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/bsonx"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/update"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type AdminUser struct {
+	mongox.Model `bson:",inline"`
+	Username     string     `bson:"username"`
+	Password     string     `bson:"password"`
+	Nickname     string     `bson:"nickname"`
+	Role         string     `bson:"role"`
+	Disabled     bool       `bson:"disabled"`
+	LastLoginAt  *time.Time `bson:"last_login_at,omitempty"`
+}
+
+type IAdminUserDao interface {
+	ISessionDao
+	ILoginAttemptDao
+	FindAll(ctx context.Context) ([]*AdminUser, error)
+	FindById(ctx context.Context, id bson.ObjectID) (*AdminUser, error)
+	FindByUsername(ctx context.Context, username string) (*AdminUser, error)
+	Count(ctx context.Context) (int64, error)
+	CountEnabledByRole(ctx context.Context, role string) (int64, error)
+	Create(ctx context.Context, user *AdminUser) (string, error)
+	UpdateById(ctx context.Context, id bson.ObjectID, nickname, role string, disabled bool) error
+	UpdatePasswordById(ctx context.Context, id bson.ObjectID, password string) error
+	UpdateLastLoginAtById(ctx context.Context, id bson.ObjectID, lastLoginAt time.Time) error
+	DeleteById(ctx context.Context, id bson.ObjectID) error
+}
+
+var _ IAdminUserDao = (*AdminUserDao)(nil)
+
+func NewAdminUserDao(db *mongox.Database) *AdminUserDao {
+	return &AdminUserDao{
+		coll:              mongox.NewCollection[AdminUser](db, "admin_users"),
+		sessionColl:       mongox.NewCollection[Session](db, "admin_sessions"),
+		loginAttemptColl:  mongox.NewCollection[LoginAttempt](db, "login_attempts"),
+		loginThrottleColl: mongox.NewCollection[LoginThrottle](db, "login_throttles"),
+	}
+}
+
+type AdminUserDao struct {
+	coll              *mongox.Collection[AdminUser]
+	sessionColl       *mongox.Collection[Session]
+	loginAttemptColl  *mongox.Collection[LoginAttempt]
+	loginThrottleColl *mongox.Collection[LoginThrottle]
+}
+
+func (d *AdminUserDao) FindAll(ctx context.Context) ([]*AdminUser, error) {
+	users, err := d.coll.Finder().Filter(bson.D{}).Find(ctx, options.Find().SetSort(bsonx.M("created_at", 1)))
+	if err != nil {
+		return nil, errors.Wrap(err, "fails to find admin users")
+	}
+	return users, nil
+}
+
+func (d *AdminUserDao) FindById(ctx context.Context, id bson.ObjectID) (*AdminUser, error) {
+	user, err := d.coll.Finder().Filter(query.Id(id)).FindOne(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find admin user by id, id=%s", id.Hex())
+	}
+	return user, nil
+}
+
+func (d *AdminUserDao) FindByUsername(ctx context.Context, username string) (*AdminUser, error) {
+	user, err := d.coll.Finder().Filter(query.Eq("username", username)).FindOne(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find admin user by username, username=%s", username)
+	}
+	return user, nil
+}
+
+func (d *AdminUserDao) Count(ctx context.Context) (int64, error) {
+	count, err := d.coll.Finder().Filter(bson.D{}).Count(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "fails to count admin users")
+	}
+	return count, nil
+}
+
+func (d *AdminUserDao) CountEnabledByRole(ctx context.Context, role string) (int64, error) {
+	count, err := d.coll.Finder().Filter(query.NewBuilder().Eq("role", role).Eq("disabled", false).Build()).Count(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to count enabled admin users, role=%s", role)
+	}
+	return count, nil
+}
+
+func (d *AdminUserDao) Create(ctx context.Context, user *AdminUser) (string, error) {
+	result, err := d.coll.Creator().InsertOne(ctx, user)
+	if err != nil {
+		return "", errors.Wrapf(err, "fails to create admin user, username=%s", user.Username)
+	}
+	return result.InsertedID.(bson.ObjectID).Hex(), nil
+}
+
+func (d *AdminUserDao) UpdateById(ctx context.Context, id bson.ObjectID, nickname, role string, disabled bool) error {
+	updateResult, err := d.coll.Updater().Filter(query.Id(id)).
+		Updates(update.NewBuilder().Set("nickname", nickname).Set("role", role).Set("disabled", disabled).Set("updated_at", time.Now().Local()).Build()).
+		UpdateOne(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to update admin user, id=%s", id.Hex())
+	}
+	if updateResult.MatchedCount == 0 {
+		return fmt.Errorf("MatchedCount=0, fails to update admin user, id=%s", id.Hex())
+	}
+	return nil
+}
+
+func (d *AdminUserDao) UpdatePasswordById(ctx context.Context, id bson.ObjectID, password string) error {
+	updateResult, err := d.coll.Updater().Filter(query.Id(id)).
+		Updates(update.NewBuilder().Set("password", password).Set("updated_at", time.Now().Local()).Build()).
+		UpdateOne(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to update the password of admin user, id=%s", id.Hex())
+	}
+	if updateResult.MatchedCount == 0 {
+		return fmt.Errorf("MatchedCount=0, fails to update the password of admin user, id=%s", id.Hex())
+	}
+	return nil
+}
+
+func (d *AdminUserDao) UpdateLastLoginAtById(ctx context.Context, id bson.ObjectID, lastLoginAt time.Time) error {
+	_, err := d.coll.Updater().Filter(query.Id(id)).
+		Updates(update.NewBuilder().Set("last_login_at", lastLoginAt).Build()).
+		UpdateOne(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to update the last login time of admin user, id=%s", id.Hex())
+	}
+	return nil
+}
+
+func (d *AdminUserDao) DeleteById(ctx context.Context, id bson.ObjectID) error {
+	deleteResult, err := d.coll.Deleter().Filter(query.Id(id)).DeleteOne(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to delete admin user, id=%s", id.Hex())
+	}
+	if deleteResult.DeletedCount == 0 {
+		return fmt.Errorf("DeletedCount=0, fails to delete admin user, id=%s", id.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/update"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type LoginAttempt struct {
+	mongox.Model `bson:",inline"`
+	Username     string `bson:"username"`
+	Ip           string `bson:"ip"`
+	UserAgent    string `bson:"user_agent"`
+	Result       string `bson:"result"`
+}
+
+// LoginThrottle 以 ip:xxx 或 username:xxx 为 _id 记录连续登录失败次数，expires_at 上建有 TTL 索引
+type LoginThrottle struct {
+	Key          string    `bson:"_id"`
+	Failures     int       `bson:"failures"`
+	LastFailedAt time.Time `bson:"last_failed_at"`
+	ExpiresAt    time.Time `bson:"expires_at"`
+}
+
+type ILoginAttemptDao interface {
+	CreateLoginAttempt(ctx context.Context, attempt *LoginAttempt) error
+	FindLoginAttempts(ctx context.Context, filter bson.D, findOptions *options.FindOptionsBuilder) ([]*LoginAttempt, error)
+	CountLoginAttempts(ctx context.Context, filter bson.D) (int64, error)
+	// ReserveLoginAttempt 在校验密码前原子地累加失败次数，返回累加前的记录，记录不存在时返回 nil；
+	// 距上次失败超过 window 时重新计数
+	ReserveLoginAttempt(ctx context.Context, key string, now time.Time, window time.Duration) (*LoginThrottle, error)
+	// ReleaseLoginAttempts 撤销未计为失败的预占次数
+	ReleaseLoginAttempts(ctx context.Context, keys []string) error
+	// MarkLoginFailure 记录失败时间，失败次数已在 ReserveLoginAttempt 中累加
+	MarkLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) error
+	DeleteLoginThrottles(ctx context.Context, keys []string) error
+}
+
+func (d *AdminUserDao) CreateLoginAttempt(ctx context.Context, attempt *LoginAttempt) error {
+	_, err := d.loginAttemptColl.Creator().InsertOne(ctx, attempt)
+	if err != nil {
+		return errors.Wrapf(err, "fails to create login attempt, username=%s, ip=%s", attempt.Username, attempt.Ip)
+	}
+	return nil
+}
+
+func (d *AdminUserDao) FindLoginAttempts(ctx context.Context, filter bson.D, findOptions *options.FindOptionsBuilder) ([]*LoginAttempt, error) {
+	attempts, err := d.loginAttemptColl.Finder().Filter(filter).Find(ctx, findOptions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find login attempts, filter=%v", filter)
+	}
+	return attempts, nil
+}
+
+func (d *AdminUserDao) CountLoginAttempts(ctx context.Context, filter bson.D) (int64, error) {
+	count, err := d.loginAttemptColl.Finder().Filter(filter).Count(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to count login attempts, filter=%v", filter)
+	}
+	return count, nil
+}
+
+func (d *AdminUserDao) ReserveLoginAttempt(ctx context.Context, key string, now time.Time, window time.Duration) (*LoginThrottle, error) {
+	// 重新计数时同时更新 last_failed_at，避免并发的请求再次清零
+	_, err := d.loginThrottleColl.Updater().Filter(query.NewBuilder().Id(key).Lt("last_failed_at", now.Add(-window)).Build()).
+		Updates(update.NewBuilder().Set("failures", 0).Set("last_failed_at", now).Build()).
+		UpdateOne(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to reset stale login failures, key=%s", key)
+	}
+	throttle, err := d.loginThrottleColl.Finder().Filter(query.Id(key)).
+		Updates(update.NewBuilder().Inc("failures", 1).Set("expires_at", now.Add(window)).SetOnInsert("last_failed_at", now).Build()).
+		FindOneAndUpdate(ctx, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "fails to reserve login attempt, key=%s", key)
+	}
+	return throttle, nil
+}
+
+func (d *AdminUserDao) ReleaseLoginAttempts(ctx context.Context, keys []string) error {
+	_, err := d.loginThrottleColl.Updater().Filter(query.NewBuilder().In("_id", toAnySlice(keys)...).Gt("failures", 0).Build()).
+		Updates(update.Inc("failures", -1)).
+		UpdateMany(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to release login attempts, keys=%v", keys)
+	}
+	return nil
+}
+
+func (d *AdminUserDao) MarkLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) error {
+	_, err := d.loginThrottleColl.Updater().Filter(query.Id(key)).
+		Updates(update.NewBuilder().Set("last_failed_at", now).Set("expires_at", now.Add(window)).SetOnInsert("failures", 1).Build()).
+		Upsert(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to mark login failure, key=%s", key)
+	}
+	return nil
+}
+
+func (d *AdminUserDao) DeleteLoginThrottles(ctx context.Context, keys []string) error {
+	_, err := d.loginThrottleColl.Deleter().Filter(query.In("_id", toAnySlice(keys)...)).DeleteMany(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to delete login throttles, keys=%v", keys)
+	}
+	return nil
+}
+
+func toAnySlice(keys []string) []any {
+	result := make([]any, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, key)
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/bsonx"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/update"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ISessionDao interface {
+	CreateSession(ctx context.Context, session *Session) error
+	FindSessionById(ctx context.Context, id bson.ObjectID) (*Session, error)
+	FindActiveSessions(ctx context.Context, filter bson.D) ([]*Session, error)
+	// RotateRefreshToken 仅当刷新令牌未被使用过时才更新，返回是否更新成功
+	RotateRefreshToken(ctx context.Context, id bson.ObjectID, oldHash, newHash string, expiresAt time.Time, ip, userAgent string) (bool, error)
+	RevokeSessions(ctx context.Context, filter bson.D) (int64, error)
+}
+
+type Session struct {
+	mongox.Model     `bson:",inline"`
+	UserId           string     `bson:"user_id"`
+	RefreshTokenHash string     `bson:"refresh_token_hash"`
+	Device           string     `bson:"device"`
+	UserAgent        string     `bson:"user_agent"`
+	Ip               string     `bson:"ip"`
+	ExpiresAt        time.Time  `bson:"expires_at"`
+	LastActiveAt     time.Time  `bson:"last_active_at"`
+	RevokedAt        *time.Time `bson:"revoked_at,omitempty"`
+}
+
+func (d *AdminUserDao) CreateSession(ctx context.Context, session *Session) error {
+	_, err := d.sessionColl.Creator().InsertOne(ctx, session)
+	if err != nil {
+		return errors.Wrapf(err, "fails to create session, userId=%s", session.UserId)
+	}
+	return nil
+}
+
+func (d *AdminUserDao) FindSessionById(ctx context.Context, id bson.ObjectID) (*Session, error) {
+	session, err := d.sessionColl.Finder().Filter(query.Id(id)).FindOne(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find session by id, id=%s", id.Hex())
+	}
+	return session, nil
+}
+
+func (d *AdminUserDao) FindActiveSessions(ctx context.Context, filter bson.D) ([]*Session, error) {
+	filter = append(filter, bson.E{Key: "revoked_at", Value: bsonx.M("$exists", false)}, bson.E{Key: "expires_at", Value: bsonx.M("$gt", time.Now().Local())})
+	sessions, err := d.sessionColl.Finder().Filter(filter).Find(ctx, options.Find().SetSort(bsonx.M("last_active_at", -1)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find active sessions, filter=%v", filter)
+	}
+	return sessions, nil
+}
+
+func (d *AdminUserDao) RotateRefreshToken(ctx context.Context, id bson.ObjectID, oldHash, newHash string, expiresAt time.Time, ip, userAgent string) (bool, error) {
+	now := time.Now().Local()
+	updateResult, err := d.sessionColl.Updater().
+		Filter(query.NewBuilder().Id(id).Eq("refresh_token_hash", oldHash).Exists("revoked_at", false).Build()).
+		Updates(update.NewBuilder().
+			Set("refresh_token_hash", newHash).
+			Set("expires_at", expiresAt).
+			Set("ip", ip).
+			Set("user_agent", userAgent).
+			Set("last_active_at", now).
+			Set("updated_at", now).
+			Build()).
+		UpdateOne(ctx)
+	if err != nil {
+		return false, errors.Wrapf(err, "fails to rotate refresh token, id=%s", id.Hex())
+	}
+	return updateResult.ModifiedCount > 0, nil
+}
+
+func (d *AdminUserDao) RevokeSessions(ctx context.Context, filter bson.D) (int64, error) {
+	now := time.Now().Local()
+	filter = append(filter, bson.E{Key: "revoked_at", Value: bsonx.M("$exists", false)})
+	updateResult, err := d.sessionColl.Updater().
+		Filter(filter).
+		Updates(update.NewBuilder().Set("revoked_at", now).Set("updated_at", now).Build()).
+		UpdateMany(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to revoke sessions, filter=%v", filter)
+	}
+	return updateResult.ModifiedCount, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/repository/dao"
+	"github.com/chenmingyong0423/go-mongox/v2/bsonx"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ILoginAttemptRepository interface {
+	CreateLoginAttempt(ctx context.Context, attempt domain.LoginAttempt) error
+	// FindLoginAttempts 按时间倒序分页查询登录记录，limit 为 0 时不限制数量
+	FindLoginAttempts(ctx context.Context, filter domain.LoginAttemptFilter, skip, limit int64) ([]domain.LoginAttempt, error)
+	CountLoginAttempts(ctx context.Context, filter domain.LoginAttemptFilter) (int64, error)
+	// ReserveLoginAttempt 原子地累加失败次数并返回累加前的记录，记录不存在时返回 nil
+	ReserveLoginAttempt(ctx context.Context, key string, now time.Time, window time.Duration) (*domain.LoginThrottle, error)
+	ReleaseLoginAttempts(ctx context.Context, keys []string) error
+	MarkLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) error
+	DeleteLoginThrottles(ctx context.Context, keys []string) error
+}
+
+func (r *AdminUserRepository) CreateLoginAttempt(ctx context.Context, attempt domain.LoginAttempt) error {
+	return r.dao.CreateLoginAttempt(ctx, &dao.LoginAttempt{
+		Username:  attempt.Username,
+		Ip:        attempt.Ip,
+		UserAgent: attempt.UserAgent,
+		Result:    string(attempt.Result),
+	})
+}
+
+func (r *AdminUserRepository) FindLoginAttempts(ctx context.Context, filter domain.LoginAttemptFilter, skip, limit int64) ([]domain.LoginAttempt, error) {
+	findOptions := options.Find().SetSort(bsonx.M("created_at", -1)).SetSkip(skip)
+	if limit > 0 {
+		findOptions.SetLimit(limit)
+	}
+	attempts, err := r.dao.FindLoginAttempts(ctx, r.loginAttemptFilterToBson(filter), findOptions)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]domain.LoginAttempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		result = append(result, domain.LoginAttempt{
+			Id:        attempt.ID.Hex(),
+			Username:  attempt.Username,
+			Ip:        attempt.Ip,
+			UserAgent: attempt.UserAgent,
+			Result:    domain.LoginResult(attempt.Result),
+			CreatedAt: attempt.CreatedAt.Unix(),
+		})
+	}
+	return result, nil
+}
+
+func (r *AdminUserRepository) CountLoginAttempts(ctx context.Context, filter domain.LoginAttemptFilter) (int64, error) {
+	return r.dao.CountLoginAttempts(ctx, r.loginAttemptFilterToBson(filter))
+}
+
+func (r *AdminUserRepository) ReserveLoginAttempt(ctx context.Context, key string, now time.Time, window time.Duration) (*domain.LoginThrottle, error) {
+	throttle, err := r.dao.ReserveLoginAttempt(ctx, key, now, window)
+	if err != nil || throttle == nil {
+		return nil, err
+	}
+	return &domain.LoginThrottle{
+		Key:          throttle.Key,
+		Failures:     throttle.Failures,
+		LastFailedAt: throttle.LastFailedAt.Unix(),
+	}, nil
+}
+
+func (r *AdminUserRepository) ReleaseLoginAttempts(ctx context.Context, keys []string) error {
+	return r.dao.ReleaseLoginAttempts(ctx, keys)
+}
+
+func (r *AdminUserRepository) MarkLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) error {
+	return r.dao.MarkLoginFailure(ctx, key, now, window)
+}
+
+func (r *AdminUserRepository) DeleteLoginThrottles(ctx context.Context, keys []string) error {
+	return r.dao.DeleteLoginThrottles(ctx, keys)
+}
+
+func (r *AdminUserRepository) loginAttemptFilterToBson(filter domain.LoginAttemptFilter) bson.D {
+	builder := query.NewBuilder()
+	if filter.Username != "" {
+		builder.Eq("username", filter.Username)
+	}
+	if filter.Ip != "" {
+		builder.Eq("ip", filter.Ip)
+	}
+	if filter.Result != "" {
+		builder.Eq("result", string(filter.Result))
+	}
+	if filter.StartTime > 0 {
+		builder.Gte("created_at", time.Unix(filter.StartTime, 0).Local())
+	}
+	if filter.EndTime > 0 {
+		builder.Lte("created_at", time.Unix(filter.EndTime, 0).Local())
+	}
+	return builder.Build()
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/repository/dao"
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type ISessionRepository interface {
+	CreateSession(ctx context.Context, session domain.Session) (string, error)
+	FindSessionById(ctx context.Context, id string) (*domain.Session, error)
+	FindActiveSessions(ctx context.Context, filter domain.SessionFilter) ([]domain.Session, error)
+	RotateRefreshToken(ctx context.Context, id string, oldHash, newHash string, expiresAt time.Time, ip, userAgent string) (bool, error)
+	RevokeSessionById(ctx context.Context, id string) (int64, error)
+	RevokeSessions(ctx context.Context, filter domain.SessionFilter) (int64, error)
+	// RevokeUserSessionsExcept 吊销用户除 exceptId 以外的所有会话
+	RevokeUserSessionsExcept(ctx context.Context, userId string, exceptId string) (int64, error)
+}
+
+func (r *AdminUserRepository) CreateSession(ctx context.Context, session domain.Session) (string, error) {
+	objectID := bson.NewObjectID()
+	err := r.dao.CreateSession(ctx, &dao.Session{
+		UserId:           session.UserId,
+		RefreshTokenHash: session.RefreshTokenHash,
+		Device:           session.Device,
+		UserAgent:        session.UserAgent,
+		Ip:               session.Ip,
+		ExpiresAt:        time.Unix(session.ExpiresAt, 0).Local(),
+		LastActiveAt:     time.Unix(session.LastActiveAt, 0).Local(),
+		// 会话 id 需要在生成刷新令牌前确定
+		Model: mongox.Model{ID: objectID},
+	})
+	if err != nil {
+		return "", err
+	}
+	return objectID.Hex(), nil
+}
+
+func (r *AdminUserRepository) FindSessionById(ctx context.Context, id string) (*domain.Session, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	session, err := r.dao.FindSessionById(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	result := r.toDomainSession(session)
+	return &result, nil
+}
+
+func (r *AdminUserRepository) FindActiveSessions(ctx context.Context, filter domain.SessionFilter) ([]domain.Session, error) {
+	sessions, err := r.dao.FindActiveSessions(ctx, r.sessionFilterToBson(filter))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]domain.Session, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, r.toDomainSession(session))
+	}
+	return result, nil
+}
+
+func (r *AdminUserRepository) RotateRefreshToken(ctx context.Context, id string, oldHash, newHash string, expiresAt time.Time, ip, userAgent string) (bool, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+	return r.dao.RotateRefreshToken(ctx, objectID, oldHash, newHash, expiresAt, ip, userAgent)
+}
+
+func (r *AdminUserRepository) RevokeSessionById(ctx context.Context, id string) (int64, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, err
+	}
+	return r.dao.RevokeSessions(ctx, query.Id(objectID))
+}
+
+func (r *AdminUserRepository) RevokeSessions(ctx context.Context, filter domain.SessionFilter) (int64, error) {
+	return r.dao.RevokeSessions(ctx, r.sessionFilterToBson(filter))
+}
+
+func (r *AdminUserRepository) RevokeUserSessionsExcept(ctx context.Context, userId string, exceptId string) (int64, error) {
+	builder := query.NewBuilder().Eq("user_id", userId)
+	if exceptId != "" {
+		objectID, err := bson.ObjectIDFromHex(exceptId)
+		if err != nil {
+			return 0, err
+		}
+		builder.Ne("_id", objectID)
+	}
+	return r.dao.RevokeSessions(ctx, builder.Build())
+}
+
+func (r *AdminUserRepository) sessionFilterToBson(filter domain.SessionFilter) bson.D {
+	cond := bson.D{}
+	if filter.UserId != "" {
+		cond = append(cond, bson.E{Key: "user_id", Value: filter.UserId})
+	}
+	if filter.Ip != "" {
+		cond = append(cond, bson.E{Key: "ip", Value: filter.Ip})
+	}
+	if filter.Device != "" {
+		cond = append(cond, bson.E{Key: "device", Value: filter.Device})
+	}
+	return cond
+}
+
+func (r *AdminUserRepository) toDomainSession(session *dao.Session) domain.Session {
+	result := domain.Session{
+		Id:               session.ID.Hex(),
+		UserId:           session.UserId,
+		RefreshTokenHash: session.RefreshTokenHash,
+		Device:           session.Device,
+		UserAgent:        session.UserAgent,
+		Ip:               session.Ip,
+		ExpiresAt:        session.ExpiresAt.Unix(),
+		LastActiveAt:     session.LastActiveAt.Unix(),
+		CreatedAt:        session.CreatedAt.Unix(),
+	}
+	if session.RevokedAt != nil {
+		result.RevokedAt = session.RevokedAt.Unix()
+	}
+	return result
+}
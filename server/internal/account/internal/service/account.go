@@ -0,0 +1,320 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/repository"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/passwordutil"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("username or password is incorrect")
+	ErrUserDisabled       = errors.New("account is disabled")
+)
+
+type IAccountService interface {
+	// Login 校验用户名和密码，成功时返回登录的用户，用户开启了两步验证时还需调用 VerifyTwoFactorChallenge 完成登录；
+	// 连续失败次数过多时返回 *LoginThrottledError
+	Login(ctx context.Context, username, password string, client domain.LoginClient) (*domain.AdminUser, error)
+	GetUserById(ctx context.Context, id string) (*domain.AdminUser, error)
+	GetUsers(ctx context.Context) ([]domain.AdminUser, error)
+	CreateUser(ctx context.Context, user domain.AdminUser) (string, error)
+	UpdateUser(ctx context.Context, user domain.AdminUser) error
+	ResetPassword(ctx context.Context, id string, password string) error
+	// ChangePassword 修改自己的密码，并吊销除当前会话以外的其他会话
+	ChangePassword(ctx context.Context, id string, sessionId string, oldPassword, newPassword string) error
+	DeleteUser(ctx context.Context, operatorId string, id string) error
+
+	CreateSession(ctx context.Context, user *domain.AdminUser, ip, userAgent string) (*domain.TokenPair, error)
+	RefreshSession(ctx context.Context, refreshToken, ip, userAgent string) (*domain.TokenPair, error)
+	Logout(ctx context.Context, sessionId string) error
+	IsSessionRevoked(ctx context.Context, sessionId string) (bool, error)
+	GetSessions(ctx context.Context, filter domain.SessionFilter) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, sessionId string, userId string) error
+	RevokeSessions(ctx context.Context, filter domain.SessionFilter) (int64, error)
+
+	CreateTwoFactorChallenge(ctx context.Context, user *domain.AdminUser) (*domain.TwoFactorChallenge, error)
+	VerifyTwoFactorChallenge(ctx context.Context, challengeToken, code, recoveryCode string, client domain.LoginClient) (*domain.AdminUser, error)
+	GetTwoFactorConfig(ctx context.Context, userId string) (*website_config.TwoFactorConfig, error)
+	BeginTotpEnrollment(ctx context.Context, userId string) (*website_config.TwoFactorEnrollment, error)
+	ConfirmTotpEnrollment(ctx context.Context, userId string, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userId string, password string) error
+	RegenerateRecoveryCodes(ctx context.Context, userId string, password string) ([]string, error)
+	ResetTwoFactor(ctx context.Context, userId string) error
+
+	GetLoginAttempts(ctx context.Context, filter domain.LoginAttemptFilter, pageNo, pageSize int64) ([]domain.LoginAttempt, int64, error)
+	// ExportLoginAttempts 按时间倒序导出登录记录，最多 MaxExportLoginAttempts 条
+	ExportLoginAttempts(ctx context.Context, filter domain.LoginAttemptFilter) ([]domain.LoginAttempt, error)
+}
+
+var _ IAccountService = (*AccountService)(nil)
+
+func NewAccountService(repo repository.IAdminUserRepository, cfgServ website_config.Service) *AccountService {
+	return &AccountService{
+		repo:    repo,
+		cfgServ: cfgServ,
+	}
+}
+
+type AccountService struct {
+	repo    repository.IAdminUserRepository
+	cfgServ website_config.Service
+}
+
+func (s *AccountService) Login(ctx context.Context, username, password string, client domain.LoginClient) (*domain.AdminUser, error) {
+	now := time.Now()
+	retryAfter, err := s.reserveLoginAttempt(ctx, client.Ip, username, now)
+	if err != nil {
+		return nil, err
+	}
+	if retryAfter > 0 {
+		s.recordLoginAttempt(ctx, username, client, domain.LoginResultThrottled, now)
+		return nil, &LoginThrottledError{RetryAfter: retryAfter}
+	}
+	user, err := s.authenticate(ctx, username, password)
+	switch {
+	case errors.Is(err, ErrInvalidCredentials):
+		s.recordLoginAttempt(ctx, username, client, domain.LoginResultInvalidCredentials, now)
+	case errors.Is(err, ErrUserDisabled):
+		s.recordLoginAttempt(ctx, username, client, domain.LoginResultDisabled, now)
+	case err != nil:
+		// 内部错误不计入登录记录，也不计入失败次数
+		s.releaseLoginAttempt(ctx, client.Ip, username)
+	case user.TwoFactorEnabled:
+		s.recordLoginAttempt(ctx, username, client, domain.LoginResultTwoFactorRequired, now)
+	default:
+		s.recordLoginAttempt(ctx, username, client, domain.LoginResultSuccess, now)
+	}
+	return user, err
+}
+
+func (s *AccountService) authenticate(ctx context.Context, username, password string) (*domain.AdminUser, error) {
+	user, err := s.repo.FindByUsername(ctx, username)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+		user, err = s.bootstrapOwner(ctx, username, password)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ok, vErr := passwordutil.Verify(user.Password, password)
+		if vErr != nil {
+			return nil, vErr
+		}
+		if !ok {
+			return nil, ErrInvalidCredentials
+		}
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	if err = s.loadTwoFactorEnabled(ctx, user); err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return user, nil
+	}
+	now := time.Now().Local()
+	if err = s.repo.UpdateLastLoginAt(ctx, user.Id, now); err != nil {
+		slog.Default().WarnContext(ctx, "Login: failed to update the last login time", "userId", user.Id, "error", err)
+	}
+	return user, nil
+}
+
+// bootstrapOwner 还没有任何后台用户时，使用网站初始化时设置的管理员账号创建站长
+func (s *AccountService) bootstrapOwner(ctx context.Context, username, password string) (*domain.AdminUser, error) {
+	count, err := s.repo.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrInvalidCredentials
+	}
+	ok, err := s.cfgServ.VerifyAdminPassword(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	// VerifyAdminPassword 会将明文密码替换为哈希值，这里重新读取
+	adminConfig, err := s.cfgServ.GetAdminConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.repo.Create(ctx, domain.AdminUser{
+		Username: adminConfig.Username,
+		Password: adminConfig.Password,
+		Nickname: adminConfig.Username,
+		Role:     domain.RoleOwner,
+	})
+	if err != nil {
+		// 其他实例已创建
+		if mongo.IsDuplicateKeyError(err) {
+			return s.repo.FindByUsername(ctx, username)
+		}
+		return nil, err
+	}
+	return s.repo.FindById(ctx, id)
+}
+
+func (s *AccountService) GetUserById(ctx context.Context, id string) (*domain.AdminUser, error) {
+	return s.repo.FindById(ctx, id)
+}
+
+func (s *AccountService) GetUsers(ctx context.Context) ([]domain.AdminUser, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *AccountService) CreateUser(ctx context.Context, user domain.AdminUser) (string, error) {
+	if !user.Role.IsValid() {
+		return "", apiwrap.NewErrorResponseBody(http.StatusBadRequest, "invalid role")
+	}
+	hashed, err := passwordutil.Hash(user.Password)
+	if err != nil {
+		return "", err
+	}
+	user.Password = hashed
+	id, err := s.repo.Create(ctx, user)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return "", apiwrap.NewErrorResponseBody(http.StatusConflict, "username already exists")
+		}
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *AccountService) UpdateUser(ctx context.Context, user domain.AdminUser) error {
+	if !user.Role.IsValid() {
+		return apiwrap.NewErrorResponseBody(http.StatusBadRequest, "invalid role")
+	}
+	current, err := s.getUser(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+	if current.Role == domain.RoleOwner && !current.Disabled && (user.Role != domain.RoleOwner || user.Disabled) {
+		if err = s.ensureAnotherOwner(ctx); err != nil {
+			return err
+		}
+	}
+	err = s.repo.Update(ctx, user)
+	if err != nil {
+		return err
+	}
+	if user.Disabled && !current.Disabled {
+		_, err = s.repo.RevokeUserSessionsExcept(ctx, user.Id, "")
+	}
+	return err
+}
+
+func (s *AccountService) ResetPassword(ctx context.Context, id string, password string) error {
+	if _, err := s.getUser(ctx, id); err != nil {
+		return err
+	}
+	hashed, err := passwordutil.Hash(password)
+	if err != nil {
+		return err
+	}
+	err = s.repo.UpdatePassword(ctx, id, hashed)
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.RevokeUserSessionsExcept(ctx, id, "")
+	return err
+}
+
+func (s *AccountService) ChangePassword(ctx context.Context, id string, sessionId string, oldPassword, newPassword string) error {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	ok, err := passwordutil.Verify(user.Password, oldPassword)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apiwrap.NewErrorResponseBody(http.StatusBadRequest, "old password is incorrect")
+	}
+	hashed, err := passwordutil.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	err = s.repo.UpdatePassword(ctx, id, hashed)
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.RevokeUserSessionsExcept(ctx, id, sessionId)
+	return err
+}
+
+func (s *AccountService) DeleteUser(ctx context.Context, operatorId string, id string) error {
+	if operatorId == id {
+		return apiwrap.NewErrorResponseBody(http.StatusBadRequest, "can not delete yourself")
+	}
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.Role == domain.RoleOwner && !user.Disabled {
+		if err = s.ensureAnotherOwner(ctx); err != nil {
+			return err
+		}
+	}
+	err = s.repo.DeleteById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err = s.cfgServ.DisableTwoFactor(ctx, id); err != nil {
+		return err
+	}
+	_, err = s.repo.RevokeUserSessionsExcept(ctx, id, "")
+	return err
+}
+
+func (s *AccountService) getUser(ctx context.Context, id string) (*domain.AdminUser, error) {
+	user, err := s.repo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "user not found")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// ensureAnotherOwner 保证至少保留一个可用的站长
+func (s *AccountService) ensureAnotherOwner(ctx context.Context) error {
+	count, err := s.repo.CountEnabledOwners(ctx)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return apiwrap.NewErrorResponseBody(http.StatusConflict, "at least one enabled owner is required")
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/domain"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultLoginFreeAttempts     = 3
+	defaultLoginBackoffBase      = time.Second
+	defaultLoginLockoutThreshold = 10
+	defaultLoginLockoutDuration  = 30 * time.Minute
+	// MaxExportLoginAttempts 单次导出的最大记录数
+	MaxExportLoginAttempts = 50000
+)
+
+// LoginThrottledError 连续登录失败次数过多，需等待 RetryAfter 后重试
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, please retry after %s", e.RetryAfter)
+}
+
+// loginThrottlePolicy 按 ip 和用户名分别统计连续失败次数：
+// 前 freeAttempts 次失败不限制，之后每次失败的等待时间从 backoffBase 开始翻倍，
+// 达到 lockoutThreshold 次后锁定 lockoutDuration，距上次失败超过 lockoutDuration 后重新计数
+type loginThrottlePolicy struct {
+	freeAttempts     int
+	backoffBase      time.Duration
+	lockoutThreshold int
+	lockoutDuration  time.Duration
+}
+
+func currentLoginThrottlePolicy() loginThrottlePolicy {
+	policy := loginThrottlePolicy{
+		freeAttempts:     viper.GetInt("login.free_attempts"),
+		backoffBase:      viper.GetDuration("login.backoff_base"),
+		lockoutThreshold: viper.GetInt("login.lockout_threshold"),
+		lockoutDuration:  viper.GetDuration("login.lockout_duration"),
+	}
+	if policy.freeAttempts <= 0 {
+		policy.freeAttempts = defaultLoginFreeAttempts
+	}
+	if policy.backoffBase <= 0 {
+		policy.backoffBase = defaultLoginBackoffBase
+	}
+	if policy.lockoutThreshold <= policy.freeAttempts {
+		policy.lockoutThreshold = max(defaultLoginLockoutThreshold, policy.freeAttempts+1)
+	}
+	if policy.lockoutDuration <= 0 {
+		policy.lockoutDuration = defaultLoginLockoutDuration
+	}
+	return policy
+}
+
+// wait 连续失败 failures 次后需要等待的时间
+func (p loginThrottlePolicy) wait(failures int) time.Duration {
+	if failures >= p.lockoutThreshold {
+		return p.lockoutDuration
+	}
+	if failures < p.freeAttempts {
+		return 0
+	}
+	wait := p.backoffBase << (failures - p.freeAttempts)
+	if wait <= 0 || wait > p.lockoutDuration {
+		return p.lockoutDuration
+	}
+	return wait
+}
+
+func loginThrottleKeys(ip, username string) []string {
+	keys := make([]string, 0, 2)
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	if username = strings.TrimSpace(username); username != "" {
+		keys = append(keys, "username:"+username)
+	}
+	return keys
+}
+
+// reserveLoginAttempt 在校验密码前为 ip 和用户名各预占一次失败次数，并返回被限流时需要等待的时间。
+// 预占与计数在同一次原子操作中完成，并发的请求会拿到不同的计数，不会同时通过检查；
+// 被限流时撤销预占，其余情况由 recordLoginAttempt 或 releaseLoginAttempt 确认或撤销
+func (s *AccountService) reserveLoginAttempt(ctx context.Context, ip, username string, now time.Time) (time.Duration, error) {
+	keys := loginThrottleKeys(ip, username)
+	policy := currentLoginThrottlePolicy()
+	var (
+		retryAfter time.Duration
+		reserved   = make([]string, 0, len(keys))
+	)
+	for _, key := range keys {
+		throttle, err := s.repo.ReserveLoginAttempt(ctx, key, now.Local(), policy.lockoutDuration)
+		if err != nil {
+			s.releaseReservedKeys(ctx, reserved)
+			return 0, err
+		}
+		reserved = append(reserved, key)
+		if throttle == nil {
+			continue
+		}
+		lastFailedAt := time.Unix(throttle.LastFailedAt, 0)
+		if now.Sub(lastFailedAt) > policy.lockoutDuration {
+			continue
+		}
+		if d := lastFailedAt.Add(policy.wait(throttle.Failures)).Sub(now); d > retryAfter {
+			retryAfter = d
+		}
+	}
+	if retryAfter <= 0 {
+		return 0, nil
+	}
+	s.releaseReservedKeys(ctx, reserved)
+	// 向上取整到秒，便于设置 Retry-After
+	if remainder := retryAfter % time.Second; remainder > 0 {
+		retryAfter += time.Second - remainder
+	}
+	return retryAfter, nil
+}
+
+// releaseLoginAttempt 撤销 reserveLoginAttempt 预占的次数，用于不计为失败的结果
+func (s *AccountService) releaseLoginAttempt(ctx context.Context, ip, username string) {
+	s.releaseReservedKeys(ctx, loginThrottleKeys(ip, username))
+}
+
+func (s *AccountService) releaseReservedKeys(ctx context.Context, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	if err := s.repo.ReleaseLoginAttempts(ctx, keys); err != nil {
+		slog.Default().WarnContext(ctx, "failed to release login attempts", "keys", keys, "error", err)
+	}
+}
+
+// recordLoginAttempt 记录登录结果并确认或撤销预占的失败次数，失败不影响登录流程
+func (s *AccountService) recordLoginAttempt(ctx context.Context, username string, client domain.LoginClient, result domain.LoginResult, now time.Time) {
+	l := slog.Default().With("username", username, "ip", client.Ip, "result", result)
+	if err := s.repo.CreateLoginAttempt(ctx, domain.LoginAttempt{
+		Username:  username,
+		Ip:        client.Ip,
+		UserAgent: client.UserAgent,
+		Result:    result,
+	}); err != nil {
+		l.WarnContext(ctx, "failed to record the login attempt", "error", err)
+	}
+	keys := loginThrottleKeys(client.Ip, username)
+	switch {
+	case result.IsFailure():
+		window := currentLoginThrottlePolicy().lockoutDuration
+		for _, key := range keys {
+			if err := s.repo.MarkLoginFailure(ctx, key, now.Local(), window); err != nil {
+				l.WarnContext(ctx, "failed to mark the login failure", "key", key, "error", err)
+			}
+		}
+	case result == domain.LoginResultSuccess && len(keys) > 0:
+		if err := s.repo.DeleteLoginThrottles(ctx, keys); err != nil {
+			l.WarnContext(ctx, "failed to reset login failures", "error", err)
+		}
+	case result == domain.LoginResultThrottled:
+		// 被限流时已撤销预占
+	default:
+		s.releaseReservedKeys(ctx, keys)
+	}
+}
+
+func (s *AccountService) GetLoginAttempts(ctx context.Context, filter domain.LoginAttemptFilter, pageNo, pageSize int64) ([]domain.LoginAttempt, int64, error) {
+	total, err := s.repo.CountLoginAttempts(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	attempts, err := s.repo.FindLoginAttempts(ctx, filter, (pageNo-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	return attempts, total, nil
+}
+
+func (s *AccountService) ExportLoginAttempts(ctx context.Context, filter domain.LoginAttemptFilter) ([]domain.LoginAttempt, error) {
+	return s.repo.FindLoginAttempts(ctx, filter, 0, MaxExportLoginAttempts)
+}
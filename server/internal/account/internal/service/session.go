@@ -0,0 +1,208 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/jwtutil"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 7 * 24 * time.Hour
+	refreshSecretSize      = 32
+)
+
+var ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+
+func accessTokenTTL() time.Duration {
+	if ttl := viper.GetDuration("jwt.access_token_ttl"); ttl > 0 {
+		return ttl
+	}
+	return defaultAccessTokenTTL
+}
+
+func refreshTokenTTL() time.Duration {
+	if ttl := viper.GetDuration("jwt.refresh_token_ttl"); ttl > 0 {
+		return ttl
+	}
+	return defaultRefreshTokenTTL
+}
+
+// CreateSession 为登录用户创建会话，并签发访问令牌和刷新令牌
+func (s *AccountService) CreateSession(ctx context.Context, user *domain.AdminUser, ip, userAgent string) (*domain.TokenPair, error) {
+	secret, hash, err := newRefreshSecret()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Local()
+	expiresAt := now.Add(refreshTokenTTL())
+	sessionId, err := s.repo.CreateSession(ctx, domain.Session{
+		UserId:           user.Id,
+		RefreshTokenHash: hash,
+		Device:           domain.DeviceOf(userAgent),
+		UserAgent:        userAgent,
+		Ip:               ip,
+		ExpiresAt:        expiresAt.Unix(),
+		LastActiveAt:     now.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user.Id, sessionId, secret, expiresAt)
+}
+
+// RefreshSession 使用刷新令牌换取新的令牌，旧的刷新令牌随即失效。
+// 已失效的刷新令牌被再次使用时，说明令牌可能已泄露，会吊销整个会话
+func (s *AccountService) RefreshSession(ctx context.Context, refreshToken, ip, userAgent string) (*domain.TokenPair, error) {
+	sessionId, secret, found := strings.Cut(refreshToken, ".")
+	if !found {
+		return nil, ErrInvalidRefreshToken
+	}
+	session, err := s.findSession(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || !session.IsActive(time.Now().Unix()) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if subtle.ConstantTimeCompare([]byte(hashRefreshSecret(secret)), []byte(session.RefreshTokenHash)) != 1 {
+		if _, err = s.repo.RevokeSessionById(ctx, session.Id); err != nil {
+			return nil, err
+		}
+		slog.Default().WarnContext(ctx, "RefreshSession: refresh token reused, the session has been revoked", "sessionId", session.Id, "ip", ip)
+		return nil, ErrInvalidRefreshToken
+	}
+	user, err := s.repo.FindById(ctx, session.UserId)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	if user == nil || user.Disabled {
+		_, err = s.repo.RevokeSessionById(ctx, session.Id)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newSecret, newHash, err := newRefreshSecret()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Local().Add(refreshTokenTTL())
+	ok, err := s.repo.RotateRefreshToken(ctx, session.Id, session.RefreshTokenHash, newHash, expiresAt, ip, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	// 同一个刷新令牌被并发使用，只有一个请求能成功
+	if !ok {
+		return nil, ErrInvalidRefreshToken
+	}
+	return s.issueTokens(user.Id, session.Id, newSecret, expiresAt)
+}
+
+func (s *AccountService) Logout(ctx context.Context, sessionId string) error {
+	_, err := s.repo.RevokeSessionById(ctx, sessionId)
+	return err
+}
+
+// IsSessionRevoked 会话不存在、已吊销或已过期时返回 true
+func (s *AccountService) IsSessionRevoked(ctx context.Context, sessionId string) (bool, error) {
+	session, err := s.findSession(ctx, sessionId)
+	if err != nil {
+		return false, err
+	}
+	return session == nil || !session.IsActive(time.Now().Unix()), nil
+}
+
+func (s *AccountService) GetSessions(ctx context.Context, filter domain.SessionFilter) ([]domain.Session, error) {
+	return s.repo.FindActiveSessions(ctx, filter)
+}
+
+// RevokeSession 吊销会话，userId 不为空时只能吊销该用户自己的会话
+func (s *AccountService) RevokeSession(ctx context.Context, sessionId string, userId string) error {
+	session, err := s.findSession(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+	if session == nil || (userId != "" && session.UserId != userId) {
+		return apiwrap.NewErrorResponseBody(http.StatusNotFound, "session not found")
+	}
+	_, err = s.repo.RevokeSessionById(ctx, sessionId)
+	return err
+}
+
+func (s *AccountService) RevokeSessions(ctx context.Context, filter domain.SessionFilter) (int64, error) {
+	if filter.UserId == "" && filter.Ip == "" && filter.Device == "" {
+		return 0, apiwrap.NewErrorResponseBody(http.StatusBadRequest, "at least one of user_id, ip and device is required")
+	}
+	return s.repo.RevokeSessions(ctx, filter)
+}
+
+func (s *AccountService) findSession(ctx context.Context, sessionId string) (*domain.Session, error) {
+	session, err := s.repo.FindSessionById(ctx, sessionId)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, bson.ErrInvalidHex) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *AccountService) issueTokens(userId, sessionId, secret string, refreshExpiresAt time.Time) (*domain.TokenPair, error) {
+	accessToken, accessExp, err := jwtutil.GenerateJwt(userId, sessionId, accessTokenTTL())
+	if err != nil {
+		return nil, err
+	}
+	return &domain.TokenPair{
+		SessionId:         sessionId,
+		AccessToken:       accessToken,
+		AccessExpiration:  accessExp,
+		RefreshToken:      sessionId + "." + secret,
+		RefreshExpiration: refreshExpiresAt.Unix(),
+	}, nil
+}
+
+func newRefreshSecret() (secret string, hash string, err error) {
+	b := make([]byte, refreshSecretSize)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", errors.Wrap(err, "fails to generate refresh token")
+	}
+	secret = base64.RawURLEncoding.EncodeToString(b)
+	return secret, hashRefreshSecret(secret), nil
+}
+
+// hashRefreshSecret 数据库中只保存刷新令牌的哈希值
+func hashRefreshSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/jwtutil"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/passwordutil"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const challengeTTL = 5 * time.Minute
+
+var ErrInvalidChallenge = errors.New("two-factor challenge is invalid or expired")
+
+// CreateTwoFactorChallenge 为通过密码校验且开启了两步验证的用户签发挑战令牌
+func (s *AccountService) CreateTwoFactorChallenge(_ context.Context, user *domain.AdminUser) (*domain.TwoFactorChallenge, error) {
+	token, exp, err := jwtutil.GenerateChallengeJwt(user.Id, challengeTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.TwoFactorChallenge{Token: token, Expiration: exp}, nil
+}
+
+// VerifyTwoFactorChallenge 校验挑战令牌和验证码（或恢复码），成功时返回登录的用户
+func (s *AccountService) VerifyTwoFactorChallenge(ctx context.Context, challengeToken, code, recoveryCode string, client domain.LoginClient) (*domain.AdminUser, error) {
+	claims, err := jwtutil.ParseChallengeJwt(challengeToken)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+	user, err := s.repo.FindById(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidChallenge
+		}
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	// 签发挑战令牌后两步验证被关闭，需要重新登录
+	if err = s.loadTwoFactorEnabled(ctx, user); err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled {
+		return nil, ErrInvalidChallenge
+	}
+	now := time.Now()
+	retryAfter, err := s.reserveLoginAttempt(ctx, client.Ip, user.Username, now)
+	if err != nil {
+		return nil, err
+	}
+	if retryAfter > 0 {
+		s.recordLoginAttempt(ctx, user.Username, client, domain.LoginResultThrottled, now)
+		return nil, &LoginThrottledError{RetryAfter: retryAfter}
+	}
+	if err = s.cfgServ.VerifyTwoFactor(ctx, user.Id, code, recoveryCode); err != nil {
+		if errors.Is(err, website_config.ErrInvalidTwoFactorCode) || errors.Is(err, website_config.ErrTwoFactorLocked) {
+			s.recordLoginAttempt(ctx, user.Username, client, domain.LoginResultTwoFactorFailed, now)
+		} else {
+			s.releaseLoginAttempt(ctx, client.Ip, user.Username)
+		}
+		return nil, err
+	}
+	s.recordLoginAttempt(ctx, user.Username, client, domain.LoginResultSuccess, now)
+	if err = s.repo.UpdateLastLoginAt(ctx, user.Id, time.Now().Local()); err != nil {
+		slog.Default().WarnContext(ctx, "VerifyTwoFactorChallenge: failed to update the last login time", "userId", user.Id, "error", err)
+	}
+	return user, nil
+}
+
+// GetTwoFactorConfig 获取用户的两步验证配置
+func (s *AccountService) GetTwoFactorConfig(ctx context.Context, userId string) (*website_config.TwoFactorConfig, error) {
+	if _, err := s.getUser(ctx, userId); err != nil {
+		return nil, err
+	}
+	return s.cfgServ.GetTwoFactorConfig(ctx, userId)
+}
+
+// BeginTotpEnrollment 生成新的 TOTP 密钥，需调用 ConfirmTotpEnrollment 验证后才会启用
+func (s *AccountService) BeginTotpEnrollment(ctx context.Context, userId string) (*website_config.TwoFactorEnrollment, error) {
+	user, err := s.getUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return s.cfgServ.BeginTotpEnrollment(ctx, userId, user.Username)
+}
+
+// ConfirmTotpEnrollment 校验验证器应用生成的验证码并启用两步验证，返回明文恢复码（仅此一次）
+func (s *AccountService) ConfirmTotpEnrollment(ctx context.Context, userId string, code string) ([]string, error) {
+	if _, err := s.getUser(ctx, userId); err != nil {
+		return nil, err
+	}
+	return s.cfgServ.ConfirmTotpEnrollment(ctx, userId, code)
+}
+
+// DisableTwoFactor 校验密码后关闭自己的两步验证
+func (s *AccountService) DisableTwoFactor(ctx context.Context, userId string, password string) error {
+	if _, err := s.verifyOwnPassword(ctx, userId, password); err != nil {
+		return err
+	}
+	return s.cfgServ.DisableTwoFactor(ctx, userId)
+}
+
+// RegenerateRecoveryCodes 校验密码后重新生成恢复码，旧的恢复码全部失效
+func (s *AccountService) RegenerateRecoveryCodes(ctx context.Context, userId string, password string) ([]string, error) {
+	if _, err := s.verifyOwnPassword(ctx, userId, password); err != nil {
+		return nil, err
+	}
+	return s.cfgServ.RegenerateRecoveryCodes(ctx, userId)
+}
+
+// ResetTwoFactor 站长为丢失验证器的用户关闭两步验证
+func (s *AccountService) ResetTwoFactor(ctx context.Context, userId string) error {
+	if _, err := s.getUser(ctx, userId); err != nil {
+		return err
+	}
+	return s.cfgServ.DisableTwoFactor(ctx, userId)
+}
+
+func (s *AccountService) verifyOwnPassword(ctx context.Context, userId string, password string) (*domain.AdminUser, error) {
+	user, err := s.getUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := passwordutil.Verify(user.Password, password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, "password is incorrect")
+	}
+	return user, nil
+}
+
+// loadTwoFactorEnabled 从 website_config 模块读取用户是否开启了两步验证
+func (s *AccountService) loadTwoFactorEnabled(ctx context.Context, user *domain.AdminUser) error {
+	cfg, err := s.cfgServ.GetTwoFactorConfig(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+	user.TwoFactorEnabled = cfg.Enabled
+	return nil
+}
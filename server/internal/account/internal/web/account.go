@@ -0,0 +1,208 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/service"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func NewAccountHandler(serv service.IAccountService) *AccountHandler {
+	return &AccountHandler{
+		serv: serv,
+	}
+}
+
+type AccountHandler struct {
+	serv service.IAccountService
+}
+
+func (h *AccountHandler) RegisterGinRoutes(engine *gin.Engine) {
+	engine.POST("/admin-api/login", apiwrap.WrapWithBody(h.AdminLogin))
+	engine.POST("/admin-api/login/2fa", apiwrap.WrapWithBody(h.AdminLoginTwoFactor))
+	engine.POST("/admin-api/token/refresh", apiwrap.WrapWithBody(h.AdminRefreshToken))
+	engine.POST("/admin-api/logout", apiwrap.Wrap(h.AdminLogout))
+
+	// 当前登录用户
+	accountGroup := engine.Group("/admin-api/account")
+	accountGroup.GET("/me", apiwrap.Wrap(h.AdminGetCurrentUser))
+	accountGroup.PUT("/password", apiwrap.WrapWithBody(h.AdminChangePassword))
+	accountGroup.GET("/sessions", apiwrap.Wrap(h.AdminGetOwnSessions))
+	accountGroup.DELETE("/sessions/:id", apiwrap.Wrap(h.AdminRevokeOwnSession))
+	accountGroup.GET("/2fa", apiwrap.Wrap(h.AdminGetTwoFactor))
+	accountGroup.POST("/2fa/totp", apiwrap.Wrap(h.AdminBeginTotpEnrollment))
+	accountGroup.POST("/2fa/totp/confirm", apiwrap.WrapWithBody(h.AdminConfirmTotpEnrollment))
+	accountGroup.POST("/2fa/recovery-codes", apiwrap.WrapWithBody(h.AdminRegenerateRecoveryCodes))
+	accountGroup.DELETE("/2fa", apiwrap.WrapWithBody(h.AdminDisableTwoFactor))
+
+	// 用户管理
+	adminGroup := engine.Group("/admin-api/users")
+	adminGroup.GET("", apiwrap.Wrap(h.AdminGetUsers))
+	adminGroup.GET("/roles", apiwrap.Wrap(h.AdminGetRoles))
+	adminGroup.GET("/sessions", apiwrap.WrapWithBody(h.AdminGetSessions))
+	adminGroup.GET("/login-attempts", apiwrap.WrapWithBody(h.AdminGetLoginAttempts))
+	adminGroup.GET("/login-attempts/export", h.AdminExportLoginAttempts)
+	adminGroup.DELETE("/sessions/:id", apiwrap.Wrap(h.AdminRevokeSession))
+	adminGroup.DELETE("/sessions", apiwrap.WrapWithBody(h.AdminRevokeSessions))
+	adminGroup.POST("", apiwrap.WrapWithBody(h.AdminCreateUser))
+	adminGroup.PUT("/:id", apiwrap.WrapWithBody(h.AdminUpdateUser))
+	adminGroup.PUT("/:id/password", apiwrap.WrapWithBody(h.AdminResetPassword))
+	adminGroup.DELETE("/:id", apiwrap.Wrap(h.AdminDeleteUser))
+	adminGroup.DELETE("/:id/2fa", apiwrap.Wrap(h.AdminResetTwoFactor))
+}
+
+func (h *AccountHandler) AdminLogin(ctx *gin.Context, req LoginRequest) (*apiwrap.ResponseBody[LoginVO], error) {
+	user, err := h.serv.Login(ctx, req.Username, req.Password, h.loginClient(ctx))
+	if err != nil {
+		var throttledErr *service.LoginThrottledError
+		if errors.As(err, &throttledErr) {
+			return nil, h.tooManyRequests(ctx, throttledErr)
+		}
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			return nil, *apiwrap.NewResponseBody[any](40101, "username or password is incorrect", nil)
+		}
+		if errors.Is(err, service.ErrUserDisabled) {
+			return nil, *apiwrap.NewResponseBody[any](40102, "account is disabled", nil)
+		}
+		return nil, err
+	}
+	// 开启了两步验证，返回挑战令牌，由 /admin-api/login/2fa 完成登录
+	if user.TwoFactorEnabled {
+		challenge, cErr := h.serv.CreateTwoFactorChallenge(ctx, user)
+		if cErr != nil {
+			return nil, cErr
+		}
+		return apiwrap.SuccessResponseWithData(LoginVO{
+			TwoFactorRequired:   true,
+			ChallengeToken:      challenge.Token,
+			ChallengeExpiration: challenge.Expiration,
+		}), nil
+	}
+	return h.login(ctx, user)
+}
+
+func (h *AccountHandler) login(ctx *gin.Context, user *domain.AdminUser) (*apiwrap.ResponseBody[LoginVO], error) {
+	tokens, err := h.serv.CreateSession(ctx, user, ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(LoginVO{
+		AdminInfo: AdminInfoVO{
+			Id:       user.Id,
+			Username: user.Username,
+			Nickname: user.Nickname,
+			Role:     string(user.Role),
+		},
+		Token:             tokens.AccessToken,
+		Expiration:        tokens.AccessExpiration,
+		RefreshToken:      tokens.RefreshToken,
+		RefreshExpiration: tokens.RefreshExpiration,
+	}), nil
+}
+
+func (h *AccountHandler) AdminGetCurrentUser(ctx *gin.Context) (*apiwrap.ResponseBody[CurrentUserVO], error) {
+	user, err := h.serv.GetUserById(ctx, ctx.GetString(CtxUserIdKey))
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(CurrentUserVO{
+		AdminUserVO: h.toAdminUserVO(*user),
+		Permissions: h.toPermissionsVO(user.Role),
+	}), nil
+}
+
+func (h *AccountHandler) AdminChangePassword(ctx *gin.Context, req ChangePasswordRequest) (*apiwrap.ResponseBody[any], error) {
+	return apiwrap.SuccessResponse(), h.serv.ChangePassword(ctx, ctx.GetString(CtxUserIdKey), ctx.GetString(CtxSessionIdKey), req.OldPassword, req.NewPassword)
+}
+
+func (h *AccountHandler) AdminGetUsers(ctx *gin.Context) (*apiwrap.ResponseBody[apiwrap.ListVO[AdminUserVO]], error) {
+	users, err := h.serv.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]AdminUserVO, 0, len(users))
+	for _, user := range users {
+		result = append(result, h.toAdminUserVO(user))
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewListVO(result)), nil
+}
+
+func (h *AccountHandler) AdminGetRoles(_ *gin.Context) (*apiwrap.ResponseBody[apiwrap.ListVO[RoleVO]], error) {
+	result := make([]RoleVO, 0, len(domain.Roles))
+	for _, role := range domain.Roles {
+		result = append(result, RoleVO{Role: string(role), Permissions: h.toPermissionsVO(role)})
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewListVO(result)), nil
+}
+
+func (h *AccountHandler) AdminCreateUser(ctx *gin.Context, req CreateUserRequest) (*apiwrap.ResponseBody[map[string]string], error) {
+	id, err := h.serv.CreateUser(ctx, domain.AdminUser{
+		Username: req.Username,
+		Password: req.Password,
+		Nickname: req.Nickname,
+		Role:     domain.Role(req.Role),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(map[string]string{"id": id}), nil
+}
+
+func (h *AccountHandler) AdminUpdateUser(ctx *gin.Context, req UpdateUserRequest) (*apiwrap.ResponseBody[any], error) {
+	return apiwrap.SuccessResponse(), h.serv.UpdateUser(ctx, domain.AdminUser{
+		Id:       ctx.Param("id"),
+		Nickname: req.Nickname,
+		Role:     domain.Role(req.Role),
+		Disabled: req.Disabled,
+	})
+}
+
+func (h *AccountHandler) AdminResetPassword(ctx *gin.Context, req ResetPasswordRequest) (*apiwrap.ResponseBody[any], error) {
+	return apiwrap.SuccessResponse(), h.serv.ResetPassword(ctx, ctx.Param("id"), req.Password)
+}
+
+func (h *AccountHandler) AdminDeleteUser(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	return apiwrap.SuccessResponse(), h.serv.DeleteUser(ctx, ctx.GetString(CtxUserIdKey), ctx.Param("id"))
+}
+
+func (h *AccountHandler) toAdminUserVO(user domain.AdminUser) AdminUserVO {
+	return AdminUserVO{
+		Id:          user.Id,
+		Username:    user.Username,
+		Nickname:    user.Nickname,
+		Role:        string(user.Role),
+		Disabled:    user.Disabled,
+		LastLoginAt: user.LastLoginAt,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+}
+
+func (h *AccountHandler) toPermissionsVO(role domain.Role) map[string]string {
+	result := make(map[string]string)
+	for resource, access := range role.Permissions() {
+		switch access {
+		case domain.AccessRead:
+			result[string(resource)] = "read"
+		case domain.AccessWrite:
+			result[string(resource)] = "write"
+		default:
+		}
+	}
+	return result
+}
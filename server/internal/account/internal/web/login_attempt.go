@@ -0,0 +1,106 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/service"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *AccountHandler) AdminGetLoginAttempts(ctx *gin.Context, req LoginAttemptPageRequest) (*apiwrap.ResponseBody[*apiwrap.PageVO[LoginAttemptVO]], error) {
+	attempts, total, err := h.serv.GetLoginAttempts(ctx, req.toDomain(), req.PageNo, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]LoginAttemptVO, 0, len(attempts))
+	for _, attempt := range attempts {
+		result = append(result, h.toLoginAttemptVO(attempt))
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewPageVO(req.PageNo, req.PageSize, total, result)), nil
+}
+
+// AdminExportLoginAttempts 导出登录记录，format 为 csv（默认）或 json
+func (h *AccountHandler) AdminExportLoginAttempts(ctx *gin.Context) {
+	var req LoginAttemptFilterRequest
+	if err := ctx.BindQuery(&req); err != nil {
+		return
+	}
+	attempts, err := h.serv.ExportLoginAttempts(ctx, req.toDomain())
+	if err != nil {
+		apiwrap.ErrorHandler(ctx, err)
+		return
+	}
+	filename := fmt.Sprintf("login-attempts-%s", time.Now().Format("20060102150405"))
+	if ctx.Query("format") == "json" {
+		result := make([]LoginAttemptVO, 0, len(attempts))
+		for _, attempt := range attempts {
+			result = append(result, h.toLoginAttemptVO(attempt))
+		}
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		ctx.JSON(http.StatusOK, result)
+		return
+	}
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+	ctx.Status(http.StatusOK)
+	w := csv.NewWriter(ctx.Writer)
+	records := make([][]string, 0, len(attempts)+1)
+	records = append(records, []string{"time", "username", "ip", "user_agent", "result"})
+	for _, attempt := range attempts {
+		records = append(records, []string{
+			time.Unix(attempt.CreatedAt, 0).Local().Format(time.RFC3339),
+			attempt.Username,
+			attempt.Ip,
+			attempt.UserAgent,
+			string(attempt.Result),
+		})
+	}
+	if err = w.WriteAll(records); err != nil {
+		slog.Default().With("X-Request-ID", ctx.GetString("X-Request-ID")).ErrorContext(ctx, "AdminExportLoginAttempts: failed to write csv", "error", err)
+	}
+}
+
+func (h *AccountHandler) toLoginAttemptVO(attempt domain.LoginAttempt) LoginAttemptVO {
+	return LoginAttemptVO{
+		Id:        attempt.Id,
+		Username:  attempt.Username,
+		Ip:        attempt.Ip,
+		UserAgent: attempt.UserAgent,
+		Result:    string(attempt.Result),
+		CreatedAt: attempt.CreatedAt,
+	}
+}
+
+func (h *AccountHandler) loginClient(ctx *gin.Context) domain.LoginClient {
+	return domain.LoginClient{
+		Ip:        ctx.ClientIP(),
+		UserAgent: ctx.GetHeader("User-Agent"),
+	}
+}
+
+// tooManyRequests 返回 429 并通过 Retry-After 告知客户端需要等待的秒数
+func (h *AccountHandler) tooManyRequests(ctx *gin.Context, err *service.LoginThrottledError) error {
+	ctx.Header("Retry-After", strconv.FormatInt(int64(err.RetryAfter/time.Second), 10))
+	return apiwrap.NewErrorResponseBody(http.StatusTooManyRequests, err.Error())
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	// CtxUserIdKey 当前登录用户的 id 在 gin.Context 中的 key
+	CtxUserIdKey = "adminUserId"
+	// CtxUsernameKey 当前登录用户的用户名在 gin.Context 中的 key
+	CtxUsernameKey = "adminUsername"
+	// CtxRoleKey 当前登录用户的角色在 gin.Context 中的 key
+	CtxRoleKey = "adminRole"
+	// CtxSessionIdKey 当前登录会话的 id 在 gin.Context 中的 key
+	CtxSessionIdKey = "adminSessionId"
+)
+
+// PermissionMiddleware 根据 jwt 中的用户 id 查询用户，并校验其角色是否有权限访问当前路由，
+// 需要在 JwtParseMiddleware 之后注册
+func (h *AccountHandler) PermissionMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		path := ctx.Request.URL.Path
+		if !strings.HasPrefix(path, "/admin-api") {
+			ctx.Next()
+			return
+		}
+		// JwtParseMiddleware 放行的接口不需要鉴权
+		value, exists := ctx.Get("jwtClaims")
+		if !exists {
+			ctx.Next()
+			return
+		}
+		claims, ok := value.(jwt.Claims)
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, nil)
+			return
+		}
+		userId, err := claims.GetSubject()
+		if err != nil || userId == "" {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, nil)
+			return
+		}
+		user, err := h.serv.GetUserById(ctx, userId)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) && !errors.Is(err, bson.ErrInvalidHex) {
+			slog.Default().With("X-Request-ID", ctx.GetString("X-Request-ID")).ErrorContext(ctx, "PermissionMiddleware: failed to get the user", "userId", userId, "error", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, nil)
+			return
+		}
+		// 用户已被删除或禁用
+		if err != nil || user.Disabled {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, nil)
+			return
+		}
+		// 先设置当前用户，被拒绝的请求也能在审计日志中记录操作人
+		ctx.Set(CtxUserIdKey, user.Id)
+		ctx.Set(CtxUsernameKey, user.Username)
+		ctx.Set(CtxRoleKey, string(user.Role))
+		if registeredClaims, ok := claims.(*jwt.RegisteredClaims); ok {
+			ctx.Set(CtxSessionIdKey, registeredClaims.ID)
+		}
+		if !user.Role.CanAccessRoute(ctx.Request.Method, path) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, nil)
+			return
+		}
+		ctx.Next()
+	}
+}
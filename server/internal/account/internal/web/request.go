@@ -0,0 +1,95 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/domain"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+)
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+	Nickname string `json:"nickname"`
+	Role     string `json:"role" binding:"required"`
+}
+
+type UpdateUserRequest struct {
+	Nickname string `json:"nickname"`
+	Role     string `json:"role" binding:"required"`
+	Disabled bool   `json:"disabled"`
+}
+
+type ResetPasswordRequest struct {
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type SessionFilterRequest struct {
+	UserId string `form:"user_id" json:"user_id"`
+	Ip     string `form:"ip" json:"ip"`
+	Device string `form:"device" json:"device"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type TotpCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type PasswordConfirmRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type LoginAttemptFilterRequest struct {
+	Username string `form:"username"`
+	Ip       string `form:"ip"`
+	Result   string `form:"result"`
+	// 秒级时间戳
+	StartTime int64 `form:"start_time"`
+	EndTime   int64 `form:"end_time"`
+}
+
+func (r LoginAttemptFilterRequest) toDomain() domain.LoginAttemptFilter {
+	return domain.LoginAttemptFilter{
+		Username:  r.Username,
+		Ip:        r.Ip,
+		Result:    domain.LoginResult(r.Result),
+		StartTime: r.StartTime,
+		EndTime:   r.EndTime,
+	}
+}
+
+type LoginAttemptPageRequest struct {
+	apiwrap.Page
+	LoginAttemptFilterRequest
+}
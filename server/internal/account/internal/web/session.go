@@ -0,0 +1,95 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/service"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func (h *AccountHandler) AdminRefreshToken(ctx *gin.Context, req RefreshTokenRequest) (*apiwrap.ResponseBody[TokenVO], error) {
+	tokens, err := h.serv.RefreshSession(ctx, req.RefreshToken, ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) {
+			return nil, *apiwrap.NewResponseBody[any](40103, err.Error(), nil)
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(TokenVO{
+		Token:             tokens.AccessToken,
+		Expiration:        tokens.AccessExpiration,
+		RefreshToken:      tokens.RefreshToken,
+		RefreshExpiration: tokens.RefreshExpiration,
+	}), nil
+}
+
+func (h *AccountHandler) AdminLogout(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	return apiwrap.SuccessResponse(), h.serv.Logout(ctx, ctx.GetString(CtxSessionIdKey))
+}
+
+func (h *AccountHandler) AdminGetOwnSessions(ctx *gin.Context) (*apiwrap.ResponseBody[apiwrap.ListVO[SessionVO]], error) {
+	sessions, err := h.serv.GetSessions(ctx, domain.SessionFilter{UserId: ctx.GetString(CtxUserIdKey)})
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewListVO(h.toSessionVOs(ctx, sessions))), nil
+}
+
+func (h *AccountHandler) AdminRevokeOwnSession(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	return apiwrap.SuccessResponse(), h.serv.RevokeSession(ctx, ctx.Param("id"), ctx.GetString(CtxUserIdKey))
+}
+
+func (h *AccountHandler) AdminGetSessions(ctx *gin.Context, req SessionFilterRequest) (*apiwrap.ResponseBody[apiwrap.ListVO[SessionVO]], error) {
+	sessions, err := h.serv.GetSessions(ctx, domain.SessionFilter{UserId: req.UserId, Ip: req.Ip, Device: req.Device})
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewListVO(h.toSessionVOs(ctx, sessions))), nil
+}
+
+func (h *AccountHandler) AdminRevokeSession(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	return apiwrap.SuccessResponse(), h.serv.RevokeSession(ctx, ctx.Param("id"), "")
+}
+
+// AdminRevokeSessions 按用户、设备和 IP 批量吊销会话
+func (h *AccountHandler) AdminRevokeSessions(ctx *gin.Context, req SessionFilterRequest) (*apiwrap.ResponseBody[RevokedCountVO], error) {
+	count, err := h.serv.RevokeSessions(ctx, domain.SessionFilter{UserId: req.UserId, Ip: req.Ip, Device: req.Device})
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(RevokedCountVO{Count: count}), nil
+}
+
+func (h *AccountHandler) toSessionVOs(ctx *gin.Context, sessions []domain.Session) []SessionVO {
+	currentId := ctx.GetString(CtxSessionIdKey)
+	result := make([]SessionVO, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, SessionVO{
+			Id:           session.Id,
+			UserId:       session.UserId,
+			Device:       session.Device,
+			UserAgent:    session.UserAgent,
+			Ip:           session.Ip,
+			Current:      session.Id == currentId,
+			ExpiresAt:    session.ExpiresAt,
+			LastActiveAt: session.LastActiveAt,
+			CreatedAt:    session.CreatedAt,
+		})
+	}
+	return result
+}
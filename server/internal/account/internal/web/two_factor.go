@@ -0,0 +1,102 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/service"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func (h *AccountHandler) AdminLoginTwoFactor(ctx *gin.Context, req TwoFactorLoginRequest) (*apiwrap.ResponseBody[LoginVO], error) {
+	if req.Code == "" && req.RecoveryCode == "" {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, "code or recovery_code is required")
+	}
+	user, err := h.serv.VerifyTwoFactorChallenge(ctx, req.ChallengeToken, req.Code, req.RecoveryCode, h.loginClient(ctx))
+	if err != nil {
+		var throttledErr *service.LoginThrottledError
+		if errors.As(err, &throttledErr) {
+			return nil, h.tooManyRequests(ctx, throttledErr)
+		}
+		return nil, h.twoFactorError(err)
+	}
+	return h.login(ctx, user)
+}
+
+func (h *AccountHandler) AdminGetTwoFactor(ctx *gin.Context) (*apiwrap.ResponseBody[TwoFactorVO], error) {
+	cfg, err := h.serv.GetTwoFactorConfig(ctx, ctx.GetString(CtxUserIdKey))
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(TwoFactorVO{
+		Enabled:                cfg.Enabled,
+		RemainingRecoveryCodes: len(cfg.RecoveryCodes),
+		EnabledAt:              cfg.EnabledAt,
+	}), nil
+}
+
+func (h *AccountHandler) AdminBeginTotpEnrollment(ctx *gin.Context) (*apiwrap.ResponseBody[TotpEnrollmentVO], error) {
+	enrollment, err := h.serv.BeginTotpEnrollment(ctx, ctx.GetString(CtxUserIdKey))
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(TotpEnrollmentVO{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	}), nil
+}
+
+func (h *AccountHandler) AdminConfirmTotpEnrollment(ctx *gin.Context, req TotpCodeRequest) (*apiwrap.ResponseBody[RecoveryCodesVO], error) {
+	codes, err := h.serv.ConfirmTotpEnrollment(ctx, ctx.GetString(CtxUserIdKey), req.Code)
+	if err != nil {
+		return nil, h.twoFactorError(err)
+	}
+	return apiwrap.SuccessResponseWithData(RecoveryCodesVO{RecoveryCodes: codes}), nil
+}
+
+func (h *AccountHandler) AdminDisableTwoFactor(ctx *gin.Context, req PasswordConfirmRequest) (*apiwrap.ResponseBody[any], error) {
+	return apiwrap.SuccessResponse(), h.serv.DisableTwoFactor(ctx, ctx.GetString(CtxUserIdKey), req.Password)
+}
+
+func (h *AccountHandler) AdminRegenerateRecoveryCodes(ctx *gin.Context, req PasswordConfirmRequest) (*apiwrap.ResponseBody[RecoveryCodesVO], error) {
+	codes, err := h.serv.RegenerateRecoveryCodes(ctx, ctx.GetString(CtxUserIdKey), req.Password)
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(RecoveryCodesVO{RecoveryCodes: codes}), nil
+}
+
+func (h *AccountHandler) AdminResetTwoFactor(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	return apiwrap.SuccessResponse(), h.serv.ResetTwoFactor(ctx, ctx.Param("id"))
+}
+
+func (h *AccountHandler) twoFactorError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserDisabled):
+		return *apiwrap.NewResponseBody[any](40102, "account is disabled", nil)
+	case errors.Is(err, service.ErrInvalidChallenge):
+		return *apiwrap.NewResponseBody[any](40104, err.Error(), nil)
+	case errors.Is(err, website_config.ErrInvalidTwoFactorCode):
+		return *apiwrap.NewResponseBody[any](40105, err.Error(), nil)
+	case errors.Is(err, website_config.ErrTwoFactorLocked):
+		return *apiwrap.NewResponseBody[any](40106, err.Error(), nil)
+	default:
+		return err
+	}
+}
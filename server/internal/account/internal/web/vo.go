@@ -0,0 +1,105 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+type LoginVO struct {
+	AdminInfo         AdminInfoVO `json:"admin_info"`
+	Expiration        int64       `json:"expiration"`
+	Token             string      `json:"token"`
+	RefreshToken      string      `json:"refresh_token"`
+	RefreshExpiration int64       `json:"refresh_expiration"`
+	// TwoFactorRequired 为 true 时仅返回挑战令牌，需携带验证码调用 /admin-api/login/2fa 完成登录
+	TwoFactorRequired   bool   `json:"two_factor_required"`
+	ChallengeToken      string `json:"challenge_token,omitempty"`
+	ChallengeExpiration int64  `json:"challenge_expiration,omitempty"`
+}
+
+type TokenVO struct {
+	Expiration        int64  `json:"expiration"`
+	Token             string `json:"token"`
+	RefreshToken      string `json:"refresh_token"`
+	RefreshExpiration int64  `json:"refresh_expiration"`
+}
+
+type AdminInfoVO struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+	Picture  string `json:"picture"`
+	Role     string `json:"role"`
+}
+
+type AdminUserVO struct {
+	Id          string `json:"id"`
+	Username    string `json:"username"`
+	Nickname    string `json:"nickname"`
+	Role        string `json:"role"`
+	Disabled    bool   `json:"disabled"`
+	LastLoginAt int64  `json:"last_login_at"`
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+}
+
+type CurrentUserVO struct {
+	AdminUserVO
+	// Permissions 资源 -> 权限，read 或 write
+	Permissions map[string]string `json:"permissions"`
+}
+
+type RoleVO struct {
+	Role        string            `json:"role"`
+	Permissions map[string]string `json:"permissions"`
+}
+
+type SessionVO struct {
+	Id           string `json:"id"`
+	UserId       string `json:"user_id"`
+	Device       string `json:"device"`
+	UserAgent    string `json:"user_agent"`
+	Ip           string `json:"ip"`
+	Current      bool   `json:"current"`
+	ExpiresAt    int64  `json:"expires_at"`
+	LastActiveAt int64  `json:"last_active_at"`
+	CreatedAt    int64  `json:"created_at"`
+}
+
+type RevokedCountVO struct {
+	Count int64 `json:"count"`
+}
+
+type TwoFactorVO struct {
+	Enabled                bool  `json:"enabled"`
+	RemainingRecoveryCodes int   `json:"remaining_recovery_codes"`
+	EnabledAt              int64 `json:"enabled_at"`
+}
+
+type TotpEnrollmentVO struct {
+	Secret string `json:"secret"`
+	// ProvisioningURI otpauth:// 格式，前端据此生成二维码
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type RecoveryCodesVO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type LoginAttemptVO struct {
+	Id        string `json:"id"`
+	Username  string `json:"username"`
+	Ip        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	Result    string `json:"result"`
+	CreatedAt int64  `json:"created_at"`
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build wireinject
+
+package account
+
+import (
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/repository"
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/repository/dao"
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/service"
+	"github.com/chenmingyong0423/fnote/server/internal/account/internal/web"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config"
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/google/wire"
+)
+
+var AccountProviders = wire.NewSet(web.NewAccountHandler, service.NewAccountService, repository.NewAdminUserRepository, dao.NewAdminUserDao,
+	wire.Bind(new(service.IAccountService), new(*service.AccountService)),
+	wire.Bind(new(repository.IAdminUserRepository), new(*repository.AdminUserRepository)),
+	wire.Bind(new(dao.IAdminUserDao), new(*dao.AdminUserDao)))
+
+func InitAccountModule(db *mongox.Database, cfgModule *website_config.Module) *Module {
+	panic(wire.Build(
+		AccountProviders,
+		wire.FieldsOf(new(*website_config.Module), "Svc"),
+		wire.Struct(new(Module), "Svc", "Hdl"),
+	))
+}
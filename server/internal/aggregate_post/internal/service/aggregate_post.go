@@ -0,0 +1,170 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	postPkg "github.com/chenmingyong0423/fnote/server/internal/post"
+	"github.com/chenmingyong0423/fnote/server/internal/post_draft"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	schedulePollInterval = 30 * time.Second
+	// publishLease 领取定时发布草稿后的租约时长，实例在发布过程中崩溃时，租约到期后由其他实例重新发布
+	publishLease = 5 * time.Minute
+	// publishTimeout 单次发布的超时时间，需小于 publishLease，保证租约过期前发布已经结束
+	publishTimeout       = time.Minute
+	maxPublishRetryDelay = 30 * time.Minute
+)
+
+type IAggregatePostService interface {
+	// PublishDraft 将草稿内容发布为文章，不删除草稿；手动发布与定时发布共用该逻辑
+	PublishDraft(ctx context.Context, draft post_draft.PostDraft) error
+}
+
+var _ IAggregatePostService = (*AggregatePostService)(nil)
+
+func NewAggregatePostService(postServ postPkg.Service, postDraftServ post_draft.Service) *AggregatePostService {
+	s := &AggregatePostService{
+		postServ:      postServ,
+		postDraftServ: postDraftServ,
+		instanceId:    uuid.NewString(),
+	}
+	go s.publishScheduledPeriodically()
+	return s
+}
+
+type AggregatePostService struct {
+	postServ      postPkg.Service
+	postDraftServ post_draft.Service
+	// instanceId 当前实例的标识，作为定时发布租约的持有者
+	instanceId string
+}
+
+func (s *AggregatePostService) PublishDraft(ctx context.Context, draft post_draft.PostDraft) error {
+	post, err := s.getPublishedPost(ctx, draft.Id)
+	if err != nil {
+		return err
+	}
+	return s.publish(ctx, post, draft)
+}
+
+// getPublishedPost 查询草稿对应的已发布文章，文章不存在时返回 nil
+func (s *AggregatePostService) getPublishedPost(ctx context.Context, id string) (*postPkg.Post, error) {
+	post, err := s.postServ.AdminGetPostById(ctx, id)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	return post, nil
+}
+
+func (s *AggregatePostService) publish(ctx context.Context, post *postPkg.Post, draft post_draft.PostDraft) error {
+	var createdAt int64
+	if post != nil {
+		createdAt = post.PrimaryPost.CreatedAt
+	}
+	return s.postServ.SavePost(ctx, post, &postPkg.Post{
+		PrimaryPost: postPkg.PrimaryPost{
+			Id:       draft.Id,
+			Author:   draft.Author,
+			Title:    draft.Title,
+			Summary:  draft.Summary,
+			CoverImg: draft.CoverImg,
+			Categories: slice.Map(draft.Categories, func(idx int, c post_draft.Category4PostDraft) postPkg.Category4Post {
+				return postPkg.Category4Post{
+					Id:   c.Id,
+					Name: c.Name,
+				}
+			}),
+			Tags: slice.Map(draft.Tags, func(idx int, t post_draft.Tag4PostDraft) postPkg.Tag4Post {
+				return postPkg.Tag4Post{
+					Id:   t.Id,
+					Name: t.Name,
+				}
+			}),
+			StickyWeight: draft.StickyWeight,
+			CreatedAt:    createdAt,
+		},
+		ExtraPost: postPkg.ExtraPost{
+			Content:          draft.Content,
+			MetaDescription:  draft.MetaDescription,
+			MetaKeywords:     draft.MetaKeywords,
+			WordCount:        draft.WordCount,
+			UpdatedAt:        time.Now().Local().Unix(),
+			IsDisplayed:      draft.IsDisplayed,
+			IsCommentAllowed: draft.IsCommentAllowed,
+			DraftRevision:    draft.Revision,
+		},
+	}, post == nil)
+}
+
+func (s *AggregatePostService) publishScheduledPeriodically() {
+	for {
+		s.publishDueDrafts(context.Background())
+		time.Sleep(schedulePollInterval)
+	}
+}
+
+// publishDueDrafts 逐篇领取并发布已到期的草稿。
+// 领取通过原子的 FindOneAndUpdate 完成，多实例部署时同一篇草稿只会被一个实例领取；
+// 发布成功后删除草稿，发布失败则释放租约并按失败次数延后重试。
+func (s *AggregatePostService) publishDueDrafts(ctx context.Context) {
+	l := slog.Default().With("X-Request-ID", uuid.NewString())
+	for {
+		draft, err := s.postDraftServ.ClaimDueScheduledPostDraft(ctx, s.instanceId, publishLease)
+		if err != nil {
+			if !errors.Is(err, mongo.ErrNoDocuments) {
+				l.Error("AggregatePost: failed to claim a scheduled post draft", "error", err)
+			}
+			return
+		}
+		err = s.publishClaimedDraft(ctx, draft)
+		if err != nil {
+			l.Error("AggregatePost: failed to publish the scheduled post draft", "id", draft.Id, "attempts", draft.PublishAttempts+1, "error", err)
+			retryAt := time.Now().Add(min(time.Duration(draft.PublishAttempts+1)*time.Minute, maxPublishRetryDelay))
+			if err = s.postDraftServ.ReleaseScheduledPostDraft(ctx, draft.Id, s.instanceId, retryAt, err.Error()); err != nil {
+				l.Error("AggregatePost: failed to release the scheduled post draft", "id", draft.Id, "error", err)
+			}
+			continue
+		}
+		l.Info("AggregatePost: scheduled post draft published", "id", draft.Id)
+	}
+}
+
+func (s *AggregatePostService) publishClaimedDraft(ctx context.Context, draft *post_draft.PostDraft) error {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	post, err := s.getPublishedPost(ctx, draft.Id)
+	if err != nil {
+		return err
+	}
+	// 文章记录的草稿版本与当前草稿一致，说明上次已发布成功，只是实例在删除草稿前崩溃，
+	// 此时不再重复发布，避免产生重复的修订记录和文章事件；其余情况一律按草稿内容发布
+	if post != nil && draft.Revision != "" && post.DraftRevision == draft.Revision {
+		slog.WarnContext(ctx, "AggregatePost: the scheduled post draft was already published, skip publishing", "id", draft.Id, "revision", draft.Revision)
+	} else if err = s.publish(ctx, post, *draft); err != nil {
+		return err
+	}
+	// 只删除仍由当前实例持有租约的草稿，发布期间被改期或取消的草稿会保留下来
+	_, err = s.postDraftServ.DeleteClaimedPostDraft(ctx, draft.Id, s.instanceId)
+	return err
+}
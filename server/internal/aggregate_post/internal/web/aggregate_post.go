@@ -17,6 +17,7 @@ package web
 import (
 	"time"
 
+	"github.com/chenmingyong0423/fnote/server/internal/aggregate_post/internal/service"
 	postPkg "github.com/chenmingyong0423/fnote/server/internal/post"
 
 	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
@@ -27,14 +28,16 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
-func NewAggregatePostHandler(postServ postPkg.Service, postDraftServ post_draft.Service) *AggregatePostHandler {
+func NewAggregatePostHandler(serv service.IAggregatePostService, postServ postPkg.Service, postDraftServ post_draft.Service) *AggregatePostHandler {
 	return &AggregatePostHandler{
+		serv:          serv,
 		postServ:      postServ,
 		postDraftServ: postDraftServ,
 	}
 }
 
 type AggregatePostHandler struct {
+	serv          service.IAggregatePostService
 	postServ      postPkg.Service
 	postDraftServ post_draft.Service
 }
@@ -132,6 +135,7 @@ func (h *AggregatePostHandler) postDraftToPostDraftVO(postDraft *post_draft.Post
 		WordCount:        postDraft.WordCount,
 		IsCommentAllowed: postDraft.IsCommentAllowed,
 		CreatedAt:        postDraft.CreatedAt,
+		ScheduledAt:      postDraft.ScheduledAt,
 	}
 }
 
@@ -165,46 +169,32 @@ func (h *AggregatePostHandler) postToPostDraftVO(post *postPkg.Post) *PostDraftV
 }
 
 func (h *AggregatePostHandler) AdminPublishDraft(ctx *gin.Context, req PostReq) (*apiwrap.ResponseBody[any], error) {
-	var createdAt int64
-	post, err := h.postServ.AdminGetPostById(ctx, req.Id)
-	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
-		return nil, err
-	}
-	if post != nil {
-		createdAt = post.PrimaryPost.CreatedAt
-	}
-	err = h.postServ.SavePost(ctx, post, &postPkg.Post{
-		PrimaryPost: postPkg.PrimaryPost{
-			Id:       req.Id,
-			Author:   req.Author,
-			Title:    req.Title,
-			Summary:  req.Summary,
-			CoverImg: req.CoverImg,
-			Categories: slice.Map(req.Categories, func(idx int, c Category4Post) postPkg.Category4Post {
-				return postPkg.Category4Post{
-					Id:   c.Id,
-					Name: c.Name,
-				}
-			}),
-			Tags: slice.Map(req.Tags, func(idx int, t Tag4Post) postPkg.Tag4Post {
-				return postPkg.Tag4Post{
-					Id:   t.Id,
-					Name: t.Name,
-				}
-			}),
-			StickyWeight: req.StickyWeight,
-			CreatedAt:    createdAt,
-		},
-		ExtraPost: postPkg.ExtraPost{
-			Content:          req.Content,
-			MetaDescription:  req.MetaDescription,
-			MetaKeywords:     req.MetaKeywords,
-			WordCount:        req.WordCount,
-			UpdatedAt:        time.Now().Local().Unix(),
-			IsDisplayed:      req.IsDisplayed,
-			IsCommentAllowed: req.IsCommentAllowed,
-		},
-	}, post == nil)
+	err := h.serv.PublishDraft(ctx, post_draft.PostDraft{
+		Id:       req.Id,
+		Author:   req.Author,
+		Title:    req.Title,
+		Summary:  req.Summary,
+		CoverImg: req.CoverImg,
+		Categories: slice.Map(req.Categories, func(idx int, c Category4Post) post_draft.Category4PostDraft {
+			return post_draft.Category4PostDraft{
+				Id:   c.Id,
+				Name: c.Name,
+			}
+		}),
+		Tags: slice.Map(req.Tags, func(idx int, t Tag4Post) post_draft.Tag4PostDraft {
+			return post_draft.Tag4PostDraft{
+				Id:   t.Id,
+				Name: t.Name,
+			}
+		}),
+		StickyWeight:     req.StickyWeight,
+		Content:          req.Content,
+		MetaDescription:  req.MetaDescription,
+		MetaKeywords:     req.MetaKeywords,
+		WordCount:        req.WordCount,
+		IsDisplayed:      req.IsDisplayed,
+		IsCommentAllowed: req.IsCommentAllowed,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -30,4 +30,6 @@ type PostDraftVO struct {
 	WordCount        int                  `json:"word_count"`
 	IsCommentAllowed bool                 `json:"is_comment_allowed"`
 	CreatedAt        int64                `json:"created_at"`
+	// 定时发布时间，未设置时不返回
+	ScheduledAt int64 `json:"scheduled_at,omitempty"`
 }
@@ -17,13 +17,14 @@
 package aggregate_post
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/aggregate_post/internal/service"
 	"github.com/chenmingyong0423/fnote/server/internal/aggregate_post/internal/web"
 	"github.com/chenmingyong0423/fnote/server/internal/post"
 	"github.com/chenmingyong0423/fnote/server/internal/post_draft"
 	"github.com/google/wire"
 )
 
-var AggregatePostProviders = wire.NewSet(web.NewAggregatePostHandler)
+var AggregatePostProviders = wire.NewSet(web.NewAggregatePostHandler, service.NewAggregatePostService, wire.Bind(new(service.IAggregatePostService), new(*service.AggregatePostService)))
 
 func InitAggregatePostModule(postModel *post.Module, postDraftModel *post_draft.Module) *Module {
 	panic(wire.Build(
@@ -7,6 +7,7 @@
 package aggregate_post
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/aggregate_post/internal/service"
 	"github.com/chenmingyong0423/fnote/server/internal/aggregate_post/internal/web"
 	"github.com/chenmingyong0423/fnote/server/internal/post"
 	"github.com/chenmingyong0423/fnote/server/internal/post_draft"
@@ -18,7 +19,8 @@ import (
 func InitAggregatePostModule(postModel *post.Module, postDraftModel *post_draft.Module) *Module {
 	iPostService := postModel.Svc
 	iPostDraftService := postDraftModel.Svc
-	aggregatePostHandler := web.NewAggregatePostHandler(iPostService, iPostDraftService)
+	aggregatePostService := service.NewAggregatePostService(iPostService, iPostDraftService)
+	aggregatePostHandler := web.NewAggregatePostHandler(aggregatePostService, iPostService, iPostDraftService)
 	module := &Module{
 		Hdl: aggregatePostHandler,
 	}
@@ -27,4 +29,4 @@ func InitAggregatePostModule(postModel *post.Module, postDraftModel *post_draft.
 
 // wire.go:
 
-var AggregatePostProviders = wire.NewSet(web.NewAggregatePostHandler)
+var AggregatePostProviders = wire.NewSet(web.NewAggregatePostHandler, service.NewAggregatePostService, wire.Bind(new(service.IAggregatePostService), new(*service.AggregatePostService)))
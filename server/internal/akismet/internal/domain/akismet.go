@@ -0,0 +1,40 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import "time"
+
+// Submission 提交给反垃圾服务的内容，字段对应 Akismet 接口的参数
+type Submission struct {
+	// 内容类型，对应 comment_type，例如 comment、reply、signup
+	Type        string
+	UserIp      string
+	UserAgent   string
+	Referrer    string
+	Permalink   string
+	Author      string
+	AuthorEmail string
+	AuthorUrl   string
+	Content     string
+	// 内容的创建时间，零值时使用服务端收到请求的时间
+	CreatedAt time.Time
+}
+
+// CheckResult 反垃圾服务的检查结果
+type CheckResult struct {
+	Spam bool
+	// 明显的垃圾内容，可以直接丢弃
+	Discard bool
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/akismet/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config"
+	"github.com/pkg/errors"
+)
+
+const (
+	requestTimeout = 5 * time.Second
+	// pauseDuration 请求失败后暂停调用的时长，避免服务不可用时拖慢每一次提交
+	pauseDuration = time.Minute
+)
+
+// ErrAkismetUnavailable 服务不可用，调用方应忽略该错误，按未检查处理
+var ErrAkismetUnavailable = errors.New("the spam check service is unavailable")
+
+type IAkismetService interface {
+	// CheckSpam 检查内容是否为垃圾内容，未启用时返回非垃圾内容
+	CheckSpam(ctx context.Context, submission domain.Submission) (domain.CheckResult, error)
+	// SubmitSpam 反馈漏判的垃圾内容，未启用时不做任何处理
+	SubmitSpam(ctx context.Context, submission domain.Submission) error
+	// SubmitHam 反馈误判的正常内容，未启用时不做任何处理
+	SubmitHam(ctx context.Context, submission domain.Submission) error
+}
+
+var _ IAkismetService = (*AkismetService)(nil)
+
+func NewAkismetService(cfgService website_config.Service) *AkismetService {
+	return &AkismetService{
+		cfgService: cfgService,
+		client:     &http.Client{Timeout: requestTimeout},
+	}
+}
+
+type AkismetService struct {
+	cfgService website_config.Service
+	client     *http.Client
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+}
+
+func (s *AkismetService) CheckSpam(ctx context.Context, submission domain.Submission) (domain.CheckResult, error) {
+	header, body, err := s.call(ctx, "comment-check", submission)
+	if err != nil || header == nil {
+		return domain.CheckResult{}, err
+	}
+	switch body {
+	case "true":
+		return domain.CheckResult{Spam: true, Discard: header.Get("X-akismet-pro-tip") == "discard"}, nil
+	case "false":
+		return domain.CheckResult{}, nil
+	}
+	return domain.CheckResult{}, invalidResponseError("comment-check", header, body)
+}
+
+func (s *AkismetService) SubmitSpam(ctx context.Context, submission domain.Submission) error {
+	_, _, err := s.call(ctx, "submit-spam", submission)
+	return err
+}
+
+func (s *AkismetService) SubmitHam(ctx context.Context, submission domain.Submission) error {
+	_, _, err := s.call(ctx, "submit-ham", submission)
+	return err
+}
+
+// call 调用 Akismet 接口，未启用时返回的 header 为 nil
+func (s *AkismetService) call(ctx context.Context, method string, submission domain.Submission) (http.Header, string, error) {
+	cfg, err := s.cfgService.GetAkismetConfig(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if !cfg.Enabled || cfg.ApiKey == "" {
+		return nil, "", nil
+	}
+	if s.paused() {
+		return nil, "", ErrAkismetUnavailable
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(cfg.Endpoint, "/")+"/"+method, strings.NewReader(toForm(cfg.ApiKey, submission).Encode()))
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to create akismet %s request", method)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "fnote")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.pause()
+		return nil, "", errors.Wrapf(ErrAkismetUnavailable, "akismet %s: %v", method, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		s.pause()
+		return nil, "", errors.Wrapf(ErrAkismetUnavailable, "akismet %s: %v", method, err)
+	}
+	body := strings.TrimSpace(string(data))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		s.pause()
+		return nil, "", errors.Wrapf(ErrAkismetUnavailable, "akismet %s: status %d", method, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || body == "invalid" {
+		return nil, "", invalidResponseError(method, resp.Header, body)
+	}
+	return resp.Header, body, nil
+}
+
+func (s *AkismetService) paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Now().Before(s.pausedUntil)
+}
+
+func (s *AkismetService) pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pausedUntil = time.Now().Add(pauseDuration)
+}
+
+func toForm(apiKey string, submission domain.Submission) url.Values {
+	form := url.Values{}
+	form.Set("api_key", apiKey)
+	form.Set("blog", pkg.GetOrDefault4String(os.Getenv("WEBSITE_BASE_HOST"), "http://localhost:3000"))
+	form.Set("blog_charset", "UTF-8")
+	form.Set("user_ip", submission.UserIp)
+	form.Set("user_agent", submission.UserAgent)
+	form.Set("comment_type", submission.Type)
+	form.Set("comment_author", submission.Author)
+	form.Set("comment_author_email", submission.AuthorEmail)
+	form.Set("comment_content", submission.Content)
+	if submission.Referrer != "" {
+		form.Set("referrer", submission.Referrer)
+	}
+	if submission.Permalink != "" {
+		form.Set("permalink", submission.Permalink)
+	}
+	if submission.AuthorUrl != "" {
+		form.Set("comment_author_url", submission.AuthorUrl)
+	}
+	if !submission.CreatedAt.IsZero() {
+		form.Set("comment_date_gmt", submission.CreatedAt.UTC().Format(time.RFC3339))
+	}
+	return form
+}
+
+func invalidResponseError(method string, header http.Header, body string) error {
+	if help := header.Get("X-akismet-debug-help"); help != "" {
+		return fmt.Errorf("akismet %s: invalid response %q: %s", method, body, help)
+	}
+	return fmt.Errorf("akismet %s: invalid response %q", method, body)
+}
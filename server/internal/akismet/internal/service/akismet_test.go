@@ -0,0 +1,160 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/chenmingyong0423/fnote/server/internal/akismet/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config"
+	"github.com/pkg/errors"
+)
+
+// fakeConfigService 只实现 GetAkismetConfig，其他方法不会被调用
+type fakeConfigService struct {
+	website_config.Service
+	cfg website_config.AkismetConfig
+}
+
+func (f *fakeConfigService) GetAkismetConfig(context.Context) (website_config.AkismetConfig, error) {
+	return f.cfg, nil
+}
+
+// newAkismetServer 启动模拟的 Akismet 服务，返回服务和请求次数
+func newAkismetServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func newTestAkismetService(endpoint string) *AkismetService {
+	return NewAkismetService(&fakeConfigService{cfg: website_config.AkismetConfig{
+		Enabled:  true,
+		Endpoint: endpoint + "/",
+		ApiKey:   "key",
+	}})
+}
+
+var submission = domain.Submission{
+	Type:        "comment",
+	UserIp:      "203.0.113.1",
+	UserAgent:   "test",
+	Author:      "fnote",
+	AuthorEmail: "fnote@example.com",
+	Content:     "hello",
+}
+
+func TestAkismetService_CheckSpam(t *testing.T) {
+	testCases := []struct {
+		name    string
+		body    string
+		proTip  string
+		want    domain.CheckResult
+		wantErr bool
+	}{
+		{name: "ham", body: "false", want: domain.CheckResult{}},
+		{name: "spam", body: "true", want: domain.CheckResult{Spam: true}},
+		{name: "discard", body: "true", proTip: "discard", want: domain.CheckResult{Spam: true, Discard: true}},
+		{name: "invalid", body: "invalid", wantErr: true},
+		{name: "unexpected body", body: "maybe", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, _ := newAkismetServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/comment-check" {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				if r.FormValue("api_key") != "key" || r.FormValue("user_ip") != submission.UserIp || r.FormValue("comment_content") != submission.Content {
+					t.Errorf("unexpected form %v", r.Form)
+				}
+				if tc.proTip != "" {
+					w.Header().Set("X-akismet-pro-tip", tc.proTip)
+				}
+				_, _ = w.Write([]byte(tc.body))
+			})
+			s := newTestAkismetService(srv.URL)
+			got, err := s.CheckSpam(context.Background(), submission)
+			if tc.wantErr {
+				if err == nil || errors.Is(err, ErrAkismetUnavailable) {
+					t.Fatalf("CheckSpam error = %v, want an invalid response error", err)
+				}
+				// 无效响应属于配置问题，不暂停调用
+				if s.paused() {
+					t.Fatal("the service should not be paused on an invalid response")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Fatalf("CheckSpam = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAkismetService_ServerError(t *testing.T) {
+	srv, hits := newAkismetServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	s := newTestAkismetService(srv.URL)
+	if _, err := s.CheckSpam(context.Background(), submission); !errors.Is(err, ErrAkismetUnavailable) {
+		t.Fatalf("CheckSpam error = %v, want ErrAkismetUnavailable", err)
+	}
+	// 暂停期间不再请求服务
+	if err := s.SubmitSpam(context.Background(), submission); !errors.Is(err, ErrAkismetUnavailable) {
+		t.Fatalf("SubmitSpam error = %v, want ErrAkismetUnavailable", err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("server was called %d times, want 1", n)
+	}
+}
+
+func TestAkismetService_Down(t *testing.T) {
+	srv, _ := newAkismetServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	s := newTestAkismetService(srv.URL)
+	srv.Close()
+	if _, err := s.CheckSpam(context.Background(), submission); !errors.Is(err, ErrAkismetUnavailable) {
+		t.Fatalf("CheckSpam error = %v, want ErrAkismetUnavailable", err)
+	}
+	if !s.paused() {
+		t.Fatal("the service should be paused after a connection failure")
+	}
+}
+
+func TestAkismetService_Disabled(t *testing.T) {
+	srv, hits := newAkismetServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	s := NewAkismetService(&fakeConfigService{cfg: website_config.AkismetConfig{Endpoint: srv.URL, ApiKey: "key"}})
+	got, err := s.CheckSpam(context.Background(), submission)
+	if err != nil || got.Spam {
+		t.Fatalf("CheckSpam = %+v, %v, want not spam", got, err)
+	}
+	if err = s.SubmitHam(context.Background(), submission); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 0 {
+		t.Fatalf("server was called %d times, want 0", n)
+	}
+}
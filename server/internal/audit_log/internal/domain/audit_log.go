@@ -0,0 +1,52 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+// AuditLog 后台写操作的审计记录
+type AuditLog struct {
+	Id       string
+	UserId   string
+	Username string
+	Role     string
+	Method   string
+	// Route 路由模板，例如 /admin-api/posts/:id
+	Route string
+	// Path 实际请求路径
+	Path string
+	// TargetId 路径参数，只有一个参数时为参数值，多个时为 name=value 以逗号拼接
+	TargetId string
+	// Request 脱敏后的请求摘要
+	Request   string
+	Ip        string
+	UserAgent string
+	// StatusCode http 状态码
+	StatusCode int
+	// ResponseCode 响应体中的业务码
+	ResponseCode int
+	// Duration 耗时，单位毫秒
+	Duration  int64
+	CreatedAt int64
+}
+
+type AuditLogFilter struct {
+	UserId     string
+	Username   string
+	Method     string
+	Route      string
+	TargetId   string
+	StatusCode int
+	StartTime  int64
+	EndTime    int64
+}
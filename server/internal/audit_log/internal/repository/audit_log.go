@@ -0,0 +1,131 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log/internal/repository/dao"
+	"github.com/chenmingyong0423/go-mongox/v2/bsonx"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type IAuditLogRepository interface {
+	Create(ctx context.Context, auditLog domain.AuditLog) error
+	FindWithPagination(ctx context.Context, filter domain.AuditLogFilter, skip, limit int64) ([]domain.AuditLog, int64, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+var _ IAuditLogRepository = (*AuditLogRepository)(nil)
+
+func NewAuditLogRepository(dao dao.IAuditLogDao) *AuditLogRepository {
+	return &AuditLogRepository{dao: dao}
+}
+
+type AuditLogRepository struct {
+	dao dao.IAuditLogDao
+}
+
+func (r *AuditLogRepository) Create(ctx context.Context, auditLog domain.AuditLog) error {
+	return r.dao.Create(ctx, &dao.AuditLog{
+		UserId:       auditLog.UserId,
+		Username:     auditLog.Username,
+		Role:         auditLog.Role,
+		Method:       auditLog.Method,
+		Route:        auditLog.Route,
+		Path:         auditLog.Path,
+		TargetId:     auditLog.TargetId,
+		Request:      auditLog.Request,
+		Ip:           auditLog.Ip,
+		UserAgent:    auditLog.UserAgent,
+		StatusCode:   auditLog.StatusCode,
+		ResponseCode: auditLog.ResponseCode,
+		Duration:     auditLog.Duration,
+	})
+}
+
+func (r *AuditLogRepository) FindWithPagination(ctx context.Context, filter domain.AuditLogFilter, skip, limit int64) ([]domain.AuditLog, int64, error) {
+	cond := r.filterToBson(filter)
+	total, err := r.dao.Count(ctx, cond)
+	if err != nil {
+		return nil, 0, err
+	}
+	auditLogs, err := r.dao.Find(ctx, cond, options.Find().SetSort(bsonx.M("created_at", -1)).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	result := make([]domain.AuditLog, 0, len(auditLogs))
+	for _, auditLog := range auditLogs {
+		result = append(result, r.toDomain(auditLog))
+	}
+	return result, total, nil
+}
+
+func (r *AuditLogRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	return r.dao.DeleteBefore(ctx, before)
+}
+
+func (r *AuditLogRepository) filterToBson(filter domain.AuditLogFilter) bson.D {
+	builder := query.NewBuilder()
+	if filter.UserId != "" {
+		builder.Eq("user_id", filter.UserId)
+	}
+	if filter.Username != "" {
+		builder.Eq("username", filter.Username)
+	}
+	if filter.Method != "" {
+		builder.Eq("method", filter.Method)
+	}
+	if filter.Route != "" {
+		builder.Eq("route", filter.Route)
+	}
+	if filter.TargetId != "" {
+		builder.Eq("target_id", filter.TargetId)
+	}
+	if filter.StatusCode != 0 {
+		builder.Eq("status_code", filter.StatusCode)
+	}
+	if filter.StartTime > 0 {
+		builder.Gte("created_at", time.Unix(filter.StartTime, 0).Local())
+	}
+	if filter.EndTime > 0 {
+		builder.Lte("created_at", time.Unix(filter.EndTime, 0).Local())
+	}
+	return builder.Build()
+}
+
+func (r *AuditLogRepository) toDomain(auditLog *dao.AuditLog) domain.AuditLog {
+	return domain.AuditLog{
+		Id:           auditLog.ID.Hex(),
+		UserId:       auditLog.UserId,
+		Username:     auditLog.Username,
+		Role:         auditLog.Role,
+		Method:       auditLog.Method,
+		Route:        auditLog.Route,
+		Path:         auditLog.Path,
+		TargetId:     auditLog.TargetId,
+		Request:      auditLog.Request,
+		Ip:           auditLog.Ip,
+		UserAgent:    auditLog.UserAgent,
+		StatusCode:   auditLog.StatusCode,
+		ResponseCode: auditLog.ResponseCode,
+		Duration:     auditLog.Duration,
+		CreatedAt:    auditLog.CreatedAt.Unix(),
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type AuditLog struct {
+	mongox.Model `bson:",inline"`
+	UserId       string `bson:"user_id"`
+	Username     string `bson:"username"`
+	Role         string `bson:"role"`
+	Method       string `bson:"method"`
+	Route        string `bson:"route"`
+	Path         string `bson:"path"`
+	TargetId     string `bson:"target_id"`
+	Request      string `bson:"request"`
+	Ip           string `bson:"ip"`
+	UserAgent    string `bson:"user_agent"`
+	StatusCode   int    `bson:"status_code"`
+	ResponseCode int    `bson:"response_code"`
+	Duration     int64  `bson:"duration"`
+}
+
+type IAuditLogDao interface {
+	Create(ctx context.Context, auditLog *AuditLog) error
+	Find(ctx context.Context, filter bson.D, findOptions *options.FindOptionsBuilder) ([]*AuditLog, error)
+	Count(ctx context.Context, filter bson.D) (int64, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+var _ IAuditLogDao = (*AuditLogDao)(nil)
+
+func NewAuditLogDao(db *mongox.Database) *AuditLogDao {
+	return &AuditLogDao{coll: mongox.NewCollection[AuditLog](db, "audit_logs")}
+}
+
+type AuditLogDao struct {
+	coll *mongox.Collection[AuditLog]
+}
+
+func (d *AuditLogDao) Create(ctx context.Context, auditLog *AuditLog) error {
+	_, err := d.coll.Creator().InsertOne(ctx, auditLog)
+	if err != nil {
+		return errors.Wrapf(err, "fails to create audit log, method=%s, path=%s", auditLog.Method, auditLog.Path)
+	}
+	return nil
+}
+
+func (d *AuditLogDao) Find(ctx context.Context, filter bson.D, findOptions *options.FindOptionsBuilder) ([]*AuditLog, error) {
+	auditLogs, err := d.coll.Finder().Filter(filter).Find(ctx, findOptions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find audit logs, filter=%v", filter)
+	}
+	return auditLogs, nil
+}
+
+func (d *AuditLogDao) Count(ctx context.Context, filter bson.D) (int64, error) {
+	count, err := d.coll.Finder().Filter(filter).Count(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to count audit logs, filter=%v", filter)
+	}
+	return count, nil
+}
+
+func (d *AuditLogDao) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	deleteResult, err := d.coll.Deleter().Filter(query.Lt("created_at", before)).DeleteMany(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to delete audit logs before %s", before)
+	}
+	return deleteResult.DeletedCount, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log/internal/repository"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultRetention = 90 * 24 * time.Hour
+	cleanupInterval  = time.Hour
+)
+
+type IAuditLogService interface {
+	Record(ctx context.Context, auditLog domain.AuditLog) error
+	GetAuditLogs(ctx context.Context, filter domain.AuditLogFilter, pageNo, pageSize int64) ([]domain.AuditLog, int64, error)
+	// CleanupExpired 删除超过保留期限的审计记录
+	CleanupExpired(ctx context.Context) (int64, error)
+}
+
+var _ IAuditLogService = (*AuditLogService)(nil)
+
+func NewAuditLogService(repo repository.IAuditLogRepository) *AuditLogService {
+	s := &AuditLogService{repo: repo}
+	go s.cleanupPeriodically()
+	return s
+}
+
+type AuditLogService struct {
+	repo repository.IAuditLogRepository
+}
+
+func (s *AuditLogService) Record(ctx context.Context, auditLog domain.AuditLog) error {
+	return s.repo.Create(ctx, auditLog)
+}
+
+func (s *AuditLogService) GetAuditLogs(ctx context.Context, filter domain.AuditLogFilter, pageNo, pageSize int64) ([]domain.AuditLog, int64, error) {
+	return s.repo.FindWithPagination(ctx, filter, (pageNo-1)*pageSize, pageSize)
+}
+
+func (s *AuditLogService) CleanupExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteBefore(ctx, time.Now().Add(-retention()).Local())
+}
+
+func (s *AuditLogService) cleanupPeriodically() {
+	for {
+		deleted, err := s.CleanupExpired(context.Background())
+		l := slog.Default().With("X-Request-ID", uuid.NewString())
+		if err != nil {
+			l.Error("AuditLog: failed to clean up expired audit logs", "error", err)
+		} else if deleted > 0 {
+			l.Info("AuditLog: expired audit logs cleaned up", "count", deleted)
+		}
+		time.Sleep(cleanupInterval)
+	}
+}
+
+// retention 审计记录的保留期限，通过 audit_log.retention 配置
+func retention() time.Duration {
+	if d := viper.GetDuration("audit_log.retention"); d > 0 {
+		return d
+	}
+	return defaultRetention
+}
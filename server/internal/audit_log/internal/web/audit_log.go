@@ -0,0 +1,74 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log/internal/service"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/gin-gonic/gin"
+)
+
+func NewAuditLogHandler(serv service.IAuditLogService) *AuditLogHandler {
+	return &AuditLogHandler{
+		serv: serv,
+	}
+}
+
+type AuditLogHandler struct {
+	serv service.IAuditLogService
+}
+
+func (h *AuditLogHandler) RegisterGinRoutes(engine *gin.Engine) {
+	adminGroup := engine.Group("/admin-api/audit-logs")
+	adminGroup.GET("", apiwrap.WrapWithBody(h.AdminGetAuditLogs))
+}
+
+func (h *AuditLogHandler) AdminGetAuditLogs(ctx *gin.Context, req PageRequest) (*apiwrap.ResponseBody[*apiwrap.PageVO[AuditLogVO]], error) {
+	auditLogs, total, err := h.serv.GetAuditLogs(ctx, domain.AuditLogFilter{
+		UserId:     req.UserId,
+		Username:   req.Username,
+		Method:     req.Method,
+		Route:      req.Route,
+		TargetId:   req.TargetId,
+		StatusCode: req.StatusCode,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+	}, req.PageNo, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]AuditLogVO, 0, len(auditLogs))
+	for _, auditLog := range auditLogs {
+		result = append(result, AuditLogVO{
+			Id:           auditLog.Id,
+			UserId:       auditLog.UserId,
+			Username:     auditLog.Username,
+			Role:         auditLog.Role,
+			Method:       auditLog.Method,
+			Route:        auditLog.Route,
+			Path:         auditLog.Path,
+			TargetId:     auditLog.TargetId,
+			Request:      auditLog.Request,
+			Ip:           auditLog.Ip,
+			UserAgent:    auditLog.UserAgent,
+			StatusCode:   auditLog.StatusCode,
+			ResponseCode: auditLog.ResponseCode,
+			Duration:     auditLog.Duration,
+			CreatedAt:    auditLog.CreatedAt,
+		})
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewPageVO(req.PageNo, req.PageSize, total, result)), nil
+}
@@ -0,0 +1,332 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/account"
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/web/audit"
+	"github.com/gin-gonic/gin"
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	// maxRequestBodySize 超过该大小的请求体只记录大小
+	maxRequestBodySize = 64 << 10
+	// maxRequestSummaryLen 请求摘要的最大长度
+	maxRequestSummaryLen = 2048
+	redacted             = "***"
+	recordTimeout        = 5 * time.Second
+)
+
+// sensitiveKeyParts 字段名包含这些内容时脱敏
+var sensitiveKeyParts = []string{"password", "passwd", "passphrase", "secret", "token", "api_key", "apikey", "private_key", "access_key", "credential", "authorization"}
+
+// sensitiveKeys 需要完全匹配才脱敏的字段，例如两步验证的验证码
+var sensitiveKeys = []string{"code", "recovery_code"}
+
+// AuditMiddleware 记录 /admin-api 下除 GET、HEAD、OPTIONS 以外的请求，需要在 JwtParseMiddleware 之前注册，
+// 以便在请求结束后读取 PermissionMiddleware 设置的当前用户；未登录的请求不记录，登录行为见登录记录
+func (h *AuditLogHandler) AuditMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		method := ctx.Request.Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions || !strings.HasPrefix(ctx.Request.URL.Path, "/admin-api") {
+			ctx.Next()
+			return
+		}
+		start := time.Now()
+		request := captureRequest(ctx.Request)
+		writer := &responseCodeWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+
+		ctx.Next()
+
+		userId := ctx.GetString(account.CtxUserIdKey)
+		if userId == "" {
+			return
+		}
+		auditLog := domain.AuditLog{
+			UserId:   userId,
+			Username: ctx.GetString(account.CtxUsernameKey),
+			Role:     ctx.GetString(account.CtxRoleKey),
+			Method:   method,
+			Route:    ctx.FullPath(),
+			Path:     ctx.Request.URL.Path,
+			TargetId: targetIdOf(ctx.Params),
+			// 路由通过 audit.Redact 声明的脱敏字段在 ctx.Next() 之后才能读取
+			Request:      request.summary(audit.RedactedFields(ctx), audit.Notes(ctx)),
+			Ip:           ctx.ClientIP(),
+			UserAgent:    ctx.Request.UserAgent(),
+			StatusCode:   writer.Status(),
+			ResponseCode: writer.responseCode(),
+			Duration:     time.Since(start).Milliseconds(),
+		}
+		requestId := ctx.GetString("X-Request-ID")
+		go func() {
+			recordCtx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+			defer cancel()
+			if err := h.serv.Record(recordCtx, auditLog); err != nil {
+				slog.Default().With("X-Request-ID", requestId).ErrorContext(recordCtx, "AuditMiddleware: failed to record audit log", "method", auditLog.Method, "path", auditLog.Path, "error", err)
+			}
+		}()
+	}
+}
+
+// responseCodeWriter 保留响应体开头的内容，用于解析 apiwrap.ResponseBody 中的业务码
+type responseCodeWriter struct {
+	gin.ResponseWriter
+	head []byte
+}
+
+const responseHeadSize = 32
+
+func (w *responseCodeWriter) Write(b []byte) (int, error) {
+	w.keepHead(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseCodeWriter) WriteString(s string) (int, error) {
+	w.keepHead([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseCodeWriter) keepHead(b []byte) {
+	if remaining := responseHeadSize - len(w.head); remaining > 0 {
+		w.head = append(w.head, b[:min(len(b), remaining)]...)
+	}
+}
+
+// responseCode 解析形如 {"code":40101,... 的响应体，解析失败时返回 0
+func (w *responseCodeWriter) responseCode() int {
+	rest, found := bytes.CutPrefix(w.head, []byte(`{"code":`))
+	if !found {
+		return 0
+	}
+	end := bytes.IndexAny(rest, ",}")
+	if end < 0 {
+		return 0
+	}
+	code, err := strconv.Atoi(string(rest[:end]))
+	if err != nil {
+		return 0
+	}
+	return code
+}
+
+func targetIdOf(params gin.Params) string {
+	switch len(params) {
+	case 0:
+		return ""
+	case 1:
+		return params[0].Value
+	default:
+		pairs := make([]string, 0, len(params))
+		for _, param := range params {
+			pairs = append(pairs, param.Key+"="+param.Value)
+		}
+		return strings.Join(pairs, ",")
+	}
+}
+
+// capturedRequest 在处理请求前读取的查询参数和请求体，读取的请求体会被放回，不影响后续处理
+type capturedRequest struct {
+	query     url.Values
+	mediaType string
+	body      []byte
+	// note 不记录内容时的说明，例如文件上传只记录类型和大小
+	note string
+}
+
+func captureRequest(r *http.Request) *capturedRequest {
+	captured := &capturedRequest{}
+	if r.URL.RawQuery != "" {
+		captured.query = r.URL.Query()
+	}
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return captured
+	}
+	captured.mediaType, _, _ = mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if captured.mediaType != "application/json" && captured.mediaType != "application/x-www-form-urlencoded" {
+		captured.note = fmt.Sprintf("%s, %d bytes", captured.mediaType, r.ContentLength)
+		return captured
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize+1))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), r.Body))
+	switch {
+	case err != nil:
+		captured.note = fmt.Sprintf("%s, unreadable: %s", captured.mediaType, err)
+	case len(data) > maxRequestBodySize:
+		captured.note = fmt.Sprintf("%s, more than %d bytes", captured.mediaType, maxRequestBodySize)
+	default:
+		captured.body = data
+	}
+	return captured
+}
+
+// summary 生成脱敏后的请求摘要，fields 为路由额外声明的脱敏字段，notes 为处理请求时附加的结果
+func (c *capturedRequest) summary(fields []string, notes []string) string {
+	r := redactor{fields: fields}
+	parts := make([]string, 0, 3)
+	if len(c.query) > 0 {
+		parts = append(parts, "query: "+encodeValues(r.redactValues(c.query)))
+	}
+	if body := c.summarizeBody(r); body != "" {
+		parts = append(parts, "body: "+body)
+	}
+	if len(notes) > 0 {
+		parts = append(parts, "result: "+strings.Join(notes, ", "))
+	}
+	summary := strings.Join(parts, "; ")
+	if len(summary) > maxRequestSummaryLen {
+		summary = summary[:maxRequestSummaryLen] + "...(truncated)"
+	}
+	return summary
+}
+
+func (c *capturedRequest) summarizeBody(r redactor) string {
+	if c.note != "" || c.body == nil {
+		return c.note
+	}
+	if c.mediaType == "application/x-www-form-urlencoded" {
+		values, err := url.ParseQuery(string(c.body))
+		if err != nil {
+			return fmt.Sprintf("%s, %d bytes", c.mediaType, len(c.body))
+		}
+		return encodeValues(r.redactValues(values))
+	}
+	var body any
+	if err := jsoniter.Unmarshal(c.body, &body); err != nil {
+		return fmt.Sprintf("invalid json, %d bytes", len(c.body))
+	}
+	summary, err := jsoniter.MarshalToString(r.redactJson(body))
+	if err != nil {
+		return fmt.Sprintf("%s, %d bytes", c.mediaType, len(c.body))
+	}
+	return summary
+}
+
+type redactor struct {
+	fields []string
+}
+
+func (r redactor) redactValues(values url.Values) url.Values {
+	for key, items := range values {
+		if r.isSensitiveKey(key) {
+			values[key] = []string{redacted}
+			continue
+		}
+		for i, item := range items {
+			items[i] = redactUrl(item)
+		}
+	}
+	return values
+}
+
+func (r redactor) redactJson(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if r.isSensitiveKey(key) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = r.redactJson(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = r.redactJson(item)
+		}
+		return v
+	case string:
+		return redactUrl(v)
+	default:
+		return v
+	}
+}
+
+func (r redactor) isSensitiveKey(key string) bool {
+	for _, field := range r.fields {
+		if strings.EqualFold(key, field) {
+			return true
+		}
+	}
+	return isSensitiveKey(key)
+}
+
+// redactUrl 脱敏地址中的用户信息和查询参数的值，例如钉钉机器人地址中的 access_token，非地址的字符串原样返回
+func redactUrl(value string) string {
+	if !strings.Contains(value, "://") {
+		return value
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" || (u.User == nil && u.RawQuery == "") {
+		return value
+	}
+	hasUser := u.User != nil
+	u.User = nil
+	query := u.Query()
+	u.RawQuery = ""
+	result := u.String()
+	if hasUser {
+		result = strings.Replace(result, "://", "://"+redacted+"@", 1)
+	}
+	if len(query) > 0 {
+		pairs := make([]string, 0, len(query))
+		for key := range query {
+			pairs = append(pairs, key+"="+redacted)
+		}
+		sort.Strings(pairs)
+		result += "?" + strings.Join(pairs, "&")
+	}
+	return result
+}
+
+// encodeValues 编码后再反转义，便于阅读
+func encodeValues(values url.Values) string {
+	encoded := values.Encode()
+	if unescaped, err := url.QueryUnescape(encoded); err == nil {
+		return unescaped
+	}
+	return encoded
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitiveKey := range sensitiveKeys {
+		if key == sensitiveKey {
+			return true
+		}
+	}
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+
+type PageRequest struct {
+	apiwrap.Page
+	UserId     string `form:"user_id"`
+	Username   string `form:"username"`
+	Method     string `form:"method"`
+	Route      string `form:"route"`
+	TargetId   string `form:"target_id"`
+	StatusCode int    `form:"status_code"`
+	// 秒级时间戳
+	StartTime int64 `form:"start_time"`
+	EndTime   int64 `form:"end_time"`
+}
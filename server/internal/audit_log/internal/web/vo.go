@@ -0,0 +1,33 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+type AuditLogVO struct {
+	Id           string `json:"id"`
+	UserId       string `json:"user_id"`
+	Username     string `json:"username"`
+	Role         string `json:"role"`
+	Method       string `json:"method"`
+	Route        string `json:"route"`
+	Path         string `json:"path"`
+	TargetId     string `json:"target_id"`
+	Request      string `json:"request"`
+	Ip           string `json:"ip"`
+	UserAgent    string `json:"user_agent"`
+	StatusCode   int    `json:"status_code"`
+	ResponseCode int    `json:"response_code"`
+	Duration     int64  `json:"duration"`
+	CreatedAt    int64  `json:"created_at"`
+}
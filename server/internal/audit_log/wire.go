@@ -0,0 +1,38 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build wireinject
+
+package audit_log
+
+import (
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log/internal/repository"
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log/internal/repository/dao"
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log/internal/service"
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log/internal/web"
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/google/wire"
+)
+
+var AuditLogProviders = wire.NewSet(web.NewAuditLogHandler, service.NewAuditLogService, repository.NewAuditLogRepository, dao.NewAuditLogDao,
+	wire.Bind(new(service.IAuditLogService), new(*service.AuditLogService)),
+	wire.Bind(new(repository.IAuditLogRepository), new(*repository.AuditLogRepository)),
+	wire.Bind(new(dao.IAuditLogDao), new(*dao.AuditLogDao)))
+
+func InitAuditLogModule(db *mongox.Database) *Module {
+	panic(wire.Build(
+		AuditLogProviders,
+		wire.Struct(new(Module), "Svc", "Hdl"),
+	))
+}
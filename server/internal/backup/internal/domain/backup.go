@@ -14,4 +14,21 @@
 
 package domain
 
-type Backup struct{}
+import "time"
+
+type BackupTrigger string
+
+const (
+	BackupTriggerManual    BackupTrigger = "manual"
+	BackupTriggerScheduled BackupTrigger = "scheduled"
+	// BackupTriggerPreRestore 恢复前自动创建
+	BackupTriggerPreRestore BackupTrigger = "pre_restore"
+)
+
+// Backup 备份目录中保存的备份，静态文件按内容去重后单独存放，不在备份归档中
+type Backup struct {
+	Name      string
+	Trigger   BackupTrigger
+	Size      int64
+	CreatedAt time.Time
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import "time"
+
+type BackupOperation string
+
+const (
+	BackupOperationBackup  BackupOperation = "backup"
+	BackupOperationRestore BackupOperation = "restore"
+)
+
+// BackupProgress 最近一次备份或恢复的进度，Total 为 0 表示总量未知
+type BackupProgress struct {
+	Operation BackupOperation
+	Running   bool
+	// Stage 当前阶段，Item 为正在处理的集合或文件
+	Stage     string
+	Item      string
+	Processed int64
+	Total     int64
+	Error     string
+	StartedAt time.Time
+	UpdatedAt time.Time
+}
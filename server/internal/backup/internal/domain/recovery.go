@@ -0,0 +1,45 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+type RecoveryOptions struct {
+	Passphrase string
+	// DryRun 只统计恢复会产生的变更，不修改数据
+	DryRun bool
+	// Collections 只恢复这些集合，为空时恢复备份中的所有集合，登录密钥、会话等敏感或易变的集合不会恢复
+	Collections []string
+	// StaticPaths 只恢复这些相对 static_path 的文件或目录，为空时恢复备份中的所有静态文件
+	StaticPaths []string
+}
+
+// CollectionRecovery 恢复单个集合产生的变更：备份中有而集合中没有的文档为新增，两边都有的为替换，集合中有而备份中没有的为删除
+type CollectionRecovery struct {
+	Name     string
+	Added    int
+	Replaced int
+	Removed  int
+}
+
+type RecoveryReport struct {
+	DryRun                 bool
+	Collections            []CollectionRecovery
+	StaticFilesAdded       int
+	StaticFilesOverwritten []string
+	// PreRestoreBackup 恢复前自动创建的备份，可以通过恢复该备份撤销本次恢复
+	PreRestoreBackup string
+	// SchemaVersion 备份的 schema 版本，低于当前版本的备份会先执行迁移，0 表示没有 manifest 的旧版本备份
+	SchemaVersion int
+	FnoteVersion  string
+}
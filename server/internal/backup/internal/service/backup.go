@@ -16,21 +16,27 @@ package service
 
 import (
 	"archive/zip"
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"log/slog"
-	"mime/multipart"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/chenmingyong0423/fnote/server/internal/backup/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/backup/internal/storage"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg"
+
 	"github.com/chenmingyong0423/go-mongox/v2"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -42,123 +48,157 @@ const (
 	backupStaticDir = "static"
 )
 
+// excludedCollections 不导出也不恢复的集合。
+// 登录密钥、会话、两步验证密钥、退订令牌以及 webhook 和通知渠道的密钥，持有备份的人可以据此伪造登录凭证或接管会话，
+// 恢复旧备份也会让已轮换的密钥和已注销的会话重新生效；登录限流、发件箱和推送记录等易变数据恢复后没有意义；
+// 审计日志和登录记录只追加，恢复会抹掉恢复操作本身及此前的记录
+var excludedCollections = map[string]struct{}{
+	"jwt_keys":              {},
+	"admin_sessions":        {},
+	"two_factor_configs":    {},
+	"unsubscribe_tokens":    {},
+	"webhooks":              {},
+	"webhook_deliveries":    {},
+	"notification_channels": {},
+	"login_throttles":       {},
+	"email_outbox":          {},
+	"audit_logs":            {},
+	"login_attempts":        {},
+}
+
+func isExcludedCollection(name string) bool {
+	_, ok := excludedCollections[name]
+	return ok
+}
+
+// backupCollections 过滤掉不导出的集合
+func backupCollections(collections []string) []string {
+	result := make([]string, 0, len(collections))
+	for _, name := range collections {
+		if !isExcludedCollection(name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
 type IBackupService interface {
 	GetBackups(ctx context.Context) (string, error)
-	Recovery(ctx context.Context, file *multipart.FileHeader) error
+	// Recovery 从上传后保存的备份文件中恢复，加密的备份优先使用 opts.Passphrase 解密，为空时使用配置中的口令
+	Recovery(ctx context.Context, filename string, opts domain.RecoveryOptions) (*domain.RecoveryReport, error)
+	// EncryptionEnabled 是否配置了备份加密口令，开启后导出和推送到备份目标的备份都会加密
+	EncryptionEnabled() bool
+
+	// CreateBackup 在备份目录中创建一份备份，静态文件按内容增量保存
+	CreateBackup(ctx context.Context, trigger domain.BackupTrigger) (*domain.Backup, error)
+	GetStoredBackups(ctx context.Context) ([]domain.Backup, error)
+	GetStoredBackup(ctx context.Context, name string) (*domain.Backup, error)
+	WriteBackupArchive(ctx context.Context, name string, w io.Writer) error
+	DeleteStoredBackup(ctx context.Context, name string) error
+	PruneBackups(ctx context.Context) (int, error)
+	// RestoreStoredBackup 从备份目录中的备份恢复，可用于撤销一次恢复
+	RestoreStoredBackup(ctx context.Context, name string, opts domain.RecoveryOptions) (*domain.RecoveryReport, error)
+	// GetProgress 最近一次备份和恢复的进度
+	GetProgress(ctx context.Context) []domain.BackupProgress
 }
 
 var _ IBackupService = (*BackupService)(nil)
 
 func NewBackupService(db *mongox.Database) *BackupService {
-	return &BackupService{db: db.Database()}
+	s := &BackupService{db: db.Database(), targets: backupTargets()}
+	go s.backupPeriodically()
+	if len(s.targets) > 0 {
+		// 补推上次运行时推送失败或新增目标前创建的备份
+		go s.syncTargetsInBackground()
+	}
+	return s
 }
 
 type BackupService struct {
 	db *mongo.Database
-}
-
-func (s *BackupService) Recovery(ctx context.Context, file *multipart.FileHeader) error {
-	src, err := file.Open()
-	if err != nil {
-		return err
-	}
-	defer src.Close()
+	// mu 保证同一时间只有一个操作修改备份目录
+	mu sync.Mutex
 
-	content, err := io.ReadAll(src)
-	if err != nil {
-		return err
-	}
-	if len(content) == 0 {
-		return fmt.Errorf("backup file is empty")
-	}
-	if !isZipFile(content) {
-		return fmt.Errorf("unsupported backup file format: only zip backup files are supported")
-	}
+	targets []storage.BackupStorage
+	syncMu  sync.Mutex
 
-	err = s.recoveryZip(ctx, bytes.NewReader(content), int64(len(content)))
-	if err != nil {
-		return fmt.Errorf("restore zip backup failed: %w", err)
-	}
-	return nil
+	restoreMu sync.Mutex
+	progress  backupProgress
 }
 
-func (s *BackupService) recoveryZip(ctx context.Context, src io.ReaderAt, size int64) error {
-	zipReader, err := zip.NewReader(src, size)
-	if err != nil {
-		return err
-	}
+func (s *BackupService) EncryptionEnabled() bool {
+	return backupPassphrase() != ""
+}
 
-	for _, file := range zipReader.File {
-		name := cleanArchiveName(file.Name)
-		if name == "" {
-			continue
+func (s *BackupService) Recovery(ctx context.Context, filename string, opts domain.RecoveryOptions) (*domain.RecoveryReport, error) {
+	return s.runRestore(func(progress *progressReporter) (*domain.RecoveryReport, error) {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
 		}
-		name = normalizeBackupEntryName(name)
-		if file.FileInfo().IsDir() {
-			if strings.HasPrefix(name, backupStaticDir+"/") {
-				if err = mkdirStaticDir(strings.TrimPrefix(name, backupStaticDir+"/")); err != nil {
-					return err
-				}
-			}
-			continue
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() == 0 {
+			return nil, fmt.Errorf("backup file is empty")
 		}
 
-		switch {
-		case strings.HasPrefix(name, backupDataDir+"/") && strings.HasSuffix(name, ".json"):
-			if err = s.restoreZipCollection(ctx, file); err != nil {
-				return err
+		header := make([]byte, len(encryptedBackupMagic))
+		n, err := file.ReadAt(header, 0)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		src, size := io.ReaderAt(file), info.Size()
+		if isEncryptedBackup(header[:n]) {
+			progress.stage(stageDecrypt, size)
+			decrypted, err := decryptToTempFile(&progressReader{r: file, progress: progress}, pkg.GetOrDefault4String(opts.Passphrase, backupPassphrase()))
+			if err != nil {
+				return nil, err
 			}
-		case strings.HasPrefix(name, backupStaticDir+"/"):
-			if err = restoreZipStaticFile(file, strings.TrimPrefix(name, backupStaticDir+"/")); err != nil {
-				return err
+			defer os.Remove(decrypted.Name())
+			defer decrypted.Close()
+			if info, err = decrypted.Stat(); err != nil {
+				return nil, err
 			}
+			src, size = decrypted, info.Size()
+		}
+		if n, _ = src.ReadAt(header[:2], 0); !isZipFile(header[:n]) {
+			return nil, fmt.Errorf("unsupported backup file format: only zip backup files are supported")
 		}
-	}
-	return nil
-}
-
-func (s *BackupService) restoreZipCollection(ctx context.Context, file *zip.File) error {
-	reader, err := file.Open()
-	if err != nil {
-		return err
-	}
-	defer reader.Close()
-
-	content, err := io.ReadAll(reader)
-	if err != nil {
-		return err
-	}
-	return s.restoreCollection(ctx, path.Base(cleanArchiveName(file.Name)), content)
-}
 
-func (s *BackupService) restoreCollection(ctx context.Context, filename string, content []byte) error {
-	colName, err := s.collectionNameFromBackupFile(filename)
-	if err != nil {
-		return err
-	}
-	return s.DeleteAndInsertCollectionDoc(ctx, colName, content)
+		report, err := s.recoverZip(ctx, src, size, opts, progress)
+		if err != nil {
+			return nil, fmt.Errorf("restore zip backup failed: %w", err)
+		}
+		return report, nil
+	})
 }
 
-func (s *BackupService) collectionNameFromBackupFile(filename string) (string, error) {
+// collectionNameFromBackupFile sourceDatabase 为 manifest 中记录的源数据库，备份来自其他名称的数据库时也能解析
+func (s *BackupService) collectionNameFromBackupFile(filename, sourceDatabase string) (string, error) {
 	base := filepath.Base(filename)
 	if filepath.Ext(base) != ".json" {
 		return "", fmt.Errorf("file name error: %s", filename)
 	}
 
 	name := strings.TrimSuffix(base, ".json")
-	dbPrefix := s.db.Name() + "_"
-	if strings.HasPrefix(name, dbPrefix) {
-		return strings.TrimPrefix(name, dbPrefix), nil
-	}
-
-	if strings.HasPrefix(name, "fnote_") {
-		return strings.TrimPrefix(name, "fnote_"), nil
+	for _, database := range []string{sourceDatabase, s.db.Name(), "fnote"} {
+		if database != "" && strings.HasPrefix(name, database+"_") {
+			return strings.TrimPrefix(name, database+"_"), nil
+		}
 	}
 
 	return "", fmt.Errorf("file name error: %s", filename)
 }
 
 func (s *BackupService) GetBackups(ctx context.Context) (zipFileName string, err error) {
+	progress := s.progress.begin(domain.BackupOperationBackup)
+	defer func() {
+		progress.finish(err)
+	}()
+
 	staticPath := viper.GetString("system.static_path")
 	if staticPath == "" {
 		return "", fmt.Errorf("system.static_path is empty")
@@ -178,12 +218,18 @@ func (s *BackupService) GetBackups(ctx context.Context) (zipFileName string, err
 		}
 	}()
 
-	if err = s.exportCollections(ctx, filepath.Join(tempDir, backupDataDir)); err != nil {
+	counts, err := s.exportCollections(ctx, filepath.Join(tempDir, backupDataDir), progress)
+	if err != nil {
 		return "", err
 	}
+	manifest := newBackupManifest(s.db.Name(), counts)
 
-	zipFileName = filepath.Join(staticPath, fmt.Sprintf("backup_%s.zip", time.Now().Local().Format("2006-01-02_150405")))
-	fileCount, err := s.createZip(zipFileName, filepath.Join(tempDir, backupDataDir), staticPath)
+	zipFileName = filepath.Join(os.TempDir(), fmt.Sprintf("backup_%s.zip", time.Now().Local().Format("2006-01-02_150405")))
+	passphrase := backupPassphrase()
+	if passphrase != "" {
+		zipFileName += ".enc"
+	}
+	fileCount, err := s.createZip(zipFileName, filepath.Join(tempDir, backupDataDir), staticPath, passphrase, manifest, progress)
 	if err != nil {
 		return "", err
 	}
@@ -196,62 +242,119 @@ func (s *BackupService) GetBackups(ctx context.Context) (zipFileName string, err
 	return zipFileName, nil
 }
 
-func (s *BackupService) exportCollections(ctx context.Context, dataDir string) error {
+// exportCollections 将非空集合逐个文档流式导出到 dataDir，返回每个集合的文档数量，excludedCollections 中的集合不导出
+func (s *BackupService) exportCollections(ctx context.Context, dataDir string, progress *progressReporter) (map[string]int, error) {
 	dbName := s.db.Name()
 	collections, err := s.db.ListCollectionNames(ctx, bson.M{})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	collections = backupCollections(collections)
 
+	var total int64
 	for _, collectionName := range collections {
-		cur, err := s.db.Collection(collectionName).Find(ctx, bson.M{})
+		// 只用于展示进度，使用估算值即可
+		n, err := s.db.Collection(collectionName).EstimatedDocumentCount(ctx)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		total += n
+	}
+	progress.stage(stageExportCollections, total)
 
-		var documents []bson.M
-		if err = cur.All(ctx, &documents); err != nil {
-			return err
+	if err = os.MkdirAll(dataDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(collections))
+	for _, collectionName := range collections {
+		filename := filepath.Join(dataDir, fmt.Sprintf("%s_%s.json", dbName, collectionName))
+		count, err := s.exportCollection(ctx, collectionName, filename, progress)
+		if err != nil {
+			return nil, fmt.Errorf("export collection %s failed: %w", collectionName, err)
 		}
-		if len(documents) == 0 {
-			continue
+		if count > 0 {
+			counts[collectionName] = count
 		}
+	}
+	return counts, nil
+}
+
+// exportCollection 将集合写为 json 数组，集合为空时不创建文件
+func (s *BackupService) exportCollection(ctx context.Context, collectionName, filename string, progress *progressReporter) (count int, err error) {
+	cur, err := s.db.Collection(collectionName).Find(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
 
+	var file *os.File
+	var writer *bufio.Writer
+	defer func() {
+		if file == nil {
+			return
+		}
+		if cErr := file.Close(); err == nil {
+			err = cErr
+		}
+		if err != nil {
+			os.Remove(filename)
+		}
+	}()
+	for cur.Next(ctx) {
+		var document bson.M
+		if err = cur.Decode(&document); err != nil {
+			return 0, err
+		}
 		if collectionName == "configs" {
-			for _, document := range documents {
-				if typ, ok := document["typ"]; ok && typ == "social" {
-					props := document["props"].(bson.D)
-					for _, prop := range props {
-						if prop.Key == "social_info_list" {
-							socialList := prop.Value.(bson.A)
-							for _, m := range socialList {
-								encodeSocialID(m)
-							}
-							break
-						}
-					}
-					break
-				}
-			}
+			encodeConfigSocialIds(document)
 		}
-
-		fileContent, err := json.MarshalIndent(documents, "", "    ")
+		content, err := json.MarshalIndent(document, "    ", "    ")
 		if err != nil {
-			return err
+			return 0, err
 		}
-		if err = os.MkdirAll(dataDir, os.ModePerm); err != nil {
-			return err
+
+		if file == nil {
+			if file, err = os.Create(filename); err != nil {
+				return 0, err
+			}
+			writer = bufio.NewWriter(file)
+			writer.WriteString("[\n    ")
+		} else {
+			writer.WriteString(",\n    ")
 		}
+		writer.Write(content)
+		count++
+		progress.advance(collectionName, 1)
+	}
+	if err = cur.Err(); err != nil {
+		return 0, err
+	}
+	if file == nil {
+		return 0, nil
+	}
+	// bufio.Writer 会保留第一次写入失败的错误，在 Flush 时返回
+	writer.WriteString("\n]")
+	return count, writer.Flush()
+}
 
-		filename := filepath.Join(dataDir, fmt.Sprintf("%s_%s.json", dbName, collectionName))
-		if err = os.WriteFile(filename, fileContent, 0644); err != nil {
-			return err
+func encodeConfigSocialIds(document bson.M) {
+	if typ, ok := document["typ"]; !ok || typ != "social" {
+		return
+	}
+	props := document["props"].(bson.D)
+	for _, prop := range props {
+		if prop.Key == "social_info_list" {
+			socialList := prop.Value.(bson.A)
+			for _, m := range socialList {
+				encodeSocialID(m)
+			}
+			return
 		}
 	}
-	return nil
 }
 
-func (s *BackupService) createZip(zipFileName, dataDir, staticPath string) (int, error) {
+// createZip 打包数据和静态文件，并将文件的 sha256 记录到 manifest 中，passphrase 不为空时加密
+func (s *BackupService) createZip(zipFileName, dataDir, staticPath, passphrase string, manifest *backupManifest, progress *progressReporter) (int, error) {
 	newZipFile, err := os.Create(zipFileName)
 	if err != nil {
 		return 0, err
@@ -269,7 +372,15 @@ func (s *BackupService) createZip(zipFileName, dataDir, staticPath string) (int,
 		}
 	}()
 
-	zipWriter := zip.NewWriter(newZipFile)
+	var out io.Writer = newZipFile
+	var encryptWriter io.WriteCloser
+	if passphrase != "" {
+		if encryptWriter, err = newEncryptWriter(newZipFile, passphrase); err != nil {
+			return 0, err
+		}
+		out = encryptWriter
+	}
+	zipWriter := zip.NewWriter(out)
 
 	fileCount := 0
 	if err = filepath.WalkDir(dataDir, func(filePath string, entry fs.DirEntry, walkErr error) error {
@@ -281,7 +392,7 @@ func (s *BackupService) createZip(zipFileName, dataDir, staticPath string) (int,
 		}
 
 		archiveName := path.Join(backupDataDir, filepath.Base(filePath))
-		if err = addFileToZip(zipWriter, filePath, archiveName); err != nil {
+		if manifest.Files[archiveName], err = addFileToZip(zipWriter, filePath, archiveName); err != nil {
 			return err
 		}
 		fileCount++
@@ -294,11 +405,19 @@ func (s *BackupService) createZip(zipFileName, dataDir, staticPath string) (int,
 	if err != nil {
 		return 0, err
 	}
+	backupDirAbs, err := filepath.Abs(backupDir())
+	if err != nil {
+		return 0, err
+	}
+	progress.stage(stageArchiveStaticFiles, 0)
 	if err = filepath.WalkDir(staticPath, func(filePath string, entry fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			return walkErr
 		}
 		if entry.IsDir() {
+			if dirAbs, err := filepath.Abs(filePath); err == nil && dirAbs == backupDirAbs {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -315,48 +434,57 @@ func (s *BackupService) createZip(zipFileName, dataDir, staticPath string) (int,
 			return err
 		}
 		archiveName := path.Join(backupStaticDir, filepath.ToSlash(relPath))
-		if err = addFileToZip(zipWriter, filePath, archiveName); err != nil {
+		if manifest.Files[archiveName], err = addFileToZip(zipWriter, filePath, archiveName); err != nil {
 			return err
 		}
 		fileCount++
+		progress.advance(filepath.ToSlash(relPath), 1)
 		return nil
 	}); err != nil {
 		return 0, err
 	}
 
+	if err = writeBackupManifest(zipWriter, manifest); err != nil {
+		return 0, err
+	}
 	if err = zipWriter.Close(); err != nil {
 		return 0, err
 	}
+	if encryptWriter != nil {
+		if err = encryptWriter.Close(); err != nil {
+			return 0, err
+		}
+	}
 	createSuccess = true
 	return fileCount, nil
 }
 
-func addFileToZip(zipWriter *zip.Writer, filename, archiveName string) error {
+// addFileToZip 返回文件内容的 sha256
+func addFileToZip(zipWriter *zip.Writer, filename, archiveName string) (string, error) {
 	fileToZip, err := os.Open(filename)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer fileToZip.Close()
 
 	info, err := fileToZip.Stat()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	header, err := zip.FileInfoHeader(info)
 	if err != nil {
-		return err
+		return "", err
 	}
 	header.Name = filepath.ToSlash(archiveName)
 	header.Method = zip.Deflate
 
 	writer, err := zipWriter.CreateHeader(header)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	_, err = io.Copy(writer, fileToZip)
-	return err
+	return sha256Hex(io.TeeReader(fileToZip, writer))
 }
 
 func restoreZipStaticFile(file *zip.File, relPath string) error {
@@ -439,19 +567,23 @@ func cleanArchiveName(name string) string {
 }
 
 func normalizeBackupEntryName(name string) string {
-	if strings.HasPrefix(name, backupDataDir+"/") || strings.HasPrefix(name, backupStaticDir+"/") {
+	if isBackupRootEntry(name) {
 		return name
 	}
 	parts := strings.SplitN(name, "/", 2)
 	if len(parts) != 2 {
 		return name
 	}
-	if strings.HasPrefix(parts[1], backupDataDir+"/") || strings.HasPrefix(parts[1], backupStaticDir+"/") {
+	if isBackupRootEntry(parts[1]) {
 		return parts[1]
 	}
 	return name
 }
 
+func isBackupRootEntry(name string) bool {
+	return name == manifestFile || strings.HasPrefix(name, backupDataDir+"/") || strings.HasPrefix(name, backupStaticDir+"/")
+}
+
 func isZipFile(content []byte) bool {
 	return len(content) >= 2 && bytes.Equal(content[:2], []byte{'P', 'K'})
 }
@@ -463,88 +595,116 @@ func isBackupArchive(filename string) bool {
 }
 
 func (s *BackupService) DeleteAndInsertCollectionDoc(ctx context.Context, colName string, content []byte) error {
+	documents, err := decodeCollectionDocuments(colName, content)
+	if err != nil {
+		return err
+	}
+	return s.replaceCollection(ctx, colName, documents)
+}
+
+// decodeCollectionDocuments 解析备份中的集合数据，并还原导出为 json 后丢失的 ObjectID 和时间类型
+func decodeCollectionDocuments(colName string, content []byte) ([]map[string]any, error) {
 	var documents []map[string]any
 	if err := json.Unmarshal(content, &documents); err != nil {
-		return err
+		return nil, err
 	}
-	col := s.db.Collection(colName)
 	for _, doc := range documents {
-		objectID, fErr := bson.ObjectIDFromHex(doc["_id"].(string))
-		// Some collections use custom string IDs instead of ObjectIDs.
-		if fErr == nil {
-			doc["_id"] = objectID
-		}
-
-		if colName == "configs" {
-			if typ, ok := doc["typ"]; ok && typ == "social" {
-				props := doc["props"].(map[string]any)
-				socialList := props["social_info_list"].([]any)
-				for _, m := range socialList {
-					obj := m.(map[string]any)
-					var fErr2 error
-					obj["id"], fErr2 = decodeSocialID(obj["id"])
-					if fErr2 != nil {
-						return fErr2
-					}
-				}
-			}
-			if typ, ok := doc["typ"]; ok && typ == "website" {
-				props := doc["props"].(map[string]any)
-				websiteRunTime := props["website_runtime"].(string)
-				parse, fErr2 := time.Parse(time.RFC3339, websiteRunTime)
-				if fErr2 != nil {
-					return fErr2
-				}
-				props["website_runtime"] = parse
-			}
-			if typ, ok := doc["typ"]; ok && typ == "notice" {
-				props := doc["props"].(map[string]any)
-				publishTime := props["publish_time"].(string)
-				parse, fErr2 := time.Parse(time.RFC3339, publishTime)
+		if err := restoreDocumentTypes(colName, doc); err != nil {
+			return nil, err
+		}
+	}
+	return documents, nil
+}
+
+// restoreDocumentTypes 还原导出为 json 后丢失的 ObjectID 和时间类型
+func restoreDocumentTypes(colName string, doc map[string]any) error {
+	id, ok := doc["_id"].(string)
+	if !ok {
+		return errors.New("document without a string _id")
+	}
+	objectID, fErr := bson.ObjectIDFromHex(id)
+	// Some collections use custom string IDs instead of ObjectIDs.
+	if fErr == nil {
+		doc["_id"] = objectID
+	}
+
+	if colName == "configs" {
+		if typ, ok := doc["typ"]; ok && typ == "social" {
+			props := doc["props"].(map[string]any)
+			socialList := props["social_info_list"].([]any)
+			for _, m := range socialList {
+				obj := m.(map[string]any)
+				var fErr2 error
+				obj["id"], fErr2 = decodeSocialID(obj["id"])
 				if fErr2 != nil {
 					return fErr2
 				}
-				props["publish_time"] = parse
-			}
-			if typ, ok := doc["typ"]; ok && typ == "carousel" {
-				props := doc["props"].(map[string]any)
-				list := props["list"].([]any)
-				for _, m := range list {
-					obj := m.(map[string]any)
-					createdAt := obj["created_at"].(string)
-					parse, fErr2 := time.Parse(time.RFC3339, createdAt)
-					if fErr2 != nil {
-						return fErr2
-					}
-					obj["created_at"] = parse
-					updatedAt := obj["updated_at"].(string)
-					parse, fErr2 = time.Parse(time.RFC3339, updatedAt)
-					if fErr2 != nil {
-						return fErr2
-					}
-					obj["updated_at"] = parse
-				}
 			}
 		}
-		if createdAt, ok := doc["created_at"].(string); ok {
-			parse, fErr2 := time.Parse(time.RFC3339, createdAt)
+		if typ, ok := doc["typ"]; ok && typ == "website" {
+			props := doc["props"].(map[string]any)
+			websiteRunTime := props["website_runtime"].(string)
+			parse, fErr2 := time.Parse(time.RFC3339, websiteRunTime)
 			if fErr2 != nil {
 				return fErr2
 			}
-			doc["created_at"] = parse
+			props["website_runtime"] = parse
 		}
-		if updatedAt, ok := doc["updated_at"].(string); ok {
-			parse, fErr2 := time.Parse(time.RFC3339, updatedAt)
+		if typ, ok := doc["typ"]; ok && typ == "notice" {
+			props := doc["props"].(map[string]any)
+			publishTime := props["publish_time"].(string)
+			parse, fErr2 := time.Parse(time.RFC3339, publishTime)
 			if fErr2 != nil {
 				return fErr2
 			}
-			doc["updated_at"] = parse
+			props["publish_time"] = parse
+		}
+		if typ, ok := doc["typ"]; ok && typ == "carousel" {
+			props := doc["props"].(map[string]any)
+			list := props["list"].([]any)
+			for _, m := range list {
+				obj := m.(map[string]any)
+				createdAt := obj["created_at"].(string)
+				parse, fErr2 := time.Parse(time.RFC3339, createdAt)
+				if fErr2 != nil {
+					return fErr2
+				}
+				obj["created_at"] = parse
+				updatedAt := obj["updated_at"].(string)
+				parse, fErr2 = time.Parse(time.RFC3339, updatedAt)
+				if fErr2 != nil {
+					return fErr2
+				}
+				obj["updated_at"] = parse
+			}
+		}
+	}
+	if createdAt, ok := doc["created_at"].(string); ok {
+		parse, fErr2 := time.Parse(time.RFC3339, createdAt)
+		if fErr2 != nil {
+			return fErr2
+		}
+		doc["created_at"] = parse
+	}
+	if updatedAt, ok := doc["updated_at"].(string); ok {
+		parse, fErr2 := time.Parse(time.RFC3339, updatedAt)
+		if fErr2 != nil {
+			return fErr2
 		}
+		doc["updated_at"] = parse
 	}
+	return nil
+}
+
+func (s *BackupService) replaceCollection(ctx context.Context, colName string, documents []map[string]any) error {
+	col := s.db.Collection(colName)
 	_, err := col.DeleteMany(ctx, bson.M{})
 	if err != nil {
 		return err
 	}
+	if len(documents) == 0 {
+		return nil
+	}
 	var documentsAny []any
 	for _, doc := range documents {
 		documentsAny = append(documentsAny, doc)
@@ -576,13 +736,8 @@ func encodeSocialID(value any) {
 }
 
 func decodeSocialID(value any) ([]byte, error) {
-	switch v := value.(type) {
-	case string:
+	if v, ok := value.(string); ok {
 		return hex.DecodeString(v)
-	case map[string]any:
-		if data, ok := v["Data"].(string); ok {
-			return hex.DecodeString(data)
-		}
 	}
 	return nil, fmt.Errorf("invalid social id format")
 }
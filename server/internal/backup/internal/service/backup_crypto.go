@@ -0,0 +1,176 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/aesutil"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/argon2"
+)
+
+// 加密备份的格式：
+//
+//	magic(8) "FNOTEENC" | version(1) | kdf(1) | argon2 time(4) | argon2 memory KiB(4) | argon2 threads(1) | salt(16) | nonce 前缀(7)
+//	之后是 aesutil 的分块 AES-256-GCM 数据，完整的头部作为每一块的附加数据，修改头部会导致解密失败
+const (
+	encryptedBackupMagic   = "FNOTEENC"
+	encryptedBackupVersion = 1
+	kdfArgon2id            = 1
+
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	// 解密时限制头部中的 argon2 参数，避免伪造的文件消耗过多资源
+	maxArgon2Time   = 16
+	maxArgon2Memory = 1024 * 1024
+
+	backupKeySize  = 32
+	backupSaltSize = 16
+
+	encryptedBackupHeaderSize = len(encryptedBackupMagic) + 1 + 1 + 4 + 4 + 1 + backupSaltSize + aesutil.StreamNoncePrefixSize
+
+	// objectNameSalt 派生对象命名密钥的固定盐值，同一口令得到相同的名称，推送到目标的对象才能去重
+	objectNameSalt = "fnote-backup-object-name"
+)
+
+var (
+	ErrBackupPassphraseRequired = errors.New("the backup is encrypted, a passphrase is required")
+	ErrBackupDecryptFailed      = errors.New("failed to decrypt the backup: wrong passphrase or corrupted file")
+)
+
+// backupPassphrase 备份加密口令，来自 backup.encryption.passphrase 或环境变量 BACKUP_PASSPHRASE，为空时不加密
+func backupPassphrase() string {
+	return viper.GetString("backup.encryption.passphrase")
+}
+
+// objectNameKey 由口令派生静态文件对象在备份目标中的命名密钥，口令为空时返回 nil
+func objectNameKey(passphrase string) []byte {
+	if passphrase == "" {
+		return nil
+	}
+	return argon2.IDKey([]byte(passphrase), []byte(objectNameSalt), argon2Time, argon2Memory, argon2Threads, backupKeySize)
+}
+
+// remoteObjectName 静态文件对象在备份目标中的名称：未加密时为内容的 sha256；
+// 加密时为 sha256 的 HMAC，避免通过已知文件的 sha256 判断目标中是否存放了该文件，更换口令后对象会以新名称重新推送
+func remoteObjectName(hash string, nameKey []byte) string {
+	if nameKey == nil {
+		return hash
+	}
+	mac := hmac.New(sha256.New, nameKey)
+	mac.Write([]byte(hash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func isEncryptedBackup(prefix []byte) bool {
+	return bytes.HasPrefix(prefix, []byte(encryptedBackupMagic))
+}
+
+// newEncryptWriter 写入头部并返回加密写入 w 的 WriteCloser，Close 不会关闭 w
+func newEncryptWriter(w io.Writer, passphrase string) (io.WriteCloser, error) {
+	header := make([]byte, 0, encryptedBackupHeaderSize)
+	header = append(header, encryptedBackupMagic...)
+	header = append(header, encryptedBackupVersion, kdfArgon2id)
+	header = binary.BigEndian.AppendUint32(header, argon2Time)
+	header = binary.BigEndian.AppendUint32(header, argon2Memory)
+	header = append(header, argon2Threads)
+	random := make([]byte, backupSaltSize+aesutil.StreamNoncePrefixSize)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	header = append(header, random...)
+	salt, noncePrefix := random[:backupSaltSize], random[backupSaltSize:]
+
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	key := argon2.IDKey([]byte(passphrase), salt, argon2Time, argon2Memory, argon2Threads, backupKeySize)
+	return aesutil.NewGCMStreamWriter(w, key, noncePrefix, header)
+}
+
+// newDecryptReader 读取头部并返回解密后的 Reader
+func newDecryptReader(r io.Reader, passphrase string) (io.Reader, error) {
+	header := make([]byte, encryptedBackupHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("invalid encrypted backup header: %w", err)
+	}
+	if !isEncryptedBackup(header) {
+		return nil, errors.New("invalid encrypted backup header")
+	}
+	rest := header[len(encryptedBackupMagic):]
+	if version := rest[0]; version != encryptedBackupVersion {
+		return nil, fmt.Errorf("unsupported encrypted backup version: %d", version)
+	}
+	if kdf := rest[1]; kdf != kdfArgon2id {
+		return nil, fmt.Errorf("unsupported encrypted backup kdf: %d", kdf)
+	}
+	t, memory, threads := binary.BigEndian.Uint32(rest[2:6]), binary.BigEndian.Uint32(rest[6:10]), rest[10]
+	if t == 0 || t > maxArgon2Time || memory == 0 || memory > maxArgon2Memory || threads == 0 {
+		return nil, errors.New("invalid encrypted backup kdf parameters")
+	}
+	salt := rest[11 : 11+backupSaltSize]
+	noncePrefix := rest[11+backupSaltSize:]
+	if passphrase == "" {
+		return nil, ErrBackupPassphraseRequired
+	}
+
+	key := argon2.IDKey([]byte(passphrase), salt, t, memory, threads, backupKeySize)
+	reader, err := aesutil.NewGCMStreamReader(r, key, noncePrefix, header)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptErrorReader{r: reader}, nil
+}
+
+// decryptErrorReader 将认证失败转换为便于理解的错误
+type decryptErrorReader struct {
+	r io.Reader
+}
+
+func (d *decryptErrorReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if errors.Is(err, aesutil.ErrStreamAuthFailed) || errors.Is(err, aesutil.ErrStreamTruncated) {
+		return n, ErrBackupDecryptFailed
+	}
+	return n, err
+}
+
+// decryptToTempFile 将 src 解密到临时文件，调用方负责关闭并删除
+func decryptToTempFile(src io.Reader, passphrase string) (tmp *os.File, err error) {
+	reader, err := newDecryptReader(src, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if tmp, err = os.CreateTemp("", "fnote-restore-*.zip"); err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(tmp, reader); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return tmp, nil
+}
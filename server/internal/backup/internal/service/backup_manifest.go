@@ -0,0 +1,231 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+const (
+	manifestFile = "manifest.json"
+	// backupSchemaVersion 备份中集合数据的格式版本，修改导出格式时需要加一，并在 backupMigrations 中注册从上一个版本升级的迁移
+	backupSchemaVersion = 1
+	// legacySchemaVersion 没有 manifest 的旧版本备份
+	legacySchemaVersion = 0
+)
+
+var (
+	ErrBackupCorrupted    = errors.New("the backup is corrupted")
+	ErrBackupSchemaTooNew = errors.New("the backup was created by a newer version of fnote, please upgrade before restoring it")
+)
+
+// backupManifest 备份根目录下的 manifest.json
+type backupManifest struct {
+	FnoteVersion  string    `json:"fnote_version"`
+	SchemaVersion int       `json:"schema_version"`
+	Database      string    `json:"database"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Collections 集合名 -> 文档数量
+	Collections map[string]int `json:"collections"`
+	// Files 备份中的文件路径（data/...、static/...）-> sha256
+	Files map[string]string `json:"files"`
+}
+
+// backupMigration 将文档从某个 schema 版本升级到下一个版本，doc 为 json 解析后、还原 ObjectID 等类型之前的数据
+type backupMigration func(colName string, doc map[string]any) error
+
+// backupMigrations key 为升级前的 schema 版本
+var backupMigrations = map[int]backupMigration{
+	legacySchemaVersion: migrateLegacySocialIds,
+}
+
+func newBackupManifest(database string, collections map[string]int) *backupManifest {
+	return &backupManifest{
+		FnoteVersion:  fnoteVersion(),
+		SchemaVersion: backupSchemaVersion,
+		Database:      database,
+		CreatedAt:     time.Now(),
+		Collections:   collections,
+		Files:         make(map[string]string),
+	}
+}
+
+// fnoteVersion 从构建信息中读取版本，本地构建时为 git 提交
+func fnoteVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return "(devel)"
+}
+
+func writeBackupManifest(zipWriter *zip.Writer, manifest *backupManifest) error {
+	content, err := json.MarshalIndent(manifest, "", "    ")
+	if err != nil {
+		return err
+	}
+	writer, err := zipWriter.Create(manifestFile)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(content)
+	return err
+}
+
+// readBackupManifest 没有 manifest 的旧版本备份返回 schema 版本为 legacySchemaVersion 的空 manifest
+func readBackupManifest(zipReader *zip.Reader) (*backupManifest, error) {
+	for _, file := range zipReader.File {
+		name := cleanArchiveName(file.Name)
+		if name == "" || normalizeBackupEntryName(name) != manifestFile {
+			continue
+		}
+		content, err := readZipFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrBackupCorrupted, err)
+		}
+		manifest := &backupManifest{}
+		if err = json.Unmarshal(content, manifest); err != nil {
+			return nil, fmt.Errorf("%w: invalid %s: %w", ErrBackupCorrupted, manifestFile, err)
+		}
+		if manifest.SchemaVersion < 1 {
+			return nil, fmt.Errorf("%w: invalid schema version %d", ErrBackupCorrupted, manifest.SchemaVersion)
+		}
+		return manifest, nil
+	}
+	return &backupManifest{SchemaVersion: legacySchemaVersion}, nil
+}
+
+// verifyBackupManifest 校验备份中的文件与 manifest 一一对应且 sha256 一致，在修改任何数据之前调用
+func verifyBackupManifest(zipReader *zip.Reader, manifest *backupManifest, progress *progressReporter) error {
+	if manifest.SchemaVersion > backupSchemaVersion {
+		return fmt.Errorf("%w: schema version %d, supported up to %d", ErrBackupSchemaTooNew, manifest.SchemaVersion, backupSchemaVersion)
+	}
+	if manifest.SchemaVersion == legacySchemaVersion {
+		return nil
+	}
+	progress.stage(stageVerify, int64(len(manifest.Files)))
+	seen := make(map[string]struct{}, len(manifest.Files))
+	for _, file := range zipReader.File {
+		name := cleanArchiveName(file.Name)
+		if name == "" || file.FileInfo().IsDir() {
+			continue
+		}
+		name = normalizeBackupEntryName(name)
+		if !strings.HasPrefix(name, backupDataDir+"/") && !strings.HasPrefix(name, backupStaticDir+"/") {
+			continue
+		}
+		expected, ok := manifest.Files[name]
+		if !ok {
+			return fmt.Errorf("%w: %s is not listed in the manifest", ErrBackupCorrupted, name)
+		}
+		actual, err := zipFileSHA256(file)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrBackupCorrupted, name, err)
+		}
+		if actual != expected {
+			return fmt.Errorf("%w: sha256 of %s mismatch", ErrBackupCorrupted, name)
+		}
+		seen[name] = struct{}{}
+		progress.advance(name, 1)
+	}
+	for name := range manifest.Files {
+		if _, ok := seen[name]; !ok {
+			return fmt.Errorf("%w: %s is missing", ErrBackupCorrupted, name)
+		}
+	}
+	return nil
+}
+
+// verifyCollectionCount 校验解析出的文档数量与 manifest 中记录的一致
+func verifyCollectionCount(manifest *backupManifest, colName string, count int) error {
+	if manifest.SchemaVersion == legacySchemaVersion {
+		return nil
+	}
+	if expected, ok := manifest.Collections[colName]; !ok || expected != count {
+		return fmt.Errorf("%w: document count of collection %s mismatch", ErrBackupCorrupted, colName)
+	}
+	return nil
+}
+
+// migrateBackupDocument 依次执行从备份的 schema 版本到当前版本的迁移
+func migrateBackupDocument(manifest *backupManifest, colName string, doc map[string]any) error {
+	for v := manifest.SchemaVersion; v < backupSchemaVersion; v++ {
+		migration, ok := backupMigrations[v]
+		if !ok {
+			return fmt.Errorf("no migration registered for schema version %d", v)
+		}
+		if err := migration(colName, doc); err != nil {
+			return fmt.Errorf("migrate collection %s from schema version %d failed: %w", colName, v, err)
+		}
+	}
+	return nil
+}
+
+// migrateLegacySocialIds 旧版本导出时社交信息的 id 被序列化为 {"Subtype": 0, "Data": "<hex>"}，统一转换为十六进制字符串
+func migrateLegacySocialIds(colName string, doc map[string]any) error {
+	if typ, ok := doc["typ"]; colName != "configs" || !ok || typ != "social" {
+		return nil
+	}
+	props, _ := doc["props"].(map[string]any)
+	socialList, _ := props["social_info_list"].([]any)
+	for _, m := range socialList {
+		obj, ok := m.(map[string]any)
+		if !ok {
+			continue
+		}
+		if legacy, ok := obj["id"].(map[string]any); ok {
+			data, ok := legacy["Data"].(string)
+			if !ok {
+				return fmt.Errorf("invalid social id format")
+			}
+			obj["id"] = data
+		}
+	}
+	return nil
+}
+
+func zipFileSHA256(file *zip.File) (string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	return sha256Hex(reader)
+}
+
+func sha256Hex(reader io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
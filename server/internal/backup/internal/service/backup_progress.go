@@ -0,0 +1,123 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/backup/internal/domain"
+)
+
+// 备份和恢复的阶段
+const (
+	stageExportCollections  = "export_collections"
+	stageArchiveStaticFiles = "archive_static_files"
+	stagePrepare            = "prepare"
+	stageDecrypt            = "decrypt"
+	stageVerify             = "verify"
+	stageInspect            = "inspect"
+	stagePreRestoreBackup   = "pre_restore_backup"
+	stageRestoreCollections = "restore_collections"
+	stageRestoreStaticFiles = "restore_static_files"
+	stageDone               = "done"
+)
+
+var ErrRestoreInProgress = errors.New("another restore is in progress")
+
+// backupProgress 记录每种操作最近一次的进度，供管理后台轮询
+type backupProgress struct {
+	mu      sync.Mutex
+	current map[domain.BackupOperation]*domain.BackupProgress
+}
+
+func (p *backupProgress) begin(operation domain.BackupOperation) *progressReporter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current == nil {
+		p.current = make(map[domain.BackupOperation]*domain.BackupProgress)
+	}
+	now := time.Now()
+	p.current[operation] = &domain.BackupProgress{Operation: operation, Running: true, StartedAt: now, UpdatedAt: now}
+	return &progressReporter{p: p, operation: operation}
+}
+
+func (p *backupProgress) list() []domain.BackupProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := make([]domain.BackupProgress, 0, len(p.current))
+	for _, operation := range []domain.BackupOperation{domain.BackupOperationBackup, domain.BackupOperationRestore} {
+		if progress, ok := p.current[operation]; ok {
+			result = append(result, *progress)
+		}
+	}
+	return result
+}
+
+// progressReporter 更新某一次操作的进度，为 nil 时不记录
+type progressReporter struct {
+	p         *backupProgress
+	operation domain.BackupOperation
+}
+
+func (r *progressReporter) update(fn func(progress *domain.BackupProgress)) {
+	if r == nil {
+		return
+	}
+	r.p.mu.Lock()
+	defer r.p.mu.Unlock()
+	progress := r.p.current[r.operation]
+	fn(progress)
+	progress.UpdatedAt = time.Now()
+}
+
+// stage 进入新的阶段并重置计数
+func (r *progressReporter) stage(stage string, total int64) {
+	r.update(func(progress *domain.BackupProgress) {
+		progress.Stage, progress.Item, progress.Processed, progress.Total = stage, "", 0, total
+	})
+}
+
+func (r *progressReporter) advance(item string, n int64) {
+	r.update(func(progress *domain.BackupProgress) {
+		progress.Item = item
+		progress.Processed += n
+	})
+}
+
+func (r *progressReporter) finish(err error) {
+	r.update(func(progress *domain.BackupProgress) {
+		progress.Running = false
+		if err != nil {
+			progress.Error = err.Error()
+			return
+		}
+		progress.Stage, progress.Item = stageDone, ""
+	})
+}
+
+// progressReader 按读取的字节数更新进度
+type progressReader struct {
+	r        io.Reader
+	progress *progressReporter
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.progress.advance("", int64(n))
+	return n, err
+}
@@ -0,0 +1,389 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/chenmingyong0423/fnote/server/internal/backup/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// restoreBatchSize 恢复时每批写入的文档数量
+const restoreBatchSize = 1000
+
+// recoveryPlan 需要恢复的集合和静态文件，集合数据在恢复前会先完整解析一遍，数据有误时在修改任何数据之前返回错误
+type recoveryPlan struct {
+	manifest    *backupManifest
+	collections []collectionRecovery
+	staticDirs  []string
+	staticFiles []staticRecovery
+}
+
+type collectionRecovery struct {
+	name string
+	file *zip.File
+	// documents 检查阶段解析出的文档数量
+	documents int
+}
+
+type staticRecovery struct {
+	file    *zip.File
+	relPath string
+}
+
+func (s *BackupService) RestoreStoredBackup(ctx context.Context, name string, opts domain.RecoveryOptions) (*domain.RecoveryReport, error) {
+	backup, err := findBackup(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.runRestore(func(progress *progressReporter) (*domain.RecoveryReport, error) {
+		progress.stage(stagePrepare, 0)
+		tmp, err := os.CreateTemp("", "fnote-restore-*.zip")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if err = s.writeBackupArchive(ctx, backup.Name, tmp, ""); err != nil {
+			return nil, err
+		}
+		info, err := tmp.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return s.recoverZip(ctx, tmp, info.Size(), opts, progress)
+	})
+}
+
+func (s *BackupService) GetProgress(_ context.Context) []domain.BackupProgress {
+	return s.progress.list()
+}
+
+// runRestore 同一时间只允许一个恢复操作，并记录恢复进度
+func (s *BackupService) runRestore(fn func(progress *progressReporter) (*domain.RecoveryReport, error)) (*domain.RecoveryReport, error) {
+	if !s.restoreMu.TryLock() {
+		return nil, ErrRestoreInProgress
+	}
+	defer s.restoreMu.Unlock()
+
+	progress := s.progress.begin(domain.BackupOperationRestore)
+	report, err := fn(progress)
+	progress.finish(err)
+	return report, err
+}
+
+func (s *BackupService) recoverZip(ctx context.Context, src io.ReaderAt, size int64, opts domain.RecoveryOptions, progress *progressReporter) (*domain.RecoveryReport, error) {
+	zipReader, err := zip.NewReader(src, size)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := readBackupManifest(zipReader)
+	if err != nil {
+		return nil, err
+	}
+	if err = verifyBackupManifest(zipReader, manifest, progress); err != nil {
+		return nil, err
+	}
+	plan, err := s.planRecovery(zipReader, manifest, opts)
+	if err != nil {
+		return nil, err
+	}
+	report, err := s.recoveryReport(ctx, plan, progress)
+	if err != nil {
+		return nil, err
+	}
+	report.DryRun = opts.DryRun
+	report.SchemaVersion = manifest.SchemaVersion
+	report.FnoteVersion = manifest.FnoteVersion
+	if opts.DryRun {
+		return report, nil
+	}
+
+	progress.stage(stagePreRestoreBackup, 0)
+	preRestore, err := s.CreateBackup(ctx, domain.BackupTriggerPreRestore)
+	if err != nil {
+		return nil, fmt.Errorf("create pre-restore backup failed: %w", err)
+	}
+	report.PreRestoreBackup = preRestore.Name
+
+	var total int64
+	for _, c := range plan.collections {
+		total += int64(c.documents)
+	}
+	progress.stage(stageRestoreCollections, total)
+	for _, c := range plan.collections {
+		if err = s.restoreCollection(ctx, plan.manifest, c, progress); err != nil {
+			return nil, fmt.Errorf("restore collection %s failed, the pre-restore backup is %s: %w", c.name, preRestore.Name, err)
+		}
+	}
+	progress.stage(stageRestoreStaticFiles, int64(len(plan.staticFiles)))
+	for _, dir := range plan.staticDirs {
+		if err = mkdirStaticDir(dir); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range plan.staticFiles {
+		if err = restoreZipStaticFile(f.file, f.relPath); err != nil {
+			return nil, fmt.Errorf("restore static file %s failed, the pre-restore backup is %s: %w", f.relPath, preRestore.Name, err)
+		}
+		progress.advance(f.relPath, 1)
+	}
+	return report, nil
+}
+
+func (s *BackupService) planRecovery(zipReader *zip.Reader, manifest *backupManifest, opts domain.RecoveryOptions) (*recoveryPlan, error) {
+	plan := &recoveryPlan{manifest: manifest}
+	foundCollections := make(map[string]struct{})
+	matchedPaths := make(map[string]struct{})
+	for _, file := range zipReader.File {
+		name := cleanArchiveName(file.Name)
+		if name == "" {
+			continue
+		}
+		name = normalizeBackupEntryName(name)
+		switch {
+		case strings.HasPrefix(name, backupDataDir+"/") && strings.HasSuffix(name, ".json") && !file.FileInfo().IsDir():
+			colName, err := s.collectionNameFromBackupFile(path.Base(name), manifest.Database)
+			if err != nil {
+				return nil, err
+			}
+			// 旧版本导出的备份中可能包含这些集合，恢复时忽略
+			if isExcludedCollection(colName) {
+				continue
+			}
+			foundCollections[colName] = struct{}{}
+			if len(opts.Collections) > 0 && !slices.Contains(opts.Collections, colName) {
+				continue
+			}
+			plan.collections = append(plan.collections, collectionRecovery{name: colName, file: file})
+		case strings.HasPrefix(name, backupStaticDir+"/"):
+			relPath := strings.TrimPrefix(name, backupStaticDir+"/")
+			matched, ok := matchStaticPath(relPath, opts.StaticPaths)
+			if !ok {
+				continue
+			}
+			matchedPaths[matched] = struct{}{}
+			if _, err := staticTargetPath(relPath); err != nil {
+				return nil, err
+			}
+			if file.FileInfo().IsDir() {
+				plan.staticDirs = append(plan.staticDirs, relPath)
+			} else {
+				plan.staticFiles = append(plan.staticFiles, staticRecovery{file: file, relPath: relPath})
+			}
+		}
+	}
+
+	// 指定的集合或路径在备份中不存在时大概率是输入有误，直接返回错误
+	for _, c := range opts.Collections {
+		if isExcludedCollection(c) {
+			return nil, fmt.Errorf("collection %s cannot be restored", c)
+		}
+		if _, ok := foundCollections[c]; !ok {
+			return nil, fmt.Errorf("collection %s not found in the backup", c)
+		}
+	}
+	for _, p := range opts.StaticPaths {
+		if _, ok := matchedPaths[strings.Trim(p, "/")]; !ok {
+			return nil, fmt.Errorf("static path %s not found in the backup", p)
+		}
+	}
+	return plan, nil
+}
+
+// matchStaticPath 判断 relPath 是否位于 allowList 中的某个文件或目录下，返回匹配的项，allowList 为空时全部匹配
+func matchStaticPath(relPath string, allowList []string) (string, bool) {
+	if len(allowList) == 0 {
+		return "", true
+	}
+	for _, p := range allowList {
+		p = strings.Trim(p, "/")
+		if relPath == p || strings.HasPrefix(relPath, p+"/") {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// recoveryReport 完整解析一遍需要恢复的集合，校验数据并统计变更
+func (s *BackupService) recoveryReport(ctx context.Context, plan *recoveryPlan, progress *progressReporter) (*domain.RecoveryReport, error) {
+	report := &domain.RecoveryReport{
+		Collections:            make([]domain.CollectionRecovery, 0, len(plan.collections)),
+		StaticFilesOverwritten: make([]string, 0),
+	}
+	var total int64
+	for _, c := range plan.collections {
+		total += int64(plan.manifest.Collections[c.name])
+	}
+	progress.stage(stageInspect, total)
+	for i := range plan.collections {
+		result, err := s.inspectCollection(ctx, plan.manifest, &plan.collections[i], progress)
+		if err != nil {
+			return nil, err
+		}
+		report.Collections = append(report.Collections, *result)
+	}
+	for _, f := range plan.staticFiles {
+		targetPath, err := staticTargetPath(f.relPath)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = os.Stat(targetPath); err == nil {
+			report.StaticFilesOverwritten = append(report.StaticFilesOverwritten, f.relPath)
+		} else {
+			report.StaticFilesAdded++
+		}
+	}
+	return report, nil
+}
+
+// inspectCollection 按批查询备份中的 _id 在集合中是否存在，不需要将整个集合读入内存
+func (s *BackupService) inspectCollection(ctx context.Context, manifest *backupManifest, c *collectionRecovery, progress *progressReporter) (*domain.CollectionRecovery, error) {
+	col := s.db.Collection(c.name)
+	existing, err := col.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.CollectionRecovery{Name: c.name}
+	ids := make([]any, 0, restoreBatchSize)
+	flush := func() error {
+		if len(ids) == 0 {
+			return nil
+		}
+		n, err := col.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return err
+		}
+		result.Replaced += int(n)
+		progress.advance(c.name, int64(len(ids)))
+		ids = ids[:0]
+		return nil
+	}
+	c.documents, err = decodeBackupCollection(c.file, manifest, c.name, func(doc map[string]any) error {
+		ids = append(ids, doc["_id"])
+		if len(ids) == restoreBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("inspect collection %s failed: %w", c.name, err)
+	}
+	if err = verifyCollectionCount(manifest, c.name, c.documents); err != nil {
+		return nil, err
+	}
+	result.Added = c.documents - result.Replaced
+	result.Removed = int(existing) - result.Replaced
+	return result, nil
+}
+
+// restoreCollection 清空集合后按批写入备份中的文档
+func (s *BackupService) restoreCollection(ctx context.Context, manifest *backupManifest, c collectionRecovery, progress *progressReporter) error {
+	col := s.db.Collection(c.name)
+	if _, err := col.DeleteMany(ctx, bson.M{}); err != nil {
+		return err
+	}
+	batch := make([]any, 0, restoreBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := col.InsertMany(ctx, batch); err != nil {
+			return err
+		}
+		progress.advance(c.name, int64(len(batch)))
+		batch = batch[:0]
+		return nil
+	}
+	if _, err := decodeBackupCollection(c.file, manifest, c.name, func(doc map[string]any) error {
+		batch = append(batch, doc)
+		if len(batch) == restoreBatchSize {
+			return flush()
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// decodeBackupCollection 流式解析备份中的集合数据，对每个文档执行迁移、还原类型后调用 fn，返回文档数量
+func decodeBackupCollection(file *zip.File, manifest *backupManifest, colName string, fn func(doc map[string]any) error) (int, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	count := 0
+	err = decodeJSONArray(reader, func(doc map[string]any) error {
+		count++
+		if err := migrateBackupDocument(manifest, colName, doc); err != nil {
+			return err
+		}
+		if err := restoreDocumentTypes(colName, doc); err != nil {
+			return fmt.Errorf("invalid data of collection %s: %w", colName, err)
+		}
+		return fn(doc)
+	})
+	return count, err
+}
+
+// decodeJSONArray 逐个解析 json 数组中的对象
+func decodeJSONArray(r io.Reader, fn func(doc map[string]any) error) error {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return errors.New("expected a json array")
+	}
+	for decoder.More() {
+		var doc map[string]any
+		if err = decoder.Decode(&doc); err != nil {
+			return err
+		}
+		if err = fn(doc); err != nil {
+			return err
+		}
+	}
+	_, err = decoder.Token()
+	return err
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
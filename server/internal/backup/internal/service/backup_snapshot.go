@@ -0,0 +1,648 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/backup/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/cronutil"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// 备份目录结构：
+//
+//	snapshots/backup_<时间>_<触发方式>.zip  集合数据、manifest.json 以及静态文件索引 static.json
+//	objects/<sha256 前两位>/<sha256>       按内容去重的静态文件
+const (
+	snapshotDir      = "snapshots"
+	objectDir        = "objects"
+	staticIndexFile  = "static.json"
+	backupTimeLayout = "2006-01-02_150405"
+
+	defaultKeepDaily  = 7
+	defaultKeepWeekly = 4
+)
+
+var backupNameRegexp = regexp.MustCompile(`^backup_(\d{4}-\d{2}-\d{2}_\d{6})_(manual|scheduled|pre_restore)\.zip$`)
+
+// staticFile 静态文件索引中的一项，通过 size 和 mod_time 判断文件是否变化，未变化时复用上一次备份的 hash
+type staticFile struct {
+	Path    string      `json:"path"`
+	Hash    string      `json:"hash"`
+	Size    int64       `json:"size"`
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mod_time"`
+}
+
+func (s *BackupService) CreateBackup(ctx context.Context, trigger domain.BackupTrigger) (*domain.Backup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	progress := s.progress.begin(domain.BackupOperationBackup)
+	backup, err := s.createBackup(ctx, trigger, progress)
+	progress.finish(err)
+	return backup, err
+}
+
+func (s *BackupService) createBackup(ctx context.Context, trigger domain.BackupTrigger, progress *progressReporter) (*domain.Backup, error) {
+	staticPath := viper.GetString("system.static_path")
+	if staticPath == "" {
+		return nil, fmt.Errorf("system.static_path is empty")
+	}
+	dir := backupDir()
+	snapshotsPath, objectsPath := filepath.Join(dir, snapshotDir), filepath.Join(dir, objectDir)
+	if err := os.MkdirAll(snapshotsPath, os.ModePerm); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(objectsPath, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Local()
+	name := fmt.Sprintf("backup_%s_%s.zip", now.Format(backupTimeLayout), trigger)
+	target := filepath.Join(snapshotsPath, name)
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("backup %s already exists", name)
+	}
+
+	previous, err := s.latestStaticIndex()
+	if err != nil {
+		// 读取不到上一次的索引时退化为全量计算 hash，已存在的对象仍然不会重复保存
+		slog.WarnContext(ctx, "Backup: failed to read previous static index", "error", err)
+	}
+	files, err := snapshotStaticFiles(staticPath, objectsPath, previous, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "fnote-backup-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if fErr := os.RemoveAll(tempDir); fErr != nil {
+			slog.Error("remove backup temp dir failed", "dir", tempDir, "error", fErr)
+		}
+	}()
+	counts, err := s.exportCollections(ctx, filepath.Join(tempDir, backupDataDir), progress)
+	if err != nil {
+		return nil, err
+	}
+	if err = writeSnapshot(target, filepath.Join(tempDir, backupDataDir), files, newBackupManifest(s.db.Name(), counts)); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+	go s.syncTargetsInBackground()
+	return &domain.Backup{Name: name, Trigger: trigger, Size: info.Size(), CreatedAt: now}, nil
+}
+
+func (s *BackupService) GetStoredBackups(_ context.Context) ([]domain.Backup, error) {
+	return listBackups()
+}
+
+func (s *BackupService) GetStoredBackup(_ context.Context, name string) (*domain.Backup, error) {
+	return findBackup(name)
+}
+
+// WriteBackupArchive 将备份还原为包含 data 和 static 的完整 zip 写入 w，格式与恢复接口一致
+func (s *BackupService) WriteBackupArchive(ctx context.Context, name string, w io.Writer) error {
+	return s.writeBackupArchive(ctx, name, w, backupPassphrase())
+}
+
+// writeBackupArchive passphrase 不为空时加密
+func (s *BackupService) writeBackupArchive(ctx context.Context, name string, w io.Writer, passphrase string) (err error) {
+	backup, err := findBackup(name)
+	if err != nil {
+		return err
+	}
+	reader, err := zip.OpenReader(filepath.Join(backupDir(), snapshotDir, backup.Name))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if passphrase != "" {
+		encryptWriter, err := newEncryptWriter(w, passphrase)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cErr := encryptWriter.Close(); err == nil {
+				err = cErr
+			}
+		}()
+		w = encryptWriter
+	}
+	zipWriter := zip.NewWriter(w)
+	var files []staticFile
+	for _, file := range reader.File {
+		switch {
+		case file.Name == staticIndexFile:
+			if files, err = readStaticIndex(file); err != nil {
+				return err
+			}
+		case file.Name == manifestFile || strings.HasPrefix(file.Name, backupDataDir+"/"):
+			if err = zipWriter.Copy(file); err != nil {
+				return err
+			}
+		}
+	}
+	objectsPath := filepath.Join(backupDir(), objectDir)
+	for _, file := range files {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = addObjectToZip(zipWriter, objectsPath, file); err != nil {
+			return err
+		}
+	}
+	return zipWriter.Close()
+}
+
+func (s *BackupService) DeleteStoredBackup(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	backup, err := findBackup(name)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(filepath.Join(backupDir(), snapshotDir, backup.Name)); err != nil {
+		return err
+	}
+	if err = s.removeUnreferencedObjects(ctx); err != nil {
+		return err
+	}
+	return s.deleteFromTargets(ctx, []string{backup.Name})
+}
+
+// PruneBackups 按照保留策略删除定时备份：保留最近 daily 天中每天最新的一份以及最近 weekly 周中每周最新的一份，
+// 手动创建的备份不受影响
+func (s *BackupService) PruneBackups(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	backups, err := listBackups()
+	if err != nil {
+		return 0, err
+	}
+	keepDaily, keepWeekly := viper.GetInt("backup.retention.daily"), viper.GetInt("backup.retention.weekly")
+	if !viper.IsSet("backup.retention.daily") {
+		keepDaily = defaultKeepDaily
+	}
+	if !viper.IsSet("backup.retention.weekly") {
+		keepWeekly = defaultKeepWeekly
+	}
+
+	days, weeks := make(map[string]struct{}), make(map[string]struct{})
+	var deleted []string
+	// listBackups 按时间倒序返回，因此每天、每周遇到的第一份即为最新的一份
+	for _, backup := range backups {
+		if backup.Trigger != domain.BackupTriggerScheduled {
+			continue
+		}
+		keep := false
+		day := backup.CreatedAt.Format(time.DateOnly)
+		if _, ok := days[day]; !ok && len(days) < keepDaily {
+			days[day] = struct{}{}
+			keep = true
+		}
+		year, w := backup.CreatedAt.ISOWeek()
+		week := fmt.Sprintf("%d-%02d", year, w)
+		if _, ok := weeks[week]; !ok && len(weeks) < keepWeekly {
+			weeks[week] = struct{}{}
+			keep = true
+		}
+		if keep {
+			continue
+		}
+		if err = os.Remove(filepath.Join(backupDir(), snapshotDir, backup.Name)); err != nil {
+			return len(deleted), err
+		}
+		deleted = append(deleted, backup.Name)
+	}
+	if len(deleted) == 0 {
+		return 0, nil
+	}
+	if err = s.removeUnreferencedObjects(ctx); err != nil {
+		return len(deleted), err
+	}
+	return len(deleted), s.deleteFromTargets(ctx, deleted)
+}
+
+// referencedObjects 返回本地所有备份引用的静态文件对象
+func referencedObjects() (map[string]struct{}, error) {
+	backups, err := listBackups()
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]struct{})
+	for _, backup := range backups {
+		files, err := readSnapshotStaticIndex(filepath.Join(backupDir(), snapshotDir, backup.Name))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			referenced[file.Hash] = struct{}{}
+		}
+	}
+	return referenced, nil
+}
+
+// removeUnreferencedObjects 删除所有备份都不再引用的静态文件对象，调用方需持有锁
+func (s *BackupService) removeUnreferencedObjects(ctx context.Context) error {
+	referenced, err := referencedObjects()
+	if err != nil {
+		return err
+	}
+	removed := 0
+	err = filepath.WalkDir(filepath.Join(backupDir(), objectDir), func(filePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		// 临时文件只会在持有锁时出现，此时遗留的都是异常中断产生的
+		if _, ok := referenced[entry.Name()]; ok {
+			return nil
+		}
+		removed++
+		return os.Remove(filePath)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// 删除清理后为空的前缀目录，非空目录删除会失败，直接忽略
+	if entries, err := os.ReadDir(filepath.Join(backupDir(), objectDir)); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				_ = os.Remove(filepath.Join(backupDir(), objectDir, entry.Name()))
+			}
+		}
+	}
+	if removed > 0 {
+		slog.InfoContext(ctx, "Backup: unreferenced static objects removed", "count", removed)
+	}
+	return nil
+}
+
+func (s *BackupService) backupPeriodically() {
+	expr := viper.GetString("backup.schedule")
+	if expr == "" {
+		return
+	}
+	schedule, err := cronutil.Parse(expr)
+	if err != nil {
+		slog.Error("Backup: invalid backup.schedule, scheduled backups are disabled", "schedule", expr, "error", err)
+		return
+	}
+	for {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			slog.Warn("Backup: backup.schedule never fires, scheduled backups are disabled", "schedule", expr)
+			return
+		}
+		time.Sleep(time.Until(next))
+
+		l := slog.Default().With("X-Request-ID", uuid.NewString())
+		backup, err := s.CreateBackup(context.Background(), domain.BackupTriggerScheduled)
+		if err != nil {
+			l.Error("Backup: scheduled backup failed", "error", err)
+			continue
+		}
+		l.Info("Backup: scheduled backup created", "name", backup.Name, "size", backup.Size)
+		// 推送到备份目标在 CreateBackup 中异步进行
+		deleted, err := s.PruneBackups(context.Background())
+		if err != nil {
+			l.Error("Backup: failed to prune backups", "error", err)
+		} else if deleted > 0 {
+			l.Info("Backup: expired backups pruned", "count", deleted)
+		}
+	}
+}
+
+// backupDir 备份目录，未配置时使用 static_path 的同级目录 backups
+func backupDir() string {
+	if dir := viper.GetString("backup.dir"); dir != "" {
+		return dir
+	}
+	return filepath.Join(filepath.Dir(filepath.Clean(viper.GetString("system.static_path"))), "backups")
+}
+
+func listBackups() ([]domain.Backup, error) {
+	entries, err := os.ReadDir(filepath.Join(backupDir(), snapshotDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []domain.Backup{}, nil
+		}
+		return nil, err
+	}
+	backups := make([]domain.Backup, 0, len(entries))
+	for _, entry := range entries {
+		backup, ok := parseBackupName(entry.Name())
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		backup.Size = info.Size()
+		backups = append(backups, backup)
+	}
+	slices.SortFunc(backups, func(a, b domain.Backup) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return backups, nil
+}
+
+// findBackup 查找指定名称的备份，名称不合法或不存在时返回 fs.ErrNotExist
+func findBackup(name string) (*domain.Backup, error) {
+	backup, ok := parseBackupName(name)
+	if !ok {
+		return nil, fmt.Errorf("backup %s: %w", name, fs.ErrNotExist)
+	}
+	info, err := os.Stat(filepath.Join(backupDir(), snapshotDir, name))
+	if err != nil {
+		return nil, err
+	}
+	backup.Size = info.Size()
+	return &backup, nil
+}
+
+func parseBackupName(name string) (domain.Backup, bool) {
+	matches := backupNameRegexp.FindStringSubmatch(name)
+	if matches == nil {
+		return domain.Backup{}, false
+	}
+	createdAt, err := time.ParseInLocation(backupTimeLayout, matches[1], time.Local)
+	if err != nil {
+		return domain.Backup{}, false
+	}
+	return domain.Backup{Name: name, Trigger: domain.BackupTrigger(matches[2]), CreatedAt: createdAt}, true
+}
+
+// latestStaticIndex 返回最近一次备份的静态文件索引
+func (s *BackupService) latestStaticIndex() (map[string]staticFile, error) {
+	backups, err := listBackups()
+	if err != nil || len(backups) == 0 {
+		return nil, err
+	}
+	files, err := readSnapshotStaticIndex(filepath.Join(backupDir(), snapshotDir, backups[0].Name))
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]staticFile, len(files))
+	for _, file := range files {
+		index[file.Path] = file
+	}
+	return index, nil
+}
+
+func readSnapshotStaticIndex(snapshotPath string) ([]staticFile, error) {
+	reader, err := zip.OpenReader(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	for _, file := range reader.File {
+		if file.Name == staticIndexFile {
+			return readStaticIndex(file)
+		}
+	}
+	return nil, nil
+}
+
+func readStaticIndex(file *zip.File) ([]staticFile, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	var files []staticFile
+	if err = json.NewDecoder(reader).Decode(&files); err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if len(file.Hash) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid static index entry: %s", file.Path)
+		}
+	}
+	return files, nil
+}
+
+// snapshotStaticFiles 遍历 static_path 并将新增或变化的文件保存到对象目录中
+func snapshotStaticFiles(staticPath, objectsPath string, previous map[string]staticFile, progress *progressReporter) ([]staticFile, error) {
+	files := make([]staticFile, 0)
+	progress.stage(stageArchiveStaticFiles, 0)
+	if _, err := os.Stat(staticPath); os.IsNotExist(err) {
+		return files, nil
+	}
+	backupDirAbs, err := filepath.Abs(backupDir())
+	if err != nil {
+		return nil, err
+	}
+	err = filepath.WalkDir(staticPath, func(filePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.IsDir() {
+			// 备份目录位于 static_path 中时跳过，避免备份自身
+			if fileAbs, err := filepath.Abs(filePath); err == nil && fileAbs == backupDirAbs {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || isBackupArchive(filePath) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(staticPath, filePath)
+		if err != nil {
+			return err
+		}
+		file := staticFile{
+			Path:    filepath.ToSlash(relPath),
+			Size:    info.Size(),
+			Mode:    info.Mode().Perm(),
+			ModTime: info.ModTime(),
+		}
+		if prev, ok := previous[file.Path]; ok && prev.Size == file.Size && prev.ModTime.Equal(file.ModTime) && objectExists(objectsPath, prev.Hash) {
+			file.Hash = prev.Hash
+		} else if file.Hash, err = storeObject(objectsPath, filePath); err != nil {
+			return err
+		}
+		files = append(files, file)
+		progress.advance(file.Path, 1)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func objectPath(objectsPath, hash string) string {
+	return filepath.Join(objectsPath, hash[:2], hash)
+}
+
+func objectExists(objectsPath, hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := os.Stat(objectPath(objectsPath, hash))
+	return err == nil
+}
+
+// storeObject 计算文件的 sha256 并在对象不存在时保存，返回 hash
+func storeObject(objectsPath, filePath string) (string, error) {
+	src, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(objectsPath, ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if fErr := os.Remove(tmp.Name()); fErr != nil && !os.IsNotExist(fErr) {
+			slog.Error("remove backup temp object failed", "file", tmp.Name(), "error", fErr)
+		}
+	}()
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), src)
+	if cErr := tmp.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return "", err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+	if objectExists(objectsPath, hash) {
+		return hash, nil
+	}
+	if err = os.MkdirAll(filepath.Dir(objectPath(objectsPath, hash)), os.ModePerm); err != nil {
+		return "", err
+	}
+	return hash, os.Rename(tmp.Name(), objectPath(objectsPath, hash))
+}
+
+// writeSnapshot 先写入临时文件再重命名，保证备份目录中不会出现不完整的备份。
+// manifest 中同时记录静态文件的 sha256，导出完整备份时原样复制
+func writeSnapshot(target, dataDir string, files []staticFile, manifest *backupManifest) (err error) {
+	tmpName := target + ".tmp"
+	out, err := os.Create(tmpName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			if fErr := os.Remove(tmpName); fErr != nil && !os.IsNotExist(fErr) {
+				slog.Error("remove failed backup file failed", "file", tmpName, "error", fErr)
+			}
+		}
+	}()
+
+	zipWriter := zip.NewWriter(out)
+	if err = filepath.WalkDir(dataDir, func(filePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		archiveName := path.Join(backupDataDir, filepath.Base(filePath))
+		hash, err := addFileToZip(zipWriter, filePath, archiveName)
+		manifest.Files[archiveName] = hash
+		return err
+	}); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, file := range files {
+		manifest.Files[path.Join(backupStaticDir, file.Path)] = file.Hash
+	}
+	if err = writeBackupManifest(zipWriter, manifest); err != nil {
+		return err
+	}
+
+	index, err := json.MarshalIndent(files, "", "    ")
+	if err != nil {
+		return err
+	}
+	writer, err := zipWriter.Create(staticIndexFile)
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(index); err != nil {
+		return err
+	}
+	if err = zipWriter.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, target)
+}
+
+func addObjectToZip(zipWriter *zip.Writer, objectsPath string, file staticFile) error {
+	object, err := os.Open(objectPath(objectsPath, file.Hash))
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	header := &zip.FileHeader{
+		Name:     path.Join(backupStaticDir, file.Path),
+		Method:   zip.Deflate,
+		Modified: file.ModTime,
+	}
+	header.SetMode(file.Mode)
+	writer, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, object)
+	return err
+}
@@ -0,0 +1,374 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/chenmingyong0423/fnote/server/internal/backup/internal/storage"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// 备份目标与本地备份目录使用相同的 key 结构，本地备份目录是唯一的数据来源：
+// 新的备份推送到所有目标，本地删除的备份也会从所有目标中删除
+
+// backupTargets 解析配置中的 backup.targets，配置有误的目标会被忽略
+func backupTargets() []storage.BackupStorage {
+	var configs []storage.Config
+	if err := viper.UnmarshalKey("backup.targets", &configs); err != nil {
+		slog.Error("Backup: invalid backup.targets", "error", err)
+		return nil
+	}
+	targets := make([]storage.BackupStorage, 0, len(configs))
+	for _, cfg := range configs {
+		target, err := storage.New(cfg)
+		if err != nil {
+			slog.Error("Backup: ignore invalid backup target", "error", err)
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+func (s *BackupService) syncTargetsInBackground() {
+	if len(s.targets) == 0 {
+		return
+	}
+	l := slog.Default().With("X-Request-ID", uuid.NewString())
+	if err := s.syncTargets(context.Background()); err != nil {
+		l.Error("Backup: failed to push backups to targets", "error", err)
+	}
+}
+
+// syncTargets 将目标中缺少的备份推送过去，静态文件对象先于备份归档推送，
+// 因此目标中存在的备份引用的对象一定也存在。设置了加密口令时静态文件对象与备份归档一样加密后推送
+func (s *BackupService) syncTargets(ctx context.Context) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	passphrase := backupPassphrase()
+	nameKey := objectNameKey(passphrase)
+	var errs []error
+	for _, target := range s.targets {
+		if err := s.syncTarget(ctx, target, passphrase, nameKey); err != nil {
+			errs = append(errs, fmt.Errorf("backup target %s: %w", target.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *BackupService) syncTarget(ctx context.Context, target storage.BackupStorage, passphrase string, nameKey []byte) error {
+	remoteSnapshots, err := listTargetNames(ctx, target, snapshotDir+"/")
+	if err != nil {
+		return err
+	}
+	remoteObjects, err := listTargetNames(ctx, target, objectDir+"/")
+	if err != nil {
+		return err
+	}
+	backups, err := listBackups()
+	if err != nil {
+		return err
+	}
+	pushed := 0
+	for _, backup := range backups {
+		if _, ok := remoteSnapshots[backup.Name]; ok {
+			continue
+		}
+		if err = s.pushBackup(ctx, target, backup.Name, remoteObjects, passphrase, nameKey); err != nil {
+			return err
+		}
+		pushed++
+	}
+	if pushed > 0 {
+		slog.InfoContext(ctx, "Backup: backups pushed to target", "target", target.Name(), "count", pushed)
+	}
+	return nil
+}
+
+// stagedFile 暂存待推送的文件，checksum 在推送前计算
+type stagedFile struct {
+	key      string
+	path     string
+	checksum string
+	// remoteName 对象在目标中的文件名，备份归档为空
+	remoteName string
+}
+
+// pushBackup 推送单个备份。持有锁时只将备份归档和目标中缺少的对象暂存到临时目录，避免暂存过程中备份被删除；
+// 上传在释放锁之后进行，慢速的目标不会阻塞备份和恢复
+func (s *BackupService) pushBackup(ctx context.Context, target storage.BackupStorage, name string, remoteObjects map[string]struct{}, passphrase string, nameKey []byte) error {
+	stageDir, files, err := s.stageBackup(name, remoteObjects, passphrase, nameKey)
+	if err != nil {
+		return err
+	}
+	if stageDir == "" {
+		return nil
+	}
+	defer os.RemoveAll(stageDir)
+
+	// 静态文件对象先于备份归档推送，files 的最后一项为备份归档
+	for _, file := range files {
+		if file.checksum, err = fileSHA256(file.path); err != nil {
+			return err
+		}
+		if err = pushFile(ctx, target, file.key, file.path, file.checksum); err != nil {
+			return err
+		}
+		if file.remoteName != "" {
+			remoteObjects[file.remoteName] = struct{}{}
+		}
+	}
+	// 推送期间备份可能已在本地被删除，此时删除刚推送的备份归档，多出的对象在下次删除备份时清理
+	if !s.backupExists(name) {
+		return target.Delete(ctx, files[len(files)-1].key)
+	}
+	return nil
+}
+
+func (s *BackupService) backupExists(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := os.Stat(filepath.Join(backupDir(), snapshotDir, name))
+	return err == nil
+}
+
+// stageBackup 在锁内暂存备份，备份已被删除时返回空的目录。
+// passphrase 不为空时暂存加密后的文件，否则优先使用硬链接，避免复制大文件
+func (s *BackupService) stageBackup(name string, remoteObjects map[string]struct{}, passphrase string, nameKey []byte) (stageDir string, files []stagedFile, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshotPath := filepath.Join(backupDir(), snapshotDir, name)
+	if _, err = os.Stat(snapshotPath); os.IsNotExist(err) {
+		return "", nil, nil
+	}
+	staticFiles, err := readSnapshotStaticIndex(snapshotPath)
+	if err != nil {
+		return "", nil, err
+	}
+	// 暂存目录与备份目录位于同一文件系统，才能使用硬链接
+	stageDir, err = os.MkdirTemp(backupDir(), ".push-*")
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(stageDir)
+		}
+	}()
+
+	objectsPath := filepath.Join(backupDir(), objectDir)
+	staged := make(map[string]struct{})
+	for _, file := range staticFiles {
+		remoteName := remoteObjectName(file.Hash, nameKey)
+		if _, ok := remoteObjects[remoteName]; ok {
+			continue
+		}
+		if _, ok := staged[remoteName]; ok {
+			continue
+		}
+		stagedPath, sErr := stageFile(stageDir, objectPath(objectsPath, file.Hash), passphrase)
+		if sErr != nil {
+			return "", nil, sErr
+		}
+		staged[remoteName] = struct{}{}
+		files = append(files, stagedFile{key: objectKey(remoteName), path: stagedPath, remoteName: remoteName})
+	}
+	stagedPath, err := stageFile(stageDir, snapshotPath, passphrase)
+	if err != nil {
+		return "", nil, err
+	}
+	files = append(files, stagedFile{key: path.Join(snapshotDir, name), path: stagedPath})
+	return stageDir, files, nil
+}
+
+// stageFile 将文件暂存到 dir 中，passphrase 不为空时加密。
+// 推送到备份目标的备份归档与本地使用相同的 key，通过头部区分是否加密
+func stageFile(dir, filePath, passphrase string) (tmpName string, err error) {
+	if passphrase == "" {
+		tmpName = filepath.Join(dir, uuid.NewString())
+		if os.Link(filePath, tmpName) == nil {
+			return tmpName, nil
+		}
+	}
+	src, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	tmp, err := os.CreateTemp(dir, "*")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if cErr := tmp.Close(); err == nil {
+			err = cErr
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+	if passphrase == "" {
+		_, err = io.Copy(tmp, src)
+		return tmp.Name(), err
+	}
+	encryptWriter, err := newEncryptWriter(tmp, passphrase)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(encryptWriter, src); err != nil {
+		return "", err
+	}
+	if err = encryptWriter.Close(); err != nil {
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// deleteFromTargets 从所有目标中删除备份并清理不再引用的对象，调用方需持有锁
+func (s *BackupService) deleteFromTargets(ctx context.Context, names []string) error {
+	if len(s.targets) == 0 {
+		return nil
+	}
+	local, err := listBackups()
+	if err != nil {
+		return err
+	}
+	localNames := make(map[string]struct{}, len(local))
+	for _, backup := range local {
+		localNames[backup.Name] = struct{}{}
+	}
+	hashes, err := referencedObjects()
+	if err != nil {
+		return err
+	}
+	// 目标中的对象以 remoteObjectName 命名，口令变更后以旧名称存放的对象不再被引用，会被一并清理
+	nameKey := objectNameKey(backupPassphrase())
+	referenced := make(map[string]struct{}, len(hashes))
+	for hash := range hashes {
+		referenced[remoteObjectName(hash, nameKey)] = struct{}{}
+	}
+
+	var errs []error
+	for _, target := range s.targets {
+		if err = deleteFromTarget(ctx, target, names, localNames, referenced); err != nil {
+			errs = append(errs, fmt.Errorf("backup target %s: %w", target.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func deleteFromTarget(ctx context.Context, target storage.BackupStorage, names []string, localNames, referenced map[string]struct{}) error {
+	for _, name := range names {
+		if err := target.Delete(ctx, path.Join(snapshotDir, name)); err != nil {
+			return err
+		}
+	}
+	remoteSnapshots, err := listTargetNames(ctx, target, snapshotDir+"/")
+	if err != nil {
+		return err
+	}
+	// 目标中存在本地没有的备份时（例如多个实例共用一个目标）无法判断对象是否仍被引用，不清理
+	for name := range remoteSnapshots {
+		if _, ok := localNames[name]; !ok {
+			slog.WarnContext(ctx, "Backup: target has backups unknown to this instance, skip removing objects", "target", target.Name(), "backup", name)
+			return nil
+		}
+	}
+	remoteObjects, err := listTargetNames(ctx, target, objectDir+"/")
+	if err != nil {
+		return err
+	}
+	for hash := range remoteObjects {
+		if _, ok := referenced[hash]; ok {
+			continue
+		}
+		if err = target.Delete(ctx, objectKey(hash)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pushFile 上传文件后重新下载计算 sha256，与预期不一致时删除目标中的文件并返回错误
+func pushFile(ctx context.Context, target storage.BackupStorage, key, filePath, checksum string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if err = target.Put(ctx, key, file, info.Size()); err != nil {
+		return err
+	}
+
+	reader, err := target.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, reader); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != checksum {
+		if dErr := target.Delete(ctx, key); dErr != nil {
+			slog.ErrorContext(ctx, "Backup: failed to delete corrupted upload", "target", target.Name(), "key", key, "error", dErr)
+		}
+		return fmt.Errorf("checksum mismatch after uploading %s: expected %s, got %s", key, checksum, actual)
+	}
+	return nil
+}
+
+// listTargetNames 返回目标中 prefix 下所有 key 的文件名
+func listTargetNames(ctx context.Context, target storage.BackupStorage, prefix string) (map[string]struct{}, error) {
+	keys, err := target.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		names[path.Base(key)] = struct{}{}
+	}
+	return names, nil
+}
+
+func objectKey(hash string) string {
+	return path.Join(objectDir, hash[:2], hash)
+}
+
+func fileSHA256(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return sha256Hex(file)
+}
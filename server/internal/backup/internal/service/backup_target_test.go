@@ -0,0 +1,236 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// memStorage 内存中的备份目标，corrupt 不为空时 Get 返回被篡改的内容
+type memStorage struct {
+	objects map[string][]byte
+	corrupt func(data []byte) []byte
+	deleted []string
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{objects: make(map[string][]byte)}
+}
+
+func (m *memStorage) Name() string {
+	return "mem"
+}
+
+func (m *memStorage) Put(_ context.Context, key string, r io.Reader, _ int64) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	if m.corrupt != nil {
+		data = m.corrupt(bytes.Clone(data))
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memStorage) List(_ context.Context, prefix string) ([]string, error) {
+	keys := make([]string, 0, len(m.objects))
+	for key := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *memStorage) Delete(_ context.Context, key string) error {
+	delete(m.objects, key)
+	m.deleted = append(m.deleted, key)
+	return nil
+}
+
+func writeTempFile(t *testing.T, content string) (string, string) {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(filePath, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	return filePath, hex.EncodeToString(sum[:])
+}
+
+func TestPushFile(t *testing.T) {
+	filePath, checksum := writeTempFile(t, "backup content")
+	target := newMemStorage()
+	if err := pushFile(context.Background(), target, "snapshots/backup_1.zip", filePath, checksum); err != nil {
+		t.Fatal(err)
+	}
+	if string(target.objects["snapshots/backup_1.zip"]) != "backup content" {
+		t.Fatalf("unexpected uploaded content %q", target.objects["snapshots/backup_1.zip"])
+	}
+}
+
+func TestPushFile_ChecksumMismatch(t *testing.T) {
+	filePath, checksum := writeTempFile(t, "backup content")
+	target := newMemStorage()
+	// 模拟传输或存储过程中内容被截断
+	target.corrupt = func(data []byte) []byte {
+		return data[:len(data)-1]
+	}
+	err := pushFile(context.Background(), target, "snapshots/backup_1.zip", filePath, checksum)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("pushFile: %v, want checksum mismatch error", err)
+	}
+	if _, ok := target.objects["snapshots/backup_1.zip"]; ok {
+		t.Fatal("corrupted upload should be deleted from the target")
+	}
+	if len(target.deleted) != 1 || target.deleted[0] != "snapshots/backup_1.zip" {
+		t.Fatalf("deleted keys = %v", target.deleted)
+	}
+}
+
+// lockCheckStorage 在上传时检查 BackupService 的锁是否已释放，onPut 在每次上传后调用
+type lockCheckStorage struct {
+	*memStorage
+	s      *BackupService
+	locked bool
+	onPut  func(key string)
+}
+
+func (l *lockCheckStorage) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if !l.s.mu.TryLock() {
+		l.locked = true
+	} else {
+		l.s.mu.Unlock()
+	}
+	if err := l.memStorage.Put(ctx, key, r, size); err != nil {
+		return err
+	}
+	if l.onPut != nil {
+		l.onPut(key)
+	}
+	return nil
+}
+
+// newTestSnapshot 在临时的备份目录中创建引用一个静态文件对象的备份，返回备份名称和对象的 hash
+func newTestSnapshot(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	viper.Set("backup.dir", dir)
+	t.Cleanup(func() { viper.Set("backup.dir", "") })
+
+	objectsPath := filepath.Join(dir, objectDir)
+	if err := os.MkdirAll(filepath.Join(dir, snapshotDir), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(objectsPath, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	filePath, _ := writeTempFile(t, "static content")
+	hash, err := storeObject(objectsPath, filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "backup_1.zip"
+	out, err := os.Create(filepath.Join(dir, snapshotDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	zipWriter := zip.NewWriter(out)
+	w, err := zipWriter.Create(staticIndexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.NewEncoder(w).Encode([]staticFile{{Path: "a.png", Hash: hash}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return name, hash
+}
+
+func TestPushBackup(t *testing.T) {
+	name, hash := newTestSnapshot(t)
+	s := &BackupService{}
+	target := &lockCheckStorage{memStorage: newMemStorage(), s: s}
+	remoteObjects := map[string]struct{}{}
+	if err := s.pushBackup(context.Background(), target, name, remoteObjects, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if target.locked {
+		t.Fatal("the backup lock should not be held while uploading")
+	}
+	if string(target.objects[objectKey(hash)]) != "static content" {
+		t.Fatalf("unexpected object content %q", target.objects[objectKey(hash)])
+	}
+	if _, ok := target.objects[path.Join(snapshotDir, name)]; !ok {
+		t.Fatal("the backup archive should be pushed")
+	}
+	if _, ok := remoteObjects[hash]; !ok {
+		t.Fatal("pushed objects should be recorded")
+	}
+	entries, err := os.ReadDir(backupDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".push-") {
+			t.Fatalf("staging directory %s should be removed", entry.Name())
+		}
+	}
+}
+
+func TestPushBackup_DeletedWhilePushing(t *testing.T) {
+	name, _ := newTestSnapshot(t)
+	s := &BackupService{}
+	target := &lockCheckStorage{memStorage: newMemStorage(), s: s}
+	// 上传静态文件对象期间备份在本地被删除
+	target.onPut = func(key string) {
+		if strings.HasPrefix(key, objectDir+"/") {
+			os.Remove(filepath.Join(backupDir(), snapshotDir, name))
+		}
+	}
+	if err := s.pushBackup(context.Background(), target, name, map[string]struct{}{}, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := target.objects[path.Join(snapshotDir, name)]; ok {
+		t.Fatal("a backup deleted while pushing should be removed from the target")
+	}
+}
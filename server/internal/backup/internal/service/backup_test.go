@@ -0,0 +1,110 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chenmingyong0423/fnote/server/internal/backup/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// secretCollections 保存密钥、会话等敏感数据的集合，不能出现在备份中
+var secretCollections = []string{"jwt_keys", "admin_sessions", "two_factor_configs", "unsubscribe_tokens", "webhooks", "notification_channels"}
+
+// securityLogCollections 只追加的安全记录，恢复会抹掉恢复操作本身的记录
+var securityLogCollections = []string{"audit_logs", "login_attempts"}
+
+func newTestBackupService(t *testing.T) *BackupService {
+	t.Helper()
+	// 不会真正连接数据库，只用于获取数据库名称
+	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &BackupService{db: client.Database("fnote")}
+}
+
+// newLegacyArchive 创建不带 manifest 的备份，collections 为备份中的集合
+func newLegacyArchive(t *testing.T, collections ...string) *zip.Reader {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zipWriter := zip.NewWriter(buf)
+	for _, name := range collections {
+		w, err := zipWriter.Create(backupDataDir + "/fnote_" + name + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(`[{"_id": "1"}]`))
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zipReader
+}
+
+func TestBackupCollections(t *testing.T) {
+	all := append([]string{"posts", "comments", "configs", "admin_users"}, secretCollections...)
+	all = append(all, securityLogCollections...)
+	all = append(all, "login_throttles", "email_outbox", "webhook_deliveries")
+
+	got := backupCollections(all)
+	for _, name := range all[4:] {
+		if slices.Contains(got, name) {
+			t.Errorf("collection %s should never be exported", name)
+		}
+	}
+	for _, name := range []string{"posts", "comments", "configs", "admin_users"} {
+		if !slices.Contains(got, name) {
+			t.Errorf("collection %s should be exported", name)
+		}
+	}
+}
+
+func TestPlanRecovery_SkipsExcludedCollections(t *testing.T) {
+	s := newTestBackupService(t)
+	// 旧版本导出的备份中包含敏感集合
+	neverRestored := append(slices.Clone(secretCollections), securityLogCollections...)
+	zipReader := newLegacyArchive(t, append([]string{"posts"}, neverRestored...)...)
+	manifest := &backupManifest{SchemaVersion: legacySchemaVersion}
+
+	plan, err := s.planRecovery(zipReader, manifest, domain.RecoveryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.collections) != 1 || plan.collections[0].name != "posts" {
+		names := make([]string, 0, len(plan.collections))
+		for _, c := range plan.collections {
+			names = append(names, c.name)
+		}
+		t.Fatalf("restored collections = %v, want only posts", names)
+	}
+
+	for _, name := range neverRestored {
+		_, err = s.planRecovery(zipReader, manifest, domain.RecoveryOptions{Collections: []string{name}})
+		if err == nil || !strings.Contains(err.Error(), "cannot be restored") {
+			t.Errorf("restore %s: %v, want cannot be restored error", name, err)
+		}
+	}
+}
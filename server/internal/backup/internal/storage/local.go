@@ -0,0 +1,121 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var _ BackupStorage = (*LocalStorage)(nil)
+
+func NewLocalStorage(name, dir string) (*LocalStorage, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("backup target %s: dir is empty", name)
+	}
+	return &LocalStorage{name: name, dir: dir}, nil
+}
+
+// LocalStorage 保存到本地目录，通常用于挂载的其他磁盘或 NAS
+type LocalStorage struct {
+	name string
+	dir  string
+}
+
+func (s *LocalStorage) Name() string {
+	return s.name
+}
+
+func (s *LocalStorage) Put(_ context.Context, key string, r io.Reader, _ int64) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, r)
+	if cErr := tmp.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *LocalStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(target)
+}
+
+func (s *LocalStorage) List(_ context.Context, prefix string) ([]string, error) {
+	root, err := s.path(prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0)
+	err = filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, filePath)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" && key != "" {
+		return "", fmt.Errorf("invalid backup key: %s", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(cleaned)), nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	TypeLocal  = "local"
+	TypeS3     = "s3"
+	TypeWebDAV = "webdav"
+
+	// defaultRequestTimeout s3 与 webdav 单次请求的默认超时时间，包括上传和下载备份归档
+	defaultRequestTimeout = 30 * time.Minute
+)
+
+// BackupStorage 备份的存放目标，key 为以 / 分隔的相对路径，例如 snapshots/backup_xxx.zip；
+// Get 在 key 不存在时返回的错误需要能通过 errors.Is(err, fs.ErrNotExist) 判断
+type BackupStorage interface {
+	// Name 配置中的目标名称，用于日志
+	Name() string
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List 返回 prefix 下的所有 key
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete 删除 key，key 不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+}
+
+// Config 对应配置文件中 backup.targets 的一项，不同类型只使用各自需要的字段
+type Config struct {
+	Name string `mapstructure:"name"`
+	Type string `mapstructure:"type"`
+
+	// local
+	Dir string `mapstructure:"dir"`
+
+	// s3
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	// PathStyle 使用 endpoint/bucket/key 形式的地址，MinIO 等自建服务通常需要开启
+	PathStyle bool `mapstructure:"path_style"`
+
+	// webdav
+	URL      string `mapstructure:"url"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+
+	// Prefix s3 与 webdav 中所有 key 的公共前缀
+	Prefix string `mapstructure:"prefix"`
+	// Timeout s3 与 webdav 单次请求的超时时间，例如 10m，需要足够上传最大的备份归档，默认 30 分钟
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+func New(cfg Config) (BackupStorage, error) {
+	if cfg.Name == "" {
+		cfg.Name = cfg.Type
+	}
+	switch strings.ToLower(cfg.Type) {
+	case TypeLocal:
+		return NewLocalStorage(cfg.Name, cfg.Dir)
+	case TypeS3:
+		return NewS3Storage(cfg)
+	case TypeWebDAV:
+		return NewWebDAVStorage(cfg)
+	default:
+		return nil, fmt.Errorf("backup target %s: unsupported type %q", cfg.Name, cfg.Type)
+	}
+}
+
+// newHttpClient 目标无响应时请求在超时后失败，不会一直阻塞备份的推送
+func newHttpClient(cfg Config) *http.Client {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// joinKey 拼接前缀和 key，前缀可以带或不带 /
+func joinKey(prefix, key string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + key
+}
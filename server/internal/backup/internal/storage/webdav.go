@@ -0,0 +1,221 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+var _ BackupStorage = (*WebDAVStorage)(nil)
+
+func NewWebDAVStorage(cfg Config) (*WebDAVStorage, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("backup target %s: url is required", cfg.Name)
+	}
+	base, err := url.Parse(strings.TrimRight(cfg.URL, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("backup target %s: invalid url: %w", cfg.Name, err)
+	}
+	return &WebDAVStorage{cfg: cfg, base: base, client: newHttpClient(cfg)}, nil
+}
+
+// WebDAVStorage 保存到 WebDAV 服务，例如 Nextcloud、坚果云
+type WebDAVStorage struct {
+	cfg    Config
+	base   *url.URL
+	client *http.Client
+}
+
+func (s *WebDAVStorage) Name() string {
+	return s.cfg.Name
+}
+
+func (s *WebDAVStorage) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	key = joinKey(s.cfg.Prefix, key)
+	if err := s.mkdirAll(ctx, path.Dir(key)); err != nil {
+		return err
+	}
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *WebDAVStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, joinKey(s.cfg.Prefix, key), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+type webDAVMultiStatus struct {
+	Responses []struct {
+		Href         string `xml:"href"`
+		ResourceType struct {
+			Collection *struct{} `xml:"collection"`
+		} `xml:"propstat>prop>resourcetype"`
+	} `xml:"response"`
+}
+
+const webDAVPropfindBody = `<?xml version="1.0" encoding="utf-8"?><d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/></d:prop></d:propfind>`
+
+// List 逐层使用 Depth: 1 的 PROPFIND 遍历，很多服务出于性能考虑禁用了 Depth: infinity
+func (s *WebDAVStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	dirs := []string{strings.Trim(joinKey(s.cfg.Prefix, prefix), "/")}
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+
+		req, err := s.newRequest(ctx, "PROPFIND", dir+"/", strings.NewReader(webDAVPropfindBody))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Depth", "1")
+		req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+		resp, err := s.do(req)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		var ms webDAVMultiStatus
+		err = xml.NewDecoder(resp.Body).Decode(&ms)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range ms.Responses {
+			key, err := s.keyFromHref(r.Href)
+			if err != nil {
+				return nil, err
+			}
+			// 响应中包含目录自身
+			if key == dir || key == "" {
+				continue
+			}
+			if r.ResourceType.Collection != nil {
+				dirs = append(dirs, key)
+			} else {
+				keys = append(keys, strings.TrimPrefix(key, joinKey(s.cfg.Prefix, "")))
+			}
+		}
+	}
+	return keys, nil
+}
+
+func (s *WebDAVStorage) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, joinKey(s.cfg.Prefix, key), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// mkdirAll 依次创建 url 下的各级目录，目录已存在时服务端返回 405
+func (s *WebDAVStorage) mkdirAll(ctx context.Context, dir string) error {
+	if dir == "." || dir == "" {
+		return nil
+	}
+	current := ""
+	for _, segment := range strings.Split(dir, "/") {
+		current = path.Join(current, segment)
+		req, err := s.newRequest(ctx, "MKCOL", current+"/", nil)
+		if err != nil {
+			return err
+		}
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("MKCOL %s: %s", current, resp.Status)
+		}
+	}
+	return nil
+}
+
+func (s *WebDAVStorage) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	u := s.base.JoinPath(strings.Split(key, "/")...)
+	// 目录需要以 / 结尾，否则部分服务会返回 301
+	if strings.HasSuffix(key, "/") && !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.Username != "" {
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+	return req, nil
+}
+
+func (s *WebDAVStorage) do(req *http.Request) (*http.Response, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	err = fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, resp.Status)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %w", fs.ErrNotExist, err)
+	}
+	return nil, err
+}
+
+// keyFromHref 将 PROPFIND 响应中的 href 转换为相对 url 的路径，href 可能是绝对路径也可能是完整的 URL
+func (s *WebDAVStorage) keyFromHref(href string) (string, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(u.Path, s.base.Path) {
+		return "", fmt.Errorf("unexpected href %s", href)
+	}
+	return strings.Trim(strings.TrimPrefix(u.Path, s.base.Path), "/"), nil
+}
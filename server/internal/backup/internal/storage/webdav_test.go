@@ -0,0 +1,140 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+// newWebDAVServer 启动一个基于内存文件系统的 WebDAV 服务，要求使用 basic auth
+func newWebDAVServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	handler := &webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "fnote" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestWebDAVStorage(t *testing.T, srv *httptest.Server, password string) *WebDAVStorage {
+	t.Helper()
+	s, err := NewWebDAVStorage(Config{
+		Name:     "dav",
+		Type:     TypeWebDAV,
+		URL:      srv.URL + "/dav/",
+		Username: "fnote",
+		Password: password,
+		Prefix:   "/fnote/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func put(t *testing.T, s BackupStorage, key, content string) {
+	t.Helper()
+	if err := s.Put(context.Background(), key, strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatalf("Put(%s): %v", key, err)
+	}
+}
+
+func TestWebDAVStorage(t *testing.T) {
+	ctx := context.Background()
+	s := newTestWebDAVStorage(t, newWebDAVServer(t), "secret")
+
+	put(t, s, "snapshots/backup_1.zip", "snapshot")
+	put(t, s, "objects/ab/abcdef", "object")
+	// 已存在的目录再次 MKCOL 返回 405，不影响上传
+	put(t, s, "objects/ab/abc123", "object2")
+	put(t, s, "snapshots/backup_1.zip", "snapshot v2")
+
+	reader, err := s.Get(ctx, "snapshots/backup_1.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "snapshot v2" {
+		t.Fatalf("Get returned %q", data)
+	}
+
+	keys, err := s.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(keys)
+	if want := []string{"objects/ab/abc123", "objects/ab/abcdef", "snapshots/backup_1.zip"}; !slices.Equal(keys, want) {
+		t.Fatalf("List(\"\") = %v, want %v", keys, want)
+	}
+	keys, err = s.List(ctx, "objects/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(keys)
+	if want := []string{"objects/ab/abc123", "objects/ab/abcdef"}; !slices.Equal(keys, want) {
+		t.Fatalf("List(objects/) = %v, want %v", keys, want)
+	}
+	// 不存在的目录视为空
+	keys, err = s.List(ctx, "missing/")
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("List(missing/) = %v, %v", keys, err)
+	}
+
+	if err = s.Delete(ctx, "objects/ab/abcdef"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Get(ctx, "objects/ab/abcdef"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Get after Delete: %v, want fs.ErrNotExist", err)
+	}
+	// 删除不存在的 key 不返回错误
+	if err = s.Delete(ctx, "objects/ab/abcdef"); err != nil {
+		t.Fatalf("Delete missing key: %v", err)
+	}
+}
+
+func TestWebDAVStorage_Unauthorized(t *testing.T) {
+	s := newTestWebDAVStorage(t, newWebDAVServer(t), "wrong")
+	err := s.Put(context.Background(), "snapshots/backup_1.zip", bytes.NewReader([]byte("x")), 1)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("Put with wrong password: %v, want 401 error", err)
+	}
+	if _, err = s.Get(context.Background(), "snapshots/backup_1.zip"); err == nil || errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Get with wrong password: %v, want non ErrNotExist error", err)
+	}
+}
@@ -15,17 +15,30 @@
 package web
 
 import (
+	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/chenmingyong0423/fnote/server/internal/backup/internal/domain"
 	"github.com/chenmingyong0423/fnote/server/internal/backup/internal/service"
 
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/web/audit"
 	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
 
+	"github.com/chenmingyong0423/gkit/slice"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
+// maxFormValueSize 恢复接口中普通表单字段的最大长度
+const maxFormValueSize = 64 << 10
+
 func NewBackupHandler(serv service.IBackupService) *BackupHandler {
 	return &BackupHandler{
 		serv: serv,
@@ -40,7 +53,14 @@ func (h *BackupHandler) RegisterGinRoutes(engine *gin.Engine) {
 	adminGroup := engine.Group("/admin-api")
 
 	adminGroup.GET("/backup", h.GetBackups)
-	adminGroup.POST("/recovery", apiwrap.Wrap(h.Recovery))
+	adminGroup.POST("/recovery", audit.Redact("passphrase"), apiwrap.Wrap(h.Recovery))
+
+	adminGroup.GET("/backups", apiwrap.Wrap(h.AdminGetStoredBackups))
+	adminGroup.GET("/backups/progress", apiwrap.Wrap(h.AdminGetBackupProgress))
+	adminGroup.POST("/backups", apiwrap.Wrap(h.AdminCreateBackup))
+	adminGroup.GET("/backups/:name", h.AdminDownloadStoredBackup)
+	adminGroup.DELETE("/backups/:name", apiwrap.Wrap(h.AdminDeleteStoredBackup))
+	adminGroup.POST("/backups/:name/restore", audit.Redact("passphrase"), apiwrap.WrapWithBody(h.AdminRestoreStoredBackup))
 }
 
 func (h *BackupHandler) GetBackups(ctx *gin.Context) {
@@ -65,14 +85,107 @@ func (h *BackupHandler) GetBackups(ctx *gin.Context) {
 	ctx.FileAttachment(zipFileName, filepath.Base(zipFileName))
 }
 
-func (h *BackupHandler) Recovery(ctx *gin.Context) (any, error) {
-	file, err := ctx.FormFile("file")
+func (h *BackupHandler) Recovery(ctx *gin.Context) (*apiwrap.ResponseBody[RecoveryReportVO], error) {
+	filename, req, err := saveRecoveryUpload(ctx)
 	if err != nil {
 		return nil, err
 	}
-	err = h.serv.Recovery(ctx, file)
+	defer func() {
+		if gErr := os.Remove(filename); gErr != nil {
+			slog.Error("remove uploaded backup failed", "file", filename, "error", gErr)
+		}
+	}()
+	report, err := h.serv.Recovery(ctx, filename, req.toOptions())
+	if err != nil {
+		return nil, recoveryError(err)
+	}
+	noteRestore(ctx, "an uploaded backup", report)
+	return apiwrap.SuccessResponseWithData(toRecoveryReportVO(report)), nil
+}
+
+// saveRecoveryUpload 逐个读取 multipart 的字段，将上传的备份文件直接写入临时文件，不会读入内存
+func saveRecoveryUpload(ctx *gin.Context) (filename string, req RecoveryRequest, err error) {
+	reader, err := ctx.Request.MultipartReader()
 	if err != nil {
-		return nil, err
+		return "", req, apiwrap.NewErrorResponseBody(http.StatusBadRequest, err.Error())
+	}
+	defer func() {
+		if err != nil && filename != "" {
+			os.Remove(filename)
+		}
+	}()
+
+	values := url.Values{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return filename, req, apiwrap.NewErrorResponseBody(http.StatusBadRequest, err.Error())
+		}
+		if part.FormName() == "file" && filename == "" {
+			filename, err = saveUploadPart(part)
+		} else {
+			var value []byte
+			value, err = io.ReadAll(io.LimitReader(part, maxFormValueSize))
+			values.Add(part.FormName(), string(value))
+		}
+		part.Close()
+		if err != nil {
+			return filename, req, err
+		}
+	}
+	if filename == "" {
+		return "", req, apiwrap.NewErrorResponseBody(http.StatusBadRequest, "file is required")
+	}
+
+	req.Passphrase = values.Get("passphrase")
+	if dryRun := values.Get("dry_run"); dryRun != "" {
+		if req.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			return filename, req, apiwrap.NewErrorResponseBody(http.StatusBadRequest, "invalid dry_run")
+		}
+	}
+	req.Collections = values["collections"]
+	req.StaticPaths = values["static_paths"]
+	return filename, req, nil
+}
+
+func saveUploadPart(part io.Reader) (string, error) {
+	tmp, err := os.CreateTemp("", "fnote-upload-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(tmp, part)
+	if cErr := tmp.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// noteRestore 在本次请求的审计日志中记录恢复的内容，审计日志不会被恢复覆盖，并在恢复完成后写入
+func noteRestore(ctx *gin.Context, source string, report *domain.RecoveryReport) {
+	if report.DryRun {
+		return
+	}
+	collections := slice.Map(report.Collections, func(_ int, c domain.CollectionRecovery) string {
+		return c.Name
+	})
+	audit.Note(ctx, fmt.Sprintf("restored %s, collections: [%s], static files: %d, pre-restore backup: %s",
+		source, strings.Join(collections, " "), report.StaticFilesAdded+len(report.StaticFilesOverwritten), report.PreRestoreBackup))
+}
+
+func recoveryError(err error) error {
+	if errors.Is(err, service.ErrRestoreInProgress) {
+		return apiwrap.NewErrorResponseBody(http.StatusConflict, err.Error())
+	}
+	if errors.Is(err, service.ErrBackupPassphraseRequired) || errors.Is(err, service.ErrBackupDecryptFailed) ||
+		errors.Is(err, service.ErrBackupCorrupted) || errors.Is(err, service.ErrBackupSchemaTooNew) {
+		return apiwrap.NewErrorResponseBody(http.StatusBadRequest, err.Error())
 	}
-	return nil, nil
+	return err
 }
@@ -14,4 +14,40 @@
 
 package web
 
+import (
+	"strings"
+
+	"github.com/chenmingyong0423/fnote/server/internal/backup/internal/domain"
+)
+
 type BackupRequest struct{}
+
+// RecoveryRequest 上传恢复时通过表单提交，从备份目录恢复时通过 json 提交；
+// collections 和 static_paths 可以重复传递，也可以使用逗号分隔
+type RecoveryRequest struct {
+	Passphrase  string   `form:"passphrase" json:"passphrase"`
+	DryRun      bool     `form:"dry_run" json:"dry_run"`
+	Collections []string `form:"collections" json:"collections"`
+	StaticPaths []string `form:"static_paths" json:"static_paths"`
+}
+
+func (r RecoveryRequest) toOptions() domain.RecoveryOptions {
+	return domain.RecoveryOptions{
+		Passphrase:  r.Passphrase,
+		DryRun:      r.DryRun,
+		Collections: splitCommaValues(r.Collections),
+		StaticPaths: splitCommaValues(r.StaticPaths),
+	}
+}
+
+func splitCommaValues(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				result = append(result, v)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"io/fs"
+	"log/slog"
+	"net/http"
+
+	"github.com/chenmingyong0423/fnote/server/internal/backup/internal/domain"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func (h *BackupHandler) AdminGetStoredBackups(ctx *gin.Context) (*apiwrap.ResponseBody[apiwrap.ListVO[StoredBackupVO]], error) {
+	backups, err := h.serv.GetStoredBackups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewListVO(slice.Map(backups, func(_ int, b domain.Backup) StoredBackupVO {
+		return h.toStoredBackupVO(b)
+	}))), nil
+}
+
+func (h *BackupHandler) AdminCreateBackup(ctx *gin.Context) (*apiwrap.ResponseBody[StoredBackupVO], error) {
+	backup, err := h.serv.CreateBackup(ctx, domain.BackupTriggerManual)
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(h.toStoredBackupVO(*backup)), nil
+}
+
+func (h *BackupHandler) AdminDownloadStoredBackup(ctx *gin.Context) {
+	backup, err := h.serv.GetStoredBackup(ctx, ctx.Param("name"))
+	if err != nil {
+		apiwrap.ErrorHandler(ctx, storedBackupError(err))
+		return
+	}
+	filename, contentType := backup.Name, "application/zip"
+	if h.serv.EncryptionEnabled() {
+		filename, contentType = filename+".enc", "application/octet-stream"
+	}
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.Status(http.StatusOK)
+	// 归档边读取边写入响应，开始写入后无法再返回错误信息，只能记录日志
+	if err = h.serv.WriteBackupArchive(ctx, backup.Name, ctx.Writer); err != nil {
+		slog.ErrorContext(ctx, "Backup: failed to write backup archive", "name", backup.Name, "error", err)
+		_ = ctx.Error(err)
+	}
+}
+
+func (h *BackupHandler) AdminDeleteStoredBackup(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	if err := h.serv.DeleteStoredBackup(ctx, ctx.Param("name")); err != nil {
+		return nil, storedBackupError(err)
+	}
+	return apiwrap.SuccessResponse(), nil
+}
+
+func (h *BackupHandler) AdminRestoreStoredBackup(ctx *gin.Context, req RecoveryRequest) (*apiwrap.ResponseBody[RecoveryReportVO], error) {
+	report, err := h.serv.RestoreStoredBackup(ctx, ctx.Param("name"), req.toOptions())
+	if err != nil {
+		return nil, storedBackupError(recoveryError(err))
+	}
+	noteRestore(ctx, "stored backup "+ctx.Param("name"), report)
+	return apiwrap.SuccessResponseWithData(toRecoveryReportVO(report)), nil
+}
+
+func (h *BackupHandler) AdminGetBackupProgress(ctx *gin.Context) (*apiwrap.ResponseBody[apiwrap.ListVO[BackupProgressVO]], error) {
+	progress := h.serv.GetProgress(ctx)
+	return apiwrap.SuccessResponseWithData(apiwrap.NewListVO(slice.Map(progress, func(_ int, p domain.BackupProgress) BackupProgressVO {
+		return BackupProgressVO{
+			Operation: string(p.Operation),
+			Running:   p.Running,
+			Stage:     p.Stage,
+			Item:      p.Item,
+			Processed: p.Processed,
+			Total:     p.Total,
+			Error:     p.Error,
+			StartedAt: p.StartedAt.Unix(),
+			UpdatedAt: p.UpdatedAt.Unix(),
+		}
+	}))), nil
+}
+
+func (h *BackupHandler) toStoredBackupVO(backup domain.Backup) StoredBackupVO {
+	return StoredBackupVO{
+		Name:      backup.Name,
+		Trigger:   string(backup.Trigger),
+		Size:      backup.Size,
+		CreatedAt: backup.CreatedAt.Unix(),
+	}
+}
+
+func storedBackupError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return apiwrap.NewErrorResponseBody(http.StatusNotFound, "Backup not found.")
+	}
+	return err
+}
+
+func toRecoveryReportVO(report *domain.RecoveryReport) RecoveryReportVO {
+	return RecoveryReportVO{
+		DryRun: report.DryRun,
+		Collections: slice.Map(report.Collections, func(_ int, c domain.CollectionRecovery) CollectionRecoveryVO {
+			return CollectionRecoveryVO{Name: c.Name, Added: c.Added, Replaced: c.Replaced, Removed: c.Removed}
+		}),
+		StaticFilesAdded:       report.StaticFilesAdded,
+		StaticFilesOverwritten: report.StaticFilesOverwritten,
+		PreRestoreBackup:       report.PreRestoreBackup,
+		SchemaVersion:          report.SchemaVersion,
+		FnoteVersion:           report.FnoteVersion,
+	}
+}
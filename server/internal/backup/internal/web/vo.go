@@ -15,3 +15,39 @@
 package web
 
 type BackupVO struct{}
+
+type StoredBackupVO struct {
+	Name      string `json:"name"`
+	Trigger   string `json:"trigger"`
+	Size      int64  `json:"size"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type RecoveryReportVO struct {
+	DryRun                 bool                   `json:"dry_run"`
+	Collections            []CollectionRecoveryVO `json:"collections"`
+	StaticFilesAdded       int                    `json:"static_files_added"`
+	StaticFilesOverwritten []string               `json:"static_files_overwritten"`
+	PreRestoreBackup       string                 `json:"pre_restore_backup,omitempty"`
+	SchemaVersion          int                    `json:"schema_version"`
+	FnoteVersion           string                 `json:"fnote_version,omitempty"`
+}
+
+type CollectionRecoveryVO struct {
+	Name     string `json:"name"`
+	Added    int    `json:"added"`
+	Replaced int    `json:"replaced"`
+	Removed  int    `json:"removed"`
+}
+
+type BackupProgressVO struct {
+	Operation string `json:"operation"`
+	Running   bool   `json:"running"`
+	Stage     string `json:"stage"`
+	Item      string `json:"item"`
+	Processed int64  `json:"processed"`
+	Total     int64  `json:"total"`
+	Error     string `json:"error,omitempty"`
+	StartedAt int64  `json:"started_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+type Source string
+
+const (
+	SourceHexo      Source = "hexo"
+	SourceHugo      Source = "hugo"
+	SourceWordPress Source = "wordpress"
+)
+
+type ItemKind string
+
+const (
+	ItemKindPost       ItemKind = "post"
+	ItemKindCategory   ItemKind = "category"
+	ItemKindTag        ItemKind = "tag"
+	ItemKindComment    ItemKind = "comment"
+	ItemKindAttachment ItemKind = "attachment"
+)
+
+type ImportOptions struct {
+	// DefaultCover 文章没有可用的封面时使用的封面，必须是 /static/ 下的文件
+	DefaultCover string
+}
+
+type ImportReport struct {
+	Source  Source
+	Created []ImportItem
+	Skipped []ImportItem
+	Failed  []ImportItem
+}
+
+type ImportItem struct {
+	Kind ItemKind
+	// Name 文章为 id，分类和标签为名称，评论为 文章 id#原评论 id，附件为原路径或链接
+	Name   string
+	Reason string
+}
@@ -0,0 +1,511 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/blog_import/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/category"
+	"github.com/chenmingyong0423/fnote/server/internal/comment"
+	"github.com/chenmingyong0423/fnote/server/internal/file"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/textutil"
+	"github.com/chenmingyong0423/fnote/server/internal/post"
+	"github.com/chenmingyong0423/fnote/server/internal/tag"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	staticURLPath = "/static/"
+	// maxImportFileSize 导入时单个文章或附件的最大长度
+	maxImportFileSize = 32 << 20
+)
+
+var (
+	ErrUnsupportedSource = errors.New("unsupported import source")
+	ErrNoPostsFound      = errors.New("no posts found in the uploaded file")
+	ErrInvalidCover      = errors.New("the default cover must be a file under /static/")
+)
+
+type IBlogImportService interface {
+	// Import 导入 Hexo、Hugo 的文章 zip 或 WordPress 的 WXR 导出文件，已存在的文章会被跳过
+	Import(ctx context.Context, source domain.Source, r io.ReaderAt, size int64, opts domain.ImportOptions) (*domain.ImportReport, error)
+}
+
+var _ IBlogImportService = (*BlogImportService)(nil)
+
+func NewBlogImportService(postServ post.Service, categoryServ category.Service, tagServ tag.Service, commentServ comment.Service, fileServ file.Service) *BlogImportService {
+	return &BlogImportService{
+		postServ:     postServ,
+		categoryServ: categoryServ,
+		tagServ:      tagServ,
+		commentServ:  commentServ,
+		fileServ:     fileServ,
+	}
+}
+
+type BlogImportService struct {
+	postServ     post.Service
+	categoryServ category.Service
+	tagServ      tag.Service
+	commentServ  comment.Service
+	fileServ     file.Service
+}
+
+func (s *BlogImportService) Import(ctx context.Context, source domain.Source, r io.ReaderAt, size int64, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	if opts.DefaultCover != "" && !strings.HasPrefix(opts.DefaultCover, staticURLPath) {
+		return nil, ErrInvalidCover
+	}
+	var (
+		posts []*sourcePost
+		err   error
+	)
+	importer := &blogImporter{
+		BlogImportService: s,
+		opts:              opts,
+		report:            &domain.ImportReport{Source: source},
+		attachments:       make(map[string]string),
+	}
+	switch source {
+	case domain.SourceHexo, domain.SourceHugo:
+		posts, err = importer.readMarkdownArchive(ctx, source, r, size)
+	case domain.SourceWordPress:
+		posts, err = importer.readWordPressExport(ctx, r, size)
+	default:
+		return nil, ErrUnsupportedSource
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, ErrNoPostsFound
+	}
+	if err = importer.loadTaxonomies(ctx); err != nil {
+		return nil, err
+	}
+	for _, p := range posts {
+		importer.importPost(ctx, p)
+	}
+	return importer.report, nil
+}
+
+// sourcePost 从各个来源解析出的文章，附件已经导入并替换为 /static/ 下的链接
+type sourcePost struct {
+	Id               string
+	Title            string
+	Author           string
+	Summary          string
+	Content          string
+	Cover            string
+	Categories       []taxonomy
+	Tags             []taxonomy
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	StickyWeight     int
+	IsDisplayed      bool
+	IsCommentAllowed bool
+	MetaKeywords     string
+	MetaDescription  string
+	Comments         []sourceComment
+	// resolveCover 导入封面引用的附件并返回站内地址，没有封面或导入失败时返回空
+	resolveCover func(ctx context.Context) string
+	// resolveContent 导入正文引用的附件并替换为站内地址，返回第一个导入成功的附件地址
+	resolveContent func(ctx context.Context) string
+}
+
+type taxonomy struct {
+	Name string
+	// Route 为空时根据名称生成
+	Route string
+}
+
+// sourceComment 原博客中的评论，ParentId 为空表示直接评论文章
+type sourceComment struct {
+	Id        string
+	ParentId  string
+	Author    string
+	Email     string
+	Website   string
+	Ip        string
+	Content   string
+	Approved  bool
+	CreatedAt time.Time
+}
+
+// blogImporter 保存一次导入过程中的分类、标签和附件，避免重复创建
+type blogImporter struct {
+	*BlogImportService
+	opts        domain.ImportOptions
+	report      *domain.ImportReport
+	categories  map[string]post.Category4Post
+	tags        map[string]post.Tag4Post
+	attachments map[string]string
+}
+
+func (i *blogImporter) created(kind domain.ItemKind, name string) {
+	i.report.Created = append(i.report.Created, domain.ImportItem{Kind: kind, Name: name})
+}
+
+func (i *blogImporter) skipped(kind domain.ItemKind, name string, reason string) {
+	i.report.Skipped = append(i.report.Skipped, domain.ImportItem{Kind: kind, Name: name, Reason: reason})
+}
+
+func (i *blogImporter) failed(kind domain.ItemKind, name string, err error) {
+	i.report.Failed = append(i.report.Failed, domain.ImportItem{Kind: kind, Name: name, Reason: err.Error()})
+}
+
+// importAttachment 将附件上传到 file 模块并返回 /static/ 下的链接，同一个附件只会导入一次
+func (i *blogImporter) importAttachment(ctx context.Context, key string, load func() ([]byte, error)) (string, bool) {
+	if url, ok := i.attachments[key]; ok {
+		return url, url != ""
+	}
+	i.attachments[key] = ""
+	content, err := load()
+	if err != nil {
+		i.failed(domain.ItemKindAttachment, key, err)
+		return "", false
+	}
+	name := path.Base(key)
+	ext := strings.ToLower(path.Ext(name))
+	f, err := i.fileServ.Upload(ctx, file.FileDTO{
+		FileName: name,
+		FileSize: int64(len(content)),
+		Content:  content,
+		FileType: mime.TypeByExtension(ext),
+		FileExt:  ext,
+	})
+	if err != nil {
+		i.failed(domain.ItemKindAttachment, key, err)
+		return "", false
+	}
+	i.attachments[key] = f.Url
+	i.created(domain.ItemKindAttachment, key)
+	return f.Url, true
+}
+
+func (i *blogImporter) loadTaxonomies(ctx context.Context) error {
+	categories, err := i.categoryServ.AdminGetSelectCategories(ctx)
+	if err != nil {
+		return err
+	}
+	i.categories = make(map[string]post.Category4Post, len(categories))
+	for _, c := range categories {
+		i.categories[c.Name] = post.Category4Post{Id: c.Id, Name: c.Name}
+	}
+	tags, err := i.tagServ.GetSelectTags(ctx)
+	if err != nil {
+		return err
+	}
+	i.tags = make(map[string]post.Tag4Post, len(tags))
+	for _, t := range tags {
+		i.tags[t.Name] = post.Tag4Post{Id: t.Id, Name: t.Name}
+	}
+	return nil
+}
+
+func (i *blogImporter) importPost(ctx context.Context, p *sourcePost) {
+	if p.Id == "" {
+		i.failed(domain.ItemKindPost, p.Title, errors.New("empty post id"))
+		return
+	}
+	existing, err := i.postServ.AdminGetPostById(ctx, p.Id)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		i.failed(domain.ItemKindPost, p.Id, err)
+		return
+	}
+	if existing != nil {
+		i.skipped(domain.ItemKindPost, p.Id, "post already exists")
+		return
+	}
+	if strings.TrimSpace(p.Title) == "" {
+		i.failed(domain.ItemKindPost, p.Id, errors.New("title is required"))
+		return
+	}
+	// 附件在文章通过校验后才导入：先导入封面，没有封面时以正文中第一个附件作为封面，
+	// 仍然没有封面时使用默认封面；被拒绝的文章没有导入成功的附件，不会留下无用的文件
+	if p.resolveCover != nil {
+		p.Cover = p.resolveCover(ctx)
+	}
+	contentResolved := false
+	if p.Cover == "" && p.resolveContent != nil {
+		p.Cover = p.resolveContent(ctx)
+		contentResolved = true
+	}
+	if p.Cover == "" {
+		p.Cover = i.opts.DefaultCover
+	}
+	if !strings.HasPrefix(p.Cover, staticURLPath) {
+		i.failed(domain.ItemKindPost, p.Id, errors.New("no cover found, please specify a default cover"))
+		return
+	}
+	if !contentResolved && p.resolveContent != nil {
+		p.resolveContent(ctx)
+	}
+
+	newPost, err := i.toPost(ctx, p)
+	if err != nil {
+		i.failed(domain.ItemKindPost, p.Id, err)
+		return
+	}
+	if err = i.postServ.AddPost(ctx, newPost); err != nil {
+		i.failed(domain.ItemKindPost, p.Id, err)
+		return
+	}
+	i.created(domain.ItemKindPost, p.Id)
+	i.importComments(ctx, newPost, p.Comments)
+}
+
+func (i *blogImporter) toPost(ctx context.Context, p *sourcePost) (*post.Post, error) {
+	categories := make([]post.Category4Post, 0, len(p.Categories))
+	for _, t := range compactTaxonomies(p.Categories) {
+		c, err := i.category(ctx, t)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "category %q", t.Name)
+		}
+		categories = append(categories, c)
+	}
+	tags := make([]post.Tag4Post, 0, len(p.Tags))
+	for _, t := range compactTaxonomies(p.Tags) {
+		tg, err := i.tag(ctx, t)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "tag %q", t.Name)
+		}
+		tags = append(tags, tg)
+	}
+	newPost := &post.Post{
+		PrimaryPost: post.PrimaryPost{
+			Id:           p.Id,
+			Author:       p.Author,
+			Title:        p.Title,
+			Summary:      p.Summary,
+			CoverImg:     p.Cover,
+			Categories:   categories,
+			Tags:         tags,
+			StickyWeight: p.StickyWeight,
+		},
+		ExtraPost: post.ExtraPost{
+			Content:          p.Content,
+			MetaDescription:  p.MetaDescription,
+			MetaKeywords:     p.MetaKeywords,
+			WordCount:        textutil.WordCount(p.Content),
+			IsDisplayed:      p.IsDisplayed,
+			IsCommentAllowed: p.IsCommentAllowed,
+		},
+	}
+	if !p.CreatedAt.IsZero() {
+		newPost.CreatedAt = p.CreatedAt.Unix()
+	}
+	if !p.UpdatedAt.IsZero() {
+		newPost.UpdatedAt = p.UpdatedAt.Unix()
+	}
+	return newPost, nil
+}
+
+// category 按名称查找分类，找不到时按路由查找，仍然找不到则创建
+func (i *blogImporter) category(ctx context.Context, t taxonomy) (post.Category4Post, error) {
+	if c, ok := i.categories[t.Name]; ok {
+		return c, nil
+	}
+	route := t.Route
+	if route == "" {
+		route = textutil.Slugify(t.Name)
+	}
+	if route == "" {
+		return post.Category4Post{}, errors.New("cannot generate a route from the name")
+	}
+	c, err := i.categoryServ.GetCategoryByRoute(ctx, route)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if err = i.categoryServ.AdminCreateCategory(ctx, category.Category{Name: t.Name, Route: route, Enabled: true}); err != nil {
+			return post.Category4Post{}, err
+		}
+		i.created(domain.ItemKindCategory, t.Name)
+		c, err = i.categoryServ.GetCategoryByRoute(ctx, route)
+	}
+	if err != nil {
+		return post.Category4Post{}, err
+	}
+	i.categories[t.Name] = post.Category4Post{Id: c.Id, Name: c.Name}
+	return i.categories[t.Name], nil
+}
+
+// tag 按名称查找标签，找不到时按路由查找，仍然找不到则创建
+func (i *blogImporter) tag(ctx context.Context, t taxonomy) (post.Tag4Post, error) {
+	if tg, ok := i.tags[t.Name]; ok {
+		return tg, nil
+	}
+	route := t.Route
+	if route == "" {
+		route = textutil.Slugify(t.Name)
+	}
+	if route == "" {
+		return post.Tag4Post{}, errors.New("cannot generate a route from the name")
+	}
+	tg, err := i.tagServ.GetTagByRoute(ctx, route)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if err = i.tagServ.AdminCreateTag(ctx, tag.Tag{Name: t.Name, Route: route, Enabled: true}); err != nil {
+			return post.Tag4Post{}, err
+		}
+		i.created(domain.ItemKindTag, t.Name)
+		tg, err = i.tagServ.GetTagByRoute(ctx, route)
+	}
+	if err != nil {
+		return post.Tag4Post{}, err
+	}
+	i.tags[t.Name] = post.Tag4Post{Id: tg.Id, Name: tg.Name}
+	return i.tags[t.Name], nil
+}
+
+// importComments fnote 的评论只有两层，原评论的所有后代都作为顶层评论的回复，回复其他回复时记录 ReplyToId
+func (i *blogImporter) importComments(ctx context.Context, p *post.Post, comments []sourceComment) {
+	byId := make(map[string]*sourceComment, len(comments))
+	for idx := range comments {
+		byId[comments[idx].Id] = &comments[idx]
+	}
+	root := func(c *sourceComment) *sourceComment {
+		// 父评论不存在（例如被过滤的垃圾评论）时当作顶层评论；
+		// 祖先链出现循环引用时无法确定顶层评论，同样当作顶层评论
+		seen := map[string]bool{c.Id: true}
+		r := c
+		for r.ParentId != "" && byId[r.ParentId] != nil {
+			if seen[r.ParentId] {
+				return c
+			}
+			r = byId[r.ParentId]
+			seen[r.Id] = true
+		}
+		return r
+	}
+
+	threads := make(map[string]*comment.CommentWithReplies)
+	order := make([]string, 0)
+	replyIds := make(map[string]string)
+	postInfo := comment.PostInfo{
+		PostId:    p.Id,
+		PostTitle: p.Title,
+		PostUrl:   fmt.Sprintf("%s/posts/%s", pkg.GetOrDefault4String(os.Getenv("WEBSITE_BASE_HOST"), "http://localhost:3000"), p.Id),
+	}
+	for idx := range comments {
+		c := &comments[idx]
+		r := root(c)
+		if r == c {
+			threads[c.Id] = &comment.CommentWithReplies{Comment: comment.Comment{
+				PostInfo:       postInfo,
+				Content:        c.Content,
+				UserInfo:       comment.UserInfo{Name: c.Author, Email: c.Email, Ip: c.Ip, Website: c.Website},
+				ApprovalStatus: c.Approved,
+				CreateTime:     unixOrNow(c.CreatedAt),
+			}}
+			order = append(order, c.Id)
+			continue
+		}
+		replyIds[c.Id] = uuid.NewString()
+	}
+	for idx := range comments {
+		c := &comments[idx]
+		r := root(c)
+		if r == c {
+			continue
+		}
+		thread, parent := threads[r.Id], byId[c.ParentId]
+		if thread == nil || parent == nil {
+			continue
+		}
+		reply := comment.CommentReply{
+			ReplyId:         replyIds[c.Id],
+			Content:         c.Content,
+			UserInfo:        comment.UserInfo4Reply{Name: c.Author, Email: c.Email, Ip: c.Ip, Website: c.Website},
+			RepliedUserInfo: comment.UserInfo4Reply{Name: parent.Author, Email: parent.Email, Ip: parent.Ip, Website: parent.Website},
+			ApprovalStatus:  c.Approved,
+			CreatedAt:       unixOrNow(c.CreatedAt),
+		}
+		if parent != r {
+			reply.ReplyToId = replyIds[parent.Id]
+		}
+		thread.Replies = append(thread.Replies, reply)
+	}
+	for _, id := range order {
+		thread := threads[id]
+		name := p.Id + "#" + id
+		if _, err := i.commentServ.ImportComment(ctx, *thread); err != nil {
+			i.failed(domain.ItemKindComment, name, err)
+			continue
+		}
+		i.created(domain.ItemKindComment, name)
+	}
+}
+
+// unixOrNow 原评论没有时间时使用导入的时间
+func unixOrNow(t time.Time) int64 {
+	if t.IsZero() {
+		return time.Now().Unix()
+	}
+	return t.Unix()
+}
+
+// compactTaxonomies 去掉空白和重复的名称，保持原有顺序
+func compactTaxonomies(ts []taxonomy) []taxonomy {
+	result := make([]taxonomy, 0, len(ts))
+	seen := make(map[string]bool, len(ts))
+	for _, t := range ts {
+		t.Name = strings.TrimSpace(t.Name)
+		if t.Name != "" && !seen[t.Name] {
+			seen[t.Name] = true
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// rewriteRefs 将 re 中第一个匹配到的分组替换为 fn 的返回值
+func rewriteRefs(text string, re *regexp.Regexp, fn func(ref string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+		for g := 2; g < len(m); g += 2 {
+			if m[g] < 0 {
+				continue
+			}
+			b.WriteString(text[last:m[g]])
+			b.WriteString(fn(text[m[g]:m[g+1]]))
+			last = m[g+1]
+			break
+		}
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// readLimited 读取 r，超过 maxImportFileSize 时返回错误
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxImportFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImportFileSize {
+		return nil, fmt.Errorf("exceeds the maximum size of %d bytes", maxImportFileSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,394 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/blog_import/internal/domain"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// markdownImageRegexp 匹配 markdown 图片和 html img 标签中的链接
+	markdownImageRegexp = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^\s)>]+)|<img\s[^>]*?src=["']?([^"'\s>]+)`)
+	// hexoAssetImgRegexp 匹配 Hexo 的 {% asset_img name [title] %} 标签
+	hexoAssetImgRegexp = regexp.MustCompile(`{%\s*asset_img\s+(\S+)\s*(.*?)\s*%}`)
+	moreRegexp         = regexp.MustCompile(`<!--\s*more\s*-->`)
+
+	dateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"}
+)
+
+// markdownArchive 上传的 Hexo 或 Hugo 的 zip，附件按 zip 中的路径查找
+type markdownArchive struct {
+	source domain.Source
+	files  map[string]*zip.File
+}
+
+func (i *blogImporter) readMarkdownArchive(ctx context.Context, source domain.Source, r io.ReaderAt, size int64) ([]*sourcePost, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	archive := &markdownArchive{source: source, files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() {
+			archive.files[strings.TrimPrefix(f.Name, "/")] = f
+		}
+	}
+
+	posts := make([]*sourcePost, 0)
+	for _, f := range zr.File {
+		name := strings.TrimPrefix(f.Name, "/")
+		if !archive.isPostFile(name) {
+			continue
+		}
+		p, err := i.readMarkdownPost(archive, name)
+		if err != nil {
+			i.failed(domain.ItemKindPost, name, err)
+			continue
+		}
+		posts = append(posts, p)
+	}
+	return posts, nil
+}
+
+// isPostFile Hexo 的文章在 _posts 和 _drafts 目录下，Hugo 的文章在 content 目录下，_index.md 是列表页
+func (a *markdownArchive) isPostFile(name string) bool {
+	if !strings.EqualFold(path.Ext(name), ".md") {
+		return false
+	}
+	segments := strings.Split(path.Dir(name), "/")
+	switch a.source {
+	case domain.SourceHexo:
+		return containsSegment(segments, "_posts") || containsSegment(segments, "_drafts")
+	case domain.SourceHugo:
+		return containsSegment(segments, "content") && path.Base(name) != "_index.md"
+	}
+	return false
+}
+
+func containsSegment(segments []string, segment string) bool {
+	for _, s := range segments {
+		if s == segment {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *blogImporter) readMarkdownPost(archive *markdownArchive, name string) (*sourcePost, error) {
+	f := archive.files[name]
+	if f.UncompressedSize64 > maxImportFileSize {
+		return nil, fmt.Errorf("exceeds the maximum size of %d bytes", maxImportFileSize)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	data, err := readLimited(rc)
+	rc.Close()
+	if err != nil {
+		return nil, err
+	}
+	fm, content, err := parseFrontMatter(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hugo 的 page bundle 使用目录名作为文章名
+	slug := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	if archive.source == domain.SourceHugo && slug == "index" {
+		slug = path.Base(path.Dir(name))
+	}
+	if s := fm.string("slug"); s != "" {
+		slug = s
+	}
+	isDraft := fm.bool("draft", false) || !fm.bool("published", true) ||
+		(archive.source == domain.SourceHexo && strings.Contains("/"+path.Dir(name)+"/", "/_drafts/"))
+
+	p := &sourcePost{
+		Id:               slug,
+		Title:            fm.string("title"),
+		Author:           fm.string("author"),
+		Summary:          fm.string("description", "summary", "excerpt"),
+		CreatedAt:        fm.time("date"),
+		UpdatedAt:        fm.time("updated", "lastmod"),
+		StickyWeight:     fm.int("sticky", "top"),
+		IsDisplayed:      !isDraft,
+		IsCommentAllowed: fm.bool("comments", true),
+		MetaKeywords:     strings.Join(fm.strings("keywords"), ","),
+		MetaDescription:  fm.string("description"),
+	}
+	for _, c := range fm.strings("categories") {
+		p.Categories = append(p.Categories, taxonomy{Name: c})
+	}
+	for _, t := range fm.strings("tags") {
+		p.Tags = append(p.Tags, taxonomy{Name: t})
+	}
+
+	// 文章的资源目录：Hexo 为与文章同名的目录，Hugo 的 page bundle 为文章所在目录
+	assetDir := path.Dir(name)
+	if archive.source == domain.SourceHexo {
+		assetDir = path.Join(assetDir, slug)
+	}
+	content = hexoAssetImgRegexp.ReplaceAllString(content, "![$2]($1)")
+	if loc := moreRegexp.FindStringIndex(content); loc != nil {
+		if p.Summary == "" {
+			p.Summary = strings.TrimSpace(content[:loc[0]])
+		}
+		content = content[:loc[0]] + content[loc[1]:]
+	}
+	p.Content = content
+	p.resolveCover = func(ctx context.Context) string {
+		cover := fm.cover()
+		if cover == "" {
+			return ""
+		}
+		u, _ := i.importArchiveAsset(ctx, archive, path.Dir(name), assetDir, cover)
+		return u
+	}
+	p.resolveContent = func(ctx context.Context) string {
+		firstImage := ""
+		p.Content = rewriteRefs(content, markdownImageRegexp, func(ref string) string {
+			if u, ok := i.importArchiveAsset(ctx, archive, path.Dir(name), assetDir, ref); ok {
+				if firstImage == "" {
+					firstImage = u
+				}
+				return u
+			}
+			return ref
+		})
+		return firstImage
+	}
+	return p, nil
+}
+
+// importArchiveAsset 在 zip 中查找文章引用的本地文件并导入，外部链接和找不到的文件保持原样
+func (i *blogImporter) importArchiveAsset(ctx context.Context, archive *markdownArchive, postDir, assetDir, ref string) (string, bool) {
+	if strings.HasPrefix(ref, staticURLPath) {
+		return ref, true
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+	name := archive.find(postDir, assetDir, u.Path)
+	if name == "" {
+		return "", false
+	}
+	f := archive.files[name]
+	return i.importAttachment(ctx, name, func() ([]byte, error) {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return readLimited(rc)
+	})
+}
+
+// find 绝对路径对应站点根目录（Hexo 的 source、Hugo 的 static），相对路径依次在资源目录和文章目录中查找
+func (a *markdownArchive) find(postDir, assetDir, ref string) string {
+	if strings.HasPrefix(ref, "/") {
+		var found string
+		for name := range a.files {
+			if (name == ref[1:] || strings.HasSuffix(name, ref)) && (found == "" || len(name) < len(found)) {
+				found = name
+			}
+		}
+		return found
+	}
+	for _, dir := range []string{assetDir, postDir} {
+		if name := path.Join(dir, ref); a.files[name] != nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// frontMatter 各个博客程序的 front matter 字段不完全相同，按候选字段依次读取
+type frontMatter map[string]any
+
+// parseFrontMatter 支持 yaml（---）和 toml（+++），Hexo 允许省略开头的 ---
+func parseFrontMatter(data []byte) (frontMatter, string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	fm := frontMatter{}
+	for _, delimiter := range []string{"---", "+++"} {
+		if !bytes.HasPrefix(data, []byte(delimiter+"\n")) {
+			continue
+		}
+		rest := data[len(delimiter)+1:]
+		header, body, ok := cutFrontMatter(rest, delimiter)
+		if !ok {
+			return nil, "", errors.New("unterminated front matter")
+		}
+		var err error
+		if delimiter == "+++" {
+			err = toml.Unmarshal(header, &fm)
+		} else {
+			err = yaml.Unmarshal(header, &fm)
+		}
+		return fm, body, err
+	}
+	if header, body, ok := cutFrontMatter(data, "---"); ok {
+		if err := yaml.Unmarshal(header, &fm); err == nil {
+			return fm, body, nil
+		}
+	}
+	return nil, "", errors.New("missing front matter")
+}
+
+func cutFrontMatter(data []byte, delimiter string) ([]byte, string, bool) {
+	if bytes.HasPrefix(data, []byte(delimiter+"\n")) {
+		return nil, strings.TrimLeft(string(data[len(delimiter)+1:]), "\n"), true
+	}
+	header, body, ok := bytes.Cut(data, []byte("\n"+delimiter+"\n"))
+	if !ok {
+		if !bytes.HasSuffix(data, []byte("\n"+delimiter)) {
+			return nil, "", false
+		}
+		header, body = data[:len(data)-len(delimiter)-1], nil
+	}
+	return header, strings.TrimLeft(string(body), "\n"), true
+}
+
+func (fm frontMatter) string(keys ...string) string {
+	for _, key := range keys {
+		switch v := fm[key].(type) {
+		case string:
+			if v = strings.TrimSpace(v); v != "" {
+				return v
+			}
+		case nil:
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// strings 读取列表字段，Hexo 的多级分类（[[a, b]]）会被展开
+func (fm frontMatter) strings(keys ...string) []string {
+	var flatten func(v any) []string
+	flatten = func(v any) []string {
+		switch v := v.(type) {
+		case nil:
+			return nil
+		case string:
+			return []string{v}
+		case []any:
+			result := make([]string, 0, len(v))
+			for _, item := range v {
+				result = append(result, flatten(item)...)
+			}
+			return result
+		default:
+			return []string{fmt.Sprint(v)}
+		}
+	}
+	for _, key := range keys {
+		if values := flatten(fm[key]); len(values) > 0 {
+			return values
+		}
+	}
+	return nil
+}
+
+func (fm frontMatter) bool(key string, defaultValue bool) bool {
+	switch v := fm[key].(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+func (fm frontMatter) int(keys ...string) int {
+	for _, key := range keys {
+		switch v := fm[key].(type) {
+		case int:
+			return v
+		case int64:
+			return int(v)
+		case float64:
+			return int(v)
+		case bool:
+			if v {
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+// time 没有时区的时间按服务器所在时区处理，与 Hexo 和 Hugo 的默认行为一致
+func (fm frontMatter) time(keys ...string) time.Time {
+	for _, key := range keys {
+		switch v := fm[key].(type) {
+		case time.Time:
+			// yaml 会将没有时区的时间解析为 UTC
+			if v.Location() == time.UTC {
+				v = time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), v.Minute(), v.Second(), 0, time.Local)
+			}
+			return v
+		case nil:
+		default:
+			if t := parseTime(fmt.Sprint(v)); !t.IsZero() {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func parseTime(value string) time.Time {
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(value), time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// cover 主题常用的封面字段，Hugo 的 cover 可能是包含 image 的对象
+func (fm frontMatter) cover() string {
+	if cover, ok := fm["cover"].(map[string]any); ok {
+		return frontMatter(cover).string("image")
+	}
+	if cover := fm.string("cover", "thumbnail", "banner", "index_img", "image", "featured_image", "featuredImage"); cover != "" {
+		return cover
+	}
+	if images := fm.strings("images"); len(images) > 0 {
+		return images[0]
+	}
+	return ""
+}
@@ -0,0 +1,309 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/blog_import/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/netutil"
+)
+
+const wordPressTimeLayout = "2006-01-02 15:04:05"
+
+var (
+	// uploadURLRegexp 匹配正文中 WordPress 媒体库的链接
+	uploadURLRegexp = regexp.MustCompile(`(https?://[^\s"'<>()]+/wp-content/uploads/[^\s"'<>()]+)`)
+
+	// attachmentClient 下载原站点的附件，导出文件中的地址不可信，只允许连接公网地址
+	attachmentClient = netutil.NewPublicHttpClient(30 * time.Second)
+)
+
+// wxr WordPress 导出的 WXR 文件，只解析导入需要的字段
+type wxr struct {
+	Channel struct {
+		Items []wxrItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrItem struct {
+	Title   string `xml:"title"`
+	Creator string `xml:"creator"`
+	// content:encoded 和 excerpt:encoded 的本地名称相同，通过命名空间区分
+	Encoded       []wxrEncoded  `xml:"encoded"`
+	PostId        string        `xml:"post_id"`
+	PostDate      string        `xml:"post_date"`
+	PostDateGmt   string        `xml:"post_date_gmt"`
+	ModifiedGmt   string        `xml:"post_modified_gmt"`
+	CommentStatus string        `xml:"comment_status"`
+	PostName      string        `xml:"post_name"`
+	Status        string        `xml:"status"`
+	PostType      string        `xml:"post_type"`
+	IsSticky      string        `xml:"is_sticky"`
+	AttachmentURL string        `xml:"attachment_url"`
+	Categories    []wxrCategory `xml:"category"`
+	PostMeta      []wxrPostMeta `xml:"postmeta"`
+	Comments      []wxrComment  `xml:"comment"`
+}
+
+type wxrEncoded struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type wxrCategory struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type wxrPostMeta struct {
+	Key   string `xml:"meta_key"`
+	Value string `xml:"meta_value"`
+}
+
+type wxrComment struct {
+	Id          string `xml:"comment_id"`
+	Author      string `xml:"comment_author"`
+	AuthorEmail string `xml:"comment_author_email"`
+	AuthorURL   string `xml:"comment_author_url"`
+	AuthorIP    string `xml:"comment_author_IP"`
+	DateGmt     string `xml:"comment_date_gmt"`
+	Date        string `xml:"comment_date"`
+	Content     string `xml:"comment_content"`
+	Approved    string `xml:"comment_approved"`
+	Type        string `xml:"comment_type"`
+	Parent      string `xml:"comment_parent"`
+}
+
+func (item *wxrItem) encoded(namespace string) string {
+	for _, e := range item.Encoded {
+		if strings.Contains(e.XMLName.Space, namespace) {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+func (item *wxrItem) meta(key string) string {
+	for _, m := range item.PostMeta {
+		if m.Key == key {
+			return m.Value
+		}
+	}
+	return ""
+}
+
+// wordPressUploads 与 WXR 一起打包上传的 wp-content/uploads 目录，附件优先从这里读取，找不到时再下载
+type wordPressUploads map[string]*zip.File
+
+// readWordPressExport 上传的文件可以是 WXR 本身，也可以是包含 WXR 和 uploads 目录的 zip
+func (i *blogImporter) readWordPressExport(ctx context.Context, r io.ReaderAt, size int64) ([]*sourcePost, error) {
+	var (
+		export  io.Reader = io.NewSectionReader(r, 0, size)
+		uploads           = wordPressUploads{}
+	)
+	magic := make([]byte, 4)
+	if _, err := r.ReadAt(magic, 0); err == nil && bytes.Equal(magic, []byte("PK\x03\x04")) {
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return nil, err
+		}
+		var wxrFile *zip.File
+		for _, f := range zr.File {
+			name := strings.TrimPrefix(f.Name, "/")
+			if _, rest, ok := strings.Cut(name, "uploads/"); ok && !f.FileInfo().IsDir() {
+				uploads[rest] = f
+			} else if strings.EqualFold(path.Ext(name), ".xml") && wxrFile == nil {
+				wxrFile = f
+			}
+		}
+		if wxrFile == nil {
+			return nil, ErrNoPostsFound
+		}
+		rc, err := wxrFile.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		export = rc
+	}
+
+	var doc wxr
+	if err := xml.NewDecoder(export).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid WordPress export file: %w", err)
+	}
+	attachments := make(map[string]string)
+	for _, item := range doc.Channel.Items {
+		if item.PostType == "attachment" && item.AttachmentURL != "" {
+			attachments[item.PostId] = item.AttachmentURL
+		}
+	}
+
+	posts := make([]*sourcePost, 0)
+	for idx := range doc.Channel.Items {
+		item := &doc.Channel.Items[idx]
+		if item.PostType != "post" {
+			continue
+		}
+		if item.Status == "trash" || item.Status == "auto-draft" {
+			i.skipped(domain.ItemKindPost, item.PostName, "post is in the trash")
+			continue
+		}
+		posts = append(posts, i.toWordPressPost(item, attachments, uploads))
+	}
+	return posts, nil
+}
+
+func (i *blogImporter) toWordPressPost(item *wxrItem, attachments map[string]string, uploads wordPressUploads) *sourcePost {
+	slug, err := url.PathUnescape(item.PostName)
+	if err != nil || slug == "" {
+		slug = item.PostId
+	}
+	p := &sourcePost{
+		Id:               slug,
+		Title:            item.Title,
+		Author:           item.Creator,
+		Summary:          strings.TrimSpace(item.encoded("excerpt")),
+		CreatedAt:        parseWordPressTime(item.PostDateGmt, item.PostDate),
+		UpdatedAt:        parseWordPressTime(item.ModifiedGmt, ""),
+		IsDisplayed:      item.Status == "publish",
+		IsCommentAllowed: item.CommentStatus == "open",
+	}
+	if item.IsSticky == "1" {
+		p.StickyWeight = 1
+	}
+	for _, c := range item.Categories {
+		route, _ := url.PathUnescape(c.Nicename)
+		switch c.Domain {
+		case "category":
+			p.Categories = append(p.Categories, taxonomy{Name: c.Name, Route: route})
+		case "post_tag":
+			p.Tags = append(p.Tags, taxonomy{Name: c.Name, Route: route})
+		}
+	}
+
+	content := item.encoded("content")
+	thumbnail := attachments[item.meta("_thumbnail_id")]
+	p.Content = content
+	p.resolveCover = func(ctx context.Context) string {
+		if thumbnail == "" {
+			return ""
+		}
+		u, _ := i.importWordPressAttachment(ctx, thumbnail, uploads)
+		return u
+	}
+	p.resolveContent = func(ctx context.Context) string {
+		firstImage := ""
+		p.Content = rewriteRefs(content, uploadURLRegexp, func(ref string) string {
+			if u, ok := i.importWordPressAttachment(ctx, ref, uploads); ok {
+				if firstImage == "" {
+					firstImage = u
+				}
+				return u
+			}
+			return ref
+		})
+		return firstImage
+	}
+
+	for _, c := range item.Comments {
+		name := slug + "#" + c.Id
+		switch {
+		case c.Type == "pingback" || c.Type == "trackback":
+			i.skipped(domain.ItemKindComment, name, c.Type)
+			continue
+		case c.Approved != "1" && c.Approved != "0":
+			// spam、trash 等
+			i.skipped(domain.ItemKindComment, name, "comment is "+c.Approved)
+			continue
+		}
+		parent := c.Parent
+		if parent == "0" {
+			parent = ""
+		}
+		p.Comments = append(p.Comments, sourceComment{
+			Id:        c.Id,
+			ParentId:  parent,
+			Author:    c.Author,
+			Email:     c.AuthorEmail,
+			Website:   c.AuthorURL,
+			Ip:        c.AuthorIP,
+			Content:   c.Content,
+			Approved:  c.Approved == "1",
+			CreatedAt: parseWordPressTime(c.DateGmt, c.Date),
+		})
+	}
+	return p
+}
+
+// importWordPressAttachment 优先使用 zip 中的 uploads 目录，找不到时从原站点下载
+func (i *blogImporter) importWordPressAttachment(ctx context.Context, rawURL string, uploads wordPressUploads) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+	return i.importAttachment(ctx, rawURL, func() ([]byte, error) {
+		if _, rest, ok := strings.Cut(u.Path, "/wp-content/uploads/"); ok {
+			if f := uploads[rest]; f != nil {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return readLimited(rc)
+			}
+		}
+		return downloadAttachment(ctx, rawURL)
+	})
+}
+
+func downloadAttachment(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := attachmentClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return readLimited(resp.Body)
+}
+
+// parseWordPressTime 优先使用 GMT 时间，草稿的 GMT 时间为 0000-00-00 00:00:00，此时使用站点时间
+func parseWordPressTime(gmt, local string) time.Time {
+	if t, err := time.ParseInLocation(wordPressTimeLayout, gmt, time.UTC); err == nil && t.Year() > 1 {
+		return t.Local()
+	}
+	if t, err := time.ParseInLocation(wordPressTimeLayout, local, time.Local); err == nil && t.Year() > 1 {
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+
+	"github.com/chenmingyong0423/fnote/server/internal/blog_import/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/blog_import/internal/service"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func NewBlogImportHandler(serv service.IBlogImportService) *BlogImportHandler {
+	return &BlogImportHandler{
+		serv: serv,
+	}
+}
+
+type BlogImportHandler struct {
+	serv service.IBlogImportService
+}
+
+func (h *BlogImportHandler) RegisterGinRoutes(engine *gin.Engine) {
+	adminGroup := engine.Group("/admin-api/import")
+
+	// source: hexo、hugo、wordpress
+	adminGroup.POST("/:source", apiwrap.Wrap(h.AdminImport))
+}
+
+func (h *BlogImportHandler) AdminImport(ctx *gin.Context) (*apiwrap.ResponseBody[ImportReportVO], error) {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, "file is required")
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	report, err := h.serv.Import(ctx, domain.Source(ctx.Param("source")), f, fileHeader.Size, domain.ImportOptions{
+		DefaultCover: ctx.PostForm("default_cover"),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedSource) || errors.Is(err, service.ErrNoPostsFound) || errors.Is(err, service.ErrInvalidCover) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, err.Error())
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(toImportReportVO(report)), nil
+}
+
+func toImportReportVO(report *domain.ImportReport) ImportReportVO {
+	toItemVOs := func(items []domain.ImportItem) []ImportItemVO {
+		return slice.Map(items, func(_ int, item domain.ImportItem) ImportItemVO {
+			return ImportItemVO{Kind: string(item.Kind), Name: item.Name, Reason: item.Reason}
+		})
+	}
+	return ImportReportVO{
+		Source:  string(report.Source),
+		Created: toItemVOs(report.Created),
+		Skipped: toItemVOs(report.Skipped),
+		Failed:  toItemVOs(report.Failed),
+	}
+}
@@ -56,6 +56,8 @@ type AdminComment struct {
 	// 该评论下的所有回复的内容
 	Replies        []AdminReply
 	ApprovalStatus bool
+	// 审核流水线的结果
+	Moderation Moderation
 	// 评论时间
 	CreatedAt int64
 	// 修改时间
@@ -76,10 +78,18 @@ type UserInfo4Reply UserInfo
 type UserInfo4Comment UserInfo
 
 type UserInfo struct {
-	Name    string
-	Email   string
-	Ip      string
-	Website string
+	Name      string
+	Email     string
+	Ip        string
+	Website   string
+	UserAgent string
+	// 是否接收回复通知邮件
+	NotifyReplies bool
+}
+
+// ShouldNotifyRepliedUser 被回复的用户订阅了回复通知且不是回复者本人时，才发送回复通知邮件
+func ShouldNotifyRepliedUser(userInfo, repliedUserInfo UserInfo4Reply) bool {
+	return repliedUserInfo.NotifyReplies && repliedUserInfo.Email != "" && !strings.EqualFold(repliedUserInfo.Email, userInfo.Email)
 }
 
 type AdminReply struct {
@@ -93,6 +103,8 @@ type AdminReply struct {
 	// 被回复用户的信息
 	RepliedUserInfo UserInfo4Reply
 	ApprovalStatus  bool
+	// 审核流水线的结果
+	Moderation Moderation
 	// 回复时间
 	CreatedAt int64
 	// 修改时间
@@ -122,6 +134,8 @@ type Comment struct {
 	UserInfo       UserInfo
 	ApprovalStatus bool
 	CreateTime     int64
+	// 审核流水线的结果，仅在新增评论时使用
+	Moderation Moderation
 }
 
 type CommentReply struct {
@@ -136,6 +150,8 @@ type CommentReply struct {
 	RepliedUserInfo UserInfo4Reply
 	ApprovalStatus  bool
 	CreatedAt       int64
+	// 审核流水线的结果，仅在新增回复时使用
+	Moderation Moderation
 }
 
 type CommentReplyWithPostInfo struct {
@@ -144,8 +160,15 @@ type CommentReplyWithPostInfo struct {
 }
 
 type EmailInfo struct {
-	Email   string
-	PostUrl string
+	Email string
+	// 收件人的昵称
+	Name      string
+	PostId    string
+	PostTitle string
+	PostUrl   string
+	// 评论或回复的作者，通知被回复用户时为回复者
+	CommenterName string
+	Content       string
 }
 
 type ReplyWithCId struct {
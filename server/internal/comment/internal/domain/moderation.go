@@ -0,0 +1,66 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+type ModerationVerdict string
+
+const (
+	// ModerationAccept 通过
+	ModerationAccept ModerationVerdict = "accept"
+	// ModerationHold 需要人工审核
+	ModerationHold ModerationVerdict = "hold"
+	// ModerationReject 拒绝
+	ModerationReject ModerationVerdict = "reject"
+)
+
+const (
+	CandidateTypeComment = "comment"
+	CandidateTypeReply   = "reply"
+)
+
+// CommentCandidate 待审核的评论或回复
+type CommentCandidate struct {
+	// comment 或 reply
+	Type     string
+	PostId   string
+	PostUrl  string
+	Content  string
+	UserInfo UserInfo
+	Referrer string
+	// 蜜罐字段的值，正常用户不会填写
+	Honeypot string
+	// 创建时间，新提交的评论为 0
+	CreatedAt int64
+}
+
+// ModerationResult 单个过滤器的审核结果
+type ModerationResult struct {
+	Verdict ModerationVerdict
+	Reason  string
+}
+
+type ModerationReason struct {
+	// 过滤器名称
+	Filter string
+	Reason string
+}
+
+// Moderation 审核流水线的最终结果，Reasons 为未通过的过滤器给出的原因
+type Moderation struct {
+	Verdict ModerationVerdict
+	Reasons []ModerationReason
+	// 是否直接审核通过，仅在全部过滤器通过且开启了自动审核时为 true
+	Approved bool
+}
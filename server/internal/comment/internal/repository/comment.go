@@ -19,6 +19,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/chenmingyong0423/go-mongox/v2"
 	"github.com/chenmingyong0423/go-mongox/v2/builder/aggregation"
 	"go.mongodb.org/mongo-driver/v2/bson"
 
@@ -34,6 +35,7 @@ import (
 
 type ICommentRepository interface {
 	AddComment(ctx context.Context, comment domain.Comment) (string, error)
+	ImportComment(ctx context.Context, comment domain.CommentWithReplies) (string, error)
 	FindApprovedCommentById(ctx context.Context, cmtId string) (*domain.CommentWithReplies, error)
 	AddReply(ctx context.Context, cmtId string, commentReply domain.CommentReply) (string, error)
 	FineLatestCommentAndReply(ctx context.Context, cnt int) ([]domain.LatestComment, error)
@@ -56,6 +58,8 @@ type ICommentRepository interface {
 	PullReplyByCIdAndRIds(ctx context.Context, commentId string, replyIds []string) error
 	DeleteManyByPostId(ctx context.Context, postId string) error
 	FindCommentsByPostId(ctx context.Context, postId string) ([]domain.AdminComment, error)
+	CountCommentsAndRepliesSince(ctx context.Context, field string, value string, since time.Time) (int64, error)
+	CountDuplicateContentSince(ctx context.Context, content string, ip string, email string, since time.Time) (int64, error)
 }
 
 func NewCommentRepository(dao dao.ICommentDao) *CommentRepository {
@@ -70,6 +74,14 @@ type CommentRepository struct {
 	dao dao.ICommentDao
 }
 
+func (r *CommentRepository) CountCommentsAndRepliesSince(ctx context.Context, field string, value string, since time.Time) (int64, error) {
+	return r.dao.CountCommentsAndRepliesSince(ctx, field, value, since)
+}
+
+func (r *CommentRepository) CountDuplicateContentSince(ctx context.Context, content string, ip string, email string, since time.Time) (int64, error) {
+	return r.dao.CountDuplicateContentSince(ctx, content, ip, email, since)
+}
+
 func (r *CommentRepository) FindCommentsByPostId(ctx context.Context, postId string) ([]domain.AdminComment, error) {
 	comments, err := r.dao.FindCommentsByPostId(ctx, postId)
 	if err != nil {
@@ -160,6 +172,7 @@ func (r *CommentRepository) AdminFindCommentsWithPagination(ctx context.Context,
 						KeyValue("post_info", 1).
 						KeyValue("user_info", 1).
 						KeyValue("approval_status", 1).
+						KeyValue("moderation", 1).
 						KeyValue("created_at", 1).
 						Filter("replies", "$replies", aggregation.EqWithoutKey("$$reply.approval_status", true), &aggregation.FilterOptions{As: "reply"}).Build(),
 				)
@@ -177,6 +190,7 @@ func (r *CommentRepository) AdminFindCommentsWithPagination(ctx context.Context,
 					KeyValue("post_info", 1).
 					KeyValue("user_info", 1).
 					KeyValue("approval_status", 1).
+					KeyValue("moderation", 1).
 					KeyValue("created_at", 1).
 					Cond("replies",
 						aggregation.EqWithoutKey("$approval_status", true),
@@ -342,7 +356,8 @@ func (r *CommentRepository) AddReply(ctx context.Context, cmtId string, commentR
 		ReplyToId:       commentReply.ReplyToId,
 		UserInfo:        dao.UserInfo4Reply(commentReply.UserInfo),
 		RepliedUserInfo: dao.UserInfo4Reply(commentReply.RepliedUserInfo),
-		ApprovalStatus:  false,
+		ApprovalStatus:  commentReply.ApprovalStatus,
+		Moderation:      toDaoModeration(commentReply.Moderation),
 		CreatedAt:       now,
 		UpdatedAt:       now,
 	})
@@ -376,10 +391,11 @@ func (r *CommentRepository) FindApprovedCommentById(ctx context.Context, cmtId s
 			},
 			Content: comment.Content,
 			UserInfo: domain.UserInfo{
-				Name:    comment.UserInfo.Name,
-				Email:   comment.UserInfo.Email,
-				Ip:      comment.UserInfo.Ip,
-				Website: comment.UserInfo.Website,
+				Name:          comment.UserInfo.Name,
+				Email:         comment.UserInfo.Email,
+				Ip:            comment.UserInfo.Ip,
+				Website:       comment.UserInfo.Website,
+				NotifyReplies: comment.UserInfo.NotifyReplies,
 			},
 		},
 		Replies: commentReplies,
@@ -392,7 +408,34 @@ func (r *CommentRepository) AddComment(ctx context.Context, comment domain.Comme
 		Content:        comment.Content,
 		UserInfo:       dao.UserInfo4Comment(comment.UserInfo),
 		Replies:        make([]dao.Reply, 0),
-		ApprovalStatus: false,
+		ApprovalStatus: comment.ApprovalStatus,
+		Moderation:     toDaoModeration(comment.Moderation),
+	})
+}
+
+// ImportComment 保存导入的评论和回复，保留原有的审核状态和时间
+func (r *CommentRepository) ImportComment(ctx context.Context, comment domain.CommentWithReplies) (string, error) {
+	replies := make([]dao.Reply, 0, len(comment.Replies))
+	for _, reply := range comment.Replies {
+		createdAt := time.Unix(reply.CreatedAt, 0).Local()
+		replies = append(replies, dao.Reply{
+			ReplyId:         reply.ReplyId,
+			Content:         reply.Content,
+			ReplyToId:       reply.ReplyToId,
+			UserInfo:        dao.UserInfo4Reply(reply.UserInfo),
+			RepliedUserInfo: dao.UserInfo4Reply(reply.RepliedUserInfo),
+			ApprovalStatus:  reply.ApprovalStatus,
+			CreatedAt:       createdAt,
+			UpdatedAt:       createdAt,
+		})
+	}
+	return r.dao.AddComment(ctx, &dao.Comment{
+		Model:          mongox.Model{CreatedAt: time.Unix(comment.CreateTime, 0).Local()},
+		PostInfo:       dao.PostInfo(comment.PostInfo),
+		Content:        comment.Content,
+		UserInfo:       dao.UserInfo4Comment(comment.UserInfo),
+		Replies:        replies,
+		ApprovalStatus: comment.ApprovalStatus,
 	})
 }
 
@@ -435,6 +478,7 @@ func (r *CommentRepository) toDomainAdminComment(comment *dao.Comment) domain.Ad
 			UserInfo:        domain.UserInfo4Reply(commentReply.UserInfo),
 			RepliedUserInfo: domain.UserInfo4Reply(commentReply.RepliedUserInfo),
 			ApprovalStatus:  commentReply.ApprovalStatus,
+			Moderation:      toDomainModeration(commentReply.Moderation),
 			CreatedAt:       commentReply.CreatedAt.Unix(),
 			UpdatedAt:       commentReply.UpdatedAt.Unix(),
 		})
@@ -446,6 +490,7 @@ func (r *CommentRepository) toDomainAdminComment(comment *dao.Comment) domain.Ad
 		UserInfo:       domain.UserInfo4Comment(comment.UserInfo),
 		Replies:        replies,
 		ApprovalStatus: comment.ApprovalStatus,
+		Moderation:     toDomainModeration(comment.Moderation),
 		CreatedAt:      comment.CreatedAt.Unix(),
 		UpdatedAt:      comment.UpdatedAt.Unix(),
 	}
@@ -495,3 +540,25 @@ func (r *CommentRepository) toDomainCommentWithReplies(comment *dao.Comment) *do
 		Replies: replies,
 	}
 }
+
+func toDaoModeration(moderation domain.Moderation) *dao.Moderation {
+	if moderation.Verdict == "" {
+		return nil
+	}
+	reasons := make([]dao.ModerationReason, 0, len(moderation.Reasons))
+	for _, reason := range moderation.Reasons {
+		reasons = append(reasons, dao.ModerationReason(reason))
+	}
+	return &dao.Moderation{Verdict: string(moderation.Verdict), Reasons: reasons}
+}
+
+func toDomainModeration(moderation *dao.Moderation) domain.Moderation {
+	if moderation == nil {
+		return domain.Moderation{}
+	}
+	reasons := make([]domain.ModerationReason, 0, len(moderation.Reasons))
+	for _, reason := range moderation.Reasons {
+		reasons = append(reasons, domain.ModerationReason(reason))
+	}
+	return domain.Moderation{Verdict: domain.ModerationVerdict(moderation.Verdict), Reasons: reasons}
+}
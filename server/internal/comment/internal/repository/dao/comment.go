@@ -44,6 +44,8 @@ type Comment struct {
 	// 该评论下的所有回复的内容
 	Replies        []Reply `bson:"replies"`
 	ApprovalStatus bool    `bson:"approval_status"`
+	// 审核流水线的结果
+	Moderation *Moderation `bson:"moderation,omitempty"`
 }
 
 type Reply struct {
@@ -57,12 +59,24 @@ type Reply struct {
 	// 被回复用户的信息
 	RepliedUserInfo UserInfo4Reply `bson:"replied_user_info"`
 	ApprovalStatus  bool           `bson:"approval_status"`
+	// 审核流水线的结果
+	Moderation *Moderation `bson:"moderation,omitempty"`
 	// 回复时间
 	CreatedAt time.Time `bson:"created_at"`
 	// 修改时间
 	UpdatedAt time.Time `bson:"updated_at"`
 }
 
+type Moderation struct {
+	Verdict string             `bson:"verdict"`
+	Reasons []ModerationReason `bson:"reasons"`
+}
+
+type ModerationReason struct {
+	Filter string `bson:"filter"`
+	Reason string `bson:"reason"`
+}
+
 type UserInfo4Reply UserInfo
 
 type UserInfo4Comment UserInfo
@@ -72,6 +86,10 @@ type UserInfo struct {
 	Email   string `bson:"email"`
 	Ip      string `bson:"ip"`
 	Website string `bson:"website"`
+	// 提交评论时的 User-Agent，用于反馈给反垃圾服务
+	UserAgent string `bson:"user_agent,omitempty"`
+	// 是否接收回复通知邮件
+	NotifyReplies bool `bson:"notify_replies,omitempty"`
 }
 
 type PostInfo struct {
@@ -134,6 +152,10 @@ type ICommentDao interface {
 	PullReplyByCIdAndRIds(ctx context.Context, commentId bson.ObjectID, replyIds []string) error
 	DeleteManyByPostId(ctx context.Context, postId string) error
 	FindCommentsByPostId(ctx context.Context, postId string) ([]*Comment, error)
+	// CountCommentsAndRepliesSince 统计 since 之后创建的、field 等于 value 的评论和回复总数，field 为相对于评论或回复的字段，例如 user_info.ip
+	CountCommentsAndRepliesSince(ctx context.Context, field string, value string, since time.Time) (int64, error)
+	// CountDuplicateContentSince 统计 since 之后创建的、内容等于 content 且 IP 或邮箱相同的评论和回复总数，ip 和 email 都为空时返回 0
+	CountDuplicateContentSince(ctx context.Context, content string, ip string, email string, since time.Time) (int64, error)
 }
 
 func NewCommentDao(db *mongox.Database) *CommentDao {
@@ -148,6 +170,56 @@ type CommentDao struct {
 	coll *mongox.Collection[Comment]
 }
 
+func (d *CommentDao) CountCommentsAndRepliesSince(ctx context.Context, field string, value string, since time.Time) (int64, error) {
+	count, err := d.countCommentsAndReplies(ctx, query.NewBuilder().Eq(field, value).Gte("created_at", since).Build())
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to count comments and replies, %s=%s, since=%v", field, value, since)
+	}
+	return count, nil
+}
+
+func (d *CommentDao) CountDuplicateContentSince(ctx context.Context, content string, ip string, email string, since time.Time) (int64, error) {
+	var senders []any
+	if ip != "" {
+		senders = append(senders, query.Eq("user_info.ip", ip))
+	}
+	if email != "" {
+		senders = append(senders, query.Eq("user_info.email", email))
+	}
+	if len(senders) == 0 {
+		return 0, nil
+	}
+	cond := query.NewBuilder().Eq("content", content).Gte("created_at", since).Or(senders...).Build()
+	count, err := d.countCommentsAndReplies(ctx, cond)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to count duplicate comments and replies, ip=%s, email=%s, since=%v", ip, email, since)
+	}
+	return count, nil
+}
+
+// countCommentsAndReplies 将评论和回复展开后统计满足 cond 的数量，cond 中的字段相对于评论或回复
+func (d *CommentDao) countCommentsAndReplies(ctx context.Context, cond bson.D) (int64, error) {
+	pipeline := aggregation.NewStageBuilder().
+		Match(query.Or(cond, query.ElemMatch("replies", cond))).
+		Project(aggregation.ConcatArrays("combined", bsonx.A("$$ROOT"), "$replies")).
+		Unwind("$combined", nil).
+		ReplaceWith("$combined").
+		Match(cond).
+		Count("count").Build()
+
+	var results []struct {
+		Count int64 `bson:"count"`
+	}
+	err := d.coll.Aggregator().Pipeline(pipeline).AggregateWithParse(ctx, &results)
+	if err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Count, nil
+}
+
 func (d *CommentDao) FindCommentsByPostId(ctx context.Context, postId string) ([]*Comment, error) {
 	result, err := d.coll.Finder().Filter(query.Eq("post_info.post_id", postId)).Find(ctx)
 	if err != nil {
@@ -41,6 +41,8 @@ import (
 
 type ICommentService interface {
 	AddComment(ctx context.Context, comment domain.Comment) (string, error)
+	// ImportComment 导入评论及其回复，保留原有的审核状态和时间，评论和每条回复各发布一个 comment 事件
+	ImportComment(ctx context.Context, comment domain.CommentWithReplies) (string, error)
 	AddReply(ctx context.Context, cmtId string, postId string, commentReply domain.CommentReply) (string, error)
 	FineLatestCommentAndReply(ctx context.Context) ([]domain.LatestComment, error)
 	FindCommentsByPostId(ctx context.Context, postId string) ([]domain.CommentWithReplies, error)
@@ -211,8 +213,13 @@ func (s *CommentService) BatchApproveComments(ctx context.Context, commentIds []
 
 			for _, comment := range comments {
 				approvalEmails = append(approvalEmails, domain.EmailInfo{
-					Email:   comment.UserInfo.Email,
-					PostUrl: comment.PostInfo.PostUrl,
+					Email:         comment.UserInfo.Email,
+					Name:          comment.UserInfo.Name,
+					PostId:        comment.PostInfo.PostId,
+					PostTitle:     comment.PostInfo.PostTitle,
+					PostUrl:       comment.PostInfo.PostUrl,
+					CommenterName: comment.UserInfo.Name,
+					Content:       comment.Content,
 				})
 			}
 			return nil
@@ -237,18 +244,28 @@ func (s *CommentService) BatchApproveComments(ctx context.Context, commentIds []
 
 			for _, ar := range comment.Replies {
 				approvalEmails = append(approvalEmails, domain.EmailInfo{
-					Email:   ar.UserInfo.Email,
-					PostUrl: comment.PostInfo.PostUrl,
+					Email:         ar.UserInfo.Email,
+					Name:          ar.UserInfo.Name,
+					PostId:        comment.PostInfo.PostId,
+					PostTitle:     comment.PostInfo.PostTitle,
+					PostUrl:       comment.PostInfo.PostUrl,
+					CommenterName: ar.UserInfo.Name,
+					Content:       ar.Content,
 				})
-				if ar.ReplyToId != "" && ar.RepliedUserInfo.Email != "" {
-					repliedEmails = append(repliedEmails, domain.EmailInfo{
-						Email:   ar.RepliedUserInfo.Email,
-						PostUrl: comment.PostInfo.PostUrl,
-					})
-				} else {
+				repliedUserInfo := ar.RepliedUserInfo
+				if repliedUserInfo.Email == "" {
+					repliedUserInfo = domain.UserInfo4Reply(comment.UserInfo)
+				}
+				// 只通知订阅了回复通知的用户
+				if domain.ShouldNotifyRepliedUser(ar.UserInfo, repliedUserInfo) {
 					repliedEmails = append(repliedEmails, domain.EmailInfo{
-						Email:   comment.UserInfo.Email,
-						PostUrl: comment.PostInfo.PostUrl,
+						Email:         repliedUserInfo.Email,
+						Name:          repliedUserInfo.Name,
+						PostId:        comment.PostInfo.PostId,
+						PostTitle:     comment.PostInfo.PostTitle,
+						PostUrl:       comment.PostInfo.PostUrl,
+						CommenterName: ar.UserInfo.Name,
+						Content:       ar.Content,
 					})
 				}
 			}
@@ -364,15 +381,17 @@ func (s *CommentService) AddReply(ctx context.Context, cmtId string, postId stri
 		return "", errors.New("PostId is invalid.")
 	}
 	commentReply.RepliedUserInfo = domain.UserInfo4Reply{
-		Name:  commentWithReplies.UserInfo.Name,
-		Email: commentWithReplies.UserInfo.Email,
-		Ip:    commentWithReplies.UserInfo.Ip,
+		Name:          commentWithReplies.UserInfo.Name,
+		Email:         commentWithReplies.UserInfo.Email,
+		Ip:            commentWithReplies.UserInfo.Ip,
+		NotifyReplies: commentWithReplies.UserInfo.NotifyReplies,
 	}
 	if commentReply.ReplyToId != "" {
 		isExist := false
 		for _, reply := range commentWithReplies.Replies {
 			if reply.ReplyId == commentReply.ReplyToId && reply.ApprovalStatus {
 				commentReply.RepliedUserInfo.Name, commentReply.RepliedUserInfo.Email, commentReply.RepliedUserInfo.Website, commentReply.RepliedUserInfo.Ip = reply.UserInfo.Name, reply.UserInfo.Email, reply.UserInfo.Website, reply.UserInfo.Ip
+				commentReply.RepliedUserInfo.NotifyReplies = reply.UserInfo.NotifyReplies
 				isExist = true
 				break
 			}
@@ -424,6 +443,32 @@ func (s *CommentService) AddComment(ctx context.Context, comment domain.Comment)
 	return commentId, nil
 }
 
+func (s *CommentService) ImportComment(ctx context.Context, comment domain.CommentWithReplies) (string, error) {
+	for i := range comment.Replies {
+		if comment.Replies[i].ReplyId == "" {
+			comment.Replies[i].ReplyId = uuid.NewString()
+		}
+	}
+	commentId, err := s.repo.ImportComment(ctx, comment)
+	if err != nil {
+		return "", err
+	}
+	events := make([]domain.CommentEvent, 0, len(comment.Replies)+1)
+	events = append(events, domain.CommentEvent{PostId: comment.PostInfo.PostId, CommentId: commentId, Count: 1, Type: "create"})
+	for _, reply := range comment.Replies {
+		events = append(events, domain.CommentEvent{PostId: comment.PostInfo.PostId, CommentId: commentId, RepliesId: []string{reply.ReplyId}, Count: 1, Type: "create"})
+	}
+	for _, commentEvent := range events {
+		marshal, err := jsoniter.Marshal(&commentEvent)
+		if err != nil {
+			slog.ErrorContext(ctx, "ImportComment: comment event: failed to marshal comment event", "error", err)
+			continue
+		}
+		s.eventBus.Publish("comment", eventbus.Event{Payload: marshal})
+	}
+	return commentId, nil
+}
+
 func (s *CommentService) subscribePostEvent() {
 	eventChan := s.eventBus.Subscribe("post")
 	type contextKey string
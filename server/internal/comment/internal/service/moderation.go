@@ -0,0 +1,330 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/chenmingyong0423/fnote/server/internal/akismet"
+	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/repository"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config"
+)
+
+// CommentFilter 评论审核流水线中的过滤器，新增过滤器只需实现该接口并注册到 CommentModerator 中
+type CommentFilter interface {
+	Name() string
+	Check(ctx context.Context, candidate domain.CommentCandidate, cfg website_config.CommentModerationConfig) (domain.ModerationResult, error)
+}
+
+// FeedbackFilter 需要接收管理员审核结果的过滤器
+type FeedbackFilter interface {
+	CommentFilter
+	Feedback(ctx context.Context, candidate domain.CommentCandidate, spam bool) error
+}
+
+type ICommentModerator interface {
+	// Moderate 依次执行所有过滤器，任一过滤器拒绝时立即返回，否则汇总需要人工审核的原因
+	Moderate(ctx context.Context, candidate domain.CommentCandidate) (domain.Moderation, error)
+	// Feedback 将管理员的审核结果反馈给实现了 FeedbackFilter 的过滤器，失败时只记录日志
+	Feedback(ctx context.Context, candidates []domain.CommentCandidate, spam bool)
+}
+
+func NewCommentModerator(repo repository.ICommentRepository, cfgService website_config.Service, akismetServ akismet.Service) *CommentModerator {
+	return &CommentModerator{
+		cfgService: cfgService,
+		filters: []CommentFilter{
+			honeypotFilter{},
+			&blocklistFilter{},
+			ipRateLimitFilter{repo: repo},
+			emailRateLimitFilter{repo: repo},
+			duplicateContentFilter{repo: repo},
+			linkCountFilter{},
+			// 外部服务放在最后，被本地规则拒绝的评论不再调用
+			akismetFilter{akismetServ: akismetServ},
+		},
+	}
+}
+
+var _ ICommentModerator = (*CommentModerator)(nil)
+
+type CommentModerator struct {
+	cfgService website_config.Service
+	filters    []CommentFilter
+}
+
+func (m *CommentModerator) Moderate(ctx context.Context, candidate domain.CommentCandidate) (domain.Moderation, error) {
+	cfg, err := m.cfgService.GetCommentModerationConfig(ctx)
+	if err != nil {
+		return domain.Moderation{}, err
+	}
+	moderation := domain.Moderation{Verdict: domain.ModerationAccept}
+	for _, filter := range m.filters {
+		result, err := filter.Check(ctx, candidate, cfg)
+		if err != nil {
+			return domain.Moderation{}, err
+		}
+		switch result.Verdict {
+		case domain.ModerationReject:
+			return domain.Moderation{
+				Verdict: domain.ModerationReject,
+				Reasons: []domain.ModerationReason{{Filter: filter.Name(), Reason: result.Reason}},
+			}, nil
+		case domain.ModerationHold:
+			moderation.Verdict = domain.ModerationHold
+			moderation.Reasons = append(moderation.Reasons, domain.ModerationReason{Filter: filter.Name(), Reason: result.Reason})
+		}
+	}
+	moderation.Approved = moderation.Verdict == domain.ModerationAccept && cfg.AutoApprove
+	return moderation, nil
+}
+
+func (m *CommentModerator) Feedback(ctx context.Context, candidates []domain.CommentCandidate, spam bool) {
+	for _, filter := range m.filters {
+		feedbackFilter, ok := filter.(FeedbackFilter)
+		if !ok {
+			continue
+		}
+		for _, candidate := range candidates {
+			if err := feedbackFilter.Feedback(ctx, candidate, spam); err != nil {
+				slog.WarnContext(ctx, "Moderation: failed to send feedback", "filter", filter.Name(), "spam", spam, "error", err)
+			}
+		}
+	}
+}
+
+var accept = domain.ModerationResult{Verdict: domain.ModerationAccept}
+
+// honeypotFilter 蜜罐字段被填写时说明是机器人提交
+type honeypotFilter struct{}
+
+func (honeypotFilter) Name() string {
+	return "honeypot"
+}
+
+func (honeypotFilter) Check(_ context.Context, candidate domain.CommentCandidate, _ website_config.CommentModerationConfig) (domain.ModerationResult, error) {
+	if candidate.Honeypot != "" {
+		return domain.ModerationResult{Verdict: domain.ModerationReject, Reason: "honeypot field is filled"}, nil
+	}
+	return accept, nil
+}
+
+// blocklistFilter 检查评论内容、昵称、邮箱和网址是否包含屏蔽关键词或匹配屏蔽的正则表达式，
+// 正则表达式编译后缓存，配置变化时才重新编译
+type blocklistFilter struct {
+	mu       sync.Mutex
+	patterns []string
+	compiled []blockedPattern
+}
+
+type blockedPattern struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func (*blocklistFilter) Name() string {
+	return "blocklist"
+}
+
+func (f *blocklistFilter) Check(ctx context.Context, candidate domain.CommentCandidate, cfg website_config.CommentModerationConfig) (domain.ModerationResult, error) {
+	fields := []string{candidate.Content, candidate.UserInfo.Name, candidate.UserInfo.Email, candidate.UserInfo.Website}
+	for _, keyword := range cfg.BlockedKeywords {
+		lower := strings.ToLower(keyword)
+		for _, field := range fields {
+			if lower != "" && strings.Contains(strings.ToLower(field), lower) {
+				return domain.ModerationResult{Verdict: domain.ModerationReject, Reason: fmt.Sprintf("contains blocked keyword %q", keyword)}, nil
+			}
+		}
+	}
+	for _, p := range f.compile(ctx, cfg.BlockedPatterns) {
+		for _, field := range fields {
+			if field != "" && p.re.MatchString(field) {
+				return domain.ModerationResult{Verdict: domain.ModerationReject, Reason: fmt.Sprintf("matches blocked pattern %q", p.pattern)}, nil
+			}
+		}
+	}
+	return accept, nil
+}
+
+// compile 返回编译后的正则表达式，patterns 与上次相同时直接使用缓存
+func (f *blocklistFilter) compile(ctx context.Context, patterns []string) []blockedPattern {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.compiled != nil && slices.Equal(f.patterns, patterns) {
+		return f.compiled
+	}
+	compiled := make([]blockedPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			// 保存配置时已校验，这里只记录日志
+			slog.WarnContext(ctx, "Moderation: invalid blocked pattern", "pattern", pattern, "error", err)
+			continue
+		}
+		compiled = append(compiled, blockedPattern{pattern: pattern, re: re})
+	}
+	f.patterns, f.compiled = slices.Clone(patterns), compiled
+	return compiled
+}
+
+var linkRegexp = regexp.MustCompile(`(?i)(?:https?://|www\.)\S+`)
+
+// linkCountFilter 链接数量超过限制时需要人工审核
+type linkCountFilter struct{}
+
+func (linkCountFilter) Name() string {
+	return "link_count"
+}
+
+func (linkCountFilter) Check(_ context.Context, candidate domain.CommentCandidate, cfg website_config.CommentModerationConfig) (domain.ModerationResult, error) {
+	if cfg.MaxLinks <= 0 {
+		return accept, nil
+	}
+	if count := len(linkRegexp.FindAllStringIndex(candidate.Content, -1)); count > cfg.MaxLinks {
+		return domain.ModerationResult{Verdict: domain.ModerationHold, Reason: fmt.Sprintf("contains %d links, more than %d", count, cfg.MaxLinks)}, nil
+	}
+	return accept, nil
+}
+
+// duplicateContentFilter 拒绝同一 IP 或邮箱在时间窗口内提交的内容完全相同的评论，
+// 不同访客的评论内容相同（例如“谢谢分享”）时不受影响
+type duplicateContentFilter struct {
+	repo repository.ICommentRepository
+}
+
+func (duplicateContentFilter) Name() string {
+	return "duplicate_content"
+}
+
+func (f duplicateContentFilter) Check(ctx context.Context, candidate domain.CommentCandidate, cfg website_config.CommentModerationConfig) (domain.ModerationResult, error) {
+	if cfg.DuplicateWindow <= 0 {
+		return accept, nil
+	}
+	count, err := f.repo.CountDuplicateContentSince(ctx, candidate.Content, candidate.UserInfo.Ip, candidate.UserInfo.Email, windowStart(cfg.DuplicateWindow))
+	if err != nil {
+		return domain.ModerationResult{}, err
+	}
+	if count > 0 {
+		return domain.ModerationResult{Verdict: domain.ModerationReject, Reason: "duplicate content"}, nil
+	}
+	return accept, nil
+}
+
+// ipRateLimitFilter 限制同一 IP 在时间窗口内的评论数量
+type ipRateLimitFilter struct {
+	repo repository.ICommentRepository
+}
+
+func (ipRateLimitFilter) Name() string {
+	return "ip_rate_limit"
+}
+
+func (f ipRateLimitFilter) Check(ctx context.Context, candidate domain.CommentCandidate, cfg website_config.CommentModerationConfig) (domain.ModerationResult, error) {
+	return checkRateLimit(ctx, f.repo, "user_info.ip", candidate.UserInfo.Ip, cfg.IpRateLimit, cfg.RateLimitWindow)
+}
+
+// emailRateLimitFilter 限制同一邮箱在时间窗口内的评论数量
+type emailRateLimitFilter struct {
+	repo repository.ICommentRepository
+}
+
+func (emailRateLimitFilter) Name() string {
+	return "email_rate_limit"
+}
+
+func (f emailRateLimitFilter) Check(ctx context.Context, candidate domain.CommentCandidate, cfg website_config.CommentModerationConfig) (domain.ModerationResult, error) {
+	return checkRateLimit(ctx, f.repo, "user_info.email", candidate.UserInfo.Email, cfg.EmailRateLimit, cfg.RateLimitWindow)
+}
+
+func checkRateLimit(ctx context.Context, repo repository.ICommentRepository, field, value string, limit, window int) (domain.ModerationResult, error) {
+	if limit <= 0 || window <= 0 || value == "" {
+		return accept, nil
+	}
+	count, err := repo.CountCommentsAndRepliesSince(ctx, field, value, windowStart(window))
+	if err != nil {
+		return domain.ModerationResult{}, err
+	}
+	if count >= int64(limit) {
+		return domain.ModerationResult{Verdict: domain.ModerationReject, Reason: fmt.Sprintf("at most %d comments are allowed within %d minutes", limit, window)}, nil
+	}
+	return accept, nil
+}
+
+// windowStart 返回 minutes 分钟前的时间
+func windowStart(minutes int) time.Time {
+	return time.Now().Local().Add(-time.Duration(minutes) * time.Minute)
+}
+
+// akismetFilter 调用 Akismet 兼容的反垃圾服务，服务不可用时按通过处理，由其他过滤器兜底
+type akismetFilter struct {
+	akismetServ akismet.Service
+}
+
+func (akismetFilter) Name() string {
+	return "akismet"
+}
+
+func (f akismetFilter) Check(ctx context.Context, candidate domain.CommentCandidate, _ website_config.CommentModerationConfig) (domain.ModerationResult, error) {
+	result, err := f.akismetServ.CheckSpam(ctx, toSubmission(candidate))
+	if err != nil {
+		if errors.Is(err, akismet.ErrAkismetUnavailable) {
+			slog.WarnContext(ctx, "Moderation: spam check service is unavailable", "error", err)
+		} else {
+			slog.ErrorContext(ctx, "Moderation: failed to check spam", "error", err)
+		}
+		return accept, nil
+	}
+	if result.Discard {
+		return domain.ModerationResult{Verdict: domain.ModerationReject, Reason: "identified as blatant spam by the spam check service"}, nil
+	}
+	if result.Spam {
+		return domain.ModerationResult{Verdict: domain.ModerationHold, Reason: "identified as spam by the spam check service"}, nil
+	}
+	return accept, nil
+}
+
+func (f akismetFilter) Feedback(ctx context.Context, candidate domain.CommentCandidate, spam bool) error {
+	if spam {
+		return f.akismetServ.SubmitSpam(ctx, toSubmission(candidate))
+	}
+	return f.akismetServ.SubmitHam(ctx, toSubmission(candidate))
+}
+
+func toSubmission(candidate domain.CommentCandidate) akismet.Submission {
+	submission := akismet.Submission{
+		Type:        candidate.Type,
+		UserIp:      candidate.UserInfo.Ip,
+		UserAgent:   candidate.UserInfo.UserAgent,
+		Referrer:    candidate.Referrer,
+		Permalink:   candidate.PostUrl,
+		Author:      candidate.UserInfo.Name,
+		AuthorEmail: candidate.UserInfo.Email,
+		AuthorUrl:   candidate.UserInfo.Website,
+		Content:     candidate.Content,
+	}
+	if candidate.CreatedAt > 0 {
+		submission.CreatedAt = time.Unix(candidate.CreatedAt, 0)
+	}
+	return submission
+}
@@ -0,0 +1,289 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/akismet"
+	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/repository"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config"
+)
+
+// storedComment 已保存的评论或回复，只包含审核用到的字段
+type storedComment struct {
+	content   string
+	ip        string
+	email     string
+	createdAt time.Time
+}
+
+// fakeCommentRepository 只实现审核用到的统计方法
+type fakeCommentRepository struct {
+	repository.ICommentRepository
+	comments []storedComment
+}
+
+func (f *fakeCommentRepository) CountCommentsAndRepliesSince(_ context.Context, field string, value string, since time.Time) (int64, error) {
+	var count int64
+	for _, c := range f.comments {
+		actual := map[string]string{"content": c.content, "user_info.ip": c.ip, "user_info.email": c.email}[field]
+		if actual == value && !c.createdAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeCommentRepository) CountDuplicateContentSince(_ context.Context, content string, ip string, email string, since time.Time) (int64, error) {
+	var count int64
+	for _, c := range f.comments {
+		sameSender := (ip != "" && c.ip == ip) || (email != "" && c.email == email)
+		if sameSender && c.content == content && !c.createdAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type fakeModerationConfigService struct {
+	website_config.Service
+	cfg website_config.CommentModerationConfig
+}
+
+func (f *fakeModerationConfigService) GetCommentModerationConfig(context.Context) (website_config.CommentModerationConfig, error) {
+	return f.cfg, nil
+}
+
+// fakeAkismetService 返回固定的检查结果，并记录调用次数
+type fakeAkismetService struct {
+	akismet.Service
+	result akismet.CheckResult
+	err    error
+	calls  int
+}
+
+func (f *fakeAkismetService) CheckSpam(context.Context, akismet.Submission) (akismet.CheckResult, error) {
+	f.calls++
+	return f.result, f.err
+}
+
+var moderationConfig = website_config.CommentModerationConfig{
+	AutoApprove:     true,
+	BlockedKeywords: []string{"Casino"},
+	BlockedPatterns: []string{`^https?://spam\.example`, `(`},
+	MaxLinks:        2,
+	DuplicateWindow: 10,
+	RateLimitWindow: 10,
+	IpRateLimit:     3,
+	EmailRateLimit:  2,
+}
+
+func newCandidate(content string) domain.CommentCandidate {
+	return domain.CommentCandidate{
+		Type:    domain.CandidateTypeComment,
+		Content: content,
+		UserInfo: domain.UserInfo{
+			Name:  "fnote",
+			Email: "fnote@example.com",
+			Ip:    "203.0.113.1",
+		},
+	}
+}
+
+func TestCommentModerator_Moderate(t *testing.T) {
+	recent := time.Now().Add(-time.Minute)
+	testCases := []struct {
+		name      string
+		cfg       func(cfg *website_config.CommentModerationConfig)
+		comments  []storedComment
+		candidate func(c *domain.CommentCandidate)
+		akismet   fakeAkismetService
+
+		wantVerdict     domain.ModerationVerdict
+		wantFilters     []string
+		wantApproved    bool
+		wantAkismetCall bool
+	}{
+		{
+			name:            "accepted and approved",
+			wantVerdict:     domain.ModerationAccept,
+			wantApproved:    true,
+			wantAkismetCall: true,
+		},
+		{
+			name:            "accepted without auto approve",
+			cfg:             func(cfg *website_config.CommentModerationConfig) { cfg.AutoApprove = false },
+			wantVerdict:     domain.ModerationAccept,
+			wantAkismetCall: true,
+		},
+		{
+			name:        "honeypot filled",
+			candidate:   func(c *domain.CommentCandidate) { c.Honeypot = "bot" },
+			wantVerdict: domain.ModerationReject,
+			wantFilters: []string{"honeypot"},
+		},
+		{
+			name:        "blocked keyword ignores case",
+			candidate:   func(c *domain.CommentCandidate) { c.UserInfo.Name = "best CASINO" },
+			wantVerdict: domain.ModerationReject,
+			wantFilters: []string{"blocklist"},
+		},
+		{
+			name:        "blocked pattern",
+			candidate:   func(c *domain.CommentCandidate) { c.UserInfo.Website = "https://spam.example.com" },
+			wantVerdict: domain.ModerationReject,
+			wantFilters: []string{"blocklist"},
+		},
+		{
+			name: "ip rate limit reached",
+			comments: []storedComment{
+				{content: "a", ip: "203.0.113.1", createdAt: recent},
+				{content: "b", ip: "203.0.113.1", createdAt: recent},
+				{content: "c", ip: "203.0.113.1", createdAt: recent},
+			},
+			wantVerdict: domain.ModerationReject,
+			wantFilters: []string{"ip_rate_limit"},
+		},
+		{
+			name: "comments outside the rate limit window",
+			comments: []storedComment{
+				{content: "a", ip: "203.0.113.1", createdAt: time.Now().Add(-time.Hour)},
+				{content: "b", ip: "203.0.113.1", createdAt: time.Now().Add(-time.Hour)},
+				{content: "c", ip: "203.0.113.1", createdAt: time.Now().Add(-time.Hour)},
+			},
+			wantVerdict:     domain.ModerationAccept,
+			wantApproved:    true,
+			wantAkismetCall: true,
+		},
+		{
+			name: "email rate limit reached",
+			comments: []storedComment{
+				{content: "a", email: "fnote@example.com", createdAt: recent},
+				{content: "b", email: "fnote@example.com", createdAt: recent},
+			},
+			wantVerdict: domain.ModerationReject,
+			wantFilters: []string{"email_rate_limit"},
+		},
+		{
+			name:        "duplicate content from the same ip",
+			comments:    []storedComment{{content: "hello", ip: "203.0.113.1", createdAt: recent}},
+			wantVerdict: domain.ModerationReject,
+			wantFilters: []string{"duplicate_content"},
+		},
+		{
+			name:        "duplicate content from the same email",
+			comments:    []storedComment{{content: "hello", ip: "198.51.100.1", email: "fnote@example.com", createdAt: recent}},
+			wantVerdict: domain.ModerationReject,
+			wantFilters: []string{"duplicate_content"},
+		},
+		{
+			name:            "same content from another visitor",
+			comments:        []storedComment{{content: "hello", ip: "198.51.100.1", email: "other@example.com", createdAt: recent}},
+			wantVerdict:     domain.ModerationAccept,
+			wantApproved:    true,
+			wantAkismetCall: true,
+		},
+		{
+			name:            "too many links",
+			candidate:       func(c *domain.CommentCandidate) { c.Content = "https://a.example www.b.example https://c.example" },
+			wantVerdict:     domain.ModerationHold,
+			wantFilters:     []string{"link_count"},
+			wantAkismetCall: true,
+		},
+		{
+			name:            "identified as spam",
+			akismet:         fakeAkismetService{result: akismet.CheckResult{Spam: true}},
+			wantVerdict:     domain.ModerationHold,
+			wantFilters:     []string{"akismet"},
+			wantAkismetCall: true,
+		},
+		{
+			name:            "identified as blatant spam",
+			akismet:         fakeAkismetService{result: akismet.CheckResult{Spam: true, Discard: true}},
+			wantVerdict:     domain.ModerationReject,
+			wantFilters:     []string{"akismet"},
+			wantAkismetCall: true,
+		},
+		{
+			name:            "spam check service unavailable",
+			akismet:         fakeAkismetService{err: akismet.ErrAkismetUnavailable},
+			wantVerdict:     domain.ModerationAccept,
+			wantApproved:    true,
+			wantAkismetCall: true,
+		},
+		{
+			name:            "reasons of all held filters",
+			candidate:       func(c *domain.CommentCandidate) { c.Content = "https://a.example www.b.example https://c.example" },
+			akismet:         fakeAkismetService{result: akismet.CheckResult{Spam: true}},
+			wantVerdict:     domain.ModerationHold,
+			wantFilters:     []string{"link_count", "akismet"},
+			wantAkismetCall: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := moderationConfig
+			if tc.cfg != nil {
+				tc.cfg(&cfg)
+			}
+			candidate := newCandidate("hello")
+			if tc.candidate != nil {
+				tc.candidate(&candidate)
+			}
+			akismetServ := tc.akismet
+			moderator := NewCommentModerator(&fakeCommentRepository{comments: tc.comments}, &fakeModerationConfigService{cfg: cfg}, &akismetServ)
+
+			moderation, err := moderator.Moderate(context.Background(), candidate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if moderation.Verdict != tc.wantVerdict || moderation.Approved != tc.wantApproved {
+				t.Errorf("verdict = %s, approved = %v, want %s, %v", moderation.Verdict, moderation.Approved, tc.wantVerdict, tc.wantApproved)
+			}
+			var filters []string
+			for _, reason := range moderation.Reasons {
+				filters = append(filters, reason.Filter)
+			}
+			if !slices.Equal(filters, tc.wantFilters) {
+				t.Errorf("filters = %v, want %v", filters, tc.wantFilters)
+			}
+			if called := akismetServ.calls > 0; called != tc.wantAkismetCall {
+				t.Errorf("spam check service called = %v, want %v", called, tc.wantAkismetCall)
+			}
+		})
+	}
+}
+
+func TestBlocklistFilter_CompilesPatternsOnce(t *testing.T) {
+	f := &blocklistFilter{}
+	patterns := []string{`spam\d+`, `(`}
+	first := f.compile(context.Background(), patterns)
+	if len(first) != 1 {
+		t.Fatalf("compiled %d patterns, want 1, invalid patterns should be skipped", len(first))
+	}
+	if again := f.compile(context.Background(), []string{`spam\d+`, `(`}); again[0].re != first[0].re {
+		t.Error("unchanged patterns should not be compiled again")
+	}
+
+	changed := f.compile(context.Background(), []string{`ham\d+`})
+	if len(changed) != 1 || changed[0].pattern != `ham\d+` {
+		t.Errorf("changed patterns = %v, want ham\\d+", changed)
+	}
+}
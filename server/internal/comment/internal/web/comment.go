@@ -20,12 +20,10 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/domain"
 	"github.com/chenmingyong0423/fnote/server/internal/message"
-	"github.com/chenmingyong0423/fnote/server/internal/pkg"
 
 	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/service"
 
@@ -42,9 +40,10 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
-func NewCommentHandler(serv service.ICommentService, cfgService website_config.Service, postServ post.Service, msgServ message.Service) *CommentHandler {
+func NewCommentHandler(serv service.ICommentService, moderator service.ICommentModerator, cfgService website_config.Service, postServ post.Service, msgServ message.Service) *CommentHandler {
 	return &CommentHandler{
 		serv:       serv,
+		moderator:  moderator,
 		cfgService: cfgService,
 		postServ:   postServ,
 		msgServ:    msgServ,
@@ -53,6 +52,7 @@ func NewCommentHandler(serv service.ICommentService, cfgService website_config.S
 
 type CommentHandler struct {
 	serv       service.ICommentService
+	moderator  service.ICommentModerator
 	cfgService website_config.Service
 	postServ   post.Service
 	msgServ    message.Service
@@ -103,19 +103,37 @@ func (h *CommentHandler) AddComment(ctx *gin.Context, req CommentRequest) (*apiw
 	if !p.IsCommentAllowed {
 		return nil, apiwrap.NewErrorResponseBody(http.StatusForbidden, "Comments are disabled for this post.")
 	}
+	userInfo := domain.UserInfo{
+		Name:          req.UserName,
+		Email:         req.Email,
+		Ip:            ip,
+		Website:       req.Website,
+		UserAgent:     ctx.Request.UserAgent(),
+		NotifyReplies: req.NotifyReplies,
+	}
+	postUrl := postUrlOf(req.PostId)
+	moderation, err := h.moderate(ctx, domain.CommentCandidate{
+		Type:     domain.CandidateTypeComment,
+		PostId:   req.PostId,
+		PostUrl:  postUrl,
+		Content:  req.Content,
+		UserInfo: userInfo,
+		Referrer: ctx.Request.Referer(),
+		Honeypot: req.Homepage,
+	})
+	if err != nil {
+		return nil, err
+	}
 	id, err := h.serv.AddComment(ctx, domain.Comment{
 		PostInfo: domain.PostInfo{
 			PostId:    req.PostId,
 			PostTitle: p.Title,
-			PostUrl:   fmt.Sprintf("%s/posts/%s", pkg.GetOrDefault4String(os.Getenv("WEBSITE_BASE_HOST"), "http://localhost:3000"), req.PostId),
-		},
-		Content: req.Content,
-		UserInfo: domain.UserInfo{
-			Name:    req.UserName,
-			Email:   req.Email,
-			Ip:      ip,
-			Website: req.Website,
+			PostUrl:   postUrl,
 		},
+		Content:        req.Content,
+		UserInfo:       userInfo,
+		ApprovalStatus: moderation.Approved,
+		Moderation:     moderation,
 	})
 	if err != nil {
 		return nil, err
@@ -123,7 +141,12 @@ func (h *CommentHandler) AddComment(ctx *gin.Context, req CommentRequest) (*apiw
 	go func() {
 		// todo 考虑邮件服务订阅事件发送邮件
 		l := slog.Default().With("X-Request-ID", ctx.GetString("X-Request-ID"))
-		gErr := h.msgServ.SendEmailToWebmaster(ctx, "comment", "text/plain")
+		gErr := h.msgServ.NotifyWebmaster(ctx, message.NotificationEventComment, message.TemplateData{
+			PostTitle:      p.Title,
+			PostUrl:        postUrl,
+			CommenterName:  req.UserName,
+			CommentContent: req.Content,
+		})
 		if gErr != nil {
 			l.WarnContext(ctx, fmt.Sprintf("%+v", gErr))
 		}
@@ -140,6 +163,10 @@ type ReplyRequest struct {
 	Email     string `json:"email" binding:"required,validateEmailFormat"`
 	Website   string `json:"website"`
 	Content   string `json:"content" binding:"required,max=200"`
+	// 蜜罐字段，前端隐藏该输入框，正常用户不会填写
+	Homepage string `json:"homepage"`
+	// 是否接收回复通知邮件
+	NotifyReplies bool `json:"notify_replies"`
 }
 
 func (h *CommentHandler) AddCommentReply(ctx *gin.Context, req ReplyRequest) (*apiwrap.ResponseBody[IdVO], error) {
@@ -169,15 +196,32 @@ func (h *CommentHandler) AddCommentReply(ctx *gin.Context, req ReplyRequest) (*a
 	if !p.IsCommentAllowed {
 		return nil, apiwrap.NewErrorResponseBody(http.StatusForbidden, "Comment are disabled for this post.")
 	}
+	userInfo := domain.UserInfo{
+		Name:          req.UserName,
+		Email:         req.Email,
+		Website:       req.Website,
+		Ip:            ip,
+		UserAgent:     ctx.Request.UserAgent(),
+		NotifyReplies: req.NotifyReplies,
+	}
+	moderation, err := h.moderate(ctx, domain.CommentCandidate{
+		Type:     domain.CandidateTypeReply,
+		PostId:   req.PostId,
+		PostUrl:  postUrlOf(req.PostId),
+		Content:  req.Content,
+		UserInfo: userInfo,
+		Referrer: ctx.Request.Referer(),
+		Honeypot: req.Homepage,
+	})
+	if err != nil {
+		return nil, err
+	}
 	id, err := h.serv.AddReply(ctx, commentId, req.PostId, domain.CommentReply{
-		Content:   req.Content,
-		ReplyToId: req.ReplyToId,
-		UserInfo: domain.UserInfo4Reply{
-			Name:    req.UserName,
-			Email:   req.Email,
-			Website: req.Website,
-			Ip:      ip,
-		},
+		Content:        req.Content,
+		ReplyToId:      req.ReplyToId,
+		UserInfo:       domain.UserInfo4Reply(userInfo),
+		ApprovalStatus: moderation.Approved,
+		Moderation:     moderation,
 	})
 	if err != nil {
 		return nil, err
@@ -185,10 +229,24 @@ func (h *CommentHandler) AddCommentReply(ctx *gin.Context, req ReplyRequest) (*a
 	go func() {
 		// todo 考虑邮件服务订阅事件发送邮件
 		l := slog.Default().With("X-Request-ID", ctx.GetString("X-Request-ID"))
-		gErr := h.msgServ.SendEmailToWebmaster(ctx, "comment", "text/plain")
+		gErr := h.msgServ.NotifyWebmaster(ctx, message.NotificationEventComment, message.TemplateData{
+			PostTitle:      p.Title,
+			PostUrl:        postUrlOf(req.PostId),
+			CommenterName:  req.UserName,
+			CommentContent: req.Content,
+		})
 		if gErr != nil {
 			l.WarnContext(ctx, fmt.Sprintf("%+v", gErr))
 		}
+		// 自动通过审核的回复直接通知被回复的用户
+		if moderation.Approved {
+			reply, gErr := h.serv.FindReplyByCIdAndRId(ctx, commentId, id)
+			if gErr != nil {
+				l.WarnContext(ctx, fmt.Sprintf("%+v", gErr))
+				return
+			}
+			h.notifyRepliedUser(ctx, *reply)
+		}
 	}()
 	return apiwrap.SuccessResponseWithData(IdVO{Id: id}), nil
 }
@@ -300,6 +358,7 @@ func (h *CommentHandler) toAdminCommentVO(comments []domain.AdminComment) []Admi
 					Picture: picture,
 				},
 				ApprovalStatus: reply.ApprovalStatus,
+				Moderation:     toModerationVO(reply.Moderation),
 				Type:           "reply",
 				CreatedAt:      reply.CreatedAt,
 				UpdatedAt:      reply.UpdatedAt,
@@ -324,6 +383,7 @@ func (h *CommentHandler) toAdminCommentVO(comments []domain.AdminComment) []Admi
 			ReplyCount:     len(replies),
 			Replies:        replies,
 			ApprovalStatus: comment.ApprovalStatus,
+			Moderation:     toModerationVO(comment.Moderation),
 			Type:           "comment",
 			CreatedAt:      comment.CreatedAt,
 			UpdatedAt:      comment.UpdatedAt,
@@ -332,6 +392,17 @@ func (h *CommentHandler) toAdminCommentVO(comments []domain.AdminComment) []Admi
 	return result
 }
 
+func toModerationVO(moderation domain.Moderation) *ModerationVO {
+	if moderation.Verdict == "" {
+		return nil
+	}
+	reasons := make([]ModerationReasonVO, 0, len(moderation.Reasons))
+	for _, reason := range moderation.Reasons {
+		reasons = append(reasons, ModerationReasonVO(reason))
+	}
+	return &ModerationVO{Verdict: string(moderation.Verdict), Reasons: reasons}
+}
+
 func (h *CommentHandler) AdminApproveComment(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
 	commentId := ctx.Param("id")
 	comment, err := h.serv.FindCommentById(ctx, commentId)
@@ -348,10 +419,17 @@ func (h *CommentHandler) AdminApproveComment(ctx *gin.Context) (*apiwrap.Respons
 	if err != nil {
 		return nil, err
 	}
+	go h.moderator.Feedback(ctx.Copy(), []domain.CommentCandidate{commentToCandidate(*comment)}, false)
 	go func() {
 		// 通知用户评论已通过
 		l := slog.Default().With("X-Request-ID", ctx.GetString("X-Request-ID"))
-		gErr := h.msgServ.SendEmailWithEmail(ctx, "user-comment-approval", []string{comment.UserInfo.Email}, "text/plain", comment.PostInfo.PostUrl)
+		gErr := h.msgServ.SendEmailWithEmail(ctx, "user-comment-approval", []string{comment.UserInfo.Email}, message.TemplateData{
+			RecipientName:  comment.UserInfo.Name,
+			PostTitle:      comment.PostInfo.PostTitle,
+			PostUrl:        comment.PostInfo.PostUrl,
+			CommenterName:  comment.UserInfo.Name,
+			CommentContent: comment.Content,
+		})
 		if gErr != nil {
 			l.WarnContext(ctx, fmt.Sprintf("%+v", gErr))
 		}
@@ -376,28 +454,42 @@ func (h *CommentHandler) AdminApproveCommentReply(ctx *gin.Context) (*apiwrap.Re
 	if err != nil {
 		return nil, err
 	}
+	go h.moderator.Feedback(ctx.Copy(), []domain.CommentCandidate{replyToCandidate(commentReplyWithPostInfo.CommentReply, commentReplyWithPostInfo.PostInfo)}, false)
 	go func() {
 		// 通知用户评论已通过
 		l := slog.Default().With("X-Request-ID", ctx.GetString("X-Request-ID"))
-		gErr := h.msgServ.SendEmailWithEmail(ctx, "user-comment-approval", []string{commentReplyWithPostInfo.UserInfo.Email}, "text/plain", commentReplyWithPostInfo.PostInfo.PostUrl)
+		gErr := h.msgServ.SendEmailWithEmail(ctx, "user-comment-approval", []string{commentReplyWithPostInfo.UserInfo.Email}, message.TemplateData{
+			RecipientName:  commentReplyWithPostInfo.UserInfo.Name,
+			PostTitle:      commentReplyWithPostInfo.PostInfo.PostTitle,
+			PostUrl:        commentReplyWithPostInfo.PostInfo.PostUrl,
+			CommenterName:  commentReplyWithPostInfo.UserInfo.Name,
+			CommentContent: commentReplyWithPostInfo.Content,
+		})
 		if gErr != nil {
 			l.WarnContext(ctx, fmt.Sprintf("%+v", gErr))
 		}
 		// 通知被回复的用户接收到了回复
-		gErr = h.msgServ.SendEmailWithEmail(ctx, "user-comment-reply", []string{commentReplyWithPostInfo.RepliedUserInfo.Email}, "text/plain", commentReplyWithPostInfo.PostInfo.PostUrl)
-		if gErr != nil {
-			l.WarnContext(ctx, fmt.Sprintf("%+v", gErr))
-		}
+		h.notifyRepliedUser(ctx, *commentReplyWithPostInfo)
 	}()
 	return apiwrap.SuccessResponse(), nil
 }
 
 func (h *CommentHandler) AdminDeleteComment(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
 	commentId := ctx.Param("id")
-	err := h.serv.DeleteCommentById(ctx, commentId)
+	comment, err := h.serv.FindCommentById(ctx, commentId)
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "Comment not found.")
+		}
 		return nil, err
 	}
+	err = h.serv.DeleteCommentById(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	if !comment.ApprovalStatus {
+		go h.moderator.Feedback(ctx.Copy(), []domain.CommentCandidate{commentToCandidate(*comment)}, true)
+	}
 	return apiwrap.SuccessResponse(), nil
 }
 
@@ -415,6 +507,9 @@ func (h *CommentHandler) AdminDeleteCommentReply(ctx *gin.Context) (*apiwrap.Res
 	if err != nil {
 		return nil, err
 	}
+	if !commentReplyWithPostInfo.ApprovalStatus {
+		go h.moderator.Feedback(ctx.Copy(), []domain.CommentCandidate{replyToCandidate(commentReplyWithPostInfo.CommentReply, commentReplyWithPostInfo.PostInfo)}, true)
+	}
 	return apiwrap.SuccessResponse(), nil
 }
 
@@ -429,17 +524,28 @@ func (h *CommentHandler) AdminBatchApproveComments(ctx *gin.Context, req BatchAp
 			ReplyIds:  v,
 		})
 	}
+	candidates, err := h.pendingCandidates(ctx, req.CommentIds, req.Replies)
+	if err != nil {
+		return nil, err
+	}
 	approvalEmailInfos, repliedEmailInfos, err := h.serv.BatchApproveComments(ctx, req.CommentIds, replies)
 	if err != nil {
 		return nil, err
 	}
+	go h.moderator.Feedback(ctx.Copy(), candidates, false)
 	go func() {
 		l := slog.Default().With("X-Request-ID", ctx.GetString("X-Request-ID"))
 		// 通知用户评论已通过
 		l.InfoContext(ctx, fmt.Sprintf("approvalEmailInfos=%v", approvalEmailInfos))
 		l.InfoContext(ctx, fmt.Sprintf("repliedEmailInfos=%v", repliedEmailInfos))
 		for _, approvalEmailInfo := range approvalEmailInfos {
-			gErr := h.msgServ.SendEmailWithEmail(ctx, "user-comment-approval", []string{approvalEmailInfo.Email}, "text/plain", approvalEmailInfo.PostUrl)
+			gErr := h.msgServ.SendEmailWithEmail(ctx, "user-comment-approval", []string{approvalEmailInfo.Email}, message.TemplateData{
+				RecipientName:  approvalEmailInfo.Name,
+				PostTitle:      approvalEmailInfo.PostTitle,
+				PostUrl:        approvalEmailInfo.PostUrl,
+				CommenterName:  approvalEmailInfo.CommenterName,
+				CommentContent: approvalEmailInfo.Content,
+			})
 			if gErr != nil {
 				l.WarnContext(ctx, fmt.Sprintf("%+v", gErr))
 			}
@@ -447,10 +553,13 @@ func (h *CommentHandler) AdminBatchApproveComments(ctx *gin.Context, req BatchAp
 
 		// 通知被回复的用户接收到了回复
 		for _, repliedEmailInfo := range repliedEmailInfos {
-			gErr := h.msgServ.SendEmailWithEmail(ctx, "user-comment-reply", []string{repliedEmailInfo.Email}, "text/plain", repliedEmailInfo.PostUrl)
-			if gErr != nil {
-				l.WarnContext(ctx, fmt.Sprintf("%+v", gErr))
-			}
+			h.sendReplyNotification(ctx, repliedEmailInfo.Email, message.TemplateData{
+				RecipientName:  repliedEmailInfo.Name,
+				PostTitle:      repliedEmailInfo.PostTitle,
+				PostUrl:        postUrlOf(repliedEmailInfo.PostId),
+				CommenterName:  repliedEmailInfo.CommenterName,
+				CommentContent: repliedEmailInfo.Content,
+			})
 		}
 	}()
 	return apiwrap.SuccessResponse(), nil
@@ -467,10 +576,15 @@ func (h *CommentHandler) AdminBatchDeleteComments(ctx *gin.Context, req BatchApp
 			ReplyIds:  v,
 		})
 	}
-	err := h.serv.BatchDeleteComments(ctx, req.CommentIds, replies)
+	candidates, err := h.pendingCandidates(ctx, req.CommentIds, req.Replies)
+	if err != nil {
+		return nil, err
+	}
+	err = h.serv.BatchDeleteComments(ctx, req.CommentIds, replies)
 	if err != nil {
 		return nil, err
 	}
+	go h.moderator.Feedback(ctx.Copy(), candidates, true)
 
 	return apiwrap.SuccessResponse(), nil
 }
@@ -0,0 +1,111 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/domain"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/gin-gonic/gin"
+)
+
+// moderate 执行评论审核流水线，被拒绝时返回包含原因的错误
+func (h *CommentHandler) moderate(ctx *gin.Context, candidate domain.CommentCandidate) (domain.Moderation, error) {
+	moderation, err := h.moderator.Moderate(ctx, candidate)
+	if err != nil {
+		return moderation, err
+	}
+	if moderation.Verdict == domain.ModerationReject {
+		reason := moderation.Reasons[0]
+		slog.InfoContext(ctx, "Comment rejected by moderation", "filter", reason.Filter, "reason", reason.Reason,
+			"postId", candidate.PostId, "ip", candidate.UserInfo.Ip, "email", candidate.UserInfo.Email)
+		return moderation, apiwrap.NewErrorResponseBody(http.StatusForbidden, "Comment rejected: "+reason.Reason)
+	}
+	return moderation, nil
+}
+
+// pendingCandidates 查询批量操作中尚未审核的评论和回复，用于将审核结果反馈给过滤器
+func (h *CommentHandler) pendingCandidates(ctx *gin.Context, commentIds []string, replies map[string][]string) ([]domain.CommentCandidate, error) {
+	ids := make([]string, 0, len(commentIds)+len(replies))
+	ids = append(ids, commentIds...)
+	for commentId := range replies {
+		ids = append(ids, commentId)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	comments, err := h.serv.FindCommentByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[string]bool, len(commentIds))
+	for _, commentId := range commentIds {
+		selected[commentId] = true
+	}
+	candidates := make([]domain.CommentCandidate, 0, len(ids))
+	for _, comment := range comments {
+		if selected[comment.Id] && !comment.ApprovalStatus {
+			candidates = append(candidates, domain.CommentCandidate{
+				Type:      domain.CandidateTypeComment,
+				PostId:    comment.PostInfo.PostId,
+				PostUrl:   comment.PostInfo.PostUrl,
+				Content:   comment.Content,
+				UserInfo:  domain.UserInfo(comment.UserInfo),
+				CreatedAt: comment.CreatedAt,
+			})
+		}
+		replyIds := make(map[string]bool, len(replies[comment.Id]))
+		for _, replyId := range replies[comment.Id] {
+			replyIds[replyId] = true
+		}
+		for _, reply := range comment.Replies {
+			if replyIds[reply.ReplyId] && !reply.ApprovalStatus {
+				candidates = append(candidates, domain.CommentCandidate{
+					Type:      domain.CandidateTypeReply,
+					PostId:    comment.PostInfo.PostId,
+					PostUrl:   comment.PostInfo.PostUrl,
+					Content:   reply.Content,
+					UserInfo:  domain.UserInfo(reply.UserInfo),
+					CreatedAt: reply.CreatedAt,
+				})
+			}
+		}
+	}
+	return candidates, nil
+}
+
+func commentToCandidate(comment domain.Comment) domain.CommentCandidate {
+	return domain.CommentCandidate{
+		Type:      domain.CandidateTypeComment,
+		PostId:    comment.PostInfo.PostId,
+		PostUrl:   comment.PostInfo.PostUrl,
+		Content:   comment.Content,
+		UserInfo:  comment.UserInfo,
+		CreatedAt: comment.CreateTime,
+	}
+}
+
+func replyToCandidate(reply domain.CommentReply, postInfo domain.PostInfo) domain.CommentCandidate {
+	return domain.CommentCandidate{
+		Type:      domain.CandidateTypeReply,
+		PostId:    postInfo.PostId,
+		PostUrl:   postInfo.PostUrl,
+		Content:   reply.Content,
+		UserInfo:  domain.UserInfo(reply.UserInfo),
+		CreatedAt: reply.CreatedAt,
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/message"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+func postUrlOf(postId string) string {
+	return fmt.Sprintf("%s/posts/%s", pkg.GetOrDefault4String(os.Getenv("WEBSITE_BASE_HOST"), "http://localhost:3000"), postId)
+}
+
+// notifyRepliedUser 回复通过审核后通知订阅了回复通知的被回复用户，邮件附带退订链接，已退订的邮箱不会收到邮件
+func (h *CommentHandler) notifyRepliedUser(ctx *gin.Context, reply domain.CommentReplyWithPostInfo) {
+	if !domain.ShouldNotifyRepliedUser(reply.UserInfo, reply.RepliedUserInfo) {
+		return
+	}
+	h.sendReplyNotification(ctx, reply.RepliedUserInfo.Email, message.TemplateData{
+		RecipientName:  reply.RepliedUserInfo.Name,
+		PostTitle:      reply.PostInfo.PostTitle,
+		PostUrl:        postUrlOf(reply.PostInfo.PostId),
+		CommenterName:  reply.UserInfo.Name,
+		CommentContent: reply.Content,
+	})
+}
+
+func (h *CommentHandler) sendReplyNotification(ctx *gin.Context, email string, data message.TemplateData) {
+	err := h.msgServ.SendNotificationEmail(ctx, "user-comment-reply", email, data)
+	if err != nil {
+		slog.Default().With("X-Request-ID", ctx.GetString("X-Request-ID")).WarnContext(ctx, fmt.Sprintf("%+v", err))
+	}
+}
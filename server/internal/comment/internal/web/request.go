@@ -20,6 +20,10 @@ type CommentRequest struct {
 	Email    string `json:"email" binding:"required,validateEmailFormat"`
 	Website  string `json:"website"`
 	Content  string `json:"content" binding:"required,max=200"`
+	// 蜜罐字段，前端隐藏该输入框，正常用户不会填写
+	Homepage string `json:"homepage"`
+	// 是否接收回复通知邮件
+	NotifyReplies bool `json:"notify_replies"`
 }
 
 type PageRequest struct {
@@ -82,6 +82,19 @@ type AdminCommentVO struct {
 
 	// 被回复的回复 Id
 	ReplyToId string `json:"reply_to_id"`
+
+	// 审核流水线的结果，早于审核功能的评论没有该字段
+	Moderation *ModerationVO `json:"moderation,omitempty"`
+}
+
+type ModerationVO struct {
+	Verdict string               `json:"verdict"`
+	Reasons []ModerationReasonVO `json:"reasons"`
+}
+
+type ModerationReasonVO struct {
+	Filter string `json:"filter"`
+	Reason string `json:"reason"`
 }
 
 type AdminCommentReplyVO struct {
@@ -15,14 +15,21 @@
 package comment
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/domain"
 	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/service"
 	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/web"
 )
 
 type (
-	Handler = web.CommentHandler
-	Service = service.ICommentService
-	Module  struct {
+	Handler            = web.CommentHandler
+	Service            = service.ICommentService
+	Comment            = domain.Comment
+	CommentReply       = domain.CommentReply
+	CommentWithReplies = domain.CommentWithReplies
+	PostInfo           = domain.PostInfo
+	UserInfo           = domain.UserInfo
+	UserInfo4Reply     = domain.UserInfo4Reply
+	Module             struct {
 		Svc Service
 		Hdl *Handler
 	}
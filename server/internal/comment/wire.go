@@ -17,6 +17,7 @@
 package comment
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/akismet"
 	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/repository"
 	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/repository/dao"
 	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/service"
@@ -29,17 +30,19 @@ import (
 	"github.com/google/wire"
 )
 
-var CommentProviders = wire.NewSet(web.NewCommentHandler, service.NewCommentService, repository.NewCommentRepository, dao.NewCommentDao,
+var CommentProviders = wire.NewSet(web.NewCommentHandler, service.NewCommentService, service.NewCommentModerator, repository.NewCommentRepository, dao.NewCommentDao,
 	wire.Bind(new(service.ICommentService), new(*service.CommentService)),
+	wire.Bind(new(service.ICommentModerator), new(*service.CommentModerator)),
 	wire.Bind(new(repository.ICommentRepository), new(*repository.CommentRepository)),
 	wire.Bind(new(dao.ICommentDao), new(*dao.CommentDao)))
 
-func InitCommentModule(db *mongox.Database, messageModule *message.Module, cfgModule *website_config.Module, postModule *post.Module, eventBus *eventbus.EventBus) *Module {
+func InitCommentModule(db *mongox.Database, messageModule *message.Module, cfgModule *website_config.Module, postModule *post.Module, akismetModule *akismet.Module, eventBus *eventbus.EventBus) *Module {
 	panic(wire.Build(
 		CommentProviders,
 		wire.FieldsOf(new(*website_config.Module), "Svc"),
 		wire.FieldsOf(new(*post.Module), "Svc"),
 		wire.FieldsOf(new(*message.Module), "Svc"),
+		wire.FieldsOf(new(*akismet.Module), "Svc"),
 		wire.Struct(new(Module), "Svc", "Hdl"),
 	))
 }
@@ -7,6 +7,7 @@
 package comment
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/akismet"
 	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/repository"
 	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/repository/dao"
 	"github.com/chenmingyong0423/fnote/server/internal/comment/internal/service"
@@ -21,14 +22,16 @@ import (
 
 // Injectors from wire.go:
 
-func InitCommentModule(db *mongox.Database, messageModule *message.Module, cfgModule *website_config.Module, postModule *post.Module, eventBus *eventbus.EventBus) *Module {
+func InitCommentModule(db *mongox.Database, messageModule *message.Module, cfgModule *website_config.Module, postModule *post.Module, akismetModule *akismet.Module, eventBus *eventbus.EventBus) *Module {
 	commentDao := dao.NewCommentDao(db)
 	commentRepository := repository.NewCommentRepository(commentDao)
 	commentService := service.NewCommentService(commentRepository, eventBus)
 	iWebsiteConfigService := cfgModule.Svc
+	iAkismetService := akismetModule.Svc
+	commentModerator := service.NewCommentModerator(commentRepository, iWebsiteConfigService, iAkismetService)
 	iPostService := postModule.Svc
 	iMessageService := messageModule.Svc
-	commentHandler := web.NewCommentHandler(commentService, iWebsiteConfigService, iPostService, iMessageService)
+	commentHandler := web.NewCommentHandler(commentService, commentModerator, iWebsiteConfigService, iPostService, iMessageService)
 	module := &Module{
 		Svc: commentService,
 		Hdl: commentHandler,
@@ -38,4 +41,4 @@ func InitCommentModule(db *mongox.Database, messageModule *message.Module, cfgMo
 
 // wire.go:
 
-var CommentProviders = wire.NewSet(web.NewCommentHandler, service.NewCommentService, repository.NewCommentRepository, dao.NewCommentDao, wire.Bind(new(service.ICommentService), new(*service.CommentService)), wire.Bind(new(repository.ICommentRepository), new(*repository.CommentRepository)), wire.Bind(new(dao.ICommentDao), new(*dao.CommentDao)))
+var CommentProviders = wire.NewSet(web.NewCommentHandler, service.NewCommentService, service.NewCommentModerator, repository.NewCommentRepository, dao.NewCommentDao, wire.Bind(new(service.ICommentService), new(*service.CommentService)), wire.Bind(new(service.ICommentModerator), new(*service.CommentModerator)), wire.Bind(new(repository.ICommentRepository), new(*repository.CommentRepository)), wire.Bind(new(dao.ICommentDao), new(*dao.CommentDao)))
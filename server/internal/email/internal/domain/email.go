@@ -24,12 +24,18 @@ type Email struct {
 	Password string
 	// 发件人
 	Name string
+	// 发件地址，为空时使用 Username
+	From string
 	// 收件人
 	To []string
 	// 标题
 	Subject string
 	// 内容
 	Body string
-	// 内容类型
+	// 内容类型，HtmlBody 不为空时忽略
 	ContentType string
+	// HTML 内容，不为空时与 Body 一同以 multipart/alternative 发送
+	HtmlBody string
+	// 额外的邮件头，例如 List-Unsubscribe
+	Headers map[string]string
 }
@@ -0,0 +1,49 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import "time"
+
+type OutboxStatus string
+
+const (
+	// OutboxStatusPending 等待发送或等待重试
+	OutboxStatusPending OutboxStatus = "pending"
+	OutboxStatusSent    OutboxStatus = "sent"
+	// OutboxStatusDead 重试次数用尽，不再自动发送，可在后台手动重发
+	OutboxStatusDead OutboxStatus = "dead"
+)
+
+// OutboxEmail 发件箱中的邮件，不保存 SMTP 账号信息，发送时读取最新的邮件配置
+type OutboxEmail struct {
+	Id string
+	// 发件人
+	Name        string
+	To          []string
+	Subject     string
+	Body        string
+	ContentType string
+	HtmlBody    string
+	Headers     map[string]string
+	Status      OutboxStatus
+	// 已尝试发送的次数
+	Attempts int
+	// 下次尝试发送的时间
+	NextAttemptAt time.Time
+	LastError     string
+	SentAt        time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
@@ -0,0 +1,159 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/bsonx"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/update"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// OutboxEmail 发件箱中的邮件。
+// 发送前需先抢占租约：lease_until 之前其他 worker 无法再次领取，实例崩溃后租约到期会被重新领取；
+// 等待重试的邮件以 lease_until 作为下次发送的时间。
+type OutboxEmail struct {
+	mongox.Model `bson:",inline"`
+	Name         string            `bson:"name"`
+	To           []string          `bson:"to"`
+	Subject      string            `bson:"subject"`
+	Body         string            `bson:"body"`
+	ContentType  string            `bson:"content_type"`
+	HtmlBody     string            `bson:"html_body,omitempty"`
+	Headers      map[string]string `bson:"headers,omitempty"`
+	Status       string            `bson:"status"`
+	Attempts     int               `bson:"attempts"`
+	LeaseOwner   string            `bson:"lease_owner,omitempty"`
+	LeaseUntil   time.Time         `bson:"lease_until"`
+	LastError    string            `bson:"last_error,omitempty"`
+	SentAt       time.Time         `bson:"sent_at,omitempty"`
+}
+
+type IOutboxDao interface {
+	Insert(ctx context.Context, email *OutboxEmail) (string, error)
+	// Claim 领取一封已到发送时间且未被其他 worker 占用的邮件，并累加尝试次数
+	Claim(ctx context.Context, owner string, now time.Time, leaseUntil time.Time) (*OutboxEmail, error)
+	MarkSent(ctx context.Context, id bson.ObjectID, owner string, now time.Time) error
+	// MarkFailed 发送失败时释放租约，status 为 pending 时 retryAt 之后才会被再次领取
+	MarkFailed(ctx context.Context, id bson.ObjectID, owner string, status string, retryAt time.Time, reason string, now time.Time) error
+	// Requeue 将已发送或已放弃的邮件重新放入发件箱，返回修改的数量
+	Requeue(ctx context.Context, id bson.ObjectID, now time.Time) (int64, error)
+	FindById(ctx context.Context, id bson.ObjectID) (*OutboxEmail, error)
+	Find(ctx context.Context, filter bson.D, findOptions *options.FindOptionsBuilder) ([]*OutboxEmail, error)
+	Count(ctx context.Context, filter bson.D) (int64, error)
+}
+
+var _ IOutboxDao = (*OutboxDao)(nil)
+
+func NewOutboxDao(db *mongox.Database) *OutboxDao {
+	return &OutboxDao{coll: mongox.NewCollection[OutboxEmail](db, "email_outbox")}
+}
+
+type OutboxDao struct {
+	coll *mongox.Collection[OutboxEmail]
+}
+
+func (d *OutboxDao) Insert(ctx context.Context, email *OutboxEmail) (string, error) {
+	result, err := d.coll.Creator().InsertOne(ctx, email)
+	if err != nil {
+		return "", errors.Wrapf(err, "fails to insert outbox email, to=%v, subject=%s", email.To, email.Subject)
+	}
+	return result.InsertedID.(bson.ObjectID).Hex(), nil
+}
+
+func (d *OutboxDao) Claim(ctx context.Context, owner string, now time.Time, leaseUntil time.Time) (*OutboxEmail, error) {
+	filter := query.NewBuilder().Eq("status", "pending").Lte("lease_until", now).Build()
+	u := update.NewBuilder().Set("lease_owner", owner).Set("lease_until", leaseUntil).Inc("attempts", 1).Build()
+	email, err := d.coll.Finder().Filter(filter).Updates(u).FindOneAndUpdate(ctx,
+		options.FindOneAndUpdate().SetSort(bsonx.M("lease_until", 1)).SetReturnDocument(options.After))
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to claim an outbox email, owner=%s", owner)
+	}
+	return email, nil
+}
+
+func (d *OutboxDao) MarkSent(ctx context.Context, id bson.ObjectID, owner string, now time.Time) error {
+	u := update.NewBuilder().
+		Set("status", "sent").
+		Set("sent_at", now).
+		Set("updated_at", now).
+		Unset("lease_owner", "last_error").
+		Build()
+	_, err := d.coll.Updater().Filter(query.NewBuilder().Id(id).Eq("lease_owner", owner).Build()).Updates(u).UpdateOne(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to mark the outbox email as sent, id=%s, owner=%s", id.Hex(), owner)
+	}
+	return nil
+}
+
+func (d *OutboxDao) MarkFailed(ctx context.Context, id bson.ObjectID, owner string, status string, retryAt time.Time, reason string, now time.Time) error {
+	u := update.NewBuilder().
+		Set("status", status).
+		Set("lease_until", retryAt).
+		Set("last_error", reason).
+		Set("updated_at", now).
+		Unset("lease_owner").
+		Build()
+	_, err := d.coll.Updater().Filter(query.NewBuilder().Id(id).Eq("lease_owner", owner).Build()).Updates(u).UpdateOne(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to mark the outbox email as failed, id=%s, owner=%s", id.Hex(), owner)
+	}
+	return nil
+}
+
+func (d *OutboxDao) Requeue(ctx context.Context, id bson.ObjectID, now time.Time) (int64, error) {
+	u := update.NewBuilder().
+		Set("status", "pending").
+		Set("attempts", 0).
+		Set("lease_until", now).
+		Set("updated_at", now).
+		Unset("lease_owner", "last_error", "sent_at").
+		Build()
+	result, err := d.coll.Updater().Filter(query.NewBuilder().Id(id).Ne("status", "pending").Build()).Updates(u).UpdateOne(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to requeue the outbox email, id=%s", id.Hex())
+	}
+	return result.ModifiedCount, nil
+}
+
+func (d *OutboxDao) FindById(ctx context.Context, id bson.ObjectID) (*OutboxEmail, error) {
+	email, err := d.coll.Finder().Filter(query.Id(id)).FindOne(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find the outbox email, id=%s", id.Hex())
+	}
+	return email, nil
+}
+
+func (d *OutboxDao) Find(ctx context.Context, filter bson.D, findOptions *options.FindOptionsBuilder) ([]*OutboxEmail, error) {
+	emails, err := d.coll.Finder().Filter(filter).Find(ctx, findOptions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find outbox emails, filter=%v", filter)
+	}
+	return emails, nil
+}
+
+func (d *OutboxDao) Count(ctx context.Context, filter bson.D) (int64, error) {
+	count, err := d.coll.Finder().Filter(filter).Count(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to count outbox emails, filter=%v", filter)
+	}
+	return count, nil
+}
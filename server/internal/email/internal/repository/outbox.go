@@ -0,0 +1,148 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/repository/dao"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/bsonx"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type IOutboxRepository interface {
+	AddOutboxEmail(ctx context.Context, email domain.OutboxEmail, now time.Time) (string, error)
+	ClaimOutboxEmail(ctx context.Context, owner string, now time.Time, leaseUntil time.Time) (*domain.OutboxEmail, error)
+	MarkOutboxEmailSent(ctx context.Context, id string, owner string, now time.Time) error
+	MarkOutboxEmailFailed(ctx context.Context, id string, owner string, status domain.OutboxStatus, retryAt time.Time, reason string, now time.Time) error
+	RequeueOutboxEmail(ctx context.Context, id string, now time.Time) (int64, error)
+	FindOutboxEmailById(ctx context.Context, id string) (*domain.OutboxEmail, error)
+	// FindOutboxEmails 按创建时间倒序分页查询发件箱，status 为空时查询全部
+	FindOutboxEmails(ctx context.Context, status domain.OutboxStatus, skip, limit int64) ([]domain.OutboxEmail, int64, error)
+}
+
+var _ IOutboxRepository = (*OutboxRepository)(nil)
+
+func NewOutboxRepository(dao dao.IOutboxDao) *OutboxRepository {
+	return &OutboxRepository{dao: dao}
+}
+
+type OutboxRepository struct {
+	dao dao.IOutboxDao
+}
+
+func (r *OutboxRepository) AddOutboxEmail(ctx context.Context, email domain.OutboxEmail, now time.Time) (string, error) {
+	return r.dao.Insert(ctx, &dao.OutboxEmail{
+		Model:       mongox.Model{CreatedAt: now, UpdatedAt: now},
+		Name:        email.Name,
+		To:          email.To,
+		Subject:     email.Subject,
+		Body:        email.Body,
+		ContentType: email.ContentType,
+		HtmlBody:    email.HtmlBody,
+		Headers:     email.Headers,
+		Status:      string(domain.OutboxStatusPending),
+		LeaseUntil:  now,
+	})
+}
+
+func (r *OutboxRepository) ClaimOutboxEmail(ctx context.Context, owner string, now time.Time, leaseUntil time.Time) (*domain.OutboxEmail, error) {
+	email, err := r.dao.Claim(ctx, owner, now, leaseUntil)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(email), nil
+}
+
+func (r *OutboxRepository) MarkOutboxEmailSent(ctx context.Context, id string, owner string, now time.Time) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	return r.dao.MarkSent(ctx, objectID, owner, now)
+}
+
+func (r *OutboxRepository) MarkOutboxEmailFailed(ctx context.Context, id string, owner string, status domain.OutboxStatus, retryAt time.Time, reason string, now time.Time) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	return r.dao.MarkFailed(ctx, objectID, owner, string(status), retryAt, reason, now)
+}
+
+func (r *OutboxRepository) RequeueOutboxEmail(ctx context.Context, id string, now time.Time) (int64, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, err
+	}
+	return r.dao.Requeue(ctx, objectID, now)
+}
+
+func (r *OutboxRepository) FindOutboxEmailById(ctx context.Context, id string) (*domain.OutboxEmail, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	email, err := r.dao.FindById(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(email), nil
+}
+
+func (r *OutboxRepository) FindOutboxEmails(ctx context.Context, status domain.OutboxStatus, skip, limit int64) ([]domain.OutboxEmail, int64, error) {
+	filter := query.NewBuilder()
+	if status != "" {
+		filter.Eq("status", string(status))
+	}
+	cond := filter.Build()
+	count, err := r.dao.Count(ctx, cond)
+	if err != nil {
+		return nil, 0, err
+	}
+	emails, err := r.dao.Find(ctx, cond, options.Find().SetSort(bsonx.M("created_at", -1)).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	return slice.Map(emails, func(_ int, e *dao.OutboxEmail) domain.OutboxEmail {
+		return *r.toDomain(e)
+	}), count, nil
+}
+
+func (r *OutboxRepository) toDomain(email *dao.OutboxEmail) *domain.OutboxEmail {
+	return &domain.OutboxEmail{
+		Id:            email.ID.Hex(),
+		Name:          email.Name,
+		To:            email.To,
+		Subject:       email.Subject,
+		Body:          email.Body,
+		ContentType:   email.ContentType,
+		HtmlBody:      email.HtmlBody,
+		Headers:       email.Headers,
+		Status:        domain.OutboxStatus(email.Status),
+		Attempts:      email.Attempts,
+		NextAttemptAt: email.LeaseUntil,
+		LastError:     email.LastError,
+		SentAt:        email.SentAt,
+		CreatedAt:     email.CreatedAt,
+		UpdatedAt:     email.UpdatedAt,
+	}
+}
@@ -1,4 +1,4 @@
-// Copyright 2023 chenmingyong0423
+// Copyright 2024 chenmingyong0423
 
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -16,8 +16,16 @@ package service
 
 import (
 	"context"
+	"crypto/tls"
+	"net/smtp"
+	"time"
 
 	"github.com/chenmingyong0423/fnote/server/internal/email/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/repository"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
 
 	"github.com/pkg/errors"
 	"gopkg.in/gomail.v2"
@@ -25,6 +33,12 @@ import (
 
 type IEmailService interface {
 	SendEmail(ctx context.Context, email domain.Email) error
+	// EnqueueEmail 将邮件放入发件箱，由后台 worker 读取最新的邮件配置发送，失败时按指数退避重试
+	EnqueueEmail(ctx context.Context, email domain.Email) (string, error)
+	// GetOutboxEmails 分页查询发件箱，status 为空时查询全部
+	GetOutboxEmails(ctx context.Context, status domain.OutboxStatus, pageNo, pageSize int64) ([]domain.OutboxEmail, int64, error)
+	// ResendOutboxEmail 将已发送或已放弃的邮件重新放入发件箱
+	ResendOutboxEmail(ctx context.Context, id string) error
 }
 
 var (
@@ -32,22 +46,122 @@ var (
 )
 
 type EmailService struct {
+	repo       repository.IOutboxRepository
+	cfgServ    website_config.Service
+	instanceId string
+	// wake 有新邮件入队时唤醒空闲的 worker
+	wake chan struct{}
+	// send 发件箱 worker 实际发送邮件的方法，默认为 SendEmail
+	send func(ctx context.Context, email domain.Email) error
 }
 
-func NewEmailService() *EmailService {
-	return &EmailService{}
+func NewEmailService(repo repository.IOutboxRepository, cfgServ website_config.Service) *EmailService {
+	s := &EmailService{
+		repo:       repo,
+		cfgServ:    cfgServ,
+		instanceId: uuid.NewString(),
+		wake:       make(chan struct{}, 1),
+	}
+	s.send = s.SendEmail
+	s.outboxWorker().Start(s.instanceId, s.wake)
+	return s
 }
 
 func (s *EmailService) SendEmail(ctx context.Context, email domain.Email) error {
+	from := email.From
+	if from == "" {
+		from = email.Username
+	}
 	m := gomail.NewMessage()
-	m.SetHeader("From", email.Username)
+	m.SetHeader("From", from)
 	m.SetHeader("To", email.To...)
 	m.SetHeader("Subject", email.Subject)
-	m.SetBody(email.ContentType, email.Body)
+	for k, v := range email.Headers {
+		m.SetHeader(k, v)
+	}
+	if email.HtmlBody != "" {
+		// 纯文本在前、HTML 在后，邮件客户端会优先展示最后一个能识别的部分
+		m.SetBody("text/plain", email.Body)
+		m.AddAlternative("text/html", email.HtmlBody)
+	} else {
+		m.SetBody(pkg.GetOrDefault4String(email.ContentType, "text/plain"), email.Body)
+	}
 	dialer := gomail.NewDialer(email.Host, email.Port, email.Username, email.Password)
+	if viper.GetBool("email.insecure_smtp") {
+		// 本地或测试用的 SMTP 服务（例如 MailHog）通常没有可信的证书，也不支持 TLS，允许跳过证书校验并明文认证
+		dialer.TLSConfig = &tls.Config{ServerName: email.Host, InsecureSkipVerify: true}
+		if email.Username != "" {
+			dialer.Auth = insecurePlainAuth{username: email.Username, password: email.Password}
+		}
+	}
 	err := dialer.DialAndSend(m)
 	if err != nil {
 		return errors.Wrap(err, "dialer.DialAndSend failed")
 	}
 	return nil
 }
+
+func (s *EmailService) EnqueueEmail(ctx context.Context, email domain.Email) (string, error) {
+	id, err := s.repo.AddOutboxEmail(ctx, domain.OutboxEmail{
+		Name:        email.Name,
+		To:          email.To,
+		Subject:     email.Subject,
+		Body:        email.Body,
+		ContentType: email.ContentType,
+		HtmlBody:    email.HtmlBody,
+		Headers:     email.Headers,
+	}, time.Now().Local())
+	if err != nil {
+		return "", err
+	}
+	s.wakeWorker()
+	return id, nil
+}
+
+func (s *EmailService) GetOutboxEmails(ctx context.Context, status domain.OutboxStatus, pageNo, pageSize int64) ([]domain.OutboxEmail, int64, error) {
+	return s.repo.FindOutboxEmails(ctx, status, (pageNo-1)*pageSize, pageSize)
+}
+
+var ErrOutboxEmailPending = errors.New("the email is already waiting to be sent")
+
+func (s *EmailService) ResendOutboxEmail(ctx context.Context, id string) error {
+	email, err := s.repo.FindOutboxEmailById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if email.Status == domain.OutboxStatusPending {
+		return ErrOutboxEmailPending
+	}
+	modified, err := s.repo.RequeueOutboxEmail(ctx, id, time.Now().Local())
+	if err != nil {
+		return err
+	}
+	if modified == 0 {
+		return ErrOutboxEmailPending
+	}
+	s.wakeWorker()
+	return nil
+}
+
+func (s *EmailService) wakeWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// insecurePlainAuth 与 smtp.PlainAuth 相同，但允许在未加密的连接上认证
+type insecurePlainAuth struct {
+	username, password string
+}
+
+func (a insecurePlainAuth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
+	return "PLAIN", []byte("\x00" + a.username + "\x00" + a.password), nil
+}
+
+func (a insecurePlainAuth) Next(_ []byte, more bool) ([]byte, error) {
+	if more {
+		return nil, errors.New("unexpected server challenge")
+	}
+	return nil, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/leaseworker"
+)
+
+const (
+	outboxPollInterval = 5 * time.Second
+	// outboxLease 领取邮件后的租约时长，实例在发送过程中崩溃时，租约到期后由其他 worker 重新发送
+	outboxLease = 5 * time.Minute
+)
+
+// defaultOutboxPolicy 发件箱默认的发送策略，累计失败 MaxAttempts 次后邮件进入 dead 状态
+var defaultOutboxPolicy = leaseworker.Policy{
+	Workers:     2,
+	MaxAttempts: 8,
+	BackoffBase: 30 * time.Second,
+	MaxBackoff:  time.Hour,
+}
+
+func currentOutboxPolicy() leaseworker.Policy {
+	return leaseworker.LoadPolicy("email.outbox", defaultOutboxPolicy)
+}
+
+// outboxWorker 逐封领取并发送发件箱中已到发送时间的邮件
+func (s *EmailService) outboxWorker() *leaseworker.Worker[domain.OutboxEmail] {
+	return &leaseworker.Worker[domain.OutboxEmail]{
+		Name:         "Email outbox",
+		Lease:        outboxLease,
+		PollInterval: outboxPollInterval,
+		Policy:       currentOutboxPolicy,
+		Claim:        s.repo.ClaimOutboxEmail,
+		Handle: func(ctx context.Context, email *domain.OutboxEmail) error {
+			return s.deliver(ctx, *email)
+		},
+		Succeed: func(ctx context.Context, email *domain.OutboxEmail, owner string, now time.Time) error {
+			return s.repo.MarkOutboxEmailSent(ctx, email.Id, owner, now)
+		},
+		Fail: func(ctx context.Context, email *domain.OutboxEmail, owner string, dead bool, retryAt time.Time, cause error, now time.Time) error {
+			status := domain.OutboxStatusPending
+			if dead {
+				status = domain.OutboxStatusDead
+			}
+			return s.repo.MarkOutboxEmailFailed(ctx, email.Id, owner, status, retryAt, cause.Error(), now)
+		},
+		Id:       func(email *domain.OutboxEmail) string { return email.Id },
+		Attempts: func(email *domain.OutboxEmail) int { return email.Attempts },
+	}
+}
+
+// deliverDueEmails 逐封领取并发送已到发送时间的邮件
+func (s *EmailService) deliverDueEmails(ctx context.Context, owner string) {
+	s.outboxWorker().RunDue(ctx, owner)
+}
+
+// deliver 使用最新的邮件配置发送邮件，修改配置后待重试的邮件会使用新的配置
+func (s *EmailService) deliver(ctx context.Context, email domain.OutboxEmail) error {
+	emailCfg, err := s.cfgServ.GetEmailConfig(ctx)
+	if err != nil {
+		return err
+	}
+	from := emailCfg.Username
+	if from == "" {
+		from = emailCfg.Email
+	}
+	return s.send(ctx, domain.Email{
+		Host:        emailCfg.Host,
+		Port:        emailCfg.Port,
+		Username:    emailCfg.Username,
+		Password:    emailCfg.Password,
+		Name:        email.Name,
+		From:        from,
+		To:          email.To,
+		Subject:     email.Subject,
+		Body:        email.Body,
+		ContentType: email.ContentType,
+		HtmlBody:    email.HtmlBody,
+		Headers:     email.Headers,
+	})
+}
@@ -0,0 +1,239 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/repository"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// fakeOutboxRepository 内存中的发件箱，领取、标记的语义与 OutboxDao 一致
+type fakeOutboxRepository struct {
+	repository.IOutboxRepository
+
+	mu     sync.Mutex
+	emails map[string]*fakeOutboxEmail
+}
+
+type fakeOutboxEmail struct {
+	domain.OutboxEmail
+	leaseOwner string
+	leaseUntil time.Time
+}
+
+func newFakeOutboxRepository() *fakeOutboxRepository {
+	return &fakeOutboxRepository{emails: make(map[string]*fakeOutboxEmail)}
+}
+
+func (r *fakeOutboxRepository) add(to string, leaseUntil time.Time) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := strconv.Itoa(len(r.emails) + 1)
+	r.emails[id] = &fakeOutboxEmail{
+		OutboxEmail: domain.OutboxEmail{Id: id, To: []string{to}, Subject: "subject", Body: "body", Status: domain.OutboxStatusPending},
+		leaseUntil:  leaseUntil,
+	}
+	return id
+}
+
+func (r *fakeOutboxRepository) get(id string) fakeOutboxEmail {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.emails[id]
+}
+
+// expireLease 模拟时间推移，使邮件立即可以被再次领取
+func (r *fakeOutboxRepository) expireLease(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emails[id].leaseUntil = time.Now().Add(-time.Second)
+}
+
+func (r *fakeOutboxRepository) ClaimOutboxEmail(_ context.Context, owner string, now time.Time, leaseUntil time.Time) (*domain.OutboxEmail, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	due := make([]*fakeOutboxEmail, 0)
+	for _, email := range r.emails {
+		if email.Status == domain.OutboxStatusPending && !email.leaseUntil.After(now) {
+			due = append(due, email)
+		}
+	}
+	if len(due) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].leaseUntil.Before(due[j].leaseUntil) })
+	email := due[0]
+	email.leaseOwner, email.leaseUntil = owner, leaseUntil
+	email.Attempts++
+	claimed := email.OutboxEmail
+	return &claimed, nil
+}
+
+func (r *fakeOutboxRepository) MarkOutboxEmailSent(_ context.Context, id string, owner string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if email := r.emails[id]; email != nil && email.leaseOwner == owner {
+		email.Status, email.SentAt, email.leaseOwner, email.LastError = domain.OutboxStatusSent, now, "", ""
+	}
+	return nil
+}
+
+func (r *fakeOutboxRepository) MarkOutboxEmailFailed(_ context.Context, id string, owner string, status domain.OutboxStatus, retryAt time.Time, reason string, _ time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if email := r.emails[id]; email != nil && email.leaseOwner == owner {
+		email.Status, email.leaseUntil, email.LastError, email.leaseOwner = status, retryAt, reason, ""
+	}
+	return nil
+}
+
+type fakeConfigService struct {
+	website_config.Service
+}
+
+func (fakeConfigService) GetEmailConfig(context.Context) (*website_config.EmailConfig, error) {
+	return &website_config.EmailConfig{Host: "smtp.example.com", Port: 465, Username: "fnote@example.com"}, nil
+}
+
+// sender 记录发送的邮件，err 不为空时发送失败
+type sender struct {
+	mu   sync.Mutex
+	sent []domain.Email
+	err  error
+}
+
+func (s *sender) send(_ context.Context, email domain.Email) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, email)
+	return s.err
+}
+
+func (s *sender) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+func newTestEmailService(t *testing.T, repo repository.IOutboxRepository, sender *sender) *EmailService {
+	t.Helper()
+	viper.Set("email.outbox.max_attempts", 3)
+	viper.Set("email.outbox.backoff_base", time.Minute)
+	viper.Set("email.outbox.max_backoff", 3*time.Minute)
+	t.Cleanup(viper.Reset)
+	return &EmailService{repo: repo, cfgServ: fakeConfigService{}, instanceId: "test", wake: make(chan struct{}, 1), send: sender.send}
+}
+
+func TestEmailService_DeliverDueEmails(t *testing.T) {
+	repo := newFakeOutboxRepository()
+	sender := &sender{}
+	s := newTestEmailService(t, repo, sender)
+	now := time.Now()
+	due := repo.add("a@example.com", now.Add(-time.Minute))
+	notDue := repo.add("b@example.com", now.Add(time.Hour))
+
+	s.deliverDueEmails(context.Background(), "worker")
+
+	if sender.count() != 1 || sender.sent[0].To[0] != "a@example.com" || sender.sent[0].Host != "smtp.example.com" {
+		t.Fatalf("sent emails = %+v, want only a@example.com with the latest config", sender.sent)
+	}
+	if email := repo.get(due); email.Status != domain.OutboxStatusSent || email.Attempts != 1 || email.leaseOwner != "" {
+		t.Fatalf("due email = %+v, want sent after one attempt", email)
+	}
+	if email := repo.get(notDue); email.Status != domain.OutboxStatusPending || email.Attempts != 0 {
+		t.Fatalf("email that is not due = %+v, want untouched", email)
+	}
+}
+
+func TestEmailService_DeliverDueEmails_Backoff(t *testing.T) {
+	repo := newFakeOutboxRepository()
+	sender := &sender{err: errors.New("smtp unavailable")}
+	s := newTestEmailService(t, repo, sender)
+	id := repo.add("a@example.com", time.Now().Add(-time.Second))
+
+	for attempt, wantBackoff := range []time.Duration{time.Minute, 2 * time.Minute} {
+		before := time.Now()
+		s.deliverDueEmails(context.Background(), "worker")
+		email := repo.get(id)
+		if email.Status != domain.OutboxStatusPending || email.Attempts != attempt+1 || email.LastError != "smtp unavailable" {
+			t.Fatalf("after attempt %d: %+v, want pending with the last error", attempt+1, email)
+		}
+		if wait := email.leaseUntil.Sub(before); wait < wantBackoff || wait > wantBackoff+time.Second {
+			t.Fatalf("after attempt %d: retry in %v, want %v", attempt+1, wait, wantBackoff)
+		}
+		// 退避期间不会重试
+		s.deliverDueEmails(context.Background(), "worker")
+		if sender.count() != attempt+1 {
+			t.Fatalf("sent %d times during backoff, want %d", sender.count(), attempt+1)
+		}
+		repo.expireLease(id)
+	}
+}
+
+func TestEmailService_DeliverDueEmails_DeadLetter(t *testing.T) {
+	repo := newFakeOutboxRepository()
+	sender := &sender{err: errors.New("mailbox unavailable")}
+	s := newTestEmailService(t, repo, sender)
+	id := repo.add("a@example.com", time.Now().Add(-time.Second))
+
+	for i := 0; i < 3; i++ {
+		s.deliverDueEmails(context.Background(), "worker")
+		repo.expireLease(id)
+	}
+	email := repo.get(id)
+	if email.Status != domain.OutboxStatusDead || email.Attempts != 3 {
+		t.Fatalf("email = %+v, want dead after 3 attempts", email)
+	}
+	// dead 状态的邮件不再自动发送
+	s.deliverDueEmails(context.Background(), "worker")
+	if sender.count() != 3 {
+		t.Fatalf("sent %d times, want 3", sender.count())
+	}
+}
+
+func TestEmailService_DeliverDueEmails_Lease(t *testing.T) {
+	repo := newFakeOutboxRepository()
+	sender := &sender{}
+	s := newTestEmailService(t, repo, sender)
+	id := repo.add("a@example.com", time.Now().Add(-time.Second))
+	// 模拟其他 worker 领取后崩溃：租约未到期前不会被重复发送
+	if _, err := repo.ClaimOutboxEmail(context.Background(), "crashed", time.Now(), time.Now().Add(outboxLease)); err != nil {
+		t.Fatal(err)
+	}
+	s.deliverDueEmails(context.Background(), "worker")
+	if sender.count() != 0 {
+		t.Fatalf("sent %d times while the lease is held by another worker, want 0", sender.count())
+	}
+	// 租约到期后由其他 worker 重新领取
+	repo.expireLease(id)
+	s.deliverDueEmails(context.Background(), "worker")
+	if sender.count() != 1 {
+		t.Fatalf("sent %d times after the lease expired, want 1", sender.count())
+	}
+	if email := repo.get(id); email.Status != domain.OutboxStatusSent || email.Attempts != 2 {
+		t.Fatalf("email = %+v, want sent after the second claim", email)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/service"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func NewEmailHandler(serv service.IEmailService) *EmailHandler {
+	return &EmailHandler{
+		serv: serv,
+	}
+}
+
+type EmailHandler struct {
+	serv service.IEmailService
+}
+
+func (h *EmailHandler) RegisterGinRoutes(engine *gin.Engine) {
+	adminGroup := engine.Group("/admin-api/emails/outbox")
+	adminGroup.GET("", apiwrap.WrapWithBody(h.AdminGetOutboxEmails))
+	adminGroup.POST("/:id/resend", apiwrap.Wrap(h.AdminResendOutboxEmail))
+}
+
+func (h *EmailHandler) AdminGetOutboxEmails(ctx *gin.Context, req OutboxPageRequest) (*apiwrap.ResponseBody[*apiwrap.PageVO[OutboxEmailVO]], error) {
+	emails, total, err := h.serv.GetOutboxEmails(ctx, domain.OutboxStatus(req.Status), req.PageNo, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewPageVO(req.PageNo, req.PageSize, total, slice.Map(emails, func(_ int, e domain.OutboxEmail) OutboxEmailVO {
+		return h.toOutboxEmailVO(e)
+	}))), nil
+}
+
+func (h *EmailHandler) AdminResendOutboxEmail(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	err := h.serv.ResendOutboxEmail(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, bson.ErrInvalidHex) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "Email not found.")
+		}
+		if errors.Is(err, service.ErrOutboxEmailPending) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusConflict, err.Error())
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponse(), nil
+}
+
+func (h *EmailHandler) toOutboxEmailVO(email domain.OutboxEmail) OutboxEmailVO {
+	vo := OutboxEmailVO{
+		Id:            email.Id,
+		To:            email.To,
+		Subject:       email.Subject,
+		Status:        string(email.Status),
+		Attempts:      email.Attempts,
+		NextAttemptAt: email.NextAttemptAt.Unix(),
+		LastError:     email.LastError,
+		CreatedAt:     email.CreatedAt.Unix(),
+		UpdatedAt:     email.UpdatedAt.Unix(),
+	}
+	if !email.SentAt.IsZero() {
+		vo.SentAt = email.SentAt.Unix()
+	}
+	return vo
+}
@@ -0,0 +1,24 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+type OutboxPageRequest struct {
+	// 当前页
+	PageNo int64 `form:"pageNo" binding:"required,min=1"`
+	// 每页数量
+	PageSize int64 `form:"pageSize" binding:"required,min=1,max=100"`
+	// 发送状态：pending、sent、dead，为空时查询全部
+	Status string `form:"status" binding:"omitempty,oneof=pending sent dead"`
+}
@@ -0,0 +1,28 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+type OutboxEmailVO struct {
+	Id            string   `json:"id"`
+	To            []string `json:"to"`
+	Subject       string   `json:"subject"`
+	Status        string   `json:"status"`
+	Attempts      int      `json:"attempts"`
+	NextAttemptAt int64    `json:"next_attempt_at"`
+	LastError     string   `json:"last_error,omitempty"`
+	SentAt        int64    `json:"sent_at,omitempty"`
+	CreatedAt     int64    `json:"created_at"`
+	UpdatedAt     int64    `json:"updated_at"`
+}
@@ -17,12 +17,15 @@ package email
 import (
 	"github.com/chenmingyong0423/fnote/server/internal/email/internal/domain"
 	"github.com/chenmingyong0423/fnote/server/internal/email/internal/service"
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/web"
 )
 
 type (
+	Handler = web.EmailHandler
 	Service = service.IEmailService
 	Email   = domain.Email
 	Module  struct {
 		Svc Service
+		Hdl *Handler
 	}
 )
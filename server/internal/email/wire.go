@@ -17,16 +17,24 @@
 package email
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/repository"
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/repository/dao"
 	"github.com/chenmingyong0423/fnote/server/internal/email/internal/service"
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/web"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config"
 	"github.com/chenmingyong0423/go-mongox/v2"
 	"github.com/google/wire"
 )
 
-var EmailProviders = wire.NewSet(service.NewEmailService, wire.Bind(new(service.IEmailService), new(*service.EmailService)))
+var EmailProviders = wire.NewSet(web.NewEmailHandler, service.NewEmailService, repository.NewOutboxRepository, dao.NewOutboxDao,
+	wire.Bind(new(service.IEmailService), new(*service.EmailService)),
+	wire.Bind(new(repository.IOutboxRepository), new(*repository.OutboxRepository)),
+	wire.Bind(new(dao.IOutboxDao), new(*dao.OutboxDao)))
 
-func InitEmailModule(db *mongox.Database) *Module {
+func InitEmailModule(db *mongox.Database, websiteConfigModule *website_config.Module) *Module {
 	panic(wire.Build(
 		EmailProviders,
-		wire.Struct(new(Module), "Svc"),
+		wire.FieldsOf(new(*website_config.Module), "Svc"),
+		wire.Struct(new(Module), "Svc", "Hdl"),
 	))
 }
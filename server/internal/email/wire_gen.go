@@ -7,21 +7,30 @@
 package email
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/repository"
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/repository/dao"
 	"github.com/chenmingyong0423/fnote/server/internal/email/internal/service"
+	"github.com/chenmingyong0423/fnote/server/internal/email/internal/web"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config"
 	"github.com/chenmingyong0423/go-mongox/v2"
 	"github.com/google/wire"
 )
 
 // Injectors from wire.go:
 
-func InitEmailModule(db *mongox.Database) *Module {
-	emailService := service.NewEmailService()
+func InitEmailModule(db *mongox.Database, websiteConfigModule *website_config.Module) *Module {
+	outboxDao := dao.NewOutboxDao(db)
+	outboxRepository := repository.NewOutboxRepository(outboxDao)
+	iWebsiteConfigService := websiteConfigModule.Svc
+	emailService := service.NewEmailService(outboxRepository, iWebsiteConfigService)
+	emailHandler := web.NewEmailHandler(emailService)
 	module := &Module{
 		Svc: emailService,
+		Hdl: emailHandler,
 	}
 	return module
 }
 
 // wire.go:
 
-var EmailProviders = wire.NewSet(service.NewEmailService, wire.Bind(new(service.IEmailService), new(*service.EmailService)))
+var EmailProviders = wire.NewSet(web.NewEmailHandler, service.NewEmailService, repository.NewOutboxRepository, dao.NewOutboxDao, wire.Bind(new(service.IEmailService), new(*service.EmailService)), wire.Bind(new(repository.IOutboxRepository), new(*repository.OutboxRepository)), wire.Bind(new(dao.IOutboxDao), new(*dao.OutboxDao)))
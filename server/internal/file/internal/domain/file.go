@@ -40,6 +40,8 @@ type FileDTO struct {
 	FileType       string `json:"file_type"`
 	FileExt        string `json:"file_ext"`
 	CustomFileName string
+	// FileId 不为空时作为文件 id，用于导入时保留原有的文件 id
+	FileId string
 }
 
 type PageDTO struct {
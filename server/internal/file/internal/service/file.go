@@ -169,6 +169,9 @@ func (s *FileService) Upload(ctx context.Context, fileDTO domain.FileDTO) (*doma
 		filename string
 	)
 	fileId := uuidx.RearrangeUUID4()
+	if fileDTO.FileId != "" {
+		fileId = fileDTO.FileId
+	}
 	if fileDTO.CustomFileName != "" {
 		filename = fileDTO.CustomFileName + fileDTO.FileExt
 		file, err := s.repo.FindByFileName(ctx, filename)
@@ -15,6 +15,7 @@
 package file
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/file/internal/domain"
 	"github.com/chenmingyong0423/fnote/server/internal/file/internal/service"
 	"github.com/chenmingyong0423/fnote/server/internal/file/internal/web"
 )
@@ -22,6 +23,7 @@ import (
 type (
 	Handler = web.FileHandler
 	Service = service.IFileService
+	FileDTO = domain.FileDTO
 	Module  struct {
 		Svc Service
 		Hdl *Handler
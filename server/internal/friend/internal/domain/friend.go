@@ -24,7 +24,16 @@ type Friend struct {
 	Priority    int
 	Status      int
 	Ip          string
-	CreatedAt   int64
+	UserAgent   string
+	// 申请时的来源页面，仅用于垃圾内容检查，不保存
+	Referrer string
+	// 是否被反垃圾服务判定为垃圾内容
+	Spam      bool
+	CreatedAt int64
+}
+
+func (f Friend) IsPending() bool {
+	return f.Status == 0
 }
 
 func (f Friend) IsApproved() bool {
@@ -41,7 +41,10 @@ type Friend struct {
 	Email        string       `bson:"email"`
 	Priority     int          `bson:"priority"`
 	Ip           string       `bson:"ip"`
+	UserAgent    string       `bson:"user_agent,omitempty"`
 	Status       FriendStatus `bson:"status"`
+	// 是否被反垃圾服务判定为垃圾内容
+	Spam bool `bson:"spam"`
 }
 
 type FriendStatus int
@@ -163,7 +166,9 @@ func (d *FriendDao) Upsert(ctx context.Context, q bson.D, friend *Friend) error
 			Set("description", friend.Description).
 			Set("email", friend.Email).
 			Set("ip", friend.Ip).
+			Set("user_agent", friend.UserAgent).
 			Set("status", friend.Status).
+			Set("spam", friend.Spam).
 			Build(),
 	).Upsert(ctx)
 	if err != nil {
@@ -140,7 +140,9 @@ func (r *FriendRepository) Save(ctx context.Context, friend domain.Friend) error
 		Description: friend.Description,
 		Email:       friend.Email,
 		Ip:          friend.Ip,
+		UserAgent:   friend.UserAgent,
 		Status:      dao.FriendStatusPending,
+		Spam:        friend.Spam,
 	})
 
 	if err != nil {
@@ -176,6 +178,8 @@ func (r *FriendRepository) toDomainFriend(friend *dao.Friend) domain.Friend {
 		Priority:    friend.Priority,
 		Email:       friend.Email,
 		Ip:          friend.Ip,
+		UserAgent:   friend.UserAgent,
+		Spam:        friend.Spam,
 		CreatedAt:   friend.CreatedAt.Unix(),
 	}
 }
@@ -16,7 +16,11 @@ package service
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/akismet"
 
 	"github.com/chenmingyong0423/fnote/server/internal/friend/internal/domain"
 	"github.com/chenmingyong0423/fnote/server/internal/friend/internal/repository"
@@ -40,14 +44,16 @@ type IFriendService interface {
 
 var _ IFriendService = (*FriendService)(nil)
 
-func NewFriendService(repo repository.IFriendRepository) *FriendService {
+func NewFriendService(repo repository.IFriendRepository, akismetServ akismet.Service) *FriendService {
 	return &FriendService{
-		repo: repo,
+		repo:        repo,
+		akismetServ: akismetServ,
 	}
 }
 
 type FriendService struct {
-	repo repository.IFriendRepository
+	repo        repository.IFriendRepository
+	akismetServ akismet.Service
 }
 
 func (s *FriendService) AdminRejectFriend(ctx context.Context, id string) (string, error) {
@@ -83,18 +89,27 @@ func (s *FriendService) AdminApproveFriend(ctx context.Context, id string) (stri
 	if err != nil {
 		return "", err
 	}
+	go s.feedback(context.WithoutCancel(ctx), friend, false)
 	return friend.Email, nil
 }
 
 func (s *FriendService) AdminDeleteFriend(ctx context.Context, id string) error {
-	_, err := s.repo.FindById(ctx, id)
+	friend, err := s.repo.FindById(ctx, id)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return apiwrap.NewErrorResponseBody(http.StatusNotFound, "friend not found")
 		}
 		return errors.WithMessage(err, "s.repo.FindById failed")
 	}
-	return s.repo.DeleteById(ctx, id)
+	err = s.repo.DeleteById(ctx, id)
+	if err != nil {
+		return err
+	}
+	// 直接删除未审核的申请视为垃圾内容，已审核的友链删除时不做反馈
+	if friend.IsPending() {
+		go s.feedback(context.WithoutCancel(ctx), friend, true)
+	}
+	return nil
 }
 
 func (s *FriendService) AdminUpdateFriend(ctx context.Context, friend domain.Friend) error {
@@ -113,7 +128,16 @@ func (s *FriendService) AdminGetFriends(ctx context.Context, pageDTO domain.Page
 }
 
 func (s *FriendService) ApplyForFriend(ctx context.Context, friend domain.Friend) error {
-	err := s.repo.Save(ctx, friend)
+	result, err := s.akismetServ.CheckSpam(ctx, toSubmission(friend))
+	if err != nil {
+		// 反垃圾服务异常时不影响申请，由管理员审核
+		slog.WarnContext(ctx, "ApplyForFriend: failed to check spam", "error", err)
+	}
+	if result.Discard {
+		return apiwrap.NewErrorResponseBody(http.StatusForbidden, "The application is identified as spam.")
+	}
+	friend.Spam = result.Spam
+	err = s.repo.Save(ctx, friend)
 	if err != nil {
 		return errors.WithMessage(err, "s.repo.Save failed")
 	}
@@ -123,3 +147,33 @@ func (s *FriendService) ApplyForFriend(ctx context.Context, friend domain.Friend
 func (s *FriendService) GetFriends(ctx context.Context) ([]domain.Friend, error) {
 	return s.repo.FindDisplaying(ctx)
 }
+
+// feedback 将管理员的审核结果反馈给反垃圾服务
+func (s *FriendService) feedback(ctx context.Context, friend domain.Friend, spam bool) {
+	var err error
+	if spam {
+		err = s.akismetServ.SubmitSpam(ctx, toSubmission(friend))
+	} else {
+		err = s.akismetServ.SubmitHam(ctx, toSubmission(friend))
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Friend: failed to send feedback to the spam check service", "id", friend.Id, "spam", spam, "error", err)
+	}
+}
+
+func toSubmission(friend domain.Friend) akismet.Submission {
+	submission := akismet.Submission{
+		Type:        "contact-form",
+		UserIp:      friend.Ip,
+		UserAgent:   friend.UserAgent,
+		Referrer:    friend.Referrer,
+		Author:      friend.Name,
+		AuthorEmail: friend.Email,
+		AuthorUrl:   friend.Url,
+		Content:     friend.Description,
+	}
+	if friend.CreatedAt > 0 {
+		submission.CreatedAt = time.Unix(friend.CreatedAt, 0)
+	}
+	return submission
+}
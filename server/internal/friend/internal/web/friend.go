@@ -18,14 +18,12 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"os"
 	"regexp"
 	"strings"
 
 	"github.com/chenmingyong0423/fnote/server/internal/friend/internal/domain"
 	"github.com/chenmingyong0423/fnote/server/internal/friend/internal/service"
 	"github.com/chenmingyong0423/fnote/server/internal/message"
-	"github.com/chenmingyong0423/fnote/server/internal/pkg"
 
 	"github.com/chenmingyong0423/fnote/server/internal/website_config"
 
@@ -108,6 +106,8 @@ func (h *FriendHandler) ApplyForFriend(ctx *gin.Context, req FriendRequest) (*ap
 		Description: req.Description,
 		Email:       req.Email,
 		Ip:          ctx.ClientIP(),
+		UserAgent:   ctx.Request.UserAgent(),
+		Referrer:    ctx.Request.Referer(),
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key error") {
@@ -118,7 +118,7 @@ func (h *FriendHandler) ApplyForFriend(ctx *gin.Context, req FriendRequest) (*ap
 
 	// 发送邮件
 	go func() {
-		gErr := h.msgServ.SendEmailToWebmaster(ctx, "friend", "text/plain")
+		gErr := h.msgServ.NotifyWebmaster(ctx, message.NotificationEventFriend, message.TemplateData{FriendName: req.Name, FriendUrl: req.Url})
 		if gErr != nil {
 			l := slog.Default().With("X-Request-ID", ctx.GetString("X-Request-ID"))
 			l.WarnContext(ctx, fmt.Sprintf("%+v", gErr))
@@ -157,6 +157,7 @@ func (h *FriendHandler) friendToAdminVO(friends []domain.Friend) []AdminFriendVO
 			Logo:        friend.Logo,
 			Description: friend.Description,
 			Status:      friend.Status,
+			Spam:        friend.Spam,
 			CreatedAt:   friend.CreatedAt,
 		})
 	}
@@ -185,7 +186,7 @@ func (h *FriendHandler) AdminApproveFriend(ctx *gin.Context) (*apiwrap.ResponseB
 	}
 	// 发送邮件通知朋友
 	go func() {
-		gErr := h.msgServ.SendEmailWithEmail(ctx, "friend-approval", []string{email}, "text/plain", pkg.GetOrDefault4String(os.Getenv("WEBSITE_BASE_HOST"), "http://localhost:3000")+"/friend")
+		gErr := h.msgServ.SendEmailWithEmail(ctx, "friend-approval", []string{email}, message.TemplateData{})
 		if gErr != nil {
 			l := slog.Default().With("X-Request-ID", ctx.GetString("X-Request-ID"))
 			l.WarnContext(ctx, fmt.Sprintf("%+v", gErr))
@@ -201,7 +202,7 @@ func (h *FriendHandler) AdminRejectFriend(ctx *gin.Context, req FriendRejectReq)
 	}
 	// 发送邮件通知朋友
 	go func() {
-		gErr := h.msgServ.SendEmailWithEmail(ctx, "friend-rejection", []string{email}, "text/plain", pkg.GetOrDefault4String(os.Getenv("WEBSITE_BASE_HOST"), "http://localhost:3000")+"/friend", req.Reason)
+		gErr := h.msgServ.SendEmailWithEmail(ctx, "friend-rejection", []string{email}, message.TemplateData{Reason: req.Reason})
 		if gErr != nil {
 			l := slog.Default().With("X-Request-ID", ctx.GetString("X-Request-ID"))
 			l.WarnContext(ctx, fmt.Sprintf("%+v", gErr))
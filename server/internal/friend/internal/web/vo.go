@@ -32,5 +32,6 @@ type AdminFriendVO struct {
 	Logo        string `json:"logo"`
 	Description string `json:"description"`
 	Status      int    `json:"status"`
+	Spam        bool   `json:"spam"`
 	CreatedAt   int64  `json:"created_at"`
 }
@@ -17,6 +17,7 @@
 package friend
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/akismet"
 	"github.com/chenmingyong0423/fnote/server/internal/friend/internal/repository"
 	"github.com/chenmingyong0423/fnote/server/internal/friend/internal/repository/dao"
 	"github.com/chenmingyong0423/fnote/server/internal/friend/internal/service"
@@ -32,11 +33,12 @@ var FriendProviders = wire.NewSet(web.NewFriendHandler, service.NewFriendService
 	wire.Bind(new(repository.IFriendRepository), new(*repository.FriendRepository)),
 	wire.Bind(new(dao.IFriendDao), new(*dao.FriendDao)))
 
-func InitFriendModule(db *mongox.Database, messageModule *message.Module, cfgModule *website_config.Module) *Module {
+func InitFriendModule(db *mongox.Database, messageModule *message.Module, cfgModule *website_config.Module, akismetModule *akismet.Module) *Module {
 	panic(wire.Build(
 		FriendProviders,
 		wire.FieldsOf(new(*website_config.Module), "Svc"),
 		wire.FieldsOf(new(*message.Module), "Svc"),
+		wire.FieldsOf(new(*akismet.Module), "Svc"),
 		wire.Struct(new(Module), "Svc", "Hdl"),
 	))
 }
@@ -7,6 +7,7 @@
 package friend
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/akismet"
 	"github.com/chenmingyong0423/fnote/server/internal/friend/internal/repository"
 	"github.com/chenmingyong0423/fnote/server/internal/friend/internal/repository/dao"
 	"github.com/chenmingyong0423/fnote/server/internal/friend/internal/service"
@@ -19,10 +20,11 @@ import (
 
 // Injectors from wire.go:
 
-func InitFriendModule(db *mongox.Database, messageModule *message.Module, cfgModule *website_config.Module) *Module {
+func InitFriendModule(db *mongox.Database, messageModule *message.Module, cfgModule *website_config.Module, akismetModule *akismet.Module) *Module {
 	friendDao := dao.NewFriendDao(db)
 	friendRepository := repository.NewFriendRepository(friendDao)
-	friendService := service.NewFriendService(friendRepository)
+	iAkismetService := akismetModule.Svc
+	friendService := service.NewFriendService(friendRepository, iAkismetService)
 	iMessageService := messageModule.Svc
 	iWebsiteConfigService := cfgModule.Svc
 	friendHandler := web.NewFriendHandler(friendService, iMessageService, iWebsiteConfigService)
@@ -22,13 +22,19 @@ import (
 	"strings"
 	"time"
 
+	"github.com/chenmingyong0423/fnote/server/internal/account"
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log"
+
 	"github.com/chenmingyong0423/fnote/server/internal/asset"
 
 	"github.com/chenmingyong0423/fnote/server/internal/backup"
 
 	"github.com/chenmingyong0423/fnote/server/internal/visit_log"
 
+	"github.com/chenmingyong0423/fnote/server/internal/email"
+	"github.com/chenmingyong0423/fnote/server/internal/message"
 	"github.com/chenmingyong0423/fnote/server/internal/message_template"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook"
 
 	"github.com/chenmingyong0423/fnote/server/internal/category"
 	"github.com/chenmingyong0423/fnote/server/internal/tag"
@@ -49,6 +55,8 @@ import (
 	"github.com/chenmingyong0423/fnote/server/internal/post_like"
 
 	"github.com/chenmingyong0423/fnote/server/internal/aggregate_post"
+	"github.com/chenmingyong0423/fnote/server/internal/blog_import"
+	"github.com/chenmingyong0423/fnote/server/internal/post_markdown"
 
 	"github.com/chenmingyong0423/fnote/server/internal/post_draft"
 	"github.com/chenmingyong0423/fnote/server/internal/post_index"
@@ -67,9 +75,13 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
-func NewGinEngine(fileHdr *file.Handler, ctgHdr *category.Handler, cmtHdr *comment.Handler, cfgHdr *website_config.Handler, frdHdr *friend.Handler, postHdr *post.Handler, vlHdr *visit_log.Handler, msgTplHandler *message_template.Handler, tagsHandler *tag.Handler, daHandler *data_analysis.Handler, csHandler *count_stats.Handler, backupHandler *backup.Handler, middleware []gin.HandlerFunc, validators Validators, postIndexHdr *post_index.Handler, postDraftHdr *post_draft.Handler, aggregatePostHdr *aggregate_post.Handler, postLikesHdr *post_like.Handler, postVisitHdr *post_visit.Handler, postAssetHdr *asset.AssetHandler) (*gin.Engine, error) {
+func NewGinEngine(fileHdr *file.Handler, ctgHdr *category.Handler, cmtHdr *comment.Handler, cfgHdr *website_config.Handler, frdHdr *friend.Handler, postHdr *post.Handler, vlHdr *visit_log.Handler, msgTplHandler *message_template.Handler, tagsHandler *tag.Handler, daHandler *data_analysis.Handler, csHandler *count_stats.Handler, backupHandler *backup.Handler, middleware []gin.HandlerFunc, validators Validators, postIndexHdr *post_index.Handler, postDraftHdr *post_draft.Handler, aggregatePostHdr *aggregate_post.Handler, postLikesHdr *post_like.Handler, postVisitHdr *post_visit.Handler, postAssetHdr *asset.AssetHandler, accountHdr *account.Handler, auditLogHdr *audit_log.Handler, postMarkdownHdr *post_markdown.Handler, blogImportHdr *blog_import.Handler, messageHdr *message.Handler, emailHdr *email.Handler, webhookHdr *webhook.Handler) (*gin.Engine, error) {
 	engine := gin.New()
 	engine.Use(gin.Recovery())
+	// 只信任配置的反向代理转发的客户端 IP，否则任何请求都可以通过 X-Forwarded-For 伪造 ClientIP
+	if err := engine.SetTrustedProxies(viper.GetStringSlice("gin.trusted_proxies")); err != nil {
+		return nil, err
+	}
 
 	// 参数校验器注册
 	if validate, ok := binding.Validator.Engine().(*validator.Validate); ok {
@@ -104,11 +116,18 @@ func NewGinEngine(fileHdr *file.Handler, ctgHdr *category.Handler, cmtHdr *comme
 		postLikesHdr.RegisterGinRoutes(engine)
 		postVisitHdr.RegisterGinRoutes(engine)
 		postAssetHdr.RegisterGinRoutes(engine)
+		accountHdr.RegisterGinRoutes(engine)
+		auditLogHdr.RegisterGinRoutes(engine)
+		postMarkdownHdr.RegisterGinRoutes(engine)
+		blogImportHdr.RegisterGinRoutes(engine)
+		messageHdr.RegisterGinRoutes(engine)
+		emailHdr.RegisterGinRoutes(engine)
+		webhookHdr.RegisterGinRoutes(engine)
 	}
 	return engine, nil
 }
 
-func InitMiddlewares(writer io.Writer, isWebsiteInitialized func() bool) []gin.HandlerFunc {
+func InitMiddlewares(writer io.Writer, isWebsiteInitialized func() bool, accountHdr *account.Handler, accountServ account.Service, auditLogHdr *audit_log.Handler) []gin.HandlerFunc {
 	return []gin.HandlerFunc{
 		gin.LoggerWithWriter(writer),
 		id.RequestId(),
@@ -125,7 +144,7 @@ func InitMiddlewares(writer io.Writer, isWebsiteInitialized func() bool) []gin.H
 			default:
 				return slog.LevelInfo
 			}
-		}(viper.GetString("logger.level")), log.WithSkipPaths([]string{"/admin-api/files/upload", "/admin-api/recovery", "/admin-api/backup"}), log.WithSkipFunc(func(ctx *gin.Context) bool {
+		}(viper.GetString("logger.level")), log.WithSkipPaths([]string{"/admin-api/files/upload", "/admin-api/recovery", "/admin-api/backup", "/admin-api/posts/export", "/admin-api/posts/import", "/admin-api/import/hexo", "/admin-api/import/hugo", "/admin-api/import/wordpress"}), log.WithSkipFunc(func(ctx *gin.Context) bool {
 			url := ctx.Request.URL.Path
 			return strings.HasPrefix(url, "/static/")
 		}))),
@@ -153,7 +172,9 @@ func InitMiddlewares(writer io.Writer, isWebsiteInitialized func() bool) []gin.H
 				ctx.Abort()
 			}
 		},
-		JwtParseMiddleware(isWebsiteInitialized),
+		auditLogHdr.AuditMiddleware(),
+		JwtParseMiddleware(isWebsiteInitialized, accountServ.IsSessionRevoked),
+		accountHdr.PermissionMiddleware(),
 	}
 }
 
@@ -15,14 +15,18 @@
 package ioc
 
 import (
+	"context"
+	"log/slog"
+	"net/http"
 	"strings"
 
 	"github.com/chenmingyong0423/fnote/server/internal/pkg/jwtutil"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-// JwtParseMiddleware jwt 解析中间件
-func JwtParseMiddleware(isWebsiteInitialized func() bool) gin.HandlerFunc {
+// JwtParseMiddleware jwt 解析中间件，isTokenRevoked 用于判断 jwt 对应的登录会话是否已被吊销
+func JwtParseMiddleware(isWebsiteInitialized func() bool, isTokenRevoked func(ctx context.Context, tokenId string) (bool, error)) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		uri := ctx.Request.RequestURI
 		if !isWebsiteInitialized() && (uri == "/admin-api/files/upload" || uri == "/admin-api/configs/initialization") {
@@ -35,7 +39,7 @@ func JwtParseMiddleware(isWebsiteInitialized func() bool) gin.HandlerFunc {
 			return
 		}
 		// 这些接口不需要 jwt
-		if uri == "/admin-api/login" || uri == "/admin-api/configs/check-initialization" || uri == "/admin-api/configs/website/meta" {
+		if uri == "/admin-api/login" || uri == "/admin-api/login/2fa" || uri == "/admin-api/token/refresh" || uri == "/admin-api/configs/check-initialization" || uri == "/admin-api/configs/website/meta" {
 			ctx.Next()
 			return
 		}
@@ -51,6 +55,25 @@ func JwtParseMiddleware(isWebsiteInitialized func() bool) gin.HandlerFunc {
 			ctx.AbortWithStatusJSON(401, nil)
 			return
 		}
+		// 校验登录会话是否已被吊销
+		tokenId := ""
+		if registeredClaims, ok := claims.(*jwt.RegisteredClaims); ok {
+			tokenId = registeredClaims.ID
+		}
+		if tokenId == "" {
+			ctx.AbortWithStatusJSON(401, nil)
+			return
+		}
+		revoked, err := isTokenRevoked(ctx, tokenId)
+		if err != nil {
+			slog.Default().With("X-Request-ID", ctx.GetString("X-Request-ID")).ErrorContext(ctx, "JwtParseMiddleware: failed to check whether the token is revoked", "tokenId", tokenId, "error", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, nil)
+			return
+		}
+		if revoked {
+			ctx.AbortWithStatusJSON(401, nil)
+			return
+		}
 		ctx.Set("jwtClaims", claims)
 		ctx.Next()
 	}
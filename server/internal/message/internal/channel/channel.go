@@ -0,0 +1,146 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/netutil"
+)
+
+const (
+	TypeWebhook  = "webhook"
+	TypeTelegram = "telegram"
+	TypeDingTalk = "dingtalk"
+	TypeFeishu   = "feishu"
+
+	requestTimeout = 10 * time.Second
+)
+
+// newHttpClient 渠道地址由后台配置，请求只允许连接公网地址，且不跟随重定向，避免被用来探测内网
+var newHttpClient = func() *http.Client {
+	client := netutil.NewPublicHttpClient(requestTimeout)
+	client.CheckRedirect = netutil.NoRedirect
+	return client
+}
+
+// Message 推送到通知渠道的消息
+type Message struct {
+	// 事件，例如 comment、friend
+	Event   string
+	Title   string
+	Content string
+	// 相关页面的链接，可为空
+	Url string
+}
+
+// Channel 站长通知的推送渠道
+type Channel interface {
+	// Name 渠道名称，用于日志
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config 通知渠道的配置，不同类型只使用各自需要的字段
+type Config struct {
+	Name string
+	Type string
+	// webhook、钉钉、飞书的请求地址；telegram 的 API 地址，为空时使用 https://api.telegram.org
+	Url string
+	// webhook 的签名密钥，钉钉、飞书机器人的加签密钥
+	Secret string
+
+	// telegram
+	BotToken string
+	ChatId   string
+}
+
+func New(cfg Config) (Channel, error) {
+	if cfg.Name == "" {
+		cfg.Name = cfg.Type
+	}
+	switch strings.ToLower(cfg.Type) {
+	case TypeWebhook:
+		return NewWebhookChannel(cfg)
+	case TypeTelegram:
+		return NewTelegramChannel(cfg)
+	case TypeDingTalk:
+		return NewDingTalkChannel(cfg)
+	case TypeFeishu:
+		return NewFeishuChannel(cfg)
+	default:
+		return nil, fmt.Errorf("notification channel %s: unsupported type %q", cfg.Name, cfg.Type)
+	}
+}
+
+// plainText 拼接标题、内容和链接，用于只支持纯文本的渠道
+func (m Message) plainText() string {
+	var sb strings.Builder
+	sb.WriteString(m.Title)
+	if m.Content != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(m.Content)
+	}
+	if m.Url != "" && !strings.Contains(m.Content, m.Url) {
+		sb.WriteString("\n\n")
+		sb.WriteString(m.Url)
+	}
+	return sb.String()
+}
+
+func hmacSha256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// postJson 发送 JSON 请求，非 2xx 响应返回错误，成功时返回响应内容
+func postJson(ctx context.Context, client *http.Client, name, url string, payload any, header http.Header) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("notification channel %s: %w", name, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("notification channel %s: %w", name, err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("User-Agent", "fnote")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("notification channel %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("notification channel %s: %w", name, err)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("notification channel %s: unexpected status %d: %s", name, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+var testMessage = Message{Event: "comment", Title: "新评论", Content: "有人评论了文章", Url: "https://example.com/posts/hello"}
+
+// recordedRequest 模拟的渠道服务收到的请求
+type recordedRequest struct {
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// recorder 记录收到的请求并返回固定的响应
+type recorder struct {
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+func newRecorder(t *testing.T, status int, response string) (*httptest.Server, *recorder) {
+	t.Helper()
+	rec := &recorder{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json; charset=utf-8" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		rec.requests = append(rec.requests, recordedRequest{Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header.Clone(), Body: body})
+		rec.mu.Unlock()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+	// 测试服务监听在回环地址上，使用不限制地址的客户端
+	previous := newHttpClient
+	newHttpClient = srv.Client
+	t.Cleanup(func() { newHttpClient = previous })
+	return srv, rec
+}
+
+// only 返回唯一的一次请求
+func (r *recorder) only(t *testing.T) recordedRequest {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.requests) != 1 {
+		t.Fatalf("received %d requests, want 1", len(r.requests))
+	}
+	return r.requests[0]
+}
+
+func decodeBody(t *testing.T, body []byte) map[string]any {
+	t.Helper()
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("invalid json body %s: %v", body, err)
+	}
+	return payload
+}
+
+func TestMessage_PlainText(t *testing.T) {
+	if got, want := testMessage.plainText(), "新评论\n\n有人评论了文章\n\nhttps://example.com/posts/hello"; got != want {
+		t.Fatalf("plainText() = %q, want %q", got, want)
+	}
+	// 内容中已包含链接时不重复追加
+	msg := Message{Title: "新友链", Content: "https://example.com 申请友链", Url: "https://example.com"}
+	if got, want := msg.plainText(), "新友链\n\nhttps://example.com 申请友链"; got != want {
+		t.Fatalf("plainText() = %q, want %q", got, want)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{Type: "Webhook", Url: "https://example.com/hook"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(Config{Type: "sms"}); err == nil {
+		t.Fatal("New should reject unsupported types")
+	}
+	if _, err := New(Config{Type: TypeDingTalk, Url: "not a url"}); err == nil {
+		t.Fatal("New should reject invalid urls")
+	}
+	if _, err := New(Config{Type: TypeTelegram, BotToken: "token"}); err == nil {
+		t.Fatal("New should require chat_id for telegram")
+	}
+}
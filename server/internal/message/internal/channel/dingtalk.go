@@ -0,0 +1,92 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var _ Channel = (*DingTalkChannel)(nil)
+
+func NewDingTalkChannel(cfg Config) (*DingTalkChannel, error) {
+	if _, err := url.ParseRequestURI(cfg.Url); err != nil {
+		return nil, fmt.Errorf("notification channel %s: invalid url: %w", cfg.Name, err)
+	}
+	return &DingTalkChannel{cfg: cfg, client: newHttpClient()}, nil
+}
+
+// DingTalkChannel 钉钉群自定义机器人，Url 为带 access_token 的 webhook 地址，
+// 安全设置为加签时需要配置 Secret
+type DingTalkChannel struct {
+	cfg    Config
+	client *http.Client
+}
+
+type dingTalkResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (c *DingTalkChannel) Name() string {
+	return c.cfg.Name
+}
+
+func (c *DingTalkChannel) Send(ctx context.Context, msg Message) error {
+	reqUrl, err := c.signedUrl(time.Now())
+	if err != nil {
+		return err
+	}
+	data, err := postJson(ctx, c.client, c.cfg.Name, reqUrl, map[string]any{
+		"msgtype": "text",
+		"text": map[string]string{
+			"content": msg.plainText(),
+		},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	var resp dingTalkResponse
+	if err = json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("notification channel %s: invalid response: %w", c.cfg.Name, err)
+	}
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("notification channel %s: errcode %d: %s", c.cfg.Name, resp.ErrCode, resp.ErrMsg)
+	}
+	return nil
+}
+
+// signedUrl 加签：sign = base64(HMAC-SHA256(secret, timestamp + "\n" + secret))，timestamp 为毫秒
+func (c *DingTalkChannel) signedUrl(now time.Time) (string, error) {
+	if c.cfg.Secret == "" {
+		return c.cfg.Url, nil
+	}
+	u, err := url.Parse(c.cfg.Url)
+	if err != nil {
+		return "", fmt.Errorf("notification channel %s: invalid url: %w", c.cfg.Name, err)
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	q := u.Query()
+	q.Set("timestamp", timestamp)
+	q.Set("sign", base64.StdEncoding.EncodeToString(hmacSha256([]byte(c.cfg.Secret), []byte(timestamp+"\n"+c.cfg.Secret))))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
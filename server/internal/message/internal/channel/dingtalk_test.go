@@ -0,0 +1,82 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDingTalkChannel_Send(t *testing.T) {
+	srv, rec := newRecorder(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	c, err := NewDingTalkChannel(Config{Name: "dingtalk", Url: srv.URL + "/robot/send?access_token=token", Secret: "SECsecret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now().UnixMilli()
+	if err = c.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	req := rec.only(t)
+	if req.Path != "/robot/send" || req.Query.Get("access_token") != "token" {
+		t.Fatalf("unexpected request %s?%s", req.Path, req.Query.Encode())
+	}
+	timestamp := req.Query.Get("timestamp")
+	if ms, err := strconv.ParseInt(timestamp, 10, 64); err != nil || ms < before || ms > time.Now().UnixMilli() {
+		t.Fatalf("timestamp %q should be the current time in milliseconds", timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte("SECsecret"))
+	mac.Write([]byte(timestamp + "\nSECsecret"))
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); req.Query.Get("sign") != want {
+		t.Fatalf("sign = %q, want %q", req.Query.Get("sign"), want)
+	}
+	payload := decodeBody(t, req.Body)
+	if payload["msgtype"] != "text" || payload["text"].(map[string]any)["content"] != testMessage.plainText() {
+		t.Fatalf("unexpected payload %s", req.Body)
+	}
+}
+
+func TestDingTalkChannel_SendWithoutSecret(t *testing.T) {
+	srv, rec := newRecorder(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	c, err := NewDingTalkChannel(Config{Name: "dingtalk", Url: srv.URL + "/robot/send?access_token=token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if req := rec.only(t); req.Query.Has("sign") || req.Query.Has("timestamp") {
+		t.Fatalf("unexpected signature in %s", req.Query.Encode())
+	}
+}
+
+func TestDingTalkChannel_SendError(t *testing.T) {
+	// 钉钉在业务错误时仍返回 200，需要检查 errcode
+	srv, _ := newRecorder(t, http.StatusOK, `{"errcode":310000,"errmsg":"sign not match"}`)
+	c, err := NewDingTalkChannel(Config{Name: "dingtalk", Url: srv.URL, Secret: "wrong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Send(context.Background(), testMessage); err == nil {
+		t.Fatal("Send should fail when errcode is not 0")
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var _ Channel = (*FeishuChannel)(nil)
+
+func NewFeishuChannel(cfg Config) (*FeishuChannel, error) {
+	if _, err := url.ParseRequestURI(cfg.Url); err != nil {
+		return nil, fmt.Errorf("notification channel %s: invalid url: %w", cfg.Name, err)
+	}
+	return &FeishuChannel{cfg: cfg, client: newHttpClient()}, nil
+}
+
+// FeishuChannel 飞书（Lark）群自定义机器人，Url 为机器人的 webhook 地址，
+// 开启签名校验时需要配置 Secret
+type FeishuChannel struct {
+	cfg    Config
+	client *http.Client
+}
+
+type feishuResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func (c *FeishuChannel) Name() string {
+	return c.cfg.Name
+}
+
+func (c *FeishuChannel) Send(ctx context.Context, msg Message) error {
+	payload := map[string]any{
+		"msg_type": "text",
+		"content": map[string]string{
+			"text": msg.plainText(),
+		},
+	}
+	if c.cfg.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		payload["timestamp"] = timestamp
+		payload["sign"] = feishuSign(c.cfg.Secret, timestamp)
+	}
+	data, err := postJson(ctx, c.client, c.cfg.Name, c.cfg.Url, payload, nil)
+	if err != nil {
+		return err
+	}
+	var resp feishuResponse
+	if err = json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("notification channel %s: invalid response: %w", c.cfg.Name, err)
+	}
+	if resp.Code != 0 {
+		return fmt.Errorf("notification channel %s: code %d: %s", c.cfg.Name, resp.Code, resp.Msg)
+	}
+	return nil
+}
+
+// feishuSign 签名：以 timestamp + "\n" + secret 为密钥对空字符串做 HMAC-SHA256 后 base64，timestamp 为秒
+func feishuSign(secret, timestamp string) string {
+	return base64.StdEncoding.EncodeToString(hmacSha256([]byte(timestamp+"\n"+secret), nil))
+}
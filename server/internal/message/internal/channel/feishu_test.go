@@ -0,0 +1,72 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"testing"
+)
+
+func TestFeishuChannel_Send(t *testing.T) {
+	srv, rec := newRecorder(t, http.StatusOK, `{"code":0,"msg":"success"}`)
+	c, err := NewFeishuChannel(Config{Name: "feishu", Url: srv.URL + "/open-apis/bot/v2/hook/token", Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	req := rec.only(t)
+	payload := decodeBody(t, req.Body)
+	if payload["msg_type"] != "text" || payload["content"].(map[string]any)["text"] != testMessage.plainText() {
+		t.Fatalf("unexpected payload %s", req.Body)
+	}
+	timestamp, _ := payload["timestamp"].(string)
+	// 飞书以 timestamp + "\n" + secret 为密钥对空内容签名
+	mac := hmac.New(sha256.New, []byte(timestamp+"\nsecret"))
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); timestamp == "" || payload["sign"] != want {
+		t.Fatalf("sign = %v, want %q (timestamp %q)", payload["sign"], want, timestamp)
+	}
+}
+
+func TestFeishuChannel_SendWithoutSecret(t *testing.T) {
+	srv, rec := newRecorder(t, http.StatusOK, `{"code":0,"msg":"success"}`)
+	c, err := NewFeishuChannel(Config{Name: "feishu", Url: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if payload := decodeBody(t, rec.only(t).Body); payload["sign"] != nil || payload["timestamp"] != nil {
+		t.Fatalf("unexpected signature in %v", payload)
+	}
+}
+
+func TestFeishuChannel_SendError(t *testing.T) {
+	srv, _ := newRecorder(t, http.StatusOK, `{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`)
+	c, err := NewFeishuChannel(Config{Name: "feishu", Url: srv.URL, Secret: "wrong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Send(context.Background(), testMessage); err == nil {
+		t.Fatal("Send should fail when code is not 0")
+	}
+}
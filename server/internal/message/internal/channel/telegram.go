@@ -0,0 +1,79 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const telegramApiUrl = "https://api.telegram.org"
+
+var _ Channel = (*TelegramChannel)(nil)
+
+func NewTelegramChannel(cfg Config) (*TelegramChannel, error) {
+	if cfg.BotToken == "" || cfg.ChatId == "" {
+		return nil, fmt.Errorf("notification channel %s: bot_token and chat_id are required", cfg.Name)
+	}
+	if cfg.Url == "" {
+		cfg.Url = telegramApiUrl
+	}
+	return &TelegramChannel{cfg: cfg, client: newHttpClient()}, nil
+}
+
+// TelegramChannel 通过 Telegram 机器人的 sendMessage 接口发送消息，
+// 国内网络无法直连时可将 Url 配置为反向代理的地址
+type TelegramChannel struct {
+	cfg    Config
+	client *http.Client
+}
+
+type telegramResponse struct {
+	Ok          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+func (c *TelegramChannel) Name() string {
+	return c.cfg.Name
+}
+
+func (c *TelegramChannel) Send(ctx context.Context, msg Message) error {
+	reqUrl := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(c.cfg.Url, "/"), c.cfg.BotToken)
+	data, err := postJson(ctx, c.client, c.cfg.Name, reqUrl, map[string]any{
+		"chat_id":                  c.cfg.ChatId,
+		"text":                     msg.plainText(),
+		"disable_web_page_preview": true,
+	}, nil)
+	if err != nil {
+		// 错误信息中的地址包含 bot token，不能直接返回
+		return redactBotToken(c.cfg.BotToken, err)
+	}
+	var resp telegramResponse
+	if err = json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("notification channel %s: invalid response: %w", c.cfg.Name, err)
+	}
+	if !resp.Ok {
+		return fmt.Errorf("notification channel %s: %s", c.cfg.Name, resp.Description)
+	}
+	return nil
+}
+
+func redactBotToken(token string, err error) error {
+	return errors.New(strings.ReplaceAll(err.Error(), token, "***"))
+}
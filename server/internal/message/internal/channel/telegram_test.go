@@ -0,0 +1,67 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTelegramChannel_Send(t *testing.T) {
+	srv, rec := newRecorder(t, http.StatusOK, `{"ok":true}`)
+	c, err := NewTelegramChannel(Config{Name: "telegram", Url: srv.URL + "/", BotToken: "123:abc", ChatId: "-100"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	req := rec.only(t)
+	if req.Path != "/bot123:abc/sendMessage" {
+		t.Fatalf("unexpected path %s", req.Path)
+	}
+	payload := decodeBody(t, req.Body)
+	if payload["chat_id"] != "-100" || payload["text"] != testMessage.plainText() || payload["disable_web_page_preview"] != true {
+		t.Fatalf("unexpected payload %s", req.Body)
+	}
+}
+
+func TestTelegramChannel_SendError(t *testing.T) {
+	srv, _ := newRecorder(t, http.StatusOK, `{"ok":false,"description":"Bad Request: chat not found"}`)
+	c, err := NewTelegramChannel(Config{Name: "telegram", Url: srv.URL, BotToken: "123:abc", ChatId: "-100"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Send(context.Background(), testMessage); err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Fatalf("Send error = %v, want the description from telegram", err)
+	}
+}
+
+func TestTelegramChannel_SendRedactsBotToken(t *testing.T) {
+	srv, _ := newRecorder(t, http.StatusOK, `{"ok":true}`)
+	c, err := NewTelegramChannel(Config{Name: "telegram", Url: srv.URL, BotToken: "123:abc", ChatId: "-100"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 连接失败时错误信息中包含请求地址
+	srv.Close()
+	err = c.Send(context.Background(), testMessage)
+	if err == nil || strings.Contains(err.Error(), "123:abc") {
+		t.Fatalf("Send error = %v, want an error without the bot token", err)
+	}
+}
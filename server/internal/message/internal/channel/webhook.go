@@ -0,0 +1,79 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var _ Channel = (*WebhookChannel)(nil)
+
+func NewWebhookChannel(cfg Config) (*WebhookChannel, error) {
+	if _, err := url.ParseRequestURI(cfg.Url); err != nil {
+		return nil, fmt.Errorf("notification channel %s: invalid url: %w", cfg.Name, err)
+	}
+	return &WebhookChannel{cfg: cfg, client: newHttpClient()}, nil
+}
+
+// WebhookChannel 以 JSON 将消息 POST 到任意地址。
+// 配置了密钥时，请求头 X-Fnote-Signature 为 sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))，
+// X-Fnote-Timestamp 为秒级时间戳，接收方可据此校验来源并拒绝过期的请求
+type WebhookChannel struct {
+	cfg    Config
+	client *http.Client
+}
+
+type webhookPayload struct {
+	Event     string `json:"event"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	Url       string `json:"url,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func (c *WebhookChannel) Name() string {
+	return c.cfg.Name
+}
+
+func (c *WebhookChannel) Send(ctx context.Context, msg Message) error {
+	timestamp := time.Now().Unix()
+	payload := webhookPayload{
+		Event:     msg.Event,
+		Title:     msg.Title,
+		Content:   msg.Content,
+		Url:       msg.Url,
+		Timestamp: timestamp,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("notification channel %s: %w", c.cfg.Name, err)
+	}
+	header := http.Header{}
+	if c.cfg.Secret != "" {
+		ts := strconv.FormatInt(timestamp, 10)
+		header.Set("X-Fnote-Timestamp", ts)
+		header.Set("X-Fnote-Signature", "sha256="+hex.EncodeToString(hmacSha256([]byte(c.cfg.Secret), append([]byte(ts+"."), body...))))
+	}
+	// 签名基于 body 的原始字节，直接发送，避免再次编码
+	_, err = postJson(ctx, c.client, c.cfg.Name, c.cfg.Url, json.RawMessage(body), header)
+	return err
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channel
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestWebhookChannel_Send(t *testing.T) {
+	srv, rec := newRecorder(t, http.StatusNoContent, "")
+	c, err := NewWebhookChannel(Config{Name: "hook", Url: srv.URL + "/hook", Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	req := rec.only(t)
+	payload := decodeBody(t, req.Body)
+	if payload["event"] != "comment" || payload["title"] != testMessage.Title || payload["content"] != testMessage.Content || payload["url"] != testMessage.Url {
+		t.Fatalf("unexpected payload %s", req.Body)
+	}
+	ts := req.Header.Get("X-Fnote-Timestamp")
+	if ts != strconv.FormatInt(int64(payload["timestamp"].(float64)), 10) {
+		t.Fatalf("X-Fnote-Timestamp %q does not match the payload timestamp %v", ts, payload["timestamp"])
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(ts + "."))
+	mac.Write(req.Body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); req.Header.Get("X-Fnote-Signature") != want {
+		t.Fatalf("X-Fnote-Signature = %q, want %q", req.Header.Get("X-Fnote-Signature"), want)
+	}
+}
+
+func TestWebhookChannel_SendWithoutSecret(t *testing.T) {
+	srv, rec := newRecorder(t, http.StatusOK, "")
+	c, err := NewWebhookChannel(Config{Name: "hook", Url: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if req := rec.only(t); req.Header.Get("X-Fnote-Signature") != "" || req.Header.Get("X-Fnote-Timestamp") != "" {
+		t.Fatalf("unexpected signature headers %v", req.Header)
+	}
+}
+
+func TestWebhookChannel_SendError(t *testing.T) {
+	srv, _ := newRecorder(t, http.StatusBadGateway, "bad gateway")
+	c, err := NewWebhookChannel(Config{Name: "hook", Url: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Send(context.Background(), testMessage); err == nil {
+		t.Fatal("Send should fail on a non-2xx response")
+	}
+}
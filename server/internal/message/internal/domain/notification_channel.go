@@ -0,0 +1,51 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import "time"
+
+const (
+	// NotificationEventComment 新的评论或回复
+	NotificationEventComment = "comment"
+	// NotificationEventFriend 新的友链申请
+	NotificationEventFriend = "friend"
+)
+
+// NotificationChannel 站长通知的推送渠道，只推送 Events 中订阅的事件
+type NotificationChannel struct {
+	Id      string
+	Name    string
+	Type    string
+	Enabled bool
+	Events  []string
+	// webhook、钉钉、飞书的地址，telegram 的 API 地址
+	Url string
+	// 签名密钥
+	Secret   string
+	BotToken string
+	ChatId   string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (c *NotificationChannel) Subscribes(event string) bool {
+	for _, e := range c.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
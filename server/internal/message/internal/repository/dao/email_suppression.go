@@ -0,0 +1,91 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/update"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// EmailSuppression 不再接收邮件通知的邮箱，以小写的邮箱地址为 _id
+type EmailSuppression struct {
+	Email     string    `bson:"_id"`
+	Reason    string    `bson:"reason"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type IEmailSuppressionDao interface {
+	// Upsert 添加退订记录，已存在时保留原有记录
+	Upsert(ctx context.Context, suppression *EmailSuppression) error
+	FindByEmail(ctx context.Context, email string) (*EmailSuppression, error)
+	FindAll(ctx context.Context) ([]*EmailSuppression, error)
+	DeleteByEmail(ctx context.Context, email string) (int64, error)
+	IUnsubscribeTokenDao
+}
+
+var _ IEmailSuppressionDao = (*EmailSuppressionDao)(nil)
+
+func NewEmailSuppressionDao(db *mongox.Database) *EmailSuppressionDao {
+	return &EmailSuppressionDao{
+		coll:      mongox.NewCollection[EmailSuppression](db, "email_suppressions"),
+		tokenColl: mongox.NewCollection[UnsubscribeToken](db, "unsubscribe_tokens"),
+	}
+}
+
+type EmailSuppressionDao struct {
+	coll      *mongox.Collection[EmailSuppression]
+	tokenColl *mongox.Collection[UnsubscribeToken]
+}
+
+func (d *EmailSuppressionDao) Upsert(ctx context.Context, suppression *EmailSuppression) error {
+	_, err := d.coll.Updater().Filter(query.Id(suppression.Email)).
+		Updates(update.NewBuilder().SetOnInsert("reason", suppression.Reason).SetOnInsert("created_at", suppression.CreatedAt).Build()).
+		Upsert(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to upsert email suppression, email=%s", suppression.Email)
+	}
+	return nil
+}
+
+func (d *EmailSuppressionDao) FindByEmail(ctx context.Context, email string) (*EmailSuppression, error) {
+	suppression, err := d.coll.Finder().Filter(query.Id(email)).FindOne(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find email suppression, email=%s", email)
+	}
+	return suppression, nil
+}
+
+func (d *EmailSuppressionDao) FindAll(ctx context.Context) ([]*EmailSuppression, error) {
+	suppressions, err := d.coll.Finder().Filter(bson.D{}).Find(ctx, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "fails to find email suppressions")
+	}
+	return suppressions, nil
+}
+
+func (d *EmailSuppressionDao) DeleteByEmail(ctx context.Context, email string) (int64, error) {
+	result, err := d.coll.Deleter().Filter(query.Id(email)).DeleteOne(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to delete email suppression, email=%s", email)
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/update"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type NotificationChannel struct {
+	mongox.Model `bson:",inline"`
+	Name         string   `bson:"name"`
+	Type         string   `bson:"type"`
+	Enabled      bool     `bson:"enabled"`
+	Events       []string `bson:"events"`
+	Url          string   `bson:"url,omitempty"`
+	Secret       string   `bson:"secret,omitempty"`
+	BotToken     string   `bson:"bot_token,omitempty"`
+	ChatId       string   `bson:"chat_id,omitempty"`
+}
+
+type INotificationChannelDao interface {
+	Insert(ctx context.Context, channel *NotificationChannel) (string, error)
+	// Update 更新渠道配置，返回匹配的数量
+	Update(ctx context.Context, channel *NotificationChannel) (int64, error)
+	DeleteById(ctx context.Context, id bson.ObjectID) (int64, error)
+	FindById(ctx context.Context, id bson.ObjectID) (*NotificationChannel, error)
+	FindAll(ctx context.Context) ([]*NotificationChannel, error)
+	// FindEnabledByEvent 查询已启用且订阅了 event 的渠道
+	FindEnabledByEvent(ctx context.Context, event string) ([]*NotificationChannel, error)
+}
+
+var _ INotificationChannelDao = (*NotificationChannelDao)(nil)
+
+func NewNotificationChannelDao(db *mongox.Database) *NotificationChannelDao {
+	return &NotificationChannelDao{coll: mongox.NewCollection[NotificationChannel](db, "notification_channels")}
+}
+
+type NotificationChannelDao struct {
+	coll *mongox.Collection[NotificationChannel]
+}
+
+func (d *NotificationChannelDao) Insert(ctx context.Context, channel *NotificationChannel) (string, error) {
+	result, err := d.coll.Creator().InsertOne(ctx, channel)
+	if err != nil {
+		return "", errors.Wrapf(err, "fails to insert notification channel, name=%s", channel.Name)
+	}
+	return result.InsertedID.(bson.ObjectID).Hex(), nil
+}
+
+func (d *NotificationChannelDao) Update(ctx context.Context, channel *NotificationChannel) (int64, error) {
+	u := update.NewBuilder().
+		Set("name", channel.Name).
+		Set("type", channel.Type).
+		Set("enabled", channel.Enabled).
+		Set("events", channel.Events).
+		Set("url", channel.Url).
+		Set("secret", channel.Secret).
+		Set("bot_token", channel.BotToken).
+		Set("chat_id", channel.ChatId).
+		Set("updated_at", channel.UpdatedAt).
+		Build()
+	result, err := d.coll.Updater().Filter(query.Id(channel.ID)).Updates(u).UpdateOne(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to update notification channel, id=%s", channel.ID.Hex())
+	}
+	return result.MatchedCount, nil
+}
+
+func (d *NotificationChannelDao) DeleteById(ctx context.Context, id bson.ObjectID) (int64, error) {
+	result, err := d.coll.Deleter().Filter(query.Id(id)).DeleteOne(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to delete notification channel, id=%s", id.Hex())
+	}
+	return result.DeletedCount, nil
+}
+
+func (d *NotificationChannelDao) FindById(ctx context.Context, id bson.ObjectID) (*NotificationChannel, error) {
+	channel, err := d.coll.Finder().Filter(query.Id(id)).FindOne(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find notification channel, id=%s", id.Hex())
+	}
+	return channel, nil
+}
+
+func (d *NotificationChannelDao) FindAll(ctx context.Context) ([]*NotificationChannel, error) {
+	channels, err := d.coll.Finder().Filter(bson.D{}).Find(ctx, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "fails to find notification channels")
+	}
+	return channels, nil
+}
+
+func (d *NotificationChannelDao) FindEnabledByEvent(ctx context.Context, event string) ([]*NotificationChannel, error) {
+	channels, err := d.coll.Finder().Filter(query.NewBuilder().Eq("enabled", true).Eq("events", event).Build()).Find(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find notification channels, event=%s", event)
+	}
+	return channels, nil
+}
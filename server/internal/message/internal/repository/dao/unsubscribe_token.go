@@ -0,0 +1,58 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/update"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// UnsubscribeToken 邮箱的退订令牌，以随机令牌为 _id，每个邮箱只有一个令牌且长期有效，
+// 与登录使用的 jwt 密钥无关，密钥轮换后已发出的退订链接仍然可用
+type UnsubscribeToken struct {
+	Token     string    `bson:"_id"`
+	Email     string    `bson:"email"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type IUnsubscribeTokenDao interface {
+	// FindOrCreateToken 返回邮箱已有的令牌，不存在时以 token 创建
+	FindOrCreateToken(ctx context.Context, email string, token string, now time.Time) (*UnsubscribeToken, error)
+	FindByToken(ctx context.Context, token string) (*UnsubscribeToken, error)
+}
+
+func (d *EmailSuppressionDao) FindOrCreateToken(ctx context.Context, email string, token string, now time.Time) (*UnsubscribeToken, error) {
+	unsubscribeToken, err := d.tokenColl.Finder().
+		Filter(query.Eq("email", email)).
+		Updates(update.NewBuilder().SetOnInsert("_id", token).SetOnInsert("created_at", now).Build()).
+		FindOneAndUpdate(ctx, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After))
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find or create unsubscribe token, email=%s", email)
+	}
+	return unsubscribeToken, nil
+}
+
+func (d *EmailSuppressionDao) FindByToken(ctx context.Context, token string) (*UnsubscribeToken, error) {
+	unsubscribeToken, err := d.tokenColl.Finder().Filter(query.Id(token)).FindOne(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "fails to find unsubscribe token")
+	}
+	return unsubscribeToken, nil
+}
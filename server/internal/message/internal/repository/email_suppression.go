@@ -0,0 +1,95 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/repository/dao"
+	"github.com/chenmingyong0423/gkit/slice"
+)
+
+type IEmailSuppressionRepository interface {
+	AddSuppression(ctx context.Context, email string, reason string, now time.Time) error
+	FindSuppressionByEmail(ctx context.Context, email string) (*domain.EmailSuppression, error)
+	FindSuppressions(ctx context.Context) ([]domain.EmailSuppression, error)
+	DeleteSuppression(ctx context.Context, email string) (int64, error)
+	// GetOrCreateUnsubscribeToken 返回邮箱的退订令牌，不存在时以 token 创建
+	GetOrCreateUnsubscribeToken(ctx context.Context, email string, token string, now time.Time) (string, error)
+	// FindEmailByUnsubscribeToken 返回令牌对应的邮箱，令牌不存在时返回 mongo.ErrNoDocuments
+	FindEmailByUnsubscribeToken(ctx context.Context, token string) (string, error)
+}
+
+var _ IEmailSuppressionRepository = (*EmailSuppressionRepository)(nil)
+
+func NewEmailSuppressionRepository(dao dao.IEmailSuppressionDao) *EmailSuppressionRepository {
+	return &EmailSuppressionRepository{dao: dao}
+}
+
+type EmailSuppressionRepository struct {
+	dao dao.IEmailSuppressionDao
+}
+
+func (r *EmailSuppressionRepository) AddSuppression(ctx context.Context, email string, reason string, now time.Time) error {
+	return r.dao.Upsert(ctx, &dao.EmailSuppression{Email: email, Reason: reason, CreatedAt: now})
+}
+
+func (r *EmailSuppressionRepository) FindSuppressionByEmail(ctx context.Context, email string) (*domain.EmailSuppression, error) {
+	suppression, err := r.dao.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(suppression), nil
+}
+
+func (r *EmailSuppressionRepository) FindSuppressions(ctx context.Context) ([]domain.EmailSuppression, error) {
+	suppressions, err := r.dao.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(suppressions, func(_ int, s *dao.EmailSuppression) domain.EmailSuppression {
+		return *r.toDomain(s)
+	}), nil
+}
+
+func (r *EmailSuppressionRepository) DeleteSuppression(ctx context.Context, email string) (int64, error) {
+	return r.dao.DeleteByEmail(ctx, email)
+}
+
+func (r *EmailSuppressionRepository) GetOrCreateUnsubscribeToken(ctx context.Context, email string, token string, now time.Time) (string, error) {
+	unsubscribeToken, err := r.dao.FindOrCreateToken(ctx, email, token, now)
+	if err != nil {
+		return "", err
+	}
+	return unsubscribeToken.Token, nil
+}
+
+func (r *EmailSuppressionRepository) FindEmailByUnsubscribeToken(ctx context.Context, token string) (string, error) {
+	unsubscribeToken, err := r.dao.FindByToken(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return unsubscribeToken.Email, nil
+}
+
+func (r *EmailSuppressionRepository) toDomain(suppression *dao.EmailSuppression) *domain.EmailSuppression {
+	return &domain.EmailSuppression{
+		Email:     suppression.Email,
+		Reason:    suppression.Reason,
+		CreatedAt: suppression.CreatedAt,
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/repository/dao"
+	"github.com/chenmingyong0423/gkit/slice"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type INotificationChannelRepository interface {
+	AddChannel(ctx context.Context, channel domain.NotificationChannel, now time.Time) (string, error)
+	UpdateChannel(ctx context.Context, channel domain.NotificationChannel, now time.Time) (int64, error)
+	DeleteChannel(ctx context.Context, id string) (int64, error)
+	FindChannelById(ctx context.Context, id string) (*domain.NotificationChannel, error)
+	FindChannels(ctx context.Context) ([]domain.NotificationChannel, error)
+	FindEnabledChannelsByEvent(ctx context.Context, event string) ([]domain.NotificationChannel, error)
+}
+
+var _ INotificationChannelRepository = (*NotificationChannelRepository)(nil)
+
+func NewNotificationChannelRepository(dao dao.INotificationChannelDao) *NotificationChannelRepository {
+	return &NotificationChannelRepository{dao: dao}
+}
+
+type NotificationChannelRepository struct {
+	dao dao.INotificationChannelDao
+}
+
+func (r *NotificationChannelRepository) AddChannel(ctx context.Context, channel domain.NotificationChannel, now time.Time) (string, error) {
+	c := r.toDao(channel)
+	c.CreatedAt, c.UpdatedAt = now, now
+	return r.dao.Insert(ctx, c)
+}
+
+func (r *NotificationChannelRepository) UpdateChannel(ctx context.Context, channel domain.NotificationChannel, now time.Time) (int64, error) {
+	id, err := bson.ObjectIDFromHex(channel.Id)
+	if err != nil {
+		return 0, err
+	}
+	c := r.toDao(channel)
+	c.ID, c.UpdatedAt = id, now
+	return r.dao.Update(ctx, c)
+}
+
+func (r *NotificationChannelRepository) DeleteChannel(ctx context.Context, id string) (int64, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, err
+	}
+	return r.dao.DeleteById(ctx, objectID)
+}
+
+func (r *NotificationChannelRepository) FindChannelById(ctx context.Context, id string) (*domain.NotificationChannel, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	channel, err := r.dao.FindById(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(channel), nil
+}
+
+func (r *NotificationChannelRepository) FindChannels(ctx context.Context) ([]domain.NotificationChannel, error) {
+	channels, err := r.dao.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomains(channels), nil
+}
+
+func (r *NotificationChannelRepository) FindEnabledChannelsByEvent(ctx context.Context, event string) ([]domain.NotificationChannel, error) {
+	channels, err := r.dao.FindEnabledByEvent(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomains(channels), nil
+}
+
+func (r *NotificationChannelRepository) toDao(channel domain.NotificationChannel) *dao.NotificationChannel {
+	return &dao.NotificationChannel{
+		Name:     channel.Name,
+		Type:     channel.Type,
+		Enabled:  channel.Enabled,
+		Events:   channel.Events,
+		Url:      channel.Url,
+		Secret:   channel.Secret,
+		BotToken: channel.BotToken,
+		ChatId:   channel.ChatId,
+	}
+}
+
+func (r *NotificationChannelRepository) toDomains(channels []*dao.NotificationChannel) []domain.NotificationChannel {
+	return slice.Map(channels, func(_ int, c *dao.NotificationChannel) domain.NotificationChannel {
+		return *r.toDomain(c)
+	})
+}
+
+func (r *NotificationChannelRepository) toDomain(channel *dao.NotificationChannel) *domain.NotificationChannel {
+	return &domain.NotificationChannel{
+		Id:        channel.ID.Hex(),
+		Name:      channel.Name,
+		Type:      channel.Type,
+		Enabled:   channel.Enabled,
+		Events:    channel.Events,
+		Url:       channel.Url,
+		Secret:    channel.Secret,
+		BotToken:  channel.BotToken,
+		ChatId:    channel.ChatId,
+		CreatedAt: channel.CreatedAt,
+		UpdatedAt: channel.UpdatedAt,
+	}
+}
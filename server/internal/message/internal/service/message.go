@@ -16,9 +16,24 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/chenmingyong0423/fnote/server/internal/email"
 	emailPkg "github.com/chenmingyong0423/fnote/server/internal/email"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/channel"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/repository"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 
 	"github.com/chenmingyong0423/fnote/server/internal/message_template"
 
@@ -26,64 +41,218 @@ import (
 )
 
 type IMessageService interface {
-	SendEmailWithEmail(ctx context.Context, msgTplName string, email []string, contentType string, args ...any) error
-	SendEmailToWebmaster(ctx context.Context, msgTplName, contentType string) error
+	// SendEmailWithEmail 使用 data 渲染模板后发送给指定邮箱
+	SendEmailWithEmail(ctx context.Context, msgTplName string, email []string, data message_template.TemplateData) error
+	SendEmailToWebmaster(ctx context.Context, msgTplName string, data message_template.TemplateData) error
+	// NotifyWebmaster 通过邮件以及订阅了 event 的通知渠道通知站长，event 同时也是消息模板的名称
+	NotifyWebmaster(ctx context.Context, event string, data message_template.TemplateData) error
+	// SendNotificationEmail 向用户发送可退订的通知邮件，邮件中附带退订链接，已退订的邮箱不会收到邮件
+	SendNotificationEmail(ctx context.Context, msgTplName string, email string, data message_template.TemplateData) error
+	// ParseUnsubscribeToken 校验退订令牌，返回对应的邮箱
+	ParseUnsubscribeToken(ctx context.Context, token string) (string, error)
+	// Unsubscribe 根据退订令牌将邮箱加入退订名单
+	Unsubscribe(ctx context.Context, token string) (string, error)
+	IsEmailSuppressed(ctx context.Context, email string) (bool, error)
+	GetEmailSuppressions(ctx context.Context) ([]domain.EmailSuppression, error)
+	DeleteEmailSuppression(ctx context.Context, email string) error
 }
 
 var (
 	_ IMessageService = (*MessageService)(nil)
+
+	ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
 )
 
-func NewMessageService(configServ website_config.Service, emailServ email.Service, msgTplService message_template.Service) *MessageService {
+// unsubscribeTokenSize 退订令牌的随机字节数
+const unsubscribeTokenSize = 32
+
+func NewMessageService(configServ website_config.Service, emailServ email.Service, msgTplService message_template.Service, suppressionRepo repository.IEmailSuppressionRepository, channelServ INotificationChannelService) *MessageService {
 	return &MessageService{
-		configServ:    configServ,
-		emailServ:     emailServ,
-		msgTplService: msgTplService,
+		configServ:      configServ,
+		emailServ:       emailServ,
+		msgTplService:   msgTplService,
+		suppressionRepo: suppressionRepo,
+		channelServ:     channelServ,
 	}
 }
 
 type MessageService struct {
-	configServ    website_config.Service
-	emailServ     email.Service
-	msgTplService message_template.Service
+	configServ      website_config.Service
+	emailServ       email.Service
+	msgTplService   message_template.Service
+	suppressionRepo repository.IEmailSuppressionRepository
+	channelServ     INotificationChannelService
 }
 
-func (s *MessageService) SendEmailToWebmaster(ctx context.Context, msgTplName, contentType string) error {
-	return s.sendEmail(ctx, msgTplName, contentType, 0, nil)
+func (s *MessageService) SendEmailToWebmaster(ctx context.Context, msgTplName string, data message_template.TemplateData) error {
+	return s.sendEmail(ctx, msgTplName, 0, nil, data)
 }
 
-func (s *MessageService) sendEmail(ctx context.Context, msgTplName, contentType string, recipientType uint, email []string, args ...any) error {
-	emailCfg, err := s.configServ.GetEmailConfig(ctx)
+func (s *MessageService) NotifyWebmaster(ctx context.Context, event string, data message_template.TemplateData) error {
+	e, _, err := s.buildEmail(ctx, event, 0, nil, data)
 	if err != nil {
 		return err
 	}
-	webNMasterCfg, err := s.configServ.GetWebSiteConfig(ctx)
+	var emailErr error
+	// 未配置站长邮箱时只推送到通知渠道
+	if e.To[0] != "" {
+		_, emailErr = s.emailServ.EnqueueEmail(ctx, *e)
+	}
+	// 渠道只支持纯文本，使用模板的纯文本部分
+	channelErr := s.channelServ.Dispatch(ctx, channel.Message{
+		Event:   event,
+		Title:   e.Subject,
+		Content: e.Body,
+		Url:     pkg.GetOrDefault4String(data.PostUrl, data.FriendUrl),
+	})
+	return stderrors.Join(emailErr, channelErr)
+}
+
+func (s *MessageService) sendEmail(ctx context.Context, msgTplName string, recipientType uint, email []string, data message_template.TemplateData) error {
+	e, _, err := s.buildEmail(ctx, msgTplName, recipientType, email, data)
 	if err != nil {
 		return err
 	}
+	_, err = s.emailServ.EnqueueEmail(ctx, *e)
+	return err
+}
+
+// buildEmail 查找模板并用 data 渲染出邮件，SiteName、SiteUrl 未指定时自动填充
+func (s *MessageService) buildEmail(ctx context.Context, msgTplName string, recipientType uint, email []string, data message_template.TemplateData) (*emailPkg.Email, *message_template.MessageTemplate, error) {
+	emailCfg, err := s.configServ.GetEmailConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	webNMasterCfg, err := s.configServ.GetWebSiteConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 	if email == nil {
 		email = []string{emailCfg.Email}
 	}
 	msgTpl, err := s.msgTplService.FindMsgTplByNameAndRcpType(ctx, msgTplName, recipientType)
+	if err != nil {
+		return nil, nil, err
+	}
+	if data.SiteName == "" {
+		data.SiteName = webNMasterCfg.WebsiteName
+	}
+	if data.SiteUrl == "" {
+		data.SiteUrl = websiteBaseHost()
+	}
+	msg, err := msgTpl.Render(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	// SMTP 配置由发件箱在发送时读取
+	return &emailPkg.Email{
+		Name:     webNMasterCfg.WebsiteName,
+		To:       email,
+		Subject:  msg.Subject,
+		Body:     msg.TextBody,
+		HtmlBody: msg.HtmlBody,
+	}, msgTpl, nil
+}
+
+func (s *MessageService) SendNotificationEmail(ctx context.Context, msgTplName string, email string, data message_template.TemplateData) error {
+	email = normalizeEmail(email)
+	suppressed, err := s.IsEmailSuppressed(ctx, email)
 	if err != nil {
 		return err
 	}
-	if len(args) > 0 {
-		msgTpl.FormatContent(args...)
-	}
-	return s.emailServ.SendEmail(ctx, emailPkg.Email{
-		Host:        emailCfg.Host,
-		Port:        emailCfg.Port,
-		Username:    emailCfg.Username,
-		Password:    emailCfg.Password,
-		Name:        webNMasterCfg.WebsiteName,
-		To:          email,
-		Subject:     msgTpl.Title,
-		Body:        msgTpl.Content,
-		ContentType: contentType,
-	})
+	if suppressed {
+		return nil
+	}
+	token, err := s.unsubscribeToken(ctx, email)
+	if err != nil {
+		return err
+	}
+	data.UnsubscribeUrl = fmt.Sprintf("%s/api/notifications/unsubscribe?token=%s", websiteBaseHost(), url.QueryEscape(token))
+	e, msgTpl, err := s.buildEmail(ctx, msgTplName, 1, []string{email}, data)
+	if err != nil {
+		return err
+	}
+	// 模板自身未引用退订链接时，在正文末尾追加
+	if !msgTpl.UsesVariable("UnsubscribeUrl") {
+		e.Body += fmt.Sprintf("\n\n不想再收到此类邮件？点击退订：%s", data.UnsubscribeUrl)
+		if e.HtmlBody != "" {
+			e.HtmlBody += fmt.Sprintf(`<p style="font-size:12px;color:#999">不想再收到此类邮件？<a href="%s">退订</a></p>`, html.EscapeString(data.UnsubscribeUrl))
+		}
+	}
+	// RFC 8058 一键退订，邮件客户端会直接 POST 该链接
+	e.Headers = map[string]string{
+		"List-Unsubscribe":      "<" + data.UnsubscribeUrl + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+	_, err = s.emailServ.EnqueueEmail(ctx, *e)
+	return err
+}
+
+// unsubscribeToken 返回邮箱的退订令牌，同一邮箱的所有邮件使用同一个令牌
+func (s *MessageService) unsubscribeToken(ctx context.Context, email string) (string, error) {
+	b := make([]byte, unsubscribeTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate unsubscribe token")
+	}
+	return s.suppressionRepo.GetOrCreateUnsubscribeToken(ctx, email, base64.RawURLEncoding.EncodeToString(b), time.Now().Local())
+}
+
+func (s *MessageService) ParseUnsubscribeToken(ctx context.Context, token string) (string, error) {
+	if token == "" {
+		return "", ErrInvalidUnsubscribeToken
+	}
+	email, err := s.suppressionRepo.FindEmailByUnsubscribeToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", ErrInvalidUnsubscribeToken
+		}
+		return "", err
+	}
+	return email, nil
+}
+
+func (s *MessageService) Unsubscribe(ctx context.Context, token string) (string, error) {
+	email, err := s.ParseUnsubscribeToken(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return email, s.suppressionRepo.AddSuppression(ctx, email, domain.SuppressionReasonUnsubscribe, time.Now().Local())
+}
+
+func (s *MessageService) IsEmailSuppressed(ctx context.Context, email string) (bool, error) {
+	_, err := s.suppressionRepo.FindSuppressionByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *MessageService) GetEmailSuppressions(ctx context.Context) ([]domain.EmailSuppression, error) {
+	return s.suppressionRepo.FindSuppressions(ctx)
+}
+
+func (s *MessageService) DeleteEmailSuppression(ctx context.Context, email string) error {
+	deleted, err := s.suppressionRepo.DeleteSuppression(ctx, normalizeEmail(email))
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (s *MessageService) SendEmailWithEmail(ctx context.Context, msgTplName string, email []string, data message_template.TemplateData) error {
+	return s.sendEmail(ctx, msgTplName, 1, email, data)
 }
 
-func (s *MessageService) SendEmailWithEmail(ctx context.Context, msgTplName string, email []string, contentType string, args ...any) error {
-	return s.sendEmail(ctx, msgTplName, contentType, 1, email, args...)
+func websiteBaseHost() string {
+	return pkg.GetOrDefault4String(os.Getenv("WEBSITE_BASE_HOST"), "http://localhost:3000")
 }
@@ -0,0 +1,140 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/channel"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/repository"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// ErrInvalidNotificationChannel 渠道配置不完整或类型不支持
+var ErrInvalidNotificationChannel = errors.New("invalid notification channel")
+
+type INotificationChannelService interface {
+	AddChannel(ctx context.Context, channel domain.NotificationChannel) (string, error)
+	UpdateChannel(ctx context.Context, channel domain.NotificationChannel) error
+	DeleteChannel(ctx context.Context, id string) error
+	GetChannels(ctx context.Context) ([]domain.NotificationChannel, error)
+	// TestChannel 向渠道发送一条测试消息，不论渠道是否启用
+	TestChannel(ctx context.Context, id string) error
+	// Dispatch 将消息推送到所有已启用且订阅了 msg.Event 的渠道，单个渠道失败不影响其他渠道
+	Dispatch(ctx context.Context, msg channel.Message) error
+}
+
+var _ INotificationChannelService = (*NotificationChannelService)(nil)
+
+func NewNotificationChannelService(repo repository.INotificationChannelRepository) *NotificationChannelService {
+	return &NotificationChannelService{repo: repo}
+}
+
+type NotificationChannelService struct {
+	repo repository.INotificationChannelRepository
+}
+
+func (s *NotificationChannelService) AddChannel(ctx context.Context, c domain.NotificationChannel) (string, error) {
+	if _, err := newChannel(c); err != nil {
+		return "", err
+	}
+	return s.repo.AddChannel(ctx, c, time.Now().Local())
+}
+
+func (s *NotificationChannelService) UpdateChannel(ctx context.Context, c domain.NotificationChannel) error {
+	if _, err := newChannel(c); err != nil {
+		return err
+	}
+	matched, err := s.repo.UpdateChannel(ctx, c, time.Now().Local())
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (s *NotificationChannelService) DeleteChannel(ctx context.Context, id string) error {
+	deleted, err := s.repo.DeleteChannel(ctx, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (s *NotificationChannelService) GetChannels(ctx context.Context) ([]domain.NotificationChannel, error) {
+	return s.repo.FindChannels(ctx)
+}
+
+func (s *NotificationChannelService) TestChannel(ctx context.Context, id string) error {
+	c, err := s.repo.FindChannelById(ctx, id)
+	if err != nil {
+		return err
+	}
+	ch, err := newChannel(*c)
+	if err != nil {
+		return err
+	}
+	return ch.Send(ctx, channel.Message{
+		Event:   "test",
+		Title:   "测试通知",
+		Content: fmt.Sprintf("这是一条来自 fnote 的测试消息，收到说明渠道 %s 配置正确。", c.Name),
+	})
+}
+
+func (s *NotificationChannelService) Dispatch(ctx context.Context, msg channel.Message) error {
+	channels, err := s.repo.FindEnabledChannelsByEvent(ctx, msg.Event)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, c := range channels {
+		ch, err := newChannel(c)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err = ch.Send(ctx, msg); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		slog.DebugContext(ctx, "Notification: message sent", "channel", c.Name, "event", msg.Event)
+	}
+	return errors.Join(errs...)
+}
+
+func newChannel(c domain.NotificationChannel) (channel.Channel, error) {
+	ch, err := channel.New(channel.Config{
+		Name:     c.Name,
+		Type:     c.Type,
+		Url:      c.Url,
+		Secret:   c.Secret,
+		BotToken: c.BotToken,
+		ChatId:   c.ChatId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotificationChannel, err)
+	}
+	return ch, nil
+}
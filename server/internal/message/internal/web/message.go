@@ -0,0 +1,227 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/service"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/web/audit"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>退订邮件通知</title></head>
+<body style="font-family:sans-serif;max-width:480px;margin:80px auto;text-align:center">
+{{if .Error}}<p>{{.Error}}</p>
+{{else if .Done}}<p>{{.Email}} 已退订，今后不会再收到评论回复等邮件通知。</p>
+{{else}}<p>确定让 {{.Email}} 不再接收评论回复等邮件通知吗？</p>
+<form method="post"><button type="submit">确认退订</button></form>
+{{end}}</body>
+</html>`))
+
+type unsubscribePageData struct {
+	Email string
+	Done  bool
+	Error string
+}
+
+func NewMessageHandler(serv service.IMessageService, channelServ service.INotificationChannelService) *MessageHandler {
+	return &MessageHandler{
+		serv:        serv,
+		channelServ: channelServ,
+	}
+}
+
+type MessageHandler struct {
+	serv        service.IMessageService
+	channelServ service.INotificationChannelService
+}
+
+func (h *MessageHandler) RegisterGinRoutes(engine *gin.Engine) {
+	group := engine.Group("/notifications")
+	// GET 只展示确认页面，避免邮件安全扫描等预取链接的行为误退订
+	group.GET("/unsubscribe", h.GetUnsubscribe)
+	// 确认页面的表单提交以及邮件客户端的一键退订（RFC 8058）
+	group.POST("/unsubscribe", h.Unsubscribe)
+
+	adminGroup := engine.Group("/admin-api/email-suppressions")
+	adminGroup.GET("", apiwrap.Wrap(h.AdminGetEmailSuppressions))
+	adminGroup.DELETE("/:email", apiwrap.Wrap(h.AdminDeleteEmailSuppression))
+
+	channelGroup := engine.Group("/admin-api/notification-channels")
+	channelGroup.GET("", apiwrap.Wrap(h.AdminGetNotificationChannels))
+	channelGroup.POST("", audit.Redact("url"), apiwrap.WrapWithBody(h.AdminAddNotificationChannel))
+	channelGroup.PUT("/:id", audit.Redact("url"), apiwrap.WrapWithBody(h.AdminUpdateNotificationChannel))
+	channelGroup.DELETE("/:id", apiwrap.Wrap(h.AdminDeleteNotificationChannel))
+	channelGroup.POST("/:id/test", apiwrap.Wrap(h.AdminTestNotificationChannel))
+}
+
+func (h *MessageHandler) GetUnsubscribe(ctx *gin.Context) {
+	email, err := h.serv.ParseUnsubscribeToken(ctx, ctx.Query("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidUnsubscribeToken) {
+			h.renderUnsubscribePage(ctx, http.StatusBadRequest, unsubscribePageData{Error: "退订链接无效。"})
+			return
+		}
+		slog.ErrorContext(ctx, "Message: failed to parse the unsubscribe token", "error", err)
+		h.renderUnsubscribePage(ctx, http.StatusInternalServerError, unsubscribePageData{Error: "退订失败，请稍后重试。"})
+		return
+	}
+	h.renderUnsubscribePage(ctx, http.StatusOK, unsubscribePageData{Email: email})
+}
+
+func (h *MessageHandler) Unsubscribe(ctx *gin.Context) {
+	email, err := h.serv.Unsubscribe(ctx, ctx.Query("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidUnsubscribeToken) {
+			h.renderUnsubscribePage(ctx, http.StatusBadRequest, unsubscribePageData{Error: "退订链接无效。"})
+			return
+		}
+		slog.ErrorContext(ctx, "Message: failed to unsubscribe", "error", err)
+		h.renderUnsubscribePage(ctx, http.StatusInternalServerError, unsubscribePageData{Error: "退订失败，请稍后重试。"})
+		return
+	}
+	h.renderUnsubscribePage(ctx, http.StatusOK, unsubscribePageData{Email: email, Done: true})
+}
+
+func (h *MessageHandler) renderUnsubscribePage(ctx *gin.Context, code int, data unsubscribePageData) {
+	ctx.Status(code)
+	ctx.Header("Content-Type", "text/html; charset=utf-8")
+	if err := unsubscribePage.Execute(ctx.Writer, data); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+func (h *MessageHandler) AdminGetEmailSuppressions(ctx *gin.Context) (*apiwrap.ResponseBody[apiwrap.ListVO[EmailSuppressionVO]], error) {
+	suppressions, err := h.serv.GetEmailSuppressions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewListVO(slice.Map(suppressions, func(_ int, s domain.EmailSuppression) EmailSuppressionVO {
+		return EmailSuppressionVO{
+			Email:     s.Email,
+			Reason:    s.Reason,
+			CreatedAt: s.CreatedAt.Unix(),
+		}
+	}))), nil
+}
+
+func (h *MessageHandler) AdminDeleteEmailSuppression(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	err := h.serv.DeleteEmailSuppression(ctx, ctx.Param("email"))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "Email suppression not found.")
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponse(), nil
+}
+
+func (h *MessageHandler) AdminGetNotificationChannels(ctx *gin.Context) (*apiwrap.ResponseBody[apiwrap.ListVO[NotificationChannelVO]], error) {
+	channels, err := h.channelServ.GetChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewListVO(slice.Map(channels, func(_ int, c domain.NotificationChannel) NotificationChannelVO {
+		return NotificationChannelVO{
+			Id:        c.Id,
+			Name:      c.Name,
+			Type:      c.Type,
+			Enabled:   c.Enabled,
+			Events:    c.Events,
+			Url:       c.Url,
+			Secret:    c.Secret,
+			BotToken:  c.BotToken,
+			ChatId:    c.ChatId,
+			CreatedAt: c.CreatedAt.Unix(),
+			UpdatedAt: c.UpdatedAt.Unix(),
+		}
+	}))), nil
+}
+
+func (h *MessageHandler) AdminAddNotificationChannel(ctx *gin.Context, req NotificationChannelRequest) (*apiwrap.ResponseBody[IdVO], error) {
+	id, err := h.channelServ.AddChannel(ctx, req.toDomain(""))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidNotificationChannel) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, err.Error())
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(IdVO{Id: id}), nil
+}
+
+func (h *MessageHandler) AdminUpdateNotificationChannel(ctx *gin.Context, req NotificationChannelRequest) (*apiwrap.ResponseBody[any], error) {
+	err := h.channelServ.UpdateChannel(ctx, req.toDomain(ctx.Param("id")))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidNotificationChannel) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "Notification channel not found.")
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponse(), nil
+}
+
+func (h *MessageHandler) AdminDeleteNotificationChannel(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	err := h.channelServ.DeleteChannel(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "Notification channel not found.")
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponse(), nil
+}
+
+func (h *MessageHandler) AdminTestNotificationChannel(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	err := h.channelServ.TestChannel(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "Notification channel not found.")
+		}
+		// 渠道返回的错误原样展示，便于排查配置问题
+		return nil, apiwrap.NewErrorResponseBody(http.StatusBadGateway, err.Error())
+	}
+	return apiwrap.SuccessResponse(), nil
+}
+
+func (r NotificationChannelRequest) toDomain(id string) domain.NotificationChannel {
+	events := r.Events
+	if events == nil {
+		events = []string{}
+	}
+	return domain.NotificationChannel{
+		Id:       id,
+		Name:     r.Name,
+		Type:     r.Type,
+		Enabled:  r.Enabled,
+		Events:   events,
+		Url:      r.Url,
+		Secret:   r.Secret,
+		BotToken: r.BotToken,
+		ChatId:   r.ChatId,
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+type NotificationChannelRequest struct {
+	Name    string   `json:"name" binding:"required"`
+	Type    string   `json:"type" binding:"required,oneof=webhook telegram dingtalk feishu"`
+	Enabled bool     `json:"enabled"`
+	Events  []string `json:"events" binding:"dive,oneof=comment friend"`
+	// webhook、钉钉、飞书的地址，telegram 的 API 地址（可选）
+	Url      string `json:"url"`
+	Secret   string `json:"secret"`
+	BotToken string `json:"bot_token"`
+	ChatId   string `json:"chat_id"`
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+type EmailSuppressionVO struct {
+	Email     string `json:"email"`
+	Reason    string `json:"reason"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type NotificationChannelVO struct {
+	Id        string   `json:"id"`
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Enabled   bool     `json:"enabled"`
+	Events    []string `json:"events"`
+	Url       string   `json:"url"`
+	Secret    string   `json:"secret"`
+	BotToken  string   `json:"bot_token"`
+	ChatId    string   `json:"chat_id"`
+	CreatedAt int64    `json:"created_at"`
+	UpdatedAt int64    `json:"updated_at"`
+}
+
+type IdVO struct {
+	Id string `json:"id"`
+}
@@ -15,12 +15,23 @@
 package message
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/domain"
 	"github.com/chenmingyong0423/fnote/server/internal/message/internal/service"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/web"
+	"github.com/chenmingyong0423/fnote/server/internal/message_template"
 )
 
 type (
-	Service = service.IMessageService
-	Module  struct {
+	Handler      = web.MessageHandler
+	Service      = service.IMessageService
+	TemplateData = message_template.TemplateData
+	Module       struct {
 		Svc Service
+		Hdl *Handler
 	}
 )
+
+const (
+	NotificationEventComment = domain.NotificationEventComment
+	NotificationEventFriend  = domain.NotificationEventFriend
+)
@@ -18,22 +18,32 @@ package message
 
 import (
 	"github.com/chenmingyong0423/fnote/server/internal/email"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/repository"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/repository/dao"
 	"github.com/chenmingyong0423/fnote/server/internal/message/internal/service"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/web"
 	"github.com/chenmingyong0423/fnote/server/internal/message_template"
 	"github.com/chenmingyong0423/fnote/server/internal/website_config"
+	"github.com/chenmingyong0423/go-mongox/v2"
 	"github.com/google/wire"
 )
 
-var MessageProviders = wire.NewSet(service.NewMessageService,
+var MessageProviders = wire.NewSet(web.NewMessageHandler, service.NewMessageService, repository.NewEmailSuppressionRepository, dao.NewEmailSuppressionDao,
+	service.NewNotificationChannelService, repository.NewNotificationChannelRepository, dao.NewNotificationChannelDao,
 	wire.Bind(new(service.IMessageService), new(*service.MessageService)),
+	wire.Bind(new(repository.IEmailSuppressionRepository), new(*repository.EmailSuppressionRepository)),
+	wire.Bind(new(dao.IEmailSuppressionDao), new(*dao.EmailSuppressionDao)),
+	wire.Bind(new(service.INotificationChannelService), new(*service.NotificationChannelService)),
+	wire.Bind(new(repository.INotificationChannelRepository), new(*repository.NotificationChannelRepository)),
+	wire.Bind(new(dao.INotificationChannelDao), new(*dao.NotificationChannelDao)),
 )
 
-func InitMessageModule(emailModule *email.Module, messageTemplateModule *message_template.Module, websiteConfigModule *website_config.Module) *Module {
+func InitMessageModule(db *mongox.Database, emailModule *email.Module, messageTemplateModule *message_template.Module, websiteConfigModule *website_config.Module) *Module {
 	panic(wire.Build(
 		MessageProviders,
 		wire.FieldsOf(new(*message_template.Module), "Svc"),
 		wire.FieldsOf(new(*website_config.Module), "Svc"),
 		wire.FieldsOf(new(*email.Module), "Svc"),
-		wire.Struct(new(Module), "Svc"),
+		wire.Struct(new(Module), "Svc", "Hdl"),
 	))
 }
@@ -8,25 +8,36 @@ package message
 
 import (
 	"github.com/chenmingyong0423/fnote/server/internal/email"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/repository"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/repository/dao"
 	"github.com/chenmingyong0423/fnote/server/internal/message/internal/service"
+	"github.com/chenmingyong0423/fnote/server/internal/message/internal/web"
 	"github.com/chenmingyong0423/fnote/server/internal/message_template"
 	"github.com/chenmingyong0423/fnote/server/internal/website_config"
+	"github.com/chenmingyong0423/go-mongox/v2"
 	"github.com/google/wire"
 )
 
 // Injectors from wire.go:
 
-func InitMessageModule(emailModule *email.Module, messageTemplateModule *message_template.Module, websiteConfigModule *website_config.Module) *Module {
+func InitMessageModule(db *mongox.Database, emailModule *email.Module, messageTemplateModule *message_template.Module, websiteConfigModule *website_config.Module) *Module {
 	iWebsiteConfigService := websiteConfigModule.Svc
 	iEmailService := emailModule.Svc
 	iMessageTemplateService := messageTemplateModule.Svc
-	messageService := service.NewMessageService(iWebsiteConfigService, iEmailService, iMessageTemplateService)
+	emailSuppressionDao := dao.NewEmailSuppressionDao(db)
+	emailSuppressionRepository := repository.NewEmailSuppressionRepository(emailSuppressionDao)
+	notificationChannelDao := dao.NewNotificationChannelDao(db)
+	notificationChannelRepository := repository.NewNotificationChannelRepository(notificationChannelDao)
+	notificationChannelService := service.NewNotificationChannelService(notificationChannelRepository)
+	messageService := service.NewMessageService(iWebsiteConfigService, iEmailService, iMessageTemplateService, emailSuppressionRepository, notificationChannelService)
+	messageHandler := web.NewMessageHandler(messageService, notificationChannelService)
 	module := &Module{
 		Svc: messageService,
+		Hdl: messageHandler,
 	}
 	return module
 }
 
 // wire.go:
 
-var MessageProviders = wire.NewSet(service.NewMessageService, wire.Bind(new(service.IMessageService), new(*service.MessageService)))
+var MessageProviders = wire.NewSet(web.NewMessageHandler, service.NewMessageService, repository.NewEmailSuppressionRepository, dao.NewEmailSuppressionDao, service.NewNotificationChannelService, repository.NewNotificationChannelRepository, dao.NewNotificationChannelDao, wire.Bind(new(service.IMessageService), new(*service.MessageService)), wire.Bind(new(repository.IEmailSuppressionRepository), new(*repository.EmailSuppressionRepository)), wire.Bind(new(dao.IEmailSuppressionDao), new(*dao.EmailSuppressionDao)), wire.Bind(new(service.INotificationChannelService), new(*service.NotificationChannelService)), wire.Bind(new(repository.INotificationChannelRepository), new(*repository.NotificationChannelRepository)), wire.Bind(new(dao.INotificationChannelDao), new(*dao.NotificationChannelDao)))
@@ -0,0 +1,64 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+// defaultMessageTemplates 内置的消息模板，用于替换数据库中仍使用位置参数的旧版本模板
+var defaultMessageTemplates = map[string]MessageTemplate{
+	"comment": {
+		Title:       "文章评论通知",
+		Content:     "您好，{{.CommenterName}} 在文章《{{.PostTitle}}》中发表了新的评论：\n\n{{.CommentContent}}\n\n详情请前往后台进行查看。",
+		HtmlContent: `<p>您好，{{.CommenterName}} 在文章《<a href="{{.PostUrl}}">{{.PostTitle}}</a>》中发表了新的评论：</p><blockquote>{{.CommentContent}}</blockquote><p>详情请前往后台进行查看。</p>`,
+	},
+	"user-comment-approval": {
+		Title:       "评论审核通过通知",
+		Content:     "您好，{{.RecipientName}}，您在文章《{{.PostTitle}}》中发表的评论已通过审核：{{.PostUrl}}",
+		HtmlContent: `<p>您好，{{.RecipientName}}，您在文章《<a href="{{.PostUrl}}">{{.PostTitle}}</a>》中发表的评论已通过审核。</p>`,
+	},
+	"user-comment-disapproval": {
+		Title:       "评论被驳回通知",
+		Content:     "您好，{{.RecipientName}}，您在文章《{{.PostTitle}}》中发表的评论未通过审核，原因：{{.Reason}}",
+		HtmlContent: `<p>您好，{{.RecipientName}}，您在文章《<a href="{{.PostUrl}}">{{.PostTitle}}</a>》中发表的评论未通过审核，原因：{{.Reason}}</p>`,
+	},
+	"user-comment-reply": {
+		Title:       "评论被回复通知",
+		Content:     "您好，{{.RecipientName}}，{{.CommenterName}} 回复了您在文章《{{.PostTitle}}》中发表的评论：\n\n{{.CommentContent}}\n\n查看回复：{{.PostUrl}}\n\n不想再收到此类邮件？点击退订：{{.UnsubscribeUrl}}",
+		HtmlContent: `<p>您好，{{.RecipientName}}，{{.CommenterName}} 回复了您在文章《<a href="{{.PostUrl}}">{{.PostTitle}}</a>》中发表的评论：</p><blockquote>{{.CommentContent}}</blockquote><p style="font-size:12px;color:#999">不想再收到此类邮件？<a href="{{.UnsubscribeUrl}}">退订</a></p>`,
+	},
+	"friend": {
+		Title:       "友链申请通知",
+		Content:     "您好，{{.FriendName}}（{{.FriendUrl}}）申请了友链，详情可前往后台查看。",
+		HtmlContent: `<p>您好，<a href="{{.FriendUrl}}">{{.FriendName}}</a> 申请了友链，详情可前往后台查看。</p>`,
+	},
+	"friend-approval": {
+		Title:       "友链申请通过通知",
+		Content:     "您好，您在 {{.SiteName}} 提交的友链申请已通过审核并展示在页面上：{{.SiteUrl}}/friend",
+		HtmlContent: `<p>您好，您在 <a href="{{.SiteUrl}}/friend">{{.SiteName}}</a> 提交的友链申请已通过审核并展示在页面上。</p>`,
+	},
+	"friend-rejection": {
+		Title:       "友链申请不通过通知",
+		Content:     "您好，您在 {{.SiteName}} 提交的友链申请未通过审核，原因：{{.Reason}}",
+		HtmlContent: `<p>您好，您在 <a href="{{.SiteUrl}}/friend">{{.SiteName}}</a> 提交的友链申请未通过审核，原因：{{.Reason}}</p>`,
+	},
+}
+
+// DefaultMessageTemplate 返回名称为 name 的内置模板
+func DefaultMessageTemplate(name string, recipientType uint) (MessageTemplate, bool) {
+	mt, ok := defaultMessageTemplates[name]
+	if !ok {
+		return MessageTemplate{}, false
+	}
+	mt.Name, mt.RecipientType = name, recipientType
+	return mt, true
+}
@@ -14,14 +14,157 @@
 
 package domain
 
-import "fmt"
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	PartTitle       = "title"
+	PartContent     = "content"
+	PartHtmlContent = "html_content"
+)
 
 type MessageTemplate struct {
-	Name    string
-	Title   string
+	Name string
+	// 邮件标题，使用 text/template 渲染
+	Title string
+	// 纯文本内容，使用 text/template 渲染
 	Content string
+	// HTML 内容，使用 html/template 渲染，为空时只发送纯文本
+	HtmlContent string
+	// 0 webmaster 站长， 1 user 用户
+	RecipientType uint
+}
+
+// TemplateData 渲染消息模板时可以使用的变量，例如 {{.PostTitle}}，未定义的变量会导致渲染失败
+type TemplateData struct {
+	// 站点名称和地址，发送时自动填充
+	SiteName string
+	SiteUrl  string
+	// 收件人的名称
+	RecipientName string
+	PostTitle     string
+	PostUrl       string
+	// 评论或回复的作者及内容
+	CommenterName  string
+	CommentContent string
+	// 申请友链的站点名称和地址
+	FriendName string
+	FriendUrl  string
+	// 驳回的原因
+	Reason string
+	// 退订链接，只在可退订的通知邮件中存在，发送时自动填充
+	UnsubscribeUrl string
 }
 
-func (mt *MessageTemplate) FormatContent(args ...any) {
-	mt.Content = fmt.Sprintf(mt.Content, args...)
+// SampleTemplateData 预览和校验模板时使用的示例数据
+func SampleTemplateData() TemplateData {
+	return TemplateData{
+		SiteName:       "fnote",
+		SiteUrl:        "https://fnote.example.com",
+		RecipientName:  "Alice",
+		PostTitle:      "Hello fnote",
+		PostUrl:        "https://fnote.example.com/posts/hello-fnote",
+		CommenterName:  "Bob",
+		CommentContent: "Nice post!",
+		FriendName:     "Bob's Blog",
+		FriendUrl:      "https://bob.example.com",
+		Reason:         "The site is unreachable.",
+		UnsubscribeUrl: "https://fnote.example.com/api/notifications/unsubscribe?token=sample",
+	}
+}
+
+// RenderedMessage 渲染后的消息，HtmlBody 为空时只有纯文本内容
+type RenderedMessage struct {
+	Subject  string
+	TextBody string
+	HtmlBody string
+}
+
+// TemplateError 模板某一部分的解析或渲染错误
+type TemplateError struct {
+	Part    string
+	Message string
+}
+
+func (e TemplateError) Error() string {
+	return e.Part + ": " + e.Message
+}
+
+// Validate 解析模板并使用示例数据渲染，返回所有部分的错误
+func (mt *MessageTemplate) Validate() []TemplateError {
+	_, errs := mt.render(SampleTemplateData())
+	return errs
+}
+
+// Render 使用 data 渲染模板
+func (mt *MessageTemplate) Render(data TemplateData) (*RenderedMessage, error) {
+	msg, errs := mt.render(data)
+	if len(errs) > 0 {
+		return nil, errors.Wrapf(errs[0], "fails to render message template %s", mt.Name)
+	}
+	return msg, nil
+}
+
+// UsesVariable 模板中是否引用了变量 name
+func (mt *MessageTemplate) UsesVariable(name string) bool {
+	ref := "." + name
+	return strings.Contains(mt.Content, ref) || strings.Contains(mt.HtmlContent, ref)
+}
+
+func (mt *MessageTemplate) render(data TemplateData) (*RenderedMessage, []TemplateError) {
+	var (
+		msg  RenderedMessage
+		errs []TemplateError
+		err  error
+	)
+	if msg.Subject, err = renderText(mt.Title, data); err != nil {
+		errs = append(errs, TemplateError{Part: PartTitle, Message: err.Error()})
+	}
+	if msg.TextBody, err = renderText(mt.Content, data); err != nil {
+		errs = append(errs, TemplateError{Part: PartContent, Message: err.Error()})
+	}
+	if mt.HtmlContent != "" {
+		if msg.HtmlBody, err = renderHtml(mt.HtmlContent, data); err != nil {
+			errs = append(errs, TemplateError{Part: PartHtmlContent, Message: err.Error()})
+		}
+	}
+	return &msg, errs
+}
+
+func renderText(text string, data TemplateData) (string, error) {
+	tpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func renderHtml(text string, data TemplateData) (string, error) {
+	tpl, err := htmltemplate.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+var legacyVerbRegexp = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+
+// IsLegacy 旧版本的模板使用 fmt.Sprintf 的位置参数，没有任何模板变量
+func (mt *MessageTemplate) IsLegacy() bool {
+	return !strings.Contains(mt.Title+mt.Content+mt.HtmlContent, "{{") && legacyVerbRegexp.MatchString(mt.Content)
 }
@@ -0,0 +1,184 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMessageTemplate_Render(t *testing.T) {
+	testCases := []struct {
+		name string
+		mt   MessageTemplate
+		data TemplateData
+
+		want      RenderedMessage
+		wantParts []string
+	}{
+		{
+			name: "text and html",
+			mt: MessageTemplate{
+				Title:       "New comment on {{.PostTitle}}",
+				Content:     "{{.CommenterName}}: {{.CommentContent}}",
+				HtmlContent: `<p>{{.CommenterName}}: {{.CommentContent}}</p><a href="{{.PostUrl}}">view</a>`,
+			},
+			data: TemplateData{PostTitle: "Hello", PostUrl: "https://fnote.example.com/posts/hello", CommenterName: "Bob", CommentContent: "Nice post!"},
+			want: RenderedMessage{
+				Subject:  "New comment on Hello",
+				TextBody: "Bob: Nice post!",
+				HtmlBody: `<p>Bob: Nice post!</p><a href="https://fnote.example.com/posts/hello">view</a>`,
+			},
+		},
+		{
+			name: "text only",
+			mt:   MessageTemplate{Title: "Hi {{.RecipientName}}", Content: "Welcome to {{.SiteName}}"},
+			data: TemplateData{RecipientName: "Alice", SiteName: "fnote"},
+			want: RenderedMessage{Subject: "Hi Alice", TextBody: "Welcome to fnote"},
+		},
+		{
+			name: "html escapes the comment content",
+			mt: MessageTemplate{
+				Title:       "New comment",
+				Content:     "{{.CommentContent}}",
+				HtmlContent: "<p>{{.CommentContent}}</p>",
+			},
+			data: TemplateData{CommentContent: `<script>alert("x")</script> & more`},
+			want: RenderedMessage{
+				Subject:  "New comment",
+				TextBody: `<script>alert("x")</script> & more`,
+				HtmlBody: "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; more</p>",
+			},
+		},
+		{
+			name:      "undefined variables",
+			mt:        MessageTemplate{Title: "{{.Unknown}}", Content: "ok", HtmlContent: "<p>{{.Missing}}</p>"},
+			wantParts: []string{PartTitle, PartHtmlContent},
+		},
+		{
+			name:      "syntax error",
+			mt:        MessageTemplate{Title: "ok", Content: "{{.PostTitle"},
+			wantParts: []string{PartContent},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, errs := tc.mt.render(tc.data)
+			var parts []string
+			for _, e := range errs {
+				parts = append(parts, e.Part)
+			}
+			if !slices.Equal(parts, tc.wantParts) {
+				t.Fatalf("error parts = %v, want %v, errors: %v", parts, tc.wantParts, errs)
+			}
+			if len(errs) > 0 {
+				if _, err := tc.mt.Render(tc.data); err == nil {
+					t.Error("Render should return an error")
+				}
+				return
+			}
+			if *msg != tc.want {
+				t.Errorf("render() = %+v, want %+v", *msg, tc.want)
+			}
+		})
+	}
+}
+
+func TestMessageTemplate_Validate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mt        MessageTemplate
+		wantParts []string
+	}{
+		{
+			name: "valid",
+			mt:   MessageTemplate{Title: "{{.SiteName}}", Content: "{{.PostTitle}} {{.UnsubscribeUrl}}", HtmlContent: "<b>{{.Reason}}</b>"},
+		},
+		{
+			name:      "missing variable",
+			mt:        MessageTemplate{Title: "{{.SiteName}}", Content: "{{.PostAuthor}}"},
+			wantParts: []string{PartContent},
+		},
+		{
+			name:      "every part is invalid",
+			mt:        MessageTemplate{Title: "{{", Content: "{{.Nope}}", HtmlContent: "{{end}}"},
+			wantParts: []string{PartTitle, PartContent, PartHtmlContent},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var parts []string
+			for _, e := range tc.mt.Validate() {
+				if !strings.HasPrefix(e.Error(), e.Part+": ") {
+					t.Errorf("unexpected error message %q", e.Error())
+				}
+				parts = append(parts, e.Part)
+			}
+			if !slices.Equal(parts, tc.wantParts) {
+				t.Errorf("error parts = %v, want %v", parts, tc.wantParts)
+			}
+		})
+	}
+}
+
+func TestMessageTemplate_IsLegacy(t *testing.T) {
+	testCases := []struct {
+		name string
+		mt   MessageTemplate
+		want bool
+	}{
+		{
+			name: "positional arguments",
+			mt:   MessageTemplate{Title: "New comment", Content: "Your post %s received a comment: %s"},
+			want: true,
+		},
+		{
+			name: "formatted verb",
+			mt:   MessageTemplate{Title: "Friend", Content: "%-10v applied"},
+			want: true,
+		},
+		{
+			name: "template variables",
+			mt:   MessageTemplate{Title: "New comment", Content: "{{.PostTitle}} received a comment"},
+		},
+		{
+			name: "template variables with a percent sign",
+			mt:   MessageTemplate{Title: "{{.SiteName}}", Content: "100%s off"},
+		},
+		{
+			name: "plain text",
+			mt:   MessageTemplate{Title: "Hello", Content: "Hello world"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.mt.IsLegacy(); got != tc.want {
+				t.Errorf("IsLegacy() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDefaultMessageTemplates(t *testing.T) {
+	for name, mt := range defaultMessageTemplates {
+		if errs := mt.Validate(); len(errs) > 0 {
+			t.Errorf("default template %s is invalid: %v", name, errs)
+		}
+		if mt.IsLegacy() {
+			t.Errorf("default template %s should not be legacy", name)
+		}
+	}
+}
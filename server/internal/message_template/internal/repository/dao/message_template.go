@@ -28,6 +28,7 @@ type MessageTemplate struct {
 	Name         string `bson:"name"`
 	Title        string `bson:"title"`
 	Content      string `bson:"content"`
+	HtmlContent  string `bson:"html_content,omitempty"`
 	// 0 未激活，1 激活
 	Active uint `bson:"active"`
 	// 0 webmaster 站长， 1 user 用户
@@ -42,8 +42,10 @@ func (r *MessageTemplateRepository) FindMessageTemplateByNameAndRcpType(ctx cont
 		return nil, err
 	}
 	return &domain.MessageTemplate{
-		Name:    MessageTemplateByName.Name,
-		Title:   MessageTemplateByName.Title,
-		Content: MessageTemplateByName.Content,
+		Name:          MessageTemplateByName.Name,
+		Title:         MessageTemplateByName.Title,
+		Content:       MessageTemplateByName.Content,
+		HtmlContent:   MessageTemplateByName.HtmlContent,
+		RecipientType: MessageTemplateByName.RecipientType,
 	}, nil
 }
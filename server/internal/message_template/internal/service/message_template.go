@@ -1,4 +1,4 @@
-// Copyright 2023 chenmingyong0423
+// Copyright 2024 chenmingyong0423
 
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -16,6 +16,7 @@ package service
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/chenmingyong0423/fnote/server/internal/message_template/internal/domain"
 
@@ -24,6 +25,10 @@ import (
 
 type IMessageTemplateService interface {
 	FindMsgTplByNameAndRcpType(ctx context.Context, name string, recipientType uint) (*domain.MessageTemplate, error)
+	// ValidateMsgTpl 解析模板并使用示例数据渲染，返回所有部分的错误
+	ValidateMsgTpl(ctx context.Context, msgTpl domain.MessageTemplate) []domain.TemplateError
+	// PreviewMsgTpl 使用示例数据渲染模板，模板有误时返回所有部分的错误
+	PreviewMsgTpl(ctx context.Context, msgTpl domain.MessageTemplate) (*domain.RenderedMessage, []domain.TemplateError)
 }
 
 var _ IMessageTemplateService = (*MessageTemplateService)(nil)
@@ -33,7 +38,33 @@ type MessageTemplateService struct {
 }
 
 func (s *MessageTemplateService) FindMsgTplByNameAndRcpType(ctx context.Context, name string, recipientType uint) (*domain.MessageTemplate, error) {
-	return s.repo.FindMessageTemplateByNameAndRcpType(ctx, name, recipientType)
+	msgTpl, err := s.repo.FindMessageTemplateByNameAndRcpType(ctx, name, recipientType)
+	if err != nil {
+		return nil, err
+	}
+	// 旧版本的模板使用位置参数，改用内置的模板
+	if msgTpl.IsLegacy() {
+		if defaultTpl, ok := domain.DefaultMessageTemplate(name, recipientType); ok {
+			slog.WarnContext(ctx, "MessageTemplate: the template uses positional arguments, the built-in template is used instead", "name", name)
+			return &defaultTpl, nil
+		}
+	}
+	return msgTpl, nil
+}
+
+func (s *MessageTemplateService) ValidateMsgTpl(_ context.Context, msgTpl domain.MessageTemplate) []domain.TemplateError {
+	return msgTpl.Validate()
+}
+
+func (s *MessageTemplateService) PreviewMsgTpl(_ context.Context, msgTpl domain.MessageTemplate) (*domain.RenderedMessage, []domain.TemplateError) {
+	if errs := msgTpl.Validate(); len(errs) > 0 {
+		return nil, errs
+	}
+	msg, err := msgTpl.Render(domain.SampleTemplateData())
+	if err != nil {
+		return nil, []domain.TemplateError{{Part: domain.PartContent, Message: err.Error()}}
+	}
+	return msg, nil
 }
 
 func NewMessageTemplateService(repo repository.IMessageTemplateRepository) *MessageTemplateService {
@@ -15,8 +15,17 @@
 package web
 
 import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chenmingyong0423/fnote/server/internal/message_template/internal/domain"
 	"github.com/chenmingyong0423/fnote/server/internal/message_template/internal/service"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/gkit/slice"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 func NewMessageTemplateHandler(serv service.IMessageTemplateService) *MessageTemplateHandler {
@@ -30,5 +39,63 @@ type MessageTemplateHandler struct {
 }
 
 func (h *MessageTemplateHandler) RegisterGinRoutes(engine *gin.Engine) {
+	adminGroup := engine.Group("/admin-api/message-templates")
+	adminGroup.POST("/validation", apiwrap.WrapWithBody(h.AdminValidateMessageTemplate))
+	adminGroup.POST("/preview", apiwrap.WrapWithBody(h.AdminPreviewMessageTemplate))
+	adminGroup.GET("/:name/preview", apiwrap.Wrap(h.AdminPreviewStoredMessageTemplate))
+}
+
+func (h *MessageTemplateHandler) AdminValidateMessageTemplate(ctx *gin.Context, req MessageTemplateRequest) (*apiwrap.ResponseBody[TemplateValidationVO], error) {
+	errs := h.serv.ValidateMsgTpl(ctx, req.toDomain())
+	return apiwrap.SuccessResponseWithData(TemplateValidationVO{
+		Valid:  len(errs) == 0,
+		Errors: toTemplateErrorVOs(errs),
+	}), nil
+}
+
+func (h *MessageTemplateHandler) AdminPreviewMessageTemplate(ctx *gin.Context, req MessageTemplateRequest) (*apiwrap.ResponseBody[TemplatePreviewVO], error) {
+	return h.preview(ctx, req.toDomain())
+}
+
+func (h *MessageTemplateHandler) AdminPreviewStoredMessageTemplate(ctx *gin.Context) (*apiwrap.ResponseBody[TemplatePreviewVO], error) {
+	recipientType, err := strconv.ParseUint(ctx.DefaultQuery("recipient_type", "0"), 10, 32)
+	if err != nil {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, "invalid recipient_type")
+	}
+	msgTpl, err := h.serv.FindMsgTplByNameAndRcpType(ctx, ctx.Param("name"), uint(recipientType))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "Message template not found.")
+		}
+		return nil, err
+	}
+	return h.preview(ctx, *msgTpl)
+}
+
+func (h *MessageTemplateHandler) preview(ctx *gin.Context, msgTpl domain.MessageTemplate) (*apiwrap.ResponseBody[TemplatePreviewVO], error) {
+	msg, errs := h.serv.PreviewMsgTpl(ctx, msgTpl)
+	if len(errs) > 0 {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, strings.Join(slice.Map(errs, func(_ int, e domain.TemplateError) string {
+			return e.Error()
+		}), "; "))
+	}
+	return apiwrap.SuccessResponseWithData(TemplatePreviewVO{
+		Subject:     msg.Subject,
+		Content:     msg.TextBody,
+		HtmlContent: msg.HtmlBody,
+	}), nil
+}
+
+func (r MessageTemplateRequest) toDomain() domain.MessageTemplate {
+	return domain.MessageTemplate{
+		Title:       r.Title,
+		Content:     r.Content,
+		HtmlContent: r.HtmlContent,
+	}
+}
 
+func toTemplateErrorVOs(errs []domain.TemplateError) []TemplateErrorVO {
+	return slice.Map(errs, func(_ int, e domain.TemplateError) TemplateErrorVO {
+		return TemplateErrorVO{Part: e.Part, Message: e.Message}
+	})
 }
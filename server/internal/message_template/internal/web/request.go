@@ -14,4 +14,8 @@
 
 package web
 
-type MessageTemplateRequest struct{}
+type MessageTemplateRequest struct {
+	Title       string `json:"title" binding:"required"`
+	Content     string `json:"content" binding:"required"`
+	HtmlContent string `json:"html_content"`
+}
@@ -15,3 +15,20 @@
 package web
 
 type MessageTemplateVO struct{}
+
+type TemplateValidationVO struct {
+	Valid  bool              `json:"valid"`
+	Errors []TemplateErrorVO `json:"errors"`
+}
+
+type TemplateErrorVO struct {
+	// 出错的部分：title、content、html_content
+	Part    string `json:"part"`
+	Message string `json:"message"`
+}
+
+type TemplatePreviewVO struct {
+	Subject     string `json:"subject"`
+	Content     string `json:"content"`
+	HtmlContent string `json:"html_content"`
+}
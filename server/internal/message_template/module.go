@@ -15,14 +15,18 @@
 package message_template
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/message_template/internal/domain"
 	"github.com/chenmingyong0423/fnote/server/internal/message_template/internal/service"
 	"github.com/chenmingyong0423/fnote/server/internal/message_template/internal/web"
 )
 
 type (
-	Handler = web.MessageTemplateHandler
-	Service = service.IMessageTemplateService
-	Module  struct {
+	Handler         = web.MessageTemplateHandler
+	MessageTemplate = domain.MessageTemplate
+	TemplateData    = domain.TemplateData
+	RenderedMessage = domain.RenderedMessage
+	Service         = service.IMessageTemplateService
+	Module          struct {
 		Svc Service
 		Hdl *Handler
 	}
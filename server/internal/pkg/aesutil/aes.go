@@ -45,6 +45,11 @@ func generateRandomBytes(n int) ([]byte, error) {
 
 // AesEncrypt 加密给定的消息
 func AesEncrypt(plainText []byte) (string, error) {
+	return AesEncryptWithKey(key, plainText)
+}
+
+// AesEncryptWithKey 使用指定的密钥加密给定的消息
+func AesEncryptWithKey(key []byte, plainText []byte) (string, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
@@ -73,6 +78,11 @@ func AesEncrypt(plainText []byte) (string, error) {
 
 // AesDecrypt 解密给定的消息
 func AesDecrypt(encrypted string) (string, error) {
+	return AesDecryptWithKey(key, encrypted)
+}
+
+// AesDecryptWithKey 使用指定的密钥解密给定的消息
+func AesDecryptWithKey(key []byte, encrypted string) (string, error) {
 	encryptedBytes, err := base64.StdEncoding.DecodeString(encrypted)
 	if err != nil {
 		return "", err
@@ -83,7 +93,7 @@ func AesDecrypt(encrypted string) (string, error) {
 		return "", err
 	}
 
-	if len(encryptedBytes) < block.BlockSize() {
+	if len(encryptedBytes) < 2*block.BlockSize() || len(encryptedBytes)%block.BlockSize() != 0 {
 		return "", fmt.Errorf("ciphertext too short")
 	}
 
@@ -0,0 +1,205 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aesutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// 分块的 AES-GCM 流式加密，适用于无法一次性读入内存的大文件。
+// 每块明文 StreamChunkSize 字节，nonce 为 7 字节前缀 + 4 字节块序号 + 1 字节结束标记，
+// 块序号防止重排，结束标记防止截断
+const (
+	StreamChunkSize       = 64 * 1024
+	StreamNoncePrefixSize = 7
+	streamCounterMax      = 1<<32 - 1
+)
+
+var (
+	ErrStreamAuthFailed = errors.New("aes-gcm stream: message authentication failed")
+	ErrStreamTruncated  = errors.New("aes-gcm stream: unexpected end of stream")
+)
+
+func newStreamAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func streamNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 0, StreamNoncePrefixSize+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+type streamWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	aad     []byte
+	buf     []byte
+	counter uint32
+	closed  bool
+}
+
+// NewGCMStreamWriter 返回加密写入 w 的 WriteCloser，必须调用 Close 写入最后一块
+func NewGCMStreamWriter(w io.Writer, key, noncePrefix, aad []byte) (io.WriteCloser, error) {
+	if len(noncePrefix) != StreamNoncePrefixSize {
+		return nil, errors.New("aes-gcm stream: invalid nonce prefix size")
+	}
+	aead, err := newStreamAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &streamWriter{w: w, aead: aead, prefix: noncePrefix, aad: aad, buf: make([]byte, 0, StreamChunkSize)}, nil
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if s.closed {
+		return 0, errors.New("aes-gcm stream: write after close")
+	}
+	n := 0
+	for len(p) > 0 {
+		// 缓冲区已满且还有数据时才写出，保证最后一块在 Close 时带上结束标记
+		if len(s.buf) == StreamChunkSize {
+			if err := s.flush(false); err != nil {
+				return n, err
+			}
+		}
+		c := copy(s.buf[len(s.buf):StreamChunkSize], p)
+		s.buf = s.buf[:len(s.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, nil
+}
+
+func (s *streamWriter) flush(last bool) error {
+	if s.counter == streamCounterMax {
+		return errors.New("aes-gcm stream: stream too large")
+	}
+	sealed := s.aead.Seal(nil, streamNonce(s.prefix, s.counter, last), s.buf, s.aad)
+	s.counter++
+	s.buf = s.buf[:0]
+	_, err := s.w.Write(sealed)
+	return err
+}
+
+func (s *streamWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.flush(true)
+}
+
+type streamReader struct {
+	r      io.Reader
+	aead   cipher.AEAD
+	prefix []byte
+	aad    []byte
+	// in 中前 have 个字节是上一次多读的数据
+	in      []byte
+	have    int
+	plain   []byte
+	out     []byte
+	counter uint32
+	done    bool
+}
+
+// NewGCMStreamReader 返回解密 r 的 Reader，数据被篡改时返回 ErrStreamAuthFailed，被截断时返回 ErrStreamTruncated
+func NewGCMStreamReader(r io.Reader, key, noncePrefix, aad []byte) (io.Reader, error) {
+	if len(noncePrefix) != StreamNoncePrefixSize {
+		return nil, errors.New("aes-gcm stream: invalid nonce prefix size")
+	}
+	aead, err := newStreamAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &streamReader{
+		r:      r,
+		aead:   aead,
+		prefix: noncePrefix,
+		aad:    aad,
+		in:     make([]byte, StreamChunkSize+aead.Overhead()+1),
+		plain:  make([]byte, 0, StreamChunkSize),
+	}, nil
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	for len(s.out) == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+		if err := s.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, s.out)
+	s.out = s.out[n:]
+	return n, nil
+}
+
+// next 读取并解密下一块，多读 1 个字节用于判断当前块是否为最后一块
+func (s *streamReader) next() error {
+	chunkSize := StreamChunkSize + s.aead.Overhead()
+	n, err := io.ReadFull(s.r, s.in[s.have:chunkSize+1])
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	n += s.have
+	if n <= chunkSize {
+		if n < s.aead.Overhead() {
+			return ErrStreamTruncated
+		}
+		return s.open(s.in[:n], true)
+	}
+	if err = s.open(s.in[:chunkSize], false); err != nil {
+		return err
+	}
+	s.in[0] = s.in[chunkSize]
+	s.have = 1
+	return nil
+}
+
+func (s *streamReader) open(chunk []byte, last bool) error {
+	if s.counter == streamCounterMax {
+		return errors.New("aes-gcm stream: stream too large")
+	}
+	plain, err := s.aead.Open(s.plain[:0], streamNonce(s.prefix, s.counter, last), chunk, s.aad)
+	if err != nil {
+		// 完整长度的块无法作为最后一块解密，但可以作为中间块解密，说明后面的块被截断
+		if last && len(chunk) == StreamChunkSize+s.aead.Overhead() {
+			if _, fErr := s.aead.Open(nil, streamNonce(s.prefix, s.counter, false), chunk, s.aad); fErr == nil {
+				return ErrStreamTruncated
+			}
+		}
+		return ErrStreamAuthFailed
+	}
+	s.counter++
+	s.out = plain
+	s.done = last
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cronutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 标准的 5 段 cron 表达式：分 时 日 月 周，支持 *、a-b、*/n、a-b/n 以及逗号分隔的列表，
+// 另外支持 @hourly、@daily、@weekly、@monthly 等简写
+var descriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+type bounds struct {
+	min, max int
+}
+
+var fieldBounds = [5]bounds{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// 超过该时间范围仍找不到匹配时间时认为表达式不会被触发，例如 2 月 30 日
+const searchLimitYears = 5
+
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// 日和周同时被限制时，满足其一即可，与 crontab 的行为一致
+	domStar, dowStar bool
+}
+
+// Parse 解析 cron 表达式
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+	var masks [5]uint64
+	for i, field := range fields {
+		mask, err := parseField(field, fieldBounds[i])
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid cron expression %q", expr)
+		}
+		masks[i] = mask
+	}
+	// 周日既可以写作 0 也可以写作 7
+	if masks[4]&(1<<7) != 0 {
+		masks[4] |= 1
+	}
+	return &Schedule{
+		minute:  masks[0],
+		hour:    masks[1],
+		dom:     masks[2],
+		month:   masks[3],
+		dow:     masks[4],
+		domStar: strings.HasPrefix(fields[2], "*"),
+		dowStar: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart, step = part[:i], s
+		}
+		lo, hi := b.min, b.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			l, h, _ := strings.Cut(rangePart, "-")
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(l)
+			hi, err2 = strconv.Atoi(h)
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			v, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			lo = v
+			// 5/15 表示从 5 开始每隔 15
+			if step == 1 {
+				hi = v
+			}
+		}
+		if lo < b.min || hi > b.max || lo > hi {
+			return 0, fmt.Errorf("value out of range [%d, %d] in %q", b.min, b.max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+// Next 返回 t 之后第一个满足表达式的时间，精确到分钟，找不到时返回零值
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	limit := t.AddDate(searchLimitYears, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
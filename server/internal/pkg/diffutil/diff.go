@@ -0,0 +1,178 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diffutil
+
+import (
+	"slices"
+	"strings"
+)
+
+type Op string
+
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+// maxEditDistance 编辑距离超过该值时不再逐行比对，直接视为整体替换，避免极端情况下占用过多内存
+const maxEditDistance = 1000
+
+// Line 为差异结果中的一行，OldNo、NewNo 为从 1 开始的行号，不存在时为 0
+type Line struct {
+	Op    Op     `json:"op"`
+	Text  string `json:"text"`
+	OldNo int    `json:"old_no"`
+	NewNo int    `json:"new_no"`
+}
+
+// SplitLines 按行切分文本，兼容 \r\n 换行
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
+
+// DiffText 计算文本 a 到 b 的逐行差异
+func DiffText(a, b string) []Line {
+	return Diff(SplitLines(a), SplitLines(b))
+}
+
+// Diff 使用 Myers 算法计算 a 到 b 的逐行差异
+func Diff(a, b []string) []Line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	ops, ok := myers(midA, midB)
+	if !ok {
+		ops = make([]Op, 0, len(midA)+len(midB))
+		for range midA {
+			ops = append(ops, OpDelete)
+		}
+		for range midB {
+			ops = append(ops, OpInsert)
+		}
+	}
+
+	lines := make([]Line, 0, prefix+len(ops)+suffix)
+	oi, ni := 0, 0
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, Line{Op: OpEqual, Text: a[oi], OldNo: oi + 1, NewNo: ni + 1})
+		oi++
+		ni++
+	}
+	for _, op := range ops {
+		switch op {
+		case OpEqual:
+			lines = append(lines, Line{Op: OpEqual, Text: a[oi], OldNo: oi + 1, NewNo: ni + 1})
+			oi++
+			ni++
+		case OpDelete:
+			lines = append(lines, Line{Op: OpDelete, Text: a[oi], OldNo: oi + 1})
+			oi++
+		case OpInsert:
+			lines = append(lines, Line{Op: OpInsert, Text: b[ni], NewNo: ni + 1})
+			ni++
+		}
+	}
+	for i := 0; i < suffix; i++ {
+		lines = append(lines, Line{Op: OpEqual, Text: a[oi], OldNo: oi + 1, NewNo: ni + 1})
+		oi++
+		ni++
+	}
+	return lines
+}
+
+// HasChanges 判断差异结果中是否存在增删的行
+func HasChanges(lines []Line) bool {
+	return slices.ContainsFunc(lines, func(l Line) bool {
+		return l.Op != OpEqual
+	})
+}
+
+// myers 返回将 a 转换为 b 的最短编辑序列，编辑距离超过 maxEditDistance 时返回 false
+func myers(a, b []string) ([]Op, bool) {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil, true
+	}
+	limit := min(n+m, maxEditDistance)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace[d] 保存第 d 轮开始前 k ∈ [-d-1, d+1] 范围内的 v，用于回溯路径
+	trace := make([][]int, 0, 8)
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, slices.Clone(v[offset-d-1:offset+d+2]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m), true
+			}
+		}
+	}
+	return nil, false
+}
+
+func backtrack(trace [][]int, n, m int) []Op {
+	ops := make([]Op, 0, n+m)
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, OpEqual)
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, OpInsert)
+			} else {
+				ops = append(ops, OpDelete)
+			}
+		}
+		x, y = prevX, prevY
+	}
+	slices.Reverse(ops)
+	return ops
+}
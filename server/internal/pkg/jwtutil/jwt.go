@@ -15,7 +15,10 @@
 package jwtutil
 
 import (
-	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/chenmingyong0423/fnote/server/internal/pkg/aesutil"
@@ -26,50 +29,140 @@ import (
 )
 
 var (
-	jwtKey []byte
+	ErrNoSigningKey       = errors.New("no signing key is available")
+	ErrUnknownSigningKey  = errors.New("unknown signing key")
+	ErrSigningKeyExpired  = errors.New("signing key has expired")
+	ErrUnexpectedAudience = errors.New("unexpected token audience")
+
+	keys atomic.Pointer[keyring]
 )
 
-func init() {
-	jwtKey = make([]byte, 32) // 生成32字节（256位）的密钥
-	if _, err := rand.Read(jwtKey); err != nil {
-		panic(err) // 生成密钥时发生错误
+// SigningKey jwt 签名密钥
+type SigningKey struct {
+	// Id 对应 jwt header 中的 kid
+	Id     string
+	Secret []byte
+	// RetiredAt 密钥被轮换的时间，为 nil 表示密钥仍在使用
+	RetiredAt *time.Time
+}
+
+type keyring struct {
+	active      *SigningKey
+	keys        map[string]SigningKey
+	gracePeriod time.Duration
+}
+
+// SetSigningKeys 设置签名密钥，未轮换的密钥中第一个用于签发 jwt，
+// 已轮换的密钥在 gracePeriod 内仍可用于校验 jwt
+func SetSigningKeys(signingKeys []SigningKey, gracePeriod time.Duration) {
+	kr := &keyring{keys: make(map[string]SigningKey, len(signingKeys)), gracePeriod: gracePeriod}
+	for _, key := range signingKeys {
+		kr.keys[key.Id] = key
+		if kr.active == nil && key.RetiredAt == nil {
+			kr.active = &key
+		}
 	}
+	keys.Store(kr)
+}
+
+// KeyIdOf 根据密钥内容生成稳定的 kid，用于配置文件中指定的密钥
+func KeyIdOf(secret []byte) string {
+	sum := sha256.Sum256(secret)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ChallengeAudience 两步验证挑战令牌的 audience，此类令牌只能用于完成两步验证，不能作为访问令牌使用
+const ChallengeAudience = "fnote-2fa-challenge"
+
+// GenerateJwt 生成有效期为 ttl 的 JWT，subject 为登录用户的 id，tokenId 为登录会话的 id，用于吊销
+func GenerateJwt(subject string, tokenId string, ttl time.Duration) (string, int64, error) {
+	return generate(subject, tokenId, nil, ttl)
 }
 
-// GenerateJwt 生成 JWT
-func GenerateJwt() (string, int64, error) {
+// GenerateChallengeJwt 生成两步验证的挑战令牌，subject 为通过密码校验的用户 id
+func GenerateChallengeJwt(subject string, ttl time.Duration) (string, int64, error) {
+	return generate(subject, "", jwt.ClaimStrings{ChallengeAudience}, ttl)
+}
+
+func generate(subject string, tokenId string, audience jwt.ClaimStrings, ttl time.Duration) (string, int64, error) {
+	kr := keys.Load()
+	if kr == nil || kr.active == nil {
+		return "", 0, ErrNoSigningKey
+	}
 	now := time.Now().Local()
-	exp := now.Add(time.Hour * 12)
+	exp := now.Add(ttl)
 	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
 		Issuer:    "https://github.com/chenmingyong0423/fnote",
-		Subject:   "",
-		Audience:  nil,
+		Subject:   subject,
+		Audience:  audience,
 		ExpiresAt: jwt.NewNumericDate(exp),
 		NotBefore: jwt.NewNumericDate(now),
 		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        tokenId,
 	})
-	signedString, err := t.SignedString(jwtKey)
+	t.Header["kid"] = kr.active.Id
+	signedString, err := t.SignedString(kr.active.Secret)
 	if err != nil {
 		return "", 0, errors.Wrap(err, "generate jwt failed")
 	}
 
-	// aes 加密
-	encrypt, err := aesutil.AesEncrypt([]byte(signedString))
+	// aes 加密，密文前拼接 kid，解密时据此选择密钥
+	encrypt, err := aesutil.AesEncryptWithKey(aesKeyOf(kr.active.Secret), []byte(signedString))
 	if err != nil {
 		return "", 0, err
 	}
-	return encrypt, exp.Unix(), nil
+	return kr.active.Id + "." + encrypt, exp.Unix(), nil
 }
 
+// ParseJwt 解析访问令牌，带有 audience 的令牌（如挑战令牌）会被拒绝
 func ParseJwt(jwtStr string) (jwt.Claims, error) {
+	claims, err := parse(jwtStr)
+	if err != nil {
+		return nil, err
+	}
+	if len(claims.Audience) > 0 {
+		return nil, ErrUnexpectedAudience
+	}
+	return claims, nil
+}
+
+// ParseChallengeJwt 解析两步验证的挑战令牌
+func ParseChallengeJwt(jwtStr string) (*jwt.RegisteredClaims, error) {
+	claims, err := parse(jwtStr)
+	if err != nil {
+		return nil, err
+	}
+	if len(claims.Audience) != 1 || claims.Audience[0] != ChallengeAudience {
+		return nil, ErrUnexpectedAudience
+	}
+	return claims, nil
+}
+
+func parse(jwtStr string) (*jwt.RegisteredClaims, error) {
+	kr := keys.Load()
+	if kr == nil {
+		return nil, ErrNoSigningKey
+	}
+	kid, encrypted, found := strings.Cut(jwtStr, ".")
+	if !found {
+		return nil, ErrUnknownSigningKey
+	}
+	key, err := kr.verificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+
 	claims := &jwt.RegisteredClaims{}
-	decrypt, err := aesutil.AesDecrypt(jwtStr)
+	decrypt, err := aesutil.AesDecryptWithKey(aesKeyOf(key.Secret), encrypted)
 	if err != nil {
 		return nil, err
 	}
 	token, err := jwt.ParseWithClaims(decrypt, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtKey, nil
-	})
+		if headerKid, _ := token.Header["kid"].(string); headerKid != kid {
+			return nil, ErrUnknownSigningKey
+		}
+		return key.Secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -81,3 +174,20 @@ func ParseJwt(jwtStr string) (jwt.Claims, error) {
 		return nil, err
 	}
 }
+
+func (kr *keyring) verificationKey(kid string) (SigningKey, error) {
+	key, ok := kr.keys[kid]
+	if !ok {
+		return SigningKey{}, ErrUnknownSigningKey
+	}
+	if key.RetiredAt != nil && time.Now().After(key.RetiredAt.Add(kr.gracePeriod)) {
+		return SigningKey{}, ErrSigningKeyExpired
+	}
+	return key, nil
+}
+
+// aesKeyOf 由签名密钥派生出 token 的 aes 加密密钥
+func aesKeyOf(secret []byte) []byte {
+	sum := sha256.Sum256(append([]byte("fnote-token-aes:"), secret...))
+	return sum[:]
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaseworker
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Policy 重试策略：第 n 次处理失败后等待 BackoffBase * 2^(n-1) 再重试，最长等待 MaxBackoff，
+// 累计失败 MaxAttempts 次后不再重试
+type Policy struct {
+	Workers     int
+	MaxAttempts int
+	BackoffBase time.Duration
+	MaxBackoff  time.Duration
+}
+
+// LoadPolicy 读取 prefix 下的 workers、max_attempts、backoff_base、max_backoff 配置，未配置或不合法的项使用 defaults
+func LoadPolicy(prefix string, defaults Policy) Policy {
+	policy := Policy{
+		Workers:     viper.GetInt(prefix + ".workers"),
+		MaxAttempts: viper.GetInt(prefix + ".max_attempts"),
+		BackoffBase: viper.GetDuration(prefix + ".backoff_base"),
+		MaxBackoff:  viper.GetDuration(prefix + ".max_backoff"),
+	}
+	if policy.Workers <= 0 {
+		policy.Workers = defaults.Workers
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaults.MaxAttempts
+	}
+	if policy.BackoffBase <= 0 {
+		policy.BackoffBase = defaults.BackoffBase
+	}
+	if policy.MaxBackoff < policy.BackoffBase {
+		policy.MaxBackoff = max(defaults.MaxBackoff, policy.BackoffBase)
+	}
+	return policy
+}
+
+// Backoff 第 attempts 次处理失败后需要等待的时间
+func (p Policy) Backoff(attempts int) time.Duration {
+	wait := p.BackoffBase
+	for i := 1; i < attempts && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, p.MaxBackoff)
+}
+
+// Worker 处理保存在数据库中的任务。
+// 领取通过原子的 FindOneAndUpdate 完成，多个 worker 或多实例部署时同一条任务只会被一个 worker 领取，
+// worker 在处理过程中崩溃时，租约到期后由其他 worker 重新领取；
+// 处理失败则释放租约并按失败次数延后重试，重试次数用尽后不再自动重试。
+type Worker[T any] struct {
+	// Name 日志的前缀
+	Name string
+	// Lease 领取任务后的租约时长，需大于处理一条任务的最长耗时
+	Lease        time.Duration
+	PollInterval time.Duration
+	// Policy 每一轮处理前读取，修改配置后无需重启
+	Policy func() Policy
+	// Claim 领取一条已到处理时间的任务并累加处理次数，没有可领取的任务时返回 mongo.ErrNoDocuments
+	Claim  func(ctx context.Context, owner string, now, leaseUntil time.Time) (*T, error)
+	Handle func(ctx context.Context, task *T) error
+	// Succeed 仅在 owner 仍持有租约时标记任务成功
+	Succeed func(ctx context.Context, task *T, owner string, now time.Time) error
+	// Fail 仅在 owner 仍持有租约时标记任务失败，dead 为 true 表示重试次数已用尽，此时 retryAt 为 now
+	Fail     func(ctx context.Context, task *T, owner string, dead bool, retryAt time.Time, cause error, now time.Time) error
+	Id       func(task *T) string
+	Attempts func(task *T) int
+}
+
+// Start 启动 Policy().Workers 个 worker，每隔 PollInterval 或 wake 中有信号时处理到期的任务
+func (w *Worker[T]) Start(instanceId string, wake <-chan struct{}) {
+	for i := 0; i < w.Policy().Workers; i++ {
+		go w.run(instanceId+"-"+strconv.Itoa(i), wake)
+	}
+}
+
+func (w *Worker[T]) run(owner string, wake <-chan struct{}) {
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+	for {
+		w.RunDue(context.Background(), owner)
+		select {
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// RunDue 逐条领取并处理已到处理时间的任务，直到没有可领取的任务
+func (w *Worker[T]) RunDue(ctx context.Context, owner string) {
+	l := slog.Default().With("X-Request-ID", uuid.NewString())
+	policy := w.Policy()
+	for {
+		now := time.Now().Local()
+		task, err := w.Claim(ctx, owner, now, now.Add(w.Lease))
+		if err != nil {
+			if !errors.Is(err, mongo.ErrNoDocuments) {
+				l.Error(w.Name+": failed to claim a task", "error", err)
+			}
+			return
+		}
+		id, attempts := w.Id(task), w.Attempts(task)
+		cause := w.Handle(ctx, task)
+		now = time.Now().Local()
+		if cause == nil {
+			if err = w.Succeed(ctx, task, owner, now); err != nil {
+				l.Error(w.Name+": failed to mark the task as succeeded", "id", id, "error", err)
+			}
+			continue
+		}
+		dead, retryAt := attempts >= policy.MaxAttempts, now.Add(policy.Backoff(attempts))
+		if dead {
+			retryAt = now
+		}
+		l.Warn(w.Name+": failed to handle the task", "id", id, "attempts", attempts, "dead", dead, "error", cause)
+		if err = w.Fail(ctx, task, owner, dead, retryAt, cause, now); err != nil {
+			l.Error(w.Name+": failed to mark the task as failed", "id", id, "error", err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaseworker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicy_Backoff(t *testing.T) {
+	policy := Policy{BackoffBase: 30 * time.Second, MaxBackoff: 5 * time.Minute}
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, w := range want {
+		if got := policy.Backoff(i + 1); got != w {
+			t.Errorf("Backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	// 次数很大时不会溢出
+	if got := policy.Backoff(1000); got != 5*time.Minute {
+		t.Errorf("Backoff(1000) = %v, want %v", got, 5*time.Minute)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netutil
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var ErrNonPublicAddress = errors.New("refusing to connect to a non-public address")
+
+// nonPublicPrefixes netip 无法直接判断的非公网地址段
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// RejectNonPublicAddress 用作 net.Dialer 的 Control，拒绝连接回环、内网、链路本地等非公网地址
+func RejectNonPublicAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, ip)
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return fmt.Errorf("%w: %s", ErrNonPublicAddress, ip)
+		}
+	}
+	return nil
+}
+
+// NewPublicHttpClient 只能连接公网地址的 http 客户端，用于请求地址不可信的场景。
+// 不使用代理，每次连接（包括重定向）都在 dialer 中校验实际连接的 IP，避免通过域名解析绕过
+func NewPublicHttpClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: 10 * time.Second,
+				Control: RejectNonPublicAddress,
+			}).DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: timeout,
+		},
+	}
+}
+
+// NoRedirect 用作 http.Client 的 CheckRedirect，不跟随重定向，直接返回 3xx 响应
+func NoRedirect(_ *http.Request, _ []*http.Request) error {
+	return http.ErrUseLastResponse
+}
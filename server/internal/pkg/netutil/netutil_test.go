@@ -0,0 +1,61 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netutil
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRejectNonPublicAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		public  bool
+	}{
+		{address: "127.0.0.1:80"},
+		{address: "10.0.0.1:80"},
+		{address: "172.16.0.1:80"},
+		{address: "192.168.1.1:80"},
+		{address: "169.254.169.254:80"},
+		{address: "100.64.0.1:80"},
+		{address: "0.0.0.0:80"},
+		{address: "[::1]:80"},
+		{address: "[::ffff:127.0.0.1]:80"},
+		{address: "[fe80::1]:80"},
+		{address: "[fc00::1]:80"},
+		{address: "93.184.216.34:443", public: true},
+		{address: "[2606:4700::1111]:443", public: true},
+	}
+	for _, tt := range tests {
+		err := RejectNonPublicAddress("tcp", tt.address, nil)
+		if tt.public && err != nil {
+			t.Errorf("%s: %v, want allowed", tt.address, err)
+		}
+		if !tt.public && !errors.Is(err, ErrNonPublicAddress) {
+			t.Errorf("%s: %v, want ErrNonPublicAddress", tt.address, err)
+		}
+	}
+}
+
+func TestNewPublicHttpClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer srv.Close()
+	_, err := NewPublicHttpClient(0).Get(srv.URL)
+	if !errors.Is(err, ErrNonPublicAddress) {
+		t.Fatalf("get %s: %v, want ErrNonPublicAddress", srv.URL, err)
+	}
+}
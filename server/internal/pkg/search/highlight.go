@@ -0,0 +1,114 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	HighlightPreTag  = "<em>"
+	HighlightPostTag = "</em>"
+)
+
+type span struct {
+	start, end int
+}
+
+// Highlight 在 text 中查找 query 的词元，截取第一个命中位置附近最多 maxRunes 个字符，
+// 并使用 <em></em> 包裹命中的内容，其余内容会进行 html 转义。没有命中时返回空字符串。
+// maxRunes <= 0 时不截取
+func Highlight(text string, query string, maxRunes int) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for idx, r := range runes {
+		lower[idx] = unicode.ToLower(r)
+	}
+
+	var spans []span
+	for _, term := range Unique(queryTokens(query)) {
+		termRunes := []rune(term)
+		for idx := 0; idx+len(termRunes) <= len(lower); idx++ {
+			if runesHasPrefix(lower[idx:], termRunes) {
+				spans = append(spans, span{start: idx, end: idx + len(termRunes)})
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return ""
+	}
+	spans = mergeSpans(spans)
+
+	start, end := 0, len(runes)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		start = max(spans[0].start-maxRunes/4, 0)
+		end = min(start+maxRunes, len(runes))
+		start = max(end-maxRunes, 0)
+	}
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("...")
+	}
+	cursor := start
+	for _, s := range spans {
+		if s.end <= start || s.start >= end {
+			continue
+		}
+		s.start, s.end = max(s.start, start), min(s.end, end)
+		sb.WriteString(html.EscapeString(string(runes[cursor:s.start])))
+		sb.WriteString(HighlightPreTag)
+		sb.WriteString(html.EscapeString(string(runes[s.start:s.end])))
+		sb.WriteString(HighlightPostTag)
+		cursor = s.end
+	}
+	sb.WriteString(html.EscapeString(string(runes[cursor:end])))
+	if end < len(runes) {
+		sb.WriteString("...")
+	}
+	return sb.String()
+}
+
+func runesHasPrefix(s, prefix []rune) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	for idx := range prefix {
+		if s[idx] != prefix[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeSpans 合并重叠或相邻的区间，例如“数据”和“据库”合并为“数据库”
+func mergeSpans(spans []span) []span {
+	sort.Slice(spans, func(x, y int) bool {
+		return spans[x].start < spans[y].start
+	})
+	merged := []span{spans[0]}
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if s.start <= last.end {
+			last.end = max(last.end, s.end)
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
@@ -0,0 +1,245 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+const (
+	// bm25 的参数
+	k1 = 1.2
+	b  = 0.75
+)
+
+type Hit[T any] struct {
+	Id      string
+	Score   float64
+	Payload T
+}
+
+type document[T any] struct {
+	fieldLens map[string]int
+	terms     []string
+	payload   T
+}
+
+// Index 基于倒排表的内存索引，使用 BM25F 计算相关度
+type Index[T any] struct {
+	mu           sync.RWMutex
+	fieldWeights map[string]float64
+	docs         map[string]*document[T]
+	// term -> docId -> field -> 词频
+	postings map[string]map[string]map[string]int
+	// field -> 所有文档该字段的总长度
+	totalFieldLens map[string]int
+	// dirty 重建期间被修改或删除的文档，为 nil 时表示没有正在进行的重建
+	dirty map[string]struct{}
+}
+
+func NewIndex[T any](fieldWeights map[string]float64) *Index[T] {
+	return &Index[T]{
+		fieldWeights:   fieldWeights,
+		docs:           make(map[string]*document[T]),
+		postings:       make(map[string]map[string]map[string]int),
+		totalFieldLens: make(map[string]int),
+	}
+}
+
+// Upsert 新增或替换文档
+func (i *Index[T]) Upsert(id string, fields map[string]string, payload T) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.markDirty(id)
+	i.delete(id)
+
+	doc := &document[T]{fieldLens: make(map[string]int, len(fields)), payload: payload}
+	seen := make(map[string]struct{})
+	for field, text := range fields {
+		if _, ok := i.fieldWeights[field]; !ok {
+			continue
+		}
+		tokens := Tokenize(text)
+		doc.fieldLens[field] = len(tokens)
+		i.totalFieldLens[field] += len(tokens)
+		for _, token := range tokens {
+			docPostings, ok := i.postings[token]
+			if !ok {
+				docPostings = make(map[string]map[string]int)
+				i.postings[token] = docPostings
+			}
+			fieldFreq, ok := docPostings[id]
+			if !ok {
+				fieldFreq = make(map[string]int)
+				docPostings[id] = fieldFreq
+			}
+			fieldFreq[field]++
+			if _, ok = seen[token]; !ok {
+				seen[token] = struct{}{}
+				doc.terms = append(doc.terms, token)
+			}
+		}
+	}
+	i.docs[id] = doc
+}
+
+// Delete 删除文档
+func (i *Index[T]) Delete(id string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.markDirty(id)
+	i.delete(id)
+}
+
+func (i *Index[T]) markDirty(id string) {
+	if i.dirty != nil {
+		i.dirty[id] = struct{}{}
+	}
+}
+
+func (i *Index[T]) delete(id string) {
+	doc, ok := i.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range doc.terms {
+		delete(i.postings[term], id)
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+		}
+	}
+	for field, l := range doc.fieldLens {
+		i.totalFieldLens[field] -= l
+	}
+	delete(i.docs, id)
+}
+
+// Reset 清空索引后批量写入文档，fn 应在调用期间读取数据源。
+// 重建期间通过 Upsert 或 Delete 修改的文档以修改后的状态为准，避免被较早的快照覆盖
+func (i *Index[T]) Reset(fn func(upsert func(id string, fields map[string]string, payload T)) error) error {
+	i.mu.Lock()
+	i.dirty = make(map[string]struct{})
+	i.mu.Unlock()
+
+	fresh := NewIndex[T](i.fieldWeights)
+	err := fn(func(id string, fields map[string]string, payload T) {
+		fresh.Upsert(id, fields, payload)
+	})
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	dirty := i.dirty
+	i.dirty = nil
+	if err != nil {
+		return err
+	}
+	for id := range dirty {
+		fresh.copyDoc(i, id)
+	}
+	i.docs = fresh.docs
+	i.postings = fresh.postings
+	i.totalFieldLens = fresh.totalFieldLens
+	return nil
+}
+
+// copyDoc 用 src 中的文档替换当前索引中的同名文档，src 中不存在时删除
+func (i *Index[T]) copyDoc(src *Index[T], id string) {
+	i.delete(id)
+	doc, ok := src.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range doc.terms {
+		docPostings, ok := i.postings[term]
+		if !ok {
+			docPostings = make(map[string]map[string]int)
+			i.postings[term] = docPostings
+		}
+		fieldFreq := make(map[string]int, len(src.postings[term][id]))
+		for field, freq := range src.postings[term][id] {
+			fieldFreq[field] = freq
+		}
+		docPostings[id] = fieldFreq
+	}
+	for field, l := range doc.fieldLens {
+		i.totalFieldLens[field] += l
+	}
+	i.docs[id] = doc
+}
+
+// Search 搜索包含 query 中所有词元的文档，并按相关度从高到低排序，filter 为 nil 时不过滤
+func (i *Index[T]) Search(query string, filter func(payload T) bool) []Hit[T] {
+	terms := Unique(queryTokens(query))
+	if len(terms) == 0 {
+		return nil
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	// 从文档数最少的词元开始求交集
+	sort.Slice(terms, func(x, y int) bool {
+		return len(i.postings[terms[x]]) < len(i.postings[terms[y]])
+	})
+	candidates := make([]string, 0, len(i.postings[terms[0]]))
+	for id := range i.postings[terms[0]] {
+		matchAll := true
+		for _, term := range terms[1:] {
+			if _, ok := i.postings[term][id]; !ok {
+				matchAll = false
+				break
+			}
+		}
+		if matchAll && (filter == nil || filter(i.docs[id].payload)) {
+			candidates = append(candidates, id)
+		}
+	}
+
+	n := float64(len(i.docs))
+	hits := make([]Hit[T], 0, len(candidates))
+	for _, id := range candidates {
+		doc := i.docs[id]
+		var score float64
+		for _, term := range terms {
+			df := float64(len(i.postings[term]))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			var tf float64
+			for field, freq := range i.postings[term][id] {
+				avgLen := float64(i.totalFieldLens[field]) / n
+				norm := 1.0
+				if avgLen > 0 {
+					norm = 1 - b + b*float64(doc.fieldLens[field])/avgLen
+				}
+				tf += i.fieldWeights[field] * float64(freq) / norm
+			}
+			score += idf * tf / (tf + k1)
+		}
+		hits = append(hits, Hit[T]{Id: id, Score: score, Payload: doc.payload})
+	}
+	sort.SliceStable(hits, func(x, y int) bool {
+		if hits[x].Score == hits[y].Score {
+			return hits[x].Id < hits[y].Id
+		}
+		return hits[x].Score > hits[y].Score
+	})
+	return hits
+}
+
+// Len 返回索引中的文档数量
+func (i *Index[T]) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.docs)
+}
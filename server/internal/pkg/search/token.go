@@ -0,0 +1,94 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"unicode"
+)
+
+// Tokenize 将文本切分为词元：英文、数字按单词切分，中日韩文字按单字和双字（bigram）切分，
+// 这样不依赖分词词典也能对中文进行检索
+func Tokenize(text string) []string {
+	return tokenize(text, true)
+}
+
+// queryTokens 将搜索内容切分为词元，连续的中日韩文字只取双字，单个字时取单字
+func queryTokens(text string) []string {
+	return tokenize(text, false)
+}
+
+func tokenize(text string, withUnigram bool) []string {
+	var (
+		tokens []string
+		word   []rune
+		cjk    []rune
+	)
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			tokens = append(tokens, string(cjk))
+		} else if len(cjk) > 1 {
+			for idx := range cjk {
+				if withUnigram {
+					tokens = append(tokens, string(cjk[idx]))
+				}
+				if idx+1 < len(cjk) {
+					tokens = append(tokens, string(cjk[idx:idx+2]))
+				}
+			}
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range text {
+		r = unicode.ToLower(r)
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// Unique 去除重复的词元，保持原有顺序
+func Unique(tokens []string) []string {
+	seen := make(map[string]struct{}, len(tokens))
+	result := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if _, ok := seen[token]; ok {
+			continue
+		}
+		seen[token] = struct{}{}
+		result = append(result, token)
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package textutil
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	testCases := []struct {
+		name string
+		want string
+	}{
+		{name: "Go Programming", want: "go-programming"},
+		{name: "  C++ & Rust!  ", want: "c-rust"},
+		{name: "编程 笔记", want: "编程-笔记"},
+		{name: "---", want: ""},
+	}
+	for _, tc := range testCases {
+		if got := Slugify(tc.name); got != tc.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWordCount(t *testing.T) {
+	testCases := []struct {
+		content string
+		want    int
+	}{
+		{content: "", want: 0},
+		{content: "hello world", want: 10},
+		{content: "你好，\n世界", want: 5},
+	}
+	for _, tc := range testCases {
+		if got := WordCount(tc.content); got != tc.want {
+			t.Errorf("WordCount(%q) = %d, want %d", tc.content, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	redactFieldsKey = "audit-redact-fields"
+	notesKey        = "audit-notes"
+)
+
+// Redact 声明路由的请求中需要在审计日志里脱敏的字段（不区分大小写，匹配任意层级），
+// 用于字段名无法体现敏感性的场景，例如包含令牌的推送地址：group.POST("", audit.Redact("url"), handler)
+func Redact(fields ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set(redactFieldsKey, append(RedactedFields(ctx), fields...))
+		ctx.Next()
+	}
+}
+
+// RedactedFields 返回路由通过 Redact 声明的脱敏字段
+func RedactedFields(ctx *gin.Context) []string {
+	return ctx.GetStringSlice(redactFieldsKey)
+}
+
+// Note 在审计日志中附加处理结果，例如恢复备份后记录恢复了哪些数据，审计日志在请求处理完成后写入
+func Note(ctx *gin.Context, note string) {
+	ctx.Set(notesKey, append(Notes(ctx), note))
+}
+
+// Notes 返回请求处理过程中通过 Note 附加的内容
+func Notes(ctx *gin.Context) []string {
+	return ctx.GetStringSlice(notesKey)
+}
@@ -37,6 +37,32 @@ type PostsQueryCondition struct {
 	Tags       []string
 }
 
+type SearchCondition struct {
+	Keyword       string
+	OnlyDisplayed bool
+	Categories    []string
+	Tags          []string
+}
+
+// SearchDocument 索引中保存的文章信息，用于过滤搜索结果
+type SearchDocument struct {
+	IsDisplayed bool
+	Categories  []string
+	Tags        []string
+}
+
+type SearchPost struct {
+	*Post
+	Highlight PostHighlight
+}
+
+// PostHighlight 搜索结果的高亮片段，命中内容使用 <em></em> 包裹，未命中的字段为空字符串
+type PostHighlight struct {
+	Title   string
+	Summary string
+	Content string
+}
+
 type DetailPostVO struct {
 	PrimaryPost
 	ExtraPost
@@ -57,6 +83,8 @@ type ExtraPost struct {
 	UpdatedAt        int64  `json:"updated_at"`
 	IsDisplayed      bool   `json:"is_displayed"`
 	IsCommentAllowed bool   `json:"is_comment_allowed"`
+	// 发布时所用草稿的版本
+	DraftRevision string `json:"-"`
 }
 
 type PrimaryPost struct {
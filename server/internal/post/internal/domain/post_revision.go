@@ -0,0 +1,62 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import "github.com/chenmingyong0423/fnote/server/internal/pkg/diffutil"
+
+type RevisionSource string
+
+const (
+	RevisionSourceCreate   RevisionSource = "create"
+	RevisionSourcePublish  RevisionSource = "publish"
+	RevisionSourceRollback RevisionSource = "rollback"
+	// RevisionSourceBaseline 修订功能上线前已存在的文章，首次修改时补录的原始版本
+	RevisionSourceBaseline RevisionSource = "baseline"
+)
+
+// PostRevision 文章每次发布时保存的不可变快照
+type PostRevision struct {
+	Id      string
+	PostId  string
+	Version int
+	Source  RevisionSource
+	// 回滚产生的修订所对应的原版本号
+	RollbackFrom    int
+	Author          string
+	Title           string
+	Summary         string
+	CoverImg        string
+	Content         string
+	MetaDescription string
+	MetaKeywords    string
+	Categories      []Category4Post
+	Tags            []Tag4Post
+	StickyWeight    int
+	WordCount       int
+	CreatedAt       int64
+}
+
+type RevisionFieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+type PostRevisionDiff struct {
+	From   *PostRevision
+	To     *PostRevision
+	Fields []RevisionFieldChange
+	Lines  []diffutil.Line
+}
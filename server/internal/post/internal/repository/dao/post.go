@@ -57,6 +57,8 @@ type PostFields struct {
 	MetaKeywords     string          `bson:"meta_keywords"`
 	WordCount        int             `bson:"word_count"`
 	IsCommentAllowed bool            `bson:"is_comment_allowed"`
+	// DraftRevision 发布时所用草稿的版本，不是由草稿发布时为空
+	DraftRevision string `bson:"draft_revision"`
 }
 
 type Category4Post struct {
@@ -87,18 +89,39 @@ type IPostDao interface {
 	IncreasePostLikeCount(ctx context.Context, postId string) error
 	FindDisplayedPosts(ctx context.Context) ([]*Post, error)
 	UpdateCoverImageById(ctx context.Context, id string, coverImage string) error
+	FindByIds(ctx context.Context, ids []string) ([]*Post, error)
+	FindAll(ctx context.Context) ([]*Post, error)
+	IPostRevisionDao
 }
 
 var _ IPostDao = (*PostDao)(nil)
 
 func NewPostDao(db *mongox.Database) *PostDao {
 	return &PostDao{
-		coll: mongox.NewCollection[Post](db, "posts"),
+		coll:         mongox.NewCollection[Post](db, "posts"),
+		revisionColl: mongox.NewCollection[PostRevision](db, "post_revisions"),
 	}
 }
 
 type PostDao struct {
-	coll *mongox.Collection[Post]
+	coll         *mongox.Collection[Post]
+	revisionColl *mongox.Collection[PostRevision]
+}
+
+func (d *PostDao) FindAll(ctx context.Context) ([]*Post, error) {
+	posts, err := d.coll.Finder().Find(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "fails to find all the documents from post")
+	}
+	return posts, nil
+}
+
+func (d *PostDao) FindByIds(ctx context.Context, ids []string) ([]*Post, error) {
+	posts, err := d.coll.Finder().Filter(query.In("_id", ids...)).Find(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find the documents from post, ids=%v", ids)
+	}
+	return posts, nil
 }
 
 func (d *PostDao) UpdateCoverImageById(ctx context.Context, id string, coverImage string) error {
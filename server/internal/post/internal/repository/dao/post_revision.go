@@ -0,0 +1,104 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/bsonx"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// PostRevision 文章修订，post_id + version 上建有唯一索引
+type PostRevision struct {
+	mongox.Model    `bson:",inline"`
+	PostId          string          `bson:"post_id"`
+	Version         int             `bson:"version"`
+	Source          string          `bson:"source"`
+	RollbackFrom    int             `bson:"rollback_from,omitempty"`
+	Author          string          `bson:"author"`
+	Title           string          `bson:"title"`
+	Summary         string          `bson:"summary"`
+	CoverImg        string          `bson:"cover_img"`
+	Content         string          `bson:"content"`
+	MetaDescription string          `bson:"meta_description"`
+	MetaKeywords    string          `bson:"meta_keywords"`
+	Categories      []Category4Post `bson:"categories"`
+	Tags            []Tag4Post      `bson:"tags"`
+	StickyWeight    int             `bson:"sticky_weight"`
+	WordCount       int             `bson:"word_count"`
+}
+
+type IPostRevisionDao interface {
+	CreateRevision(ctx context.Context, revision *PostRevision) error
+	FindLatestRevision(ctx context.Context, postId string) (*PostRevision, error)
+	FindRevisionByVersion(ctx context.Context, postId string, version int) (*PostRevision, error)
+	// FindRevisions 按版本号倒序分页查询修订，不返回正文
+	FindRevisions(ctx context.Context, postId string, skip, limit int64) ([]*PostRevision, error)
+	CountRevisions(ctx context.Context, postId string) (int64, error)
+	DeleteRevisionsByPostId(ctx context.Context, postId string) error
+}
+
+func (d *PostDao) CreateRevision(ctx context.Context, revision *PostRevision) error {
+	_, err := d.revisionColl.Creator().InsertOne(ctx, revision)
+	if err != nil {
+		return errors.Wrapf(err, "fails to insert a post revision, post_id=%s, version=%d", revision.PostId, revision.Version)
+	}
+	return nil
+}
+
+func (d *PostDao) FindLatestRevision(ctx context.Context, postId string) (*PostRevision, error) {
+	revision, err := d.revisionColl.Finder().Filter(query.Eq("post_id", postId)).FindOne(ctx, options.FindOne().SetSort(bsonx.M("version", -1)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find the latest post revision, post_id=%s", postId)
+	}
+	return revision, nil
+}
+
+func (d *PostDao) FindRevisionByVersion(ctx context.Context, postId string, version int) (*PostRevision, error) {
+	revision, err := d.revisionColl.Finder().Filter(query.NewBuilder().Eq("post_id", postId).Eq("version", version).Build()).FindOne(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find the post revision, post_id=%s, version=%d", postId, version)
+	}
+	return revision, nil
+}
+
+func (d *PostDao) FindRevisions(ctx context.Context, postId string, skip, limit int64) ([]*PostRevision, error) {
+	findOptions := options.Find().SetSort(bsonx.M("version", -1)).SetSkip(skip).SetLimit(limit).SetProjection(bsonx.M("content", 0))
+	revisions, err := d.revisionColl.Finder().Filter(query.Eq("post_id", postId)).Find(ctx, findOptions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find post revisions, post_id=%s", postId)
+	}
+	return revisions, nil
+}
+
+func (d *PostDao) CountRevisions(ctx context.Context, postId string) (int64, error) {
+	count, err := d.revisionColl.Finder().Filter(query.Eq("post_id", postId)).Count(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to count post revisions, post_id=%s", postId)
+	}
+	return count, nil
+}
+
+func (d *PostDao) DeleteRevisionsByPostId(ctx context.Context, postId string) error {
+	_, err := d.revisionColl.Deleter().Filter(query.Eq("post_id", postId)).DeleteMany(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to delete post revisions, post_id=%s", postId)
+	}
+	return nil
+}
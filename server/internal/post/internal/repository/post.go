@@ -51,6 +51,9 @@ type IPostRepository interface {
 	IncreasePostLikeCount(ctx context.Context, postId string) error
 	FindDisplayedPosts(ctx context.Context) ([]domain.Post, error)
 	UpdateCoverImage(ctx context.Context, id string, coverImage string) error
+	FindPostsByIds(ctx context.Context, ids []string) ([]*domain.Post, error)
+	FindAllPosts(ctx context.Context) ([]*domain.Post, error)
+	IPostRevisionRepository
 }
 
 var _ IPostRepository = (*PostRepository)(nil)
@@ -65,6 +68,33 @@ type PostRepository struct {
 	dao dao.IPostDao
 }
 
+func (r *PostRepository) FindAllPosts(ctx context.Context) ([]*domain.Post, error) {
+	posts, err := r.dao.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomainPosts(posts), nil
+}
+
+// FindPostsByIds 根据 id 查询文章，返回结果的顺序与 ids 一致，不存在的文章会被忽略
+func (r *PostRepository) FindPostsByIds(ctx context.Context, ids []string) ([]*domain.Post, error) {
+	posts, err := r.dao.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	postMap := make(map[string]*dao.Post, len(posts))
+	for _, post := range posts {
+		postMap[post.Id] = post
+	}
+	result := make([]*domain.Post, 0, len(posts))
+	for _, id := range ids {
+		if post, ok := postMap[id]; ok {
+			result = append(result, r.daoPostToDomainPost(post))
+		}
+	}
+	return result, nil
+}
+
 func (r *PostRepository) UpdateCoverImage(ctx context.Context, id string, coverImage string) error {
 	return r.dao.UpdateCoverImageById(ctx, id, coverImage)
 }
@@ -107,6 +137,7 @@ func (r *PostRepository) SavePost(ctx context.Context, post *domain.Post) error
 			MetaKeywords:     post.MetaKeywords,
 			WordCount:        post.WordCount,
 			IsCommentAllowed: post.IsCommentAllowed,
+			DraftRevision:    post.DraftRevision,
 		},
 	})
 }
@@ -138,7 +169,15 @@ func (r *PostRepository) DeletePost(ctx context.Context, id string) error {
 }
 
 func (r *PostRepository) AddPost(ctx context.Context, post *domain.Post) error {
-	now := time.Now().Local()
+	// 导入文章时保留原有的创建和更新时间
+	createdAt, updatedAt := time.Now().Local(), time.Now().Local()
+	if post.CreatedAt != 0 {
+		createdAt = time.Unix(post.CreatedAt, 0).Local()
+		updatedAt = createdAt
+	}
+	if post.UpdatedAt != 0 {
+		updatedAt = time.Unix(post.UpdatedAt, 0).Local()
+	}
 	categories := make([]dao.Category4Post, 0, len(post.Categories))
 	for _, category := range post.Categories {
 		categories = append(categories, dao.Category4Post{
@@ -170,11 +209,11 @@ func (r *PostRepository) AddPost(ctx context.Context, post *domain.Post) error {
 			StickyWeight:     post.StickyWeight,
 			MetaDescription:  post.MetaDescription,
 			MetaKeywords:     post.MetaKeywords,
-			WordCount:        0,
+			WordCount:        post.WordCount,
 			IsCommentAllowed: post.IsCommentAllowed,
 		},
-		CreatedAt: now,
-		UpdatedAt: now,
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
 	})
 	if err != nil {
 		return err
@@ -330,7 +369,7 @@ func (r *PostRepository) daoPostToDomainPost(post *dao.Post) *domain.Post {
 			Name: t.Name,
 		}
 	})
-	return &domain.Post{PrimaryPost: domain.PrimaryPost{Id: post.Id, Author: post.Author, Title: post.Title, Summary: post.Summary, CoverImg: post.CoverImg, Categories: categories, Tags: tags, LikeCount: post.LikeCount, CommentCount: post.CommentCount, VisitCount: post.VisitCount, StickyWeight: post.StickyWeight, CreatedAt: post.CreatedAt.Unix()}, ExtraPost: domain.ExtraPost{Content: post.Content, MetaDescription: post.MetaDescription, MetaKeywords: post.MetaKeywords, WordCount: post.WordCount, UpdatedAt: post.UpdatedAt.Unix(), IsCommentAllowed: post.IsCommentAllowed, IsDisplayed: post.IsDisplayed}}
+	return &domain.Post{PrimaryPost: domain.PrimaryPost{Id: post.Id, Author: post.Author, Title: post.Title, Summary: post.Summary, CoverImg: post.CoverImg, Categories: categories, Tags: tags, LikeCount: post.LikeCount, CommentCount: post.CommentCount, VisitCount: post.VisitCount, StickyWeight: post.StickyWeight, CreatedAt: post.CreatedAt.Unix()}, ExtraPost: domain.ExtraPost{Content: post.Content, MetaDescription: post.MetaDescription, MetaKeywords: post.MetaKeywords, WordCount: post.WordCount, UpdatedAt: post.UpdatedAt.Unix(), IsCommentAllowed: post.IsCommentAllowed, IsDisplayed: post.IsDisplayed, DraftRevision: post.DraftRevision}}
 }
 
 func (r *PostRepository) toDaoTags4Post(ts []domain.Tag4Post) []dao.Tag4Post {
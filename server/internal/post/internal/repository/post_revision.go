@@ -0,0 +1,112 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+
+	"github.com/chenmingyong0423/fnote/server/internal/post/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/post/internal/repository/dao"
+	"github.com/chenmingyong0423/gkit/slice"
+)
+
+type IPostRevisionRepository interface {
+	CreateRevision(ctx context.Context, revision *domain.PostRevision) error
+	FindLatestRevision(ctx context.Context, postId string) (*domain.PostRevision, error)
+	FindRevisionByVersion(ctx context.Context, postId string, version int) (*domain.PostRevision, error)
+	FindRevisions(ctx context.Context, postId string, skip, limit int64) ([]*domain.PostRevision, error)
+	CountRevisions(ctx context.Context, postId string) (int64, error)
+	DeleteRevisionsByPostId(ctx context.Context, postId string) error
+}
+
+func (r *PostRepository) CreateRevision(ctx context.Context, revision *domain.PostRevision) error {
+	return r.dao.CreateRevision(ctx, &dao.PostRevision{
+		PostId:          revision.PostId,
+		Version:         revision.Version,
+		Source:          string(revision.Source),
+		RollbackFrom:    revision.RollbackFrom,
+		Author:          revision.Author,
+		Title:           revision.Title,
+		Summary:         revision.Summary,
+		CoverImg:        revision.CoverImg,
+		Content:         revision.Content,
+		MetaDescription: revision.MetaDescription,
+		MetaKeywords:    revision.MetaKeywords,
+		Categories:      r.toDaoCategory4Post(revision.Categories),
+		Tags:            r.toDaoTags4Post(revision.Tags),
+		StickyWeight:    revision.StickyWeight,
+		WordCount:       revision.WordCount,
+	})
+}
+
+func (r *PostRepository) FindLatestRevision(ctx context.Context, postId string) (*domain.PostRevision, error) {
+	revision, err := r.dao.FindLatestRevision(ctx, postId)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomainRevision(revision), nil
+}
+
+func (r *PostRepository) FindRevisionByVersion(ctx context.Context, postId string, version int) (*domain.PostRevision, error) {
+	revision, err := r.dao.FindRevisionByVersion(ctx, postId, version)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomainRevision(revision), nil
+}
+
+func (r *PostRepository) FindRevisions(ctx context.Context, postId string, skip, limit int64) ([]*domain.PostRevision, error) {
+	revisions, err := r.dao.FindRevisions(ctx, postId, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(revisions, func(_ int, revision *dao.PostRevision) *domain.PostRevision {
+		return r.toDomainRevision(revision)
+	}), nil
+}
+
+func (r *PostRepository) CountRevisions(ctx context.Context, postId string) (int64, error) {
+	return r.dao.CountRevisions(ctx, postId)
+}
+
+func (r *PostRepository) DeleteRevisionsByPostId(ctx context.Context, postId string) error {
+	return r.dao.DeleteRevisionsByPostId(ctx, postId)
+}
+
+func (r *PostRepository) toDomainRevision(revision *dao.PostRevision) *domain.PostRevision {
+	return &domain.PostRevision{
+		Id:              revision.ID.Hex(),
+		PostId:          revision.PostId,
+		Version:         revision.Version,
+		Source:          domain.RevisionSource(revision.Source),
+		RollbackFrom:    revision.RollbackFrom,
+		Author:          revision.Author,
+		Title:           revision.Title,
+		Summary:         revision.Summary,
+		CoverImg:        revision.CoverImg,
+		Content:         revision.Content,
+		MetaDescription: revision.MetaDescription,
+		MetaKeywords:    revision.MetaKeywords,
+		Categories: slice.Map(revision.Categories, func(_ int, c dao.Category4Post) domain.Category4Post {
+			return domain.Category4Post{Id: c.Id, Name: c.Name}
+		}),
+		Tags: slice.Map(revision.Tags, func(_ int, t dao.Tag4Post) domain.Tag4Post {
+			return domain.Tag4Post{Id: t.Id, Name: t.Name}
+		}),
+		StickyWeight: revision.StickyWeight,
+		WordCount:    revision.WordCount,
+		CreatedAt:    revision.CreatedAt.Unix(),
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 
 	jsoniter "github.com/json-iterator/go"
@@ -32,8 +33,11 @@ import (
 	"github.com/chenmingyong0423/fnote/server/internal/post/internal/repository"
 	"github.com/chenmingyong0423/fnote/server/internal/website_config"
 
+	"github.com/chenmingyong0423/gkit"
 	"github.com/chenmingyong0423/gkit/slice"
 
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/search"
+
 	"github.com/chenmingyong0423/gkit/uuidx"
 
 	"github.com/gin-gonic/gin"
@@ -55,15 +59,23 @@ type IPostService interface {
 	IncreasePostLikeCount(ctx context.Context, postId string) error
 	FindDisplayedPosts(ctx context.Context) ([]domain.Post, error)
 	UpdatePostCoverImage(ctx context.Context, postId string, coverImage string) error
+	SearchPosts(ctx context.Context, pageRequest *domain.PostRequest) ([]*domain.SearchPost, int64, error)
+
+	GetPostRevisions(ctx context.Context, postId string, pageNo, pageSize int64) ([]*domain.PostRevision, int64, error)
+	GetPostRevision(ctx context.Context, postId string, version int) (*domain.PostRevision, error)
+	DiffPostRevisions(ctx context.Context, postId string, fromVersion, toVersion int) (*domain.PostRevisionDiff, error)
+	// RollbackPost 将文章回滚到指定修订，并像普通发布一样发布 post 事件和记录新的修订
+	RollbackPost(ctx context.Context, postId string, version int) error
 }
 
 var _ IPostService = (*PostService)(nil)
 
-func NewPostService(repo repository.IPostRepository, cfgService website_config.Service, eventBus *eventbus.EventBus) *PostService {
+func NewPostService(repo repository.IPostRepository, cfgService website_config.Service, eventBus *eventbus.EventBus, searchServ ISearchService) *PostService {
 	s := &PostService{
 		repo:       repo,
 		cfgService: cfgService,
 		eventBus:   eventBus,
+		searchServ: searchServ,
 	}
 	go s.subscribeCommentEvent()
 	return s
@@ -73,6 +85,45 @@ type PostService struct {
 	repo       repository.IPostRepository
 	cfgService website_config.Service
 	eventBus   *eventbus.EventBus
+	searchServ ISearchService
+}
+
+func (s *PostService) SearchPosts(ctx context.Context, pageRequest *domain.PostRequest) ([]*domain.SearchPost, int64, error) {
+	keyword := strings.TrimSpace(gkit.GetValueOrDefault(pageRequest.Keyword))
+	var (
+		posts []*domain.Post
+		total int64
+		err   error
+	)
+	if s.searchServ.IsReady() {
+		hits := s.searchServ.Search(ctx, domain.SearchCondition{
+			Keyword:       keyword,
+			OnlyDisplayed: true,
+			Categories:    pageRequest.Categories,
+			Tags:          pageRequest.Tags,
+		})
+		total = int64(len(hits))
+		skip := min((pageRequest.PageNo-1)*pageRequest.PageSize, total)
+		end := min(skip+pageRequest.PageSize, total)
+		ids := slice.Map(hits[skip:end], func(_ int, hit search.Hit[domain.SearchDocument]) string {
+			return hit.Id
+		})
+		if len(ids) > 0 {
+			posts, err = s.repo.FindPostsByIds(ctx, ids)
+		}
+	} else {
+		// 索引尚未构建完成，退化为标题的模糊查询
+		posts, total, err = s.GetPosts(ctx, pageRequest)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return slice.Map(posts, func(_ int, post *domain.Post) *domain.SearchPost {
+		return &domain.SearchPost{
+			Post:      post,
+			Highlight: s.searchServ.Highlight(post, keyword),
+		}
+	}), total, nil
 }
 
 func (s *PostService) UpdatePostCoverImage(ctx context.Context, postId string, coverImage string) error {
@@ -92,10 +143,27 @@ func (s *PostService) UpdatePostIsCommentAllowed(ctx context.Context, id string,
 }
 
 func (s *PostService) UpdatePostIsDisplayed(ctx context.Context, id string, isDisplayed bool) error {
-	return s.repo.UpdatePostIsDisplayedById(ctx, id, isDisplayed)
+	err := s.repo.UpdatePostIsDisplayedById(ctx, id, isDisplayed)
+	if err != nil {
+		return err
+	}
+	// 修改展示状态不会发布 post 事件，需要主动更新索引
+	err = s.searchServ.RefreshPost(ctx, id)
+	if err != nil {
+		slog.WarnContext(ctx, "Post: failed to refresh the search index of post", "postId", id, "error", err)
+	}
+	return nil
 }
 
 func (s *PostService) SavePost(ctx context.Context, originalPost *domain.Post, savedPost *domain.Post, isNewPost bool) error {
+	source := domain.RevisionSourcePublish
+	if isNewPost {
+		source = domain.RevisionSourceCreate
+	}
+	return s.savePost(ctx, originalPost, savedPost, isNewPost, source, 0)
+}
+
+func (s *PostService) savePost(ctx context.Context, originalPost *domain.Post, savedPost *domain.Post, isNewPost bool, source domain.RevisionSource, rollbackFrom int) error {
 	var (
 		marshal []byte
 		err     error
@@ -116,6 +184,7 @@ func (s *PostService) SavePost(ctx context.Context, originalPost *domain.Post, s
 	if err != nil {
 		return err
 	}
+	s.recordRevision(ctx, originalPost, savedPost, source, rollbackFrom)
 
 	go func() {
 		if isNewPost {
@@ -219,6 +288,10 @@ func (s *PostService) DeletePost(ctx context.Context, id string) error {
 	if err != nil {
 		return err
 	}
+	err = s.repo.DeleteRevisionsByPostId(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Post: failed to delete the revisions of post", "postId", id, "error", err)
+	}
 
 	s.eventBus.Publish("post", eventbus.Event{Payload: marshal})
 	return nil
@@ -238,6 +311,7 @@ func (s *PostService) AddPost(ctx context.Context, post *domain.Post) error {
 	if err != nil {
 		return err
 	}
+	s.recordRevision(ctx, nil, post, domain.RevisionSourceCreate, 0)
 	s.eventBus.Publish("post", eventbus.Event{Payload: marshal})
 	return nil
 }
@@ -262,7 +336,35 @@ func (s *PostService) marshalPostEvent(post *domain.Post) ([]byte, error) {
 }
 
 func (s *PostService) AdminGetPosts(ctx context.Context, page domain.Page) ([]*domain.Post, int64, error) {
-	return s.repo.QueryAdminPostsPage(ctx, page)
+	keyword := strings.TrimSpace(page.Keyword)
+	if keyword == "" || !s.searchServ.IsReady() {
+		return s.repo.QueryAdminPostsPage(ctx, page)
+	}
+	// 按搜索结果的相关度排序分页，后台的分类和标签筛选只需命中其中之一，与 QueryAdminPostsPage 一致
+	ids := slice.FilterMap(s.searchServ.Search(ctx, domain.SearchCondition{Keyword: keyword}), func(_ int, hit search.Hit[domain.SearchDocument]) (string, bool) {
+		return hit.Id, containsAny(hit.Payload.Categories, page.CategoryFilter) && containsAny(hit.Payload.Tags, page.TagFilter)
+	})
+	total := int64(len(ids))
+	skip := min(page.Skip, total)
+	end := min(skip+page.Size, total)
+	if skip == end {
+		return []*domain.Post{}, total, nil
+	}
+	posts, err := s.repo.FindPostsByIds(ctx, ids[skip:end])
+	if err != nil {
+		return nil, 0, err
+	}
+	return posts, total, nil
+}
+
+// containsAny filter 为空或 values 中包含 filter 中的任意一项时返回 true
+func containsAny(values, filter []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(values, func(v string) bool {
+		return slices.Contains(filter, v)
+	})
 }
 
 func (s *PostService) IncreaseVisitCount(ctx context.Context, id string) error {
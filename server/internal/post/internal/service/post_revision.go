@@ -0,0 +1,171 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/diffutil"
+	"github.com/chenmingyong0423/fnote/server/internal/post/internal/domain"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// revisionInsertRetries 并发发布导致版本号冲突时的最大重试次数
+const revisionInsertRetries = 3
+
+func (s *PostService) GetPostRevisions(ctx context.Context, postId string, pageNo, pageSize int64) ([]*domain.PostRevision, int64, error) {
+	total, err := s.repo.CountRevisions(ctx, postId)
+	if err != nil {
+		return nil, 0, err
+	}
+	revisions, err := s.repo.FindRevisions(ctx, postId, (pageNo-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	return revisions, total, nil
+}
+
+func (s *PostService) GetPostRevision(ctx context.Context, postId string, version int) (*domain.PostRevision, error) {
+	return s.repo.FindRevisionByVersion(ctx, postId, version)
+}
+
+func (s *PostService) DiffPostRevisions(ctx context.Context, postId string, fromVersion, toVersion int) (*domain.PostRevisionDiff, error) {
+	from, err := s.repo.FindRevisionByVersion(ctx, postId, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.repo.FindRevisionByVersion(ctx, postId, toVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.PostRevisionDiff{
+		From:   from,
+		To:     to,
+		Fields: s.diffRevisionFields(from, to),
+		Lines:  diffutil.DiffText(from.Content, to.Content),
+	}, nil
+}
+
+func (s *PostService) RollbackPost(ctx context.Context, postId string, version int) error {
+	revision, err := s.repo.FindRevisionByVersion(ctx, postId, version)
+	if err != nil {
+		return err
+	}
+	post, err := s.repo.FindPostById(ctx, postId)
+	if err != nil {
+		return err
+	}
+	// 只回滚修订中保存的内容，点赞、评论等计数以及显示状态保持不变
+	savedPost := *post
+	savedPost.Author = revision.Author
+	savedPost.Title = revision.Title
+	savedPost.Summary = revision.Summary
+	savedPost.CoverImg = revision.CoverImg
+	savedPost.Categories = revision.Categories
+	savedPost.Tags = revision.Tags
+	savedPost.StickyWeight = revision.StickyWeight
+	savedPost.Content = revision.Content
+	savedPost.MetaDescription = revision.MetaDescription
+	savedPost.MetaKeywords = revision.MetaKeywords
+	savedPost.WordCount = revision.WordCount
+	savedPost.UpdatedAt = time.Now().Local().Unix()
+	return s.savePost(ctx, post, &savedPost, false, domain.RevisionSourceRollback, version)
+}
+
+// recordRevision 为保存后的文章追加一条修订；修订写入失败只记录日志，不影响文章本身的保存
+func (s *PostService) recordRevision(ctx context.Context, originalPost *domain.Post, savedPost *domain.Post, source domain.RevisionSource, rollbackFrom int) {
+	revision := s.postToRevision(savedPost, source)
+	revision.RollbackFrom = rollbackFrom
+	for i := 0; i < revisionInsertRetries; i++ {
+		latest, err := s.repo.FindLatestRevision(ctx, savedPost.Id)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			slog.ErrorContext(ctx, "Post: failed to find the latest revision", "postId", savedPost.Id, "error", err)
+			return
+		}
+		if latest == nil && originalPost != nil {
+			// 修订功能上线前已存在的文章，先补录修改前的版本，以便可以回滚到该版本
+			latest = s.postToRevision(originalPost, domain.RevisionSourceBaseline)
+			latest.Version = 1
+			err = s.repo.CreateRevision(ctx, latest)
+			if err != nil && !mongo.IsDuplicateKeyError(err) {
+				slog.ErrorContext(ctx, "Post: failed to create the baseline revision", "postId", savedPost.Id, "error", err)
+				return
+			}
+			if err != nil {
+				continue
+			}
+		}
+		revision.Version = 1
+		if latest != nil {
+			revision.Version = latest.Version + 1
+		}
+		err = s.repo.CreateRevision(ctx, revision)
+		if err == nil {
+			return
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			slog.ErrorContext(ctx, "Post: failed to create the revision", "postId", savedPost.Id, "error", err)
+			return
+		}
+	}
+	slog.ErrorContext(ctx, "Post: failed to create the revision due to version conflicts", "postId", savedPost.Id)
+}
+
+func (s *PostService) postToRevision(post *domain.Post, source domain.RevisionSource) *domain.PostRevision {
+	return &domain.PostRevision{
+		PostId:          post.Id,
+		Source:          source,
+		Author:          post.Author,
+		Title:           post.Title,
+		Summary:         post.Summary,
+		CoverImg:        post.CoverImg,
+		Content:         post.Content,
+		MetaDescription: post.MetaDescription,
+		MetaKeywords:    post.MetaKeywords,
+		Categories:      post.Categories,
+		Tags:            post.Tags,
+		StickyWeight:    post.StickyWeight,
+		WordCount:       post.WordCount,
+	}
+}
+
+func (s *PostService) diffRevisionFields(from, to *domain.PostRevision) []domain.RevisionFieldChange {
+	categoryNames := func(cs []domain.Category4Post) string {
+		return strings.Join(slice.Map(cs, func(_ int, c domain.Category4Post) string { return c.Name }), ", ")
+	}
+	tagNames := func(ts []domain.Tag4Post) string {
+		return strings.Join(slice.Map(ts, func(_ int, t domain.Tag4Post) string { return t.Name }), ", ")
+	}
+	candidates := []domain.RevisionFieldChange{
+		{Field: "title", Old: from.Title, New: to.Title},
+		{Field: "author", Old: from.Author, New: to.Author},
+		{Field: "summary", Old: from.Summary, New: to.Summary},
+		{Field: "cover_img", Old: from.CoverImg, New: to.CoverImg},
+		{Field: "meta_description", Old: from.MetaDescription, New: to.MetaDescription},
+		{Field: "meta_keywords", Old: from.MetaKeywords, New: to.MetaKeywords},
+		{Field: "categories", Old: categoryNames(from.Categories), New: categoryNames(to.Categories)},
+		{Field: "tags", Old: tagNames(from.Tags), New: tagNames(to.Tags)},
+		{Field: "sticky_weight", Old: strconv.Itoa(from.StickyWeight), New: strconv.Itoa(to.StickyWeight)},
+	}
+	return slice.Filter(candidates, func(_ int, c domain.RevisionFieldChange) bool {
+		return c.Old != c.New
+	})
+}
@@ -0,0 +1,237 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/search"
+	"github.com/chenmingyong0423/fnote/server/internal/post/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/post/internal/repository"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/chenmingyong0423/go-eventbus"
+	"github.com/google/uuid"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/viper"
+)
+
+const (
+	searchFieldTitle    = "title"
+	searchFieldSummary  = "summary"
+	searchFieldContent  = "content"
+	searchFieldCategory = "category"
+	searchFieldTag      = "tag"
+
+	// 高亮片段的最大长度
+	summarySnippetLength = 120
+	contentSnippetLength = 160
+)
+
+var (
+	// 各字段在相关度计算中的权重
+	searchFieldWeights = map[string]float64{
+		searchFieldTitle:    5,
+		searchFieldCategory: 3,
+		searchFieldTag:      3,
+		searchFieldSummary:  2,
+		searchFieldContent:  1,
+	}
+
+	mdCodeFenceRegexp = regexp.MustCompile("(?m)^\\s*(```|~~~).*$")
+	mdImageRegexp     = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLinkRegexp      = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdHtmlTagRegexp   = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	mdSyntaxRegexp    = regexp.MustCompile("(?m)^\\s{0,3}(#{1,6}|>+|[-*+]|\\d+\\.)\\s+|[*_`~]{1,3}")
+	whitespaceRegexp  = regexp.MustCompile(`\s+`)
+)
+
+type ISearchService interface {
+	// Search 搜索文章，返回按相关度排序的命中结果
+	Search(ctx context.Context, cond domain.SearchCondition) []search.Hit[domain.SearchDocument]
+	// IsReady 索引是否已经完成首次构建
+	IsReady() bool
+	// RefreshPost 重新索引指定文章，文章不存在时从索引中删除
+	RefreshPost(ctx context.Context, postId string) error
+	// Highlight 生成文章的高亮片段
+	Highlight(post *domain.Post, keyword string) domain.PostHighlight
+}
+
+var _ ISearchService = (*SearchService)(nil)
+
+func NewSearchService(repo repository.IPostRepository, eventBus *eventbus.EventBus) *SearchService {
+	s := &SearchService{
+		repo:     repo,
+		eventBus: eventBus,
+		index:    search.NewIndex[domain.SearchDocument](searchFieldWeights),
+	}
+	go s.subscribePostEvent()
+	go s.rebuildPeriodically()
+	return s
+}
+
+type SearchService struct {
+	repo     repository.IPostRepository
+	eventBus *eventbus.EventBus
+	index    *search.Index[domain.SearchDocument]
+	ready    atomic.Bool
+}
+
+func (s *SearchService) Search(_ context.Context, cond domain.SearchCondition) []search.Hit[domain.SearchDocument] {
+	return s.index.Search(cond.Keyword, func(doc domain.SearchDocument) bool {
+		if cond.OnlyDisplayed && !doc.IsDisplayed {
+			return false
+		}
+		for _, category := range cond.Categories {
+			if !slices.Contains(doc.Categories, category) {
+				return false
+			}
+		}
+		for _, tag := range cond.Tags {
+			if !slices.Contains(doc.Tags, tag) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (s *SearchService) IsReady() bool {
+	return s.ready.Load()
+}
+
+func (s *SearchService) RefreshPost(ctx context.Context, postId string) error {
+	post, err := s.repo.FindPostById(ctx, postId)
+	if err != nil {
+		return err
+	}
+	s.index.Upsert(post.Id, s.searchFields(post), s.toSearchDocument(post))
+	return nil
+}
+
+func (s *SearchService) Highlight(post *domain.Post, keyword string) domain.PostHighlight {
+	return domain.PostHighlight{
+		Title:   search.Highlight(post.Title, keyword, 0),
+		Summary: search.Highlight(post.Summary, keyword, summarySnippetLength),
+		Content: search.Highlight(plainText(post.Content), keyword, contentSnippetLength),
+	}
+}
+
+func (s *SearchService) rebuild(ctx context.Context) error {
+	err := s.index.Reset(func(upsert func(id string, fields map[string]string, payload domain.SearchDocument)) error {
+		posts, err := s.repo.FindAllPosts(ctx)
+		if err != nil {
+			return err
+		}
+		for _, post := range posts {
+			upsert(post.Id, s.searchFields(post), s.toSearchDocument(post))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.ready.Store(true)
+	return nil
+}
+
+// rebuildPeriodically 启动时构建索引，之后定期全量重建，用于修复多实例部署时其他实例修改文章导致的索引不一致
+func (s *SearchService) rebuildPeriodically() {
+	interval := viper.GetDuration("search.rebuild_interval")
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	for {
+		l := slog.Default().With("X-Request-ID", uuid.NewString())
+		start := time.Now()
+		err := s.rebuild(context.Background())
+		if err != nil {
+			l.Error("Search: failed to rebuild the index of posts", "error", err)
+		} else {
+			l.Info("Search: rebuild the index of posts successfully", "count", s.index.Len(), "cost", time.Since(start).String())
+		}
+		time.Sleep(interval)
+	}
+}
+
+func (s *SearchService) searchFields(post *domain.Post) map[string]string {
+	return map[string]string{
+		searchFieldTitle:   post.Title,
+		searchFieldSummary: post.Summary,
+		searchFieldContent: plainText(post.Content),
+		searchFieldCategory: strings.Join(slice.Map(post.Categories, func(_ int, c domain.Category4Post) string {
+			return c.Name
+		}), " "),
+		searchFieldTag: strings.Join(slice.Map(post.Tags, func(_ int, t domain.Tag4Post) string {
+			return t.Name
+		}), " "),
+	}
+}
+
+func (s *SearchService) toSearchDocument(post *domain.Post) domain.SearchDocument {
+	return domain.SearchDocument{
+		IsDisplayed: post.IsDisplayed,
+		Categories: slice.Map(post.Categories, func(_ int, c domain.Category4Post) string {
+			return c.Name
+		}),
+		Tags: slice.Map(post.Tags, func(_ int, t domain.Tag4Post) string {
+			return t.Name
+		}),
+	}
+}
+
+func (s *SearchService) subscribePostEvent() {
+	eventChan := s.eventBus.Subscribe("post")
+	type contextKey string
+	for event := range eventChan {
+		rid := uuid.NewString()
+		var key contextKey = "X-Request-ID"
+		ctx := context.WithValue(context.Background(), key, rid)
+		l := slog.Default().With("X-Request-ID", rid)
+		l.InfoContext(ctx, "Search: post event", "payload", string(event.Payload))
+		var e domain.PostEvent
+		err := jsoniter.Unmarshal(event.Payload, &e)
+		if err != nil {
+			l.ErrorContext(ctx, "Search: post event: failed to unmarshal", "error", err)
+			continue
+		}
+		switch e.Type {
+		case "create", "update":
+			err = s.RefreshPost(ctx, e.PostId)
+			if err != nil {
+				l.ErrorContext(ctx, "Search: post event: failed to index the post", "postId", e.PostId, "error", err)
+				continue
+			}
+		case "delete":
+			s.index.Delete(e.PostId)
+		}
+		l.InfoContext(ctx, "Search: post event: handle successfully")
+	}
+}
+
+// plainText 去除 markdown 语法，得到用于索引和摘要的纯文本
+func plainText(markdown string) string {
+	text := mdCodeFenceRegexp.ReplaceAllString(markdown, " ")
+	text = mdImageRegexp.ReplaceAllString(text, "$1")
+	text = mdLinkRegexp.ReplaceAllString(text, "$1")
+	text = mdHtmlTagRegexp.ReplaceAllString(text, " ")
+	text = mdSyntaxRegexp.ReplaceAllString(text, "")
+	return strings.TrimSpace(whitespaceRegexp.ReplaceAllString(text, " "))
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/chenmingyong0423/go-eventbus"
@@ -53,6 +54,14 @@ type SummaryPostVO struct {
 	VisitCount   int      `json:"visit_count"`
 	StickyWeight int      `json:"sticky_weight"`
 	CreatedAt    int64    `json:"created_at"`
+	// 搜索时返回的高亮片段
+	Highlight *PostHighlightVO `json:"highlight,omitempty"`
+}
+
+type PostHighlightVO struct {
+	Title   string `json:"title,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	Content string `json:"content,omitempty"`
 }
 
 func NewPostHandler(serv service.IPostService, cfgService website_config.Service, postLikeServ post_like.Service, eventBus *eventbus.EventBus) *PostHandler {
@@ -87,6 +96,10 @@ func (h *PostHandler) RegisterGinRoutes(engine *gin.Engine) {
 	adminGroup.PUT("/:id/display", apiwrap.WrapWithBody(h.UpdatePostIsDisplayed))
 	adminGroup.PUT("/:id/comment-allowed", apiwrap.WrapWithBody(h.UpdatePostIsCommentAllowed))
 	adminGroup.PUT("/:id/cover", apiwrap.WrapWithBody(h.UpdatePostCoverImage))
+	adminGroup.GET("/:id/revisions", apiwrap.WrapWithBody(h.AdminGetPostRevisions))
+	adminGroup.GET("/:id/revisions/diff", apiwrap.WrapWithBody(h.AdminDiffPostRevisions))
+	adminGroup.GET("/:id/revisions/:version", apiwrap.Wrap(h.AdminGetPostRevision))
+	adminGroup.POST("/:id/revisions/:version/rollback", apiwrap.Wrap(h.AdminRollbackPost))
 }
 
 func (h *PostHandler) GetLatestPosts(ctx *gin.Context) (*apiwrap.ResponseBody[apiwrap.ListVO[*SummaryPostVO]], error) {
@@ -128,18 +141,45 @@ func (h *PostHandler) postsToPostVOs(posts []*domain.Post) []*SummaryPostVO {
 	return postVOs
 }
 
+func (h *PostHandler) searchPostsToPostVOs(searchPosts []*domain.SearchPost) []*SummaryPostVO {
+	postVOs := h.postsToPostVOs(slice.Map(searchPosts, func(_ int, p *domain.SearchPost) *domain.Post {
+		return p.Post
+	}))
+	for i, searchPost := range searchPosts {
+		postVOs[i].Highlight = &PostHighlightVO{
+			Title:   searchPost.Highlight.Title,
+			Summary: searchPost.Highlight.Summary,
+			Content: searchPost.Highlight.Content,
+		}
+	}
+	return postVOs
+}
+
 func (h *PostHandler) GetPosts(ctx *gin.Context, req *domain.PostRequest) (*apiwrap.ResponseBody[apiwrap.PageVO[*SummaryPostVO]], error) {
 	req.ValidateAndSetDefault()
-	posts, cnt, err := h.serv.GetPosts(ctx, req)
-	if err != nil {
-		return nil, err
+	var (
+		postVOs []*SummaryPostVO
+		cnt     int64
+	)
+	if req.Keyword != nil && strings.TrimSpace(*req.Keyword) != "" {
+		searchPosts, total, err := h.serv.SearchPosts(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		postVOs, cnt = h.searchPostsToPostVOs(searchPosts), total
+	} else {
+		posts, total, err := h.serv.GetPosts(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		postVOs, cnt = h.postsToPostVOs(posts), total
 	}
 	pageVO := apiwrap.PageVO[*SummaryPostVO]{
 		Page: apiwrap.Page{
 			PageNo:   req.Page2.PageNo,
 			PageSize: req.Page2.PageSize,
 		},
-		List: h.postsToPostVOs(posts),
+		List: postVOs,
 	}
 	pageVO.SetTotalCountAndCalculateTotalPages(cnt)
 	return apiwrap.SuccessResponseWithData(pageVO), nil
@@ -0,0 +1,147 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/diffutil"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/fnote/server/internal/post/internal/domain"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type PostRevisionDiffRequest struct {
+	From int `form:"from" binding:"required,min=1"`
+	To   int `form:"to" binding:"required,min=1"`
+}
+
+type PostRevisionVO struct {
+	Version         int               `json:"version"`
+	Source          string            `json:"source"`
+	RollbackFrom    int               `json:"rollback_from,omitempty"`
+	Author          string            `json:"author"`
+	Title           string            `json:"title"`
+	Summary         string            `json:"summary"`
+	CoverImg        string            `json:"cover_img"`
+	Content         string            `json:"content,omitempty"`
+	MetaDescription string            `json:"meta_description"`
+	MetaKeywords    string            `json:"meta_keywords"`
+	Categories      []Category4PostVO `json:"categories"`
+	Tags            []Tag4PostVO      `json:"tags"`
+	StickyWeight    int               `json:"sticky_weight"`
+	WordCount       int               `json:"word_count"`
+	CreatedAt       int64             `json:"created_at"`
+}
+
+type RevisionFieldChangeVO struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type PostRevisionDiffVO struct {
+	From   PostRevisionVO          `json:"from"`
+	To     PostRevisionVO          `json:"to"`
+	Fields []RevisionFieldChangeVO `json:"fields"`
+	Lines  []diffutil.Line         `json:"lines"`
+}
+
+func (h *PostHandler) AdminGetPostRevisions(ctx *gin.Context, req apiwrap.Page) (*apiwrap.ResponseBody[*apiwrap.PageVO[PostRevisionVO]], error) {
+	revisions, total, err := h.serv.GetPostRevisions(ctx, ctx.Param("id"), req.PageNo, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewPageVO(req.PageNo, req.PageSize, total, slice.Map(revisions, func(_ int, revision *domain.PostRevision) PostRevisionVO {
+		return h.revisionToVO(revision)
+	}))), nil
+}
+
+func (h *PostHandler) AdminGetPostRevision(ctx *gin.Context) (*apiwrap.ResponseBody[PostRevisionVO], error) {
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, "invalid version")
+	}
+	revision, err := h.serv.GetPostRevision(ctx, ctx.Param("id"), version)
+	if err != nil {
+		return nil, h.revisionError(err)
+	}
+	return apiwrap.SuccessResponseWithData(h.revisionToVO(revision)), nil
+}
+
+func (h *PostHandler) AdminDiffPostRevisions(ctx *gin.Context, req PostRevisionDiffRequest) (*apiwrap.ResponseBody[PostRevisionDiffVO], error) {
+	diff, err := h.serv.DiffPostRevisions(ctx, ctx.Param("id"), req.From, req.To)
+	if err != nil {
+		return nil, h.revisionError(err)
+	}
+	from, to := h.revisionToVO(diff.From), h.revisionToVO(diff.To)
+	// 正文的差异已体现在 lines 中，不再重复返回
+	from.Content, to.Content = "", ""
+	return apiwrap.SuccessResponseWithData(PostRevisionDiffVO{
+		From: from,
+		To:   to,
+		Fields: slice.Map(diff.Fields, func(_ int, c domain.RevisionFieldChange) RevisionFieldChangeVO {
+			return RevisionFieldChangeVO{Field: c.Field, Old: c.Old, New: c.New}
+		}),
+		Lines: diff.Lines,
+	}), nil
+}
+
+func (h *PostHandler) AdminRollbackPost(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, "invalid version")
+	}
+	err = h.serv.RollbackPost(ctx, ctx.Param("id"), version)
+	if err != nil {
+		return nil, h.revisionError(err)
+	}
+	return apiwrap.SuccessResponse(), nil
+}
+
+func (h *PostHandler) revisionError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return apiwrap.NewErrorResponseBody(http.StatusNotFound, "post or revision not found")
+	}
+	return err
+}
+
+func (h *PostHandler) revisionToVO(revision *domain.PostRevision) PostRevisionVO {
+	return PostRevisionVO{
+		Version:         revision.Version,
+		Source:          string(revision.Source),
+		RollbackFrom:    revision.RollbackFrom,
+		Author:          revision.Author,
+		Title:           revision.Title,
+		Summary:         revision.Summary,
+		CoverImg:        revision.CoverImg,
+		Content:         revision.Content,
+		MetaDescription: revision.MetaDescription,
+		MetaKeywords:    revision.MetaKeywords,
+		Categories: slice.Map(revision.Categories, func(_ int, c domain.Category4Post) Category4PostVO {
+			return Category4PostVO{Id: c.Id, Name: c.Name}
+		}),
+		Tags: slice.Map(revision.Tags, func(_ int, t domain.Tag4Post) Tag4PostVO {
+			return Tag4PostVO{Id: t.Id, Name: t.Name}
+		}),
+		StickyWeight: revision.StickyWeight,
+		WordCount:    revision.WordCount,
+		CreatedAt:    revision.CreatedAt,
+	}
+}
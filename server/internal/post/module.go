@@ -28,6 +28,7 @@ type (
 	ExtraPost     = domain.ExtraPost
 	Category4Post = domain.Category4Post
 	Tag4Post      = domain.Tag4Post
+	Page          = domain.Page
 	Module        struct {
 		Svc Service
 		Hdl *Handler
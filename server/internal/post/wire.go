@@ -28,8 +28,9 @@ import (
 	"github.com/google/wire"
 )
 
-var PostProviders = wire.NewSet(web.NewPostHandler, service.NewPostService, repository.NewPostRepository, dao.NewPostDao,
+var PostProviders = wire.NewSet(web.NewPostHandler, service.NewPostService, service.NewSearchService, repository.NewPostRepository, dao.NewPostDao,
 	wire.Bind(new(service.IPostService), new(*service.PostService)),
+	wire.Bind(new(service.ISearchService), new(*service.SearchService)),
 	wire.Bind(new(repository.IPostRepository), new(*repository.PostRepository)),
 	wire.Bind(new(dao.IPostDao), new(*dao.PostDao)))
 
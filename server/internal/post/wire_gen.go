@@ -24,7 +24,8 @@ func InitPostModule(db *mongox.Database, cfgModel *website_config.Module, postLi
 	postDao := dao.NewPostDao(db)
 	postRepository := repository.NewPostRepository(postDao)
 	iWebsiteConfigService := cfgModel.Svc
-	postService := service.NewPostService(postRepository, iWebsiteConfigService, eventBus)
+	searchService := service.NewSearchService(postRepository, eventBus)
+	postService := service.NewPostService(postRepository, iWebsiteConfigService, eventBus, searchService)
 	iPostLikeService := postLikeModel.Svc
 	postHandler := web.NewPostHandler(postService, iWebsiteConfigService, iPostLikeService, eventBus)
 	module := &Module{
@@ -36,4 +37,4 @@ func InitPostModule(db *mongox.Database, cfgModel *website_config.Module, postLi
 
 // wire.go:
 
-var PostProviders = wire.NewSet(web.NewPostHandler, service.NewPostService, repository.NewPostRepository, dao.NewPostDao, wire.Bind(new(service.IPostService), new(*service.PostService)), wire.Bind(new(repository.IPostRepository), new(*repository.PostRepository)), wire.Bind(new(dao.IPostDao), new(*dao.PostDao)))
+var PostProviders = wire.NewSet(web.NewPostHandler, service.NewPostService, service.NewSearchService, repository.NewPostRepository, dao.NewPostDao, wire.Bind(new(service.IPostService), new(*service.PostService)), wire.Bind(new(service.ISearchService), new(*service.SearchService)), wire.Bind(new(repository.IPostRepository), new(*repository.PostRepository)), wire.Bind(new(dao.IPostDao), new(*dao.PostDao)))
@@ -30,6 +30,12 @@ type PostDraft struct {
 	IsDisplayed      bool                 `json:"is_displayed"`
 	IsCommentAllowed bool                 `json:"is_comment_allowed"`
 	CreatedAt        int64                `json:"created_at"`
+	// 定时发布时间，0 表示未设置
+	ScheduledAt      int64  `json:"scheduled_at"`
+	PublishAttempts  int    `json:"publish_attempts"`
+	LastPublishError string `json:"last_publish_error"`
+	// 草稿内容的版本，每次保存都会变化，用于判断该版本是否已经发布
+	Revision string `json:"revision"`
 }
 
 type Category4PostDraft struct {
@@ -29,10 +29,11 @@ import (
 )
 
 type PostDraft struct {
-	ID              string    `bson:"_id"`
-	CreatedAt       time.Time `bson:"created_at,omitempty"`
-	UpdatedAt       time.Time `bson:"updated_at"`
-	PostDraftFields `bson:",inline"`
+	ID               string    `bson:"_id"`
+	CreatedAt        time.Time `bson:"created_at,omitempty"`
+	UpdatedAt        time.Time `bson:"updated_at"`
+	PostDraftFields  `bson:",inline"`
+	ScheduledPublish `bson:",inline"`
 }
 
 type PostDraftUpdate struct {
@@ -54,6 +55,8 @@ type PostDraftFields struct {
 	MetaKeywords     string               `bson:"meta_keywords"`
 	WordCount        int                  `bson:"word_count"`
 	IsCommentAllowed bool                 `bson:"is_comment_allowed"`
+	// Revision 每次保存草稿时重新生成，发布时记录到文章上
+	Revision string `bson:"revision"`
 }
 
 type Category4PostDraft struct {
@@ -71,6 +74,7 @@ type IPostDraftDao interface {
 	GetById(ctx context.Context, id string) (*PostDraft, error)
 	DeleteById(ctx context.Context, id string) (int64, error)
 	QueryPage(ctx context.Context, cond bson.D, findOptions *options.FindOptionsBuilder) ([]*PostDraft, int64, error)
+	IScheduledPublishDao
 }
 
 var _ IPostDraftDao = (*PostDraftDao)(nil)
@@ -0,0 +1,107 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chenmingyong0423/go-mongox/v2/bsonx"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/update"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ScheduledPublish 草稿的定时发布信息，不随草稿内容一起保存，只通过下面的方法单独修改。
+// 发布前需先抢占租约：publish_lease_until 之前其他实例无法再次领取，实例崩溃后租约到期会被重新领取。
+type ScheduledPublish struct {
+	ScheduledAt       time.Time `bson:"scheduled_at,omitempty"`
+	PublishLeaseOwner string    `bson:"publish_lease_owner,omitempty"`
+	PublishLeaseUntil time.Time `bson:"publish_lease_until,omitempty"`
+	PublishAttempts   int       `bson:"publish_attempts,omitempty"`
+	LastPublishError  string    `bson:"last_publish_error,omitempty"`
+}
+
+type IScheduledPublishDao interface {
+	// SetScheduledAt 设置或修改定时发布时间，并重置租约和失败记录
+	SetScheduledAt(ctx context.Context, id string, scheduledAt time.Time) error
+	UnsetScheduledAt(ctx context.Context, id string) (int64, error)
+	// ClaimDueScheduled 领取一篇已到发布时间且未被其他实例占用的草稿
+	ClaimDueScheduled(ctx context.Context, owner string, now time.Time, leaseUntil time.Time) (*PostDraft, error)
+	// ReleaseScheduled 发布失败时释放租约，retryAt 之后才会被再次领取
+	ReleaseScheduled(ctx context.Context, id string, owner string, retryAt time.Time, reason string) error
+	// DeleteClaimed 发布成功后删除仍由 owner 持有租约的草稿，返回删除的数量
+	DeleteClaimed(ctx context.Context, id string, owner string) (int64, error)
+}
+
+func (d *PostDraftDao) SetScheduledAt(ctx context.Context, id string, scheduledAt time.Time) error {
+	u := update.NewBuilder().
+		Set("scheduled_at", scheduledAt).
+		Set("publish_lease_until", scheduledAt).
+		Unset("publish_lease_owner", "publish_attempts", "last_publish_error").
+		Build()
+	result, err := d.coll.Updater().Filter(query.Id(id)).Updates(u).UpdateOne(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to schedule post draft: %s, scheduled_at: %v", id, scheduledAt)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("MatchedCount=0, failed to schedule post draft: %s, scheduled_at: %v", id, scheduledAt)
+	}
+	return nil
+}
+
+func (d *PostDraftDao) UnsetScheduledAt(ctx context.Context, id string) (int64, error) {
+	u := update.NewBuilder().Unset("scheduled_at", "publish_lease_owner", "publish_lease_until", "publish_attempts", "last_publish_error").Build()
+	result, err := d.coll.Updater().Filter(query.NewBuilder().Id(id).Exists("scheduled_at", true).Build()).Updates(u).UpdateOne(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to cancel the scheduled publish of post draft: %s", id)
+	}
+	return result.ModifiedCount, nil
+}
+
+func (d *PostDraftDao) ClaimDueScheduled(ctx context.Context, owner string, now time.Time, leaseUntil time.Time) (*PostDraft, error) {
+	filter := query.NewBuilder().Lte("scheduled_at", now).Lte("publish_lease_until", now).Build()
+	u := update.NewBuilder().Set("publish_lease_owner", owner).Set("publish_lease_until", leaseUntil).Build()
+	postDraft, err := d.coll.Finder().Filter(filter).Updates(u).FindOneAndUpdate(ctx,
+		options.FindOneAndUpdate().SetSort(bsonx.M("scheduled_at", 1)).SetReturnDocument(options.After))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to claim a scheduled post draft, owner: %s", owner)
+	}
+	return postDraft, nil
+}
+
+func (d *PostDraftDao) ReleaseScheduled(ctx context.Context, id string, owner string, retryAt time.Time, reason string) error {
+	u := update.NewBuilder().
+		Set("publish_lease_until", retryAt).
+		Set("last_publish_error", reason).
+		Inc("publish_attempts", 1).
+		Unset("publish_lease_owner").
+		Build()
+	_, err := d.coll.Updater().Filter(query.NewBuilder().Id(id).Eq("publish_lease_owner", owner).Build()).Updates(u).UpdateOne(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to release the scheduled post draft: %s, owner: %s", id, owner)
+	}
+	return nil
+}
+
+func (d *PostDraftDao) DeleteClaimed(ctx context.Context, id string, owner string) (int64, error) {
+	result, err := d.coll.Deleter().Filter(query.NewBuilder().Id(id).Eq("publish_lease_owner", owner).Build()).DeleteOne(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to delete the claimed post draft: %s, owner: %s", id, owner)
+	}
+	return result.DeletedCount, nil
+}
@@ -36,6 +36,7 @@ type IPostDraftRepository interface {
 	GetById(ctx context.Context, id string) (*domain.PostDraft, error)
 	DeleteById(ctx context.Context, id string) (int64, error)
 	GetPostDraftPage(ctx context.Context, pageQuery domain.PageQuery) ([]*domain.PostDraft, int64, error)
+	IScheduledPublishRepository
 }
 
 var _ IPostDraftRepository = (*PostDraftRepository)(nil)
@@ -115,6 +116,7 @@ func (r *PostDraftRepository) Save(ctx context.Context, postDraft domain.PostDra
 			MetaKeywords:     postDraft.MetaKeywords,
 			WordCount:        postDraft.WordCount,
 			IsCommentAllowed: postDraft.IsCommentAllowed,
+			Revision:         uuidx.RearrangeUUID4(),
 		},
 	})
 }
@@ -148,6 +150,10 @@ func (r *PostDraftRepository) toDomain(postDraft *dao.PostDraft) *domain.PostDra
 		IsDisplayed:      postDraft.IsDisplayed,
 		IsCommentAllowed: postDraft.IsCommentAllowed,
 		CreatedAt:        postDraft.CreatedAt.Unix(),
+		ScheduledAt:      unixOrZero(postDraft.ScheduledAt),
+		PublishAttempts:  postDraft.PublishAttempts,
+		LastPublishError: postDraft.LastPublishError,
+		Revision:         postDraft.Revision,
 	}
 }
 
@@ -0,0 +1,75 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/post_draft/internal/domain"
+	"github.com/chenmingyong0423/go-mongox/v2/bsonx"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type IScheduledPublishRepository interface {
+	SetScheduledAt(ctx context.Context, id string, scheduledAt time.Time) error
+	UnsetScheduledAt(ctx context.Context, id string) (int64, error)
+	// GetScheduledPage 按发布时间升序分页查询待发布的草稿
+	GetScheduledPage(ctx context.Context, skip, limit int64) ([]*domain.PostDraft, int64, error)
+	ClaimDueScheduled(ctx context.Context, owner string, now time.Time, leaseUntil time.Time) (*domain.PostDraft, error)
+	ReleaseScheduled(ctx context.Context, id string, owner string, retryAt time.Time, reason string) error
+	DeleteClaimed(ctx context.Context, id string, owner string) (int64, error)
+}
+
+func (r *PostDraftRepository) SetScheduledAt(ctx context.Context, id string, scheduledAt time.Time) error {
+	return r.dao.SetScheduledAt(ctx, id, scheduledAt)
+}
+
+func (r *PostDraftRepository) UnsetScheduledAt(ctx context.Context, id string) (int64, error) {
+	return r.dao.UnsetScheduledAt(ctx, id)
+}
+
+func (r *PostDraftRepository) GetScheduledPage(ctx context.Context, skip, limit int64) ([]*domain.PostDraft, int64, error) {
+	findOptions := options.Find().SetSort(bsonx.M("scheduled_at", 1)).SetSkip(skip).SetLimit(limit).SetProjection(bsonx.M("content", 0))
+	postDrafts, cnt, err := r.dao.QueryPage(ctx, query.Exists("scheduled_at", true), findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	return r.toDomains(postDrafts), cnt, nil
+}
+
+func (r *PostDraftRepository) ClaimDueScheduled(ctx context.Context, owner string, now time.Time, leaseUntil time.Time) (*domain.PostDraft, error) {
+	postDraft, err := r.dao.ClaimDueScheduled(ctx, owner, now, leaseUntil)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(postDraft), nil
+}
+
+func (r *PostDraftRepository) ReleaseScheduled(ctx context.Context, id string, owner string, retryAt time.Time, reason string) error {
+	return r.dao.ReleaseScheduled(ctx, id, owner, retryAt, reason)
+}
+
+func (r *PostDraftRepository) DeleteClaimed(ctx context.Context, id string, owner string) (int64, error) {
+	return r.dao.DeleteClaimed(ctx, id, owner)
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
@@ -16,6 +16,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/chenmingyong0423/fnote/server/internal/post_draft/internal/domain"
 	"github.com/chenmingyong0423/fnote/server/internal/post_draft/internal/repository"
@@ -26,6 +27,15 @@ type IPostDraftService interface {
 	GetPostDraftById(ctx context.Context, id string) (*domain.PostDraft, error)
 	DeletePostDraftById(ctx context.Context, id string) (int64, error)
 	GetPostDraftPage(ctx context.Context, page domain.Page) ([]*domain.PostDraft, int64, error)
+
+	// SchedulePublish 设置或修改草稿的定时发布时间
+	SchedulePublish(ctx context.Context, id string, scheduledAt time.Time) error
+	CancelScheduledPublish(ctx context.Context, id string) (int64, error)
+	GetScheduledPostDrafts(ctx context.Context, pageNo, pageSize int64) ([]*domain.PostDraft, int64, error)
+	// ClaimDueScheduledPostDraft 以 owner 的身份领取一篇到期的草稿，租约时长为 lease，没有可领取的草稿时返回 mongo.ErrNoDocuments
+	ClaimDueScheduledPostDraft(ctx context.Context, owner string, lease time.Duration) (*domain.PostDraft, error)
+	ReleaseScheduledPostDraft(ctx context.Context, id string, owner string, retryAt time.Time, reason string) error
+	DeleteClaimedPostDraft(ctx context.Context, id string, owner string) (int64, error)
 }
 
 var _ IPostDraftService = (*PostDraftService)(nil)
@@ -0,0 +1,51 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/post_draft/internal/domain"
+)
+
+func (s *PostDraftService) SchedulePublish(ctx context.Context, id string, scheduledAt time.Time) error {
+	// 确认草稿存在，不存在时返回 mongo.ErrNoDocuments
+	if _, err := s.repo.GetById(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.SetScheduledAt(ctx, id, scheduledAt.Local())
+}
+
+func (s *PostDraftService) CancelScheduledPublish(ctx context.Context, id string) (int64, error) {
+	return s.repo.UnsetScheduledAt(ctx, id)
+}
+
+func (s *PostDraftService) GetScheduledPostDrafts(ctx context.Context, pageNo, pageSize int64) ([]*domain.PostDraft, int64, error) {
+	return s.repo.GetScheduledPage(ctx, (pageNo-1)*pageSize, pageSize)
+}
+
+func (s *PostDraftService) ClaimDueScheduledPostDraft(ctx context.Context, owner string, lease time.Duration) (*domain.PostDraft, error) {
+	now := time.Now().Local()
+	return s.repo.ClaimDueScheduled(ctx, owner, now, now.Add(lease))
+}
+
+func (s *PostDraftService) ReleaseScheduledPostDraft(ctx context.Context, id string, owner string, retryAt time.Time, reason string) error {
+	return s.repo.ReleaseScheduled(ctx, id, owner, retryAt.Local(), reason)
+}
+
+func (s *PostDraftService) DeleteClaimedPostDraft(ctx context.Context, id string, owner string) (int64, error) {
+	return s.repo.DeleteClaimed(ctx, id, owner)
+}
@@ -37,6 +37,9 @@ func (h *PostDraftHandler) RegisterGinRoutes(engine *gin.Engine) {
 	adminGroup.POST("/post-draft", apiwrap.WrapWithBody(h.SavePostDraft))
 	adminGroup.GET("/post-draft", apiwrap.WrapWithBody(h.GetPostDraftPage))
 	adminGroup.DELETE("/post-draft/:id", apiwrap.Wrap(h.DeletePostDraft))
+	adminGroup.GET("/post-draft/scheduled", apiwrap.WrapWithBody(h.GetScheduledPostDrafts))
+	adminGroup.PUT("/post-draft/:id/schedule", apiwrap.WrapWithBody(h.SchedulePublish))
+	adminGroup.DELETE("/post-draft/:id/schedule", apiwrap.Wrap(h.CancelScheduledPublish))
 }
 
 func (h *PostDraftHandler) SavePostDraft(ctx *gin.Context, req PostDraftRequest) (*apiwrap.ResponseBody[map[string]string], error) {
@@ -90,9 +93,10 @@ func (h *PostDraftHandler) GetPostDraftPage(ctx *gin.Context, req PageRequest) (
 	}
 	return apiwrap.SuccessResponseWithData(apiwrap.NewPageVO(req.PageNo, req.PageSize, cnt, slice.Map(postDrafts, func(idx int, pd *domain.PostDraft) PostDraftBriefVO {
 		return PostDraftBriefVO{
-			Id:        pd.Id,
-			Title:     pd.Title,
-			CreatedAt: pd.CreatedAt,
+			Id:          pd.Id,
+			Title:       pd.Title,
+			CreatedAt:   pd.CreatedAt,
+			ScheduledAt: pd.ScheduledAt,
 		}
 	}))), nil
 }
@@ -0,0 +1,84 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+	"time"
+
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/fnote/server/internal/post_draft/internal/domain"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type SchedulePublishRequest struct {
+	// 发布时间，秒级时间戳
+	ScheduledAt int64 `json:"scheduled_at" binding:"required"`
+}
+
+type ScheduledPostDraftVO struct {
+	Id               string `json:"id"`
+	Title            string `json:"title"`
+	ScheduledAt      int64  `json:"scheduled_at"`
+	PublishAttempts  int    `json:"publish_attempts"`
+	LastPublishError string `json:"last_publish_error,omitempty"`
+	CreatedAt        int64  `json:"created_at"`
+}
+
+func (h *PostDraftHandler) GetScheduledPostDrafts(ctx *gin.Context, req apiwrap.Page) (*apiwrap.ResponseBody[*apiwrap.PageVO[ScheduledPostDraftVO]], error) {
+	postDrafts, cnt, err := h.serv.GetScheduledPostDrafts(ctx, req.PageNo, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewPageVO(req.PageNo, req.PageSize, cnt, slice.Map(postDrafts, func(idx int, pd *domain.PostDraft) ScheduledPostDraftVO {
+		return ScheduledPostDraftVO{
+			Id:               pd.Id,
+			Title:            pd.Title,
+			ScheduledAt:      pd.ScheduledAt,
+			PublishAttempts:  pd.PublishAttempts,
+			LastPublishError: pd.LastPublishError,
+			CreatedAt:        pd.CreatedAt,
+		}
+	}))), nil
+}
+
+func (h *PostDraftHandler) SchedulePublish(ctx *gin.Context, req SchedulePublishRequest) (*apiwrap.ResponseBody[any], error) {
+	scheduledAt := time.Unix(req.ScheduledAt, 0)
+	if !scheduledAt.After(time.Now()) {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, "scheduled_at must be in the future")
+	}
+	err := h.serv.SchedulePublish(ctx, ctx.Param("id"), scheduledAt)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "id does not exist.")
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponse(), nil
+}
+
+func (h *PostDraftHandler) CancelScheduledPublish(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	cnt, err := h.serv.CancelScheduledPublish(ctx, ctx.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+	if cnt == 0 {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "scheduled publish does not exist.")
+	}
+	return apiwrap.SuccessResponse(), nil
+}
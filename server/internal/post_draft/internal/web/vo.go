@@ -36,4 +36,6 @@ type PostDraftBriefVO struct {
 	Id        string `json:"id"`
 	Title     string `json:"title"`
 	CreatedAt int64  `json:"created_at"`
+	// 定时发布时间，未设置时不返回
+	ScheduledAt int64 `json:"scheduled_at,omitempty"`
 }
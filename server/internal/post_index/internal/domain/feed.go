@@ -0,0 +1,44 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import "time"
+
+type FeedFormat string
+
+const (
+	FeedFormatRSS  FeedFormat = "rss"
+	FeedFormatAtom FeedFormat = "atom"
+	FeedFormatJSON FeedFormat = "json"
+)
+
+const (
+	FeedScopeCategory = "category"
+	FeedScopeTag      = "tag"
+)
+
+// FeedScope 订阅源的范围，Typ 为空时表示全站订阅源，否则为 Route 对应的分类或标签的订阅源
+type FeedScope struct {
+	Typ   string
+	Route string
+}
+
+type Feed struct {
+	Body        []byte
+	ContentType string
+	ETag        string
+	// LastBuildDate 订阅源中文章的最近更新时间
+	LastBuildDate time.Time
+}
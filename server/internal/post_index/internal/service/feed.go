@@ -0,0 +1,449 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/pkg"
+	"github.com/chenmingyong0423/fnote/server/internal/post"
+	"github.com/chenmingyong0423/fnote/server/internal/post_index/internal/domain"
+	"github.com/chenmingyong0423/gkit/slice"
+	jsoniter "github.com/json-iterator/go"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const feedGenerator = "fnote"
+
+// feedChannel 与输出格式无关的订阅源内容
+type feedChannel struct {
+	Title         string
+	Link          string
+	SelfLink      string
+	Description   string
+	Author        string
+	LastBuildDate time.Time
+	FullContent   bool
+	Items         []feedItem
+}
+
+type feedItem struct {
+	Title      string
+	Link       string
+	Author     string
+	Summary    string
+	Content    string
+	Image      string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+}
+
+func (s *PostIndexService) GetFeed(ctx context.Context, format domain.FeedFormat, scope domain.FeedScope) (*domain.Feed, error) {
+	channel, err := s.buildFeedChannel(ctx, format, scope)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		body        []byte
+		contentType string
+	)
+	switch format {
+	case domain.FeedFormatAtom:
+		body, err = renderAtom(channel)
+		contentType = "application/atom+xml; charset=utf-8"
+	case domain.FeedFormatJSON:
+		body, err = renderJSONFeed(channel)
+		contentType = "application/feed+json; charset=utf-8"
+	default:
+		body, err = renderRSS(channel)
+		contentType = "application/rss+xml; charset=utf-8"
+	}
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body)
+	return &domain.Feed{
+		Body:          body,
+		ContentType:   contentType,
+		ETag:          `"` + hex.EncodeToString(sum[:16]) + `"`,
+		LastBuildDate: channel.LastBuildDate,
+	}, nil
+}
+
+func (s *PostIndexService) buildFeedChannel(ctx context.Context, format domain.FeedFormat, scope domain.FeedScope) (*feedChannel, error) {
+	feedCfg, err := s.cfgServ.GetFeedConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	websiteCfg, err := s.cfgServ.GetWebSiteConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seoCfg, err := s.cfgServ.GetSeoMetaConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	baseHost := pkg.GetOrDefault4String(os.Getenv("WEBSITE_BASE_HOST"), "http://localhost:3000")
+	uploaderHost := pkg.GetOrDefault4String(os.Getenv("UPLOADER_HOST"), "http://localhost:8080")
+
+	channel := &feedChannel{
+		Title:       websiteCfg.WebsiteName,
+		Link:        baseHost,
+		Description: seoCfg.Description,
+		Author:      websiteCfg.WebsiteOwner,
+		FullContent: feedCfg.FullContent,
+	}
+	selfPath := ""
+	match := func(p *post.Post) bool { return true }
+	switch scope.Typ {
+	case domain.FeedScopeCategory:
+		c, err := s.categoryServ.GetCategoryByRoute(ctx, scope.Route)
+		if err != nil {
+			return nil, err
+		}
+		if !c.Enabled {
+			return nil, mongo.ErrNoDocuments
+		}
+		channel.Title = fmt.Sprintf("%s - %s", websiteCfg.WebsiteName, c.Name)
+		channel.Link = fmt.Sprintf("%s/categories/%s", baseHost, c.Route)
+		channel.Description = pkg.GetOrDefault4String(c.Description, channel.Description)
+		selfPath = "/categories/route/" + c.Route
+		match = func(p *post.Post) bool {
+			return slices.ContainsFunc(p.Categories, func(pc post.Category4Post) bool { return pc.Id == c.Id })
+		}
+	case domain.FeedScopeTag:
+		t, err := s.tagServ.GetTagByRoute(ctx, scope.Route)
+		if err != nil {
+			return nil, err
+		}
+		if !t.Enabled {
+			return nil, mongo.ErrNoDocuments
+		}
+		channel.Title = fmt.Sprintf("%s - %s", websiteCfg.WebsiteName, t.Name)
+		channel.Link = fmt.Sprintf("%s/tags/%s", baseHost, t.Route)
+		selfPath = "/tags/route/" + t.Route
+		match = func(p *post.Post) bool {
+			return slices.ContainsFunc(p.Tags, func(pt post.Tag4Post) bool { return pt.Id == t.Id })
+		}
+	}
+	channel.SelfLink = baseHost + selfPath + feedFileName(format)
+
+	posts, err := s.postServ.FindDisplayedPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	posts = slices.DeleteFunc(posts, func(p post.Post) bool {
+		return p.Id == "about-me" || !match(&p)
+	})
+	slices.SortFunc(posts, func(a, b post.Post) int {
+		return int(b.CreatedAt - a.CreatedAt)
+	})
+	posts = posts[:min(len(posts), feedCfg.Count)]
+
+	for _, p := range posts {
+		published, updated := time.Unix(p.CreatedAt, 0), time.Unix(max(p.UpdatedAt, p.CreatedAt), 0)
+		if updated.After(channel.LastBuildDate) {
+			channel.LastBuildDate = updated
+		}
+		item := feedItem{
+			Title:   p.Title,
+			Link:    fmt.Sprintf("%s/posts/%s", baseHost, p.Id),
+			Author:  p.Author,
+			Summary: p.Summary,
+			Categories: append(
+				slice.Map(p.Categories, func(_ int, c post.Category4Post) string { return c.Name }),
+				slice.Map(p.Tags, func(_ int, t post.Tag4Post) string { return t.Name })...,
+			),
+			Published: published,
+			Updated:   updated,
+		}
+		if p.CoverImg != "" {
+			item.Image = uploaderHost + p.CoverImg
+		}
+		if feedCfg.FullContent {
+			item.Content = p.Content
+		}
+		channel.Items = append(channel.Items, item)
+	}
+	// 没有文章时以建站时间作为订阅源的更新时间
+	if channel.LastBuildDate.IsZero() && websiteCfg.WebsiteRuntime != nil {
+		channel.LastBuildDate = *websiteCfg.WebsiteRuntime
+	}
+	return channel, nil
+}
+
+func feedFileName(format domain.FeedFormat) string {
+	switch format {
+	case domain.FeedFormatAtom:
+		return "/atom.xml"
+	case domain.FeedFormatJSON:
+		return "/feed.json"
+	default:
+		return "/feed.xml"
+	}
+}
+
+// textToHTML 文章内容以 Markdown 原文保存，转义后按段落输出，保证阅读器中的换行和原文一致
+func textToHTML(text string) string {
+	var sb strings.Builder
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		sb.WriteString("<p>")
+		sb.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n"))
+		sb.WriteString("</p>\n")
+	}
+	return sb.String()
+}
+
+type rssFeed struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string      `xml:"title"`
+	Link          string      `xml:"link"`
+	Description   string      `xml:"description"`
+	AtomLink      rssAtomLink `xml:"atom:link"`
+	Generator     string      `xml:"generator"`
+	LastBuildDate string      `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem   `xml:"item"`
+}
+
+type rssAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Guid        rssGuid  `xml:"guid"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"content:encoded,omitempty"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func renderRSS(channel *feedChannel) ([]byte, error) {
+	feed := rssFeed{
+		Version:   "2.0",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		Channel: rssChannel{
+			Title:       channel.Title,
+			Link:        channel.Link,
+			Description: channel.Description,
+			AtomLink:    rssAtomLink{Href: channel.SelfLink, Rel: "self", Type: "application/rss+xml"},
+			Generator:   feedGenerator,
+		},
+	}
+	if !channel.LastBuildDate.IsZero() {
+		feed.Channel.LastBuildDate = channel.LastBuildDate.Format(time.RFC1123Z)
+	}
+	for _, item := range channel.Items {
+		rItem := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Guid:        rssGuid{IsPermaLink: true, Value: item.Link},
+			Description: item.Summary,
+			PubDate:     item.Published.Format(time.RFC1123Z),
+			Categories:  item.Categories,
+		}
+		if item.Content != "" {
+			rItem.Content = textToHTML(item.Content)
+		}
+		feed.Channel.Items = append(feed.Channel.Items, rItem)
+	}
+	return marshalXML(feed)
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title     string      `xml:"title"`
+	Subtitle  string      `xml:"subtitle,omitempty"`
+	Id        string      `xml:"id"`
+	Updated   string      `xml:"updated"`
+	Links     []atomLink  `xml:"link"`
+	Author    *atomPerson `xml:"author,omitempty"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	Id         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+func renderAtom(channel *feedChannel) ([]byte, error) {
+	feed := atomFeed{
+		Title:    channel.Title,
+		Subtitle: channel.Description,
+		Id:       channel.SelfLink,
+		// updated 为 Atom 的必填项
+		Updated: channel.LastBuildDate.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: channel.Link, Rel: "alternate", Type: "text/html"},
+			{Href: channel.SelfLink, Rel: "self", Type: "application/atom+xml"},
+		},
+		Author:    &atomPerson{Name: pkg.GetOrDefault4String(channel.Author, channel.Title)},
+		Generator: feedGenerator,
+	}
+	for _, item := range channel.Items {
+		entry := atomEntry{
+			Title:     item.Title,
+			Id:        item.Link,
+			Link:      atomLink{Href: item.Link, Rel: "alternate", Type: "text/html"},
+			Published: item.Published.Format(time.RFC3339),
+			Updated:   item.Updated.Format(time.RFC3339),
+			Categories: slice.Map(item.Categories, func(_ int, c string) atomCategory {
+				return atomCategory{Term: c}
+			}),
+		}
+		if item.Author != "" {
+			entry.Author = &atomPerson{Name: item.Author}
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Body: item.Summary}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Body: textToHTML(item.Content)}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return marshalXML(feed)
+}
+
+func marshalXML(v any) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// jsonFeed JSON Feed 1.1，https://www.jsonfeed.org/version/1.1/
+type jsonFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	FeedURL     string           `json:"feed_url"`
+	Description string           `json:"description,omitempty"`
+	Authors     []jsonFeedAuthor `json:"authors,omitempty"`
+	Items       []jsonFeedItem   `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	Id            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text,omitempty"`
+	ContentHTML   string           `json:"content_html,omitempty"`
+	Summary       string           `json:"summary,omitempty"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+func renderJSONFeed(channel *feedChannel) ([]byte, error) {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       channel.Title,
+		HomePageURL: channel.Link,
+		FeedURL:     channel.SelfLink,
+		Description: channel.Description,
+		Items:       make([]jsonFeedItem, 0, len(channel.Items)),
+	}
+	if channel.Author != "" {
+		feed.Authors = []jsonFeedAuthor{{Name: channel.Author}}
+	}
+	for _, item := range channel.Items {
+		jItem := jsonFeedItem{
+			Id:            item.Link,
+			URL:           item.Link,
+			Title:         item.Title,
+			Summary:       item.Summary,
+			Image:         item.Image,
+			DatePublished: item.Published.Format(time.RFC3339),
+			DateModified:  item.Updated.Format(time.RFC3339),
+			Tags:          item.Categories,
+		}
+		// content_text 与 content_html 至少需要一个
+		if item.Content != "" {
+			jItem.ContentText = item.Content
+			jItem.ContentHTML = textToHTML(item.Content)
+		} else {
+			jItem.ContentText = item.Summary
+		}
+		if item.Author != "" {
+			jItem.Authors = []jsonFeedAuthor{{Name: item.Author}}
+		}
+		feed.Items = append(feed.Items, jItem)
+	}
+	return jsoniter.MarshalIndent(feed, "", "  ")
+}
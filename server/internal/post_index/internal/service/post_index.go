@@ -32,6 +32,8 @@ import (
 type IPostIndexService interface {
 	PushUrls2Baidu(ctx context.Context, urls string) (*domain.BaiduResponse, error)
 	GenerateSitemap(ctx context.Context) error
+	// GetFeed 生成 RSS、Atom 或 JSON Feed 订阅源，分类或标签不存在、未启用时返回 mongo.ErrNoDocuments
+	GetFeed(ctx context.Context, format domain.FeedFormat, scope domain.FeedScope) (*domain.Feed, error)
 }
 
 var _ IPostIndexService = (*PostIndexService)(nil)
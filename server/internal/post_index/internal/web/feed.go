@@ -0,0 +1,83 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/fnote/server/internal/post_index/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func (h *PostIndexHandler) registerFeedRoutes(engine *gin.Engine) {
+	for _, scope := range []struct {
+		prefix string
+		typ    string
+	}{
+		{prefix: ""},
+		{prefix: "/categories/route/:route", typ: domain.FeedScopeCategory},
+		{prefix: "/tags/route/:route", typ: domain.FeedScopeTag},
+	} {
+		engine.GET(scope.prefix+"/feed.xml", h.feedHandler(domain.FeedFormatRSS, scope.typ))
+		engine.GET(scope.prefix+"/atom.xml", h.feedHandler(domain.FeedFormatAtom, scope.typ))
+		engine.GET(scope.prefix+"/feed.json", h.feedHandler(domain.FeedFormatJSON, scope.typ))
+	}
+}
+
+func (h *PostIndexHandler) feedHandler(format domain.FeedFormat, scopeTyp string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		feed, err := h.serv.GetFeed(ctx, format, domain.FeedScope{Typ: scopeTyp, Route: ctx.Param("route")})
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				err = apiwrap.NewErrorResponseBody(http.StatusNotFound, "Feed not found.")
+			}
+			apiwrap.ErrorHandler(ctx, err)
+			return
+		}
+		ctx.Header("ETag", feed.ETag)
+		ctx.Header("Cache-Control", "public, max-age=300")
+		if !feed.LastBuildDate.IsZero() {
+			ctx.Header("Last-Modified", feed.LastBuildDate.UTC().Format(http.TimeFormat))
+		}
+		if notModified(ctx.Request, feed) {
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+		ctx.Data(http.StatusOK, feed.ContentType, feed.Body)
+	}
+}
+
+// notModified 按照 RFC 9110，存在 If-None-Match 时忽略 If-Modified-Since
+func notModified(r *http.Request, feed *domain.Feed) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == feed.ETag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !feed.LastBuildDate.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !feed.LastBuildDate.Truncate(time.Second).After(t)
+	}
+	return false
+}
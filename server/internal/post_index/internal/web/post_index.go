@@ -32,6 +32,7 @@ type PostIndexHandler struct {
 
 func (h *PostIndexHandler) RegisterGinRoutes(engine *gin.Engine) {
 	engine.POST("/post-index/baidu/push", apiwrap.WrapWithBody(h.BaiduPostIndex))
+	h.registerFeedRoutes(engine)
 	adminGroup := engine.Group("/admin-api")
 	adminGroup.POST("/post-index/sitemap", apiwrap.Wrap(h.GenerateSitemap))
 }
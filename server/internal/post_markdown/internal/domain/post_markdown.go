@@ -0,0 +1,32 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+// ImportResult 导入结果，单个文件失败不影响其他文件的导入
+type ImportResult struct {
+	// Created、Updated、Unchanged 为文章 id
+	Created           []string
+	Updated           []string
+	Unchanged         []string
+	CategoriesCreated []string
+	TagsCreated       []string
+	StaticFiles       int
+	Failures          []ImportFailure
+}
+
+type ImportFailure struct {
+	File  string
+	Error string
+}
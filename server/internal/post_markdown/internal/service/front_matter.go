@@ -0,0 +1,86 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const frontMatterDelimiter = "---"
+
+// frontMatter markdown 文件头部的 yaml，导入时 is_displayed 和 is_comment_allowed 缺省为 true
+type frontMatter struct {
+	Title            string    `yaml:"title"`
+	Author           string    `yaml:"author,omitempty"`
+	Summary          string    `yaml:"summary"`
+	Categories       []string  `yaml:"categories"`
+	Tags             []string  `yaml:"tags"`
+	Cover            string    `yaml:"cover"`
+	CreatedAt        time.Time `yaml:"created_at,omitempty"`
+	UpdatedAt        time.Time `yaml:"updated_at,omitempty"`
+	StickyWeight     int       `yaml:"sticky_weight"`
+	IsDisplayed      *bool     `yaml:"is_displayed"`
+	IsCommentAllowed *bool     `yaml:"is_comment_allowed"`
+	MetaDescription  string    `yaml:"meta_description,omitempty"`
+	MetaKeywords     string    `yaml:"meta_keywords,omitempty"`
+}
+
+func marshalMarkdown(fm *frontMatter, content string) ([]byte, error) {
+	header, err := yaml.Marshal(fm)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(frontMatterDelimiter + "\n")
+	buf.Write(header)
+	buf.WriteString(frontMatterDelimiter + "\n\n")
+	buf.WriteString(content)
+	return buf.Bytes(), nil
+}
+
+// parseMarkdown 拆分 front matter 和正文
+func parseMarkdown(data []byte) (*frontMatter, string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(data, []byte(frontMatterDelimiter+"\n")) {
+		return nil, "", errors.New("missing front matter")
+	}
+	rest := data[len(frontMatterDelimiter)+1:]
+	var header, body []byte
+	if bytes.HasPrefix(rest, []byte(frontMatterDelimiter+"\n")) {
+		body = rest[len(frontMatterDelimiter)+1:]
+	} else {
+		end := bytes.Index(rest, []byte("\n"+frontMatterDelimiter+"\n"))
+		if end < 0 {
+			if !bytes.HasSuffix(rest, []byte("\n"+frontMatterDelimiter)) {
+				return nil, "", errors.New("unterminated front matter")
+			}
+			end = len(rest) - len(frontMatterDelimiter) - 1
+			header, body = rest[:end], nil
+		} else {
+			header, body = rest[:end], rest[end+len(frontMatterDelimiter)+2:]
+		}
+	}
+
+	fm := &frontMatter{}
+	if err := yaml.Unmarshal(header, fm); err != nil {
+		return nil, "", err
+	}
+	return fm, string(bytes.TrimLeft(body, "\n")), nil
+}
@@ -0,0 +1,496 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/chenmingyong0423/fnote/server/internal/category"
+	"github.com/chenmingyong0423/fnote/server/internal/file"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/textutil"
+	"github.com/chenmingyong0423/fnote/server/internal/post"
+	"github.com/chenmingyong0423/fnote/server/internal/post_markdown/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/tag"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	markdownExt   = ".md"
+	staticDir     = "static"
+	staticURLPath = "/static/"
+	// maxImportEntrySize 导入时单个文件的最大长度
+	maxImportEntrySize = 32 << 20
+)
+
+var (
+	ErrUnsupportedImportFile = errors.New("only .md and .zip files can be imported")
+
+	// staticRefRegexp 匹配文章中引用的静态文件，静态文件都保存在 static_path 目录下，不包含子目录
+	staticRefRegexp = regexp.MustCompile(`/static/([^\s"'()<>\[\]{}/?#]+)`)
+)
+
+type IPostMarkdownService interface {
+	// ExportPosts 将所有文章导出为 <id>.md，连同引用的静态文件一起写入 zip
+	ExportPosts(ctx context.Context, w io.Writer) error
+	// ImportPosts 导入单个 .md 文件或 ExportPosts 导出的 zip，文章 id 取文件名
+	ImportPosts(ctx context.Context, filename string, r io.ReaderAt, size int64) (*domain.ImportResult, error)
+}
+
+var _ IPostMarkdownService = (*PostMarkdownService)(nil)
+
+func NewPostMarkdownService(postServ post.Service, categoryServ category.Service, tagServ tag.Service, fileServ file.Service) *PostMarkdownService {
+	return &PostMarkdownService{
+		postServ:     postServ,
+		categoryServ: categoryServ,
+		tagServ:      tagServ,
+		fileServ:     fileServ,
+	}
+}
+
+type PostMarkdownService struct {
+	postServ     post.Service
+	categoryServ category.Service
+	tagServ      tag.Service
+	fileServ     file.Service
+}
+
+func (s *PostMarkdownService) ExportPosts(ctx context.Context, w io.Writer) error {
+	posts, _, err := s.postServ.AdminGetPosts(ctx, post.Page{})
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	refs := make([]string, 0)
+	for _, p := range posts {
+		data, err := marshalMarkdown(toFrontMatter(p), p.Content)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal post, id=%s", p.Id)
+		}
+		if err = writeZipEntry(zw, p.Id+markdownExt, bytes.NewReader(data)); err != nil {
+			return err
+		}
+		refs = append(refs, staticRefs(p.CoverImg)...)
+		refs = append(refs, staticRefs(p.Content)...)
+	}
+
+	staticPath := viper.GetString("system.static_path")
+	slices.Sort(refs)
+	for _, name := range slices.Compact(refs) {
+		if err = exportStaticFile(zw, staticPath, name); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			slog.WarnContext(ctx, "PostMarkdown: referenced static file not found, skipped", "file", name)
+		}
+	}
+	return zw.Close()
+}
+
+func toFrontMatter(p *post.Post) *frontMatter {
+	return &frontMatter{
+		Title:   p.Title,
+		Author:  p.Author,
+		Summary: p.Summary,
+		Categories: slice.Map(p.Categories, func(_ int, c post.Category4Post) string {
+			return c.Name
+		}),
+		Tags: slice.Map(p.Tags, func(_ int, t post.Tag4Post) string {
+			return t.Name
+		}),
+		Cover:            p.CoverImg,
+		CreatedAt:        time.Unix(p.CreatedAt, 0).Local(),
+		UpdatedAt:        time.Unix(p.UpdatedAt, 0).Local(),
+		StickyWeight:     p.StickyWeight,
+		IsDisplayed:      &p.IsDisplayed,
+		IsCommentAllowed: &p.IsCommentAllowed,
+		MetaDescription:  p.MetaDescription,
+		MetaKeywords:     p.MetaKeywords,
+	}
+}
+
+// staticRefs 返回文本中引用的静态文件名
+func staticRefs(text string) []string {
+	refs := make([]string, 0)
+	for _, match := range staticRefRegexp.FindAllStringSubmatch(text, -1) {
+		name, err := url.PathUnescape(match[1])
+		if err != nil || !isStaticFileName(name) {
+			continue
+		}
+		refs = append(refs, name)
+	}
+	return refs
+}
+
+func isStaticFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+func exportStaticFile(zw *zip.Writer, staticPath, name string) error {
+	f, err := os.Open(filepath.Join(staticPath, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return writeZipEntry(zw, path.Join(staticDir, name), f)
+}
+
+func writeZipEntry(zw *zip.Writer, name string, r io.Reader) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func (s *PostMarkdownService) ImportPosts(ctx context.Context, filename string, r io.ReaderAt, size int64) (*domain.ImportResult, error) {
+	result := &domain.ImportResult{}
+	importer := &postImporter{PostMarkdownService: s, result: result}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case markdownExt:
+		if size > maxImportEntrySize {
+			return nil, fmt.Errorf("%s exceeds the maximum size of %d bytes", filename, maxImportEntrySize)
+		}
+		data, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return nil, err
+		}
+		if err = importer.loadTaxonomies(ctx); err != nil {
+			return nil, err
+		}
+		importer.importMarkdown(ctx, filename, data)
+	case ".zip":
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return nil, err
+		}
+		if err = importer.loadTaxonomies(ctx); err != nil {
+			return nil, err
+		}
+		importer.importZip(ctx, zr)
+	default:
+		return nil, ErrUnsupportedImportFile
+	}
+	return result, nil
+}
+
+// postImporter 保存一次导入过程中的分类和标签，避免同名的分类和标签被重复创建
+type postImporter struct {
+	*PostMarkdownService
+	result     *domain.ImportResult
+	categories map[string]post.Category4Post
+	tags       map[string]post.Tag4Post
+}
+
+func (i *postImporter) loadTaxonomies(ctx context.Context) error {
+	categories, err := i.categoryServ.AdminGetSelectCategories(ctx)
+	if err != nil {
+		return err
+	}
+	i.categories = make(map[string]post.Category4Post, len(categories))
+	for _, c := range categories {
+		i.categories[c.Name] = post.Category4Post{Id: c.Id, Name: c.Name}
+	}
+	tags, err := i.tagServ.GetSelectTags(ctx)
+	if err != nil {
+		return err
+	}
+	i.tags = make(map[string]post.Tag4Post, len(tags))
+	for _, t := range tags {
+		i.tags[t.Name] = post.Tag4Post{Id: t.Id, Name: t.Name}
+	}
+	return nil
+}
+
+func (i *postImporter) fail(file string, err error) {
+	i.result.Failures = append(i.result.Failures, domain.ImportFailure{File: file, Error: err.Error()})
+}
+
+// importZip 先导入静态文件，保证文章的封面和正文中引用的文件已经存在
+func (i *postImporter) importZip(ctx context.Context, zr *zip.Reader) {
+	markdowns := make([]*zip.File, 0, len(zr.File))
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		dir, name := path.Split(strings.TrimPrefix(f.Name, "/"))
+		switch {
+		case path.Base(dir) == staticDir:
+			i.importStaticFile(ctx, f, name)
+		case strings.EqualFold(path.Ext(name), markdownExt):
+			markdowns = append(markdowns, f)
+		}
+	}
+	for _, f := range markdowns {
+		data, err := readZipFile(f)
+		if err != nil {
+			i.fail(f.Name, err)
+			continue
+		}
+		i.importMarkdown(ctx, f.Name, data)
+	}
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxImportEntrySize {
+		return nil, fmt.Errorf("exceeds the maximum size of %d bytes", maxImportEntrySize)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, maxImportEntrySize))
+}
+
+// importStaticFile 导入静态文件，同名且内容相同的文件会被跳过，内容不同时不会覆盖
+func (i *postImporter) importStaticFile(ctx context.Context, f *zip.File, name string) {
+	if !isStaticFileName(name) {
+		i.fail(f.Name, errors.New("invalid static file name"))
+		return
+	}
+	content, err := readZipFile(f)
+	if err != nil {
+		i.fail(f.Name, err)
+		return
+	}
+	existing, err := os.ReadFile(filepath.Join(viper.GetString("system.static_path"), name))
+	if err == nil {
+		if sha256.Sum256(existing) != sha256.Sum256(content) {
+			i.fail(f.Name, errors.New("a different static file with the same name already exists"))
+		}
+		return
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		i.fail(f.Name, err)
+		return
+	}
+
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	fileDTO := file.FileDTO{
+		FileName:       name,
+		FileSize:       int64(len(content)),
+		Content:        content,
+		FileType:       mime.TypeByExtension(ext),
+		FileExt:        ext,
+		CustomFileName: stem,
+	}
+	// 通过上传接口生成的文件名就是文件 id，保留下来文章事件才能关联到该文件
+	if isHexString(stem) {
+		fileDTO.FileId = stem
+	}
+	if _, err = i.fileServ.Upload(ctx, fileDTO); err != nil {
+		i.fail(f.Name, err)
+		return
+	}
+	i.result.StaticFiles++
+}
+
+func isHexString(s string) bool {
+	if s == "" || len(s)%2 != 0 {
+		return false
+	}
+	return strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.Is(unicode.ASCII_Hex_Digit, r)
+	}) < 0
+}
+
+func (i *postImporter) importMarkdown(ctx context.Context, filename string, data []byte) {
+	if err := i.importPost(ctx, filename, data); err != nil {
+		i.fail(filename, err)
+	}
+}
+
+func (i *postImporter) importPost(ctx context.Context, filename string, data []byte) error {
+	id := strings.TrimSuffix(path.Base(filepath.ToSlash(filename)), path.Ext(filename))
+	if id == "" {
+		return errors.New("empty post id")
+	}
+	fm, content, err := parseMarkdown(data)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(fm.Title) == "" {
+		return errors.New("title is required")
+	}
+	if !strings.HasPrefix(fm.Cover, staticURLPath) {
+		return errors.New("cover must be a file under /static/")
+	}
+
+	existing, err := i.postServ.AdminGetPostById(ctx, id)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	imported, err := i.toPost(ctx, id, fm, content)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		if err = i.postServ.AddPost(ctx, imported); err != nil {
+			return err
+		}
+		i.result.Created = append(i.result.Created, id)
+		return nil
+	}
+	if imported.Author == "" {
+		imported.Author = existing.Author
+	}
+	imported.CreatedAt = existing.CreatedAt
+	if samePost(existing, imported) {
+		i.result.Unchanged = append(i.result.Unchanged, id)
+		return nil
+	}
+	if err = i.postServ.SavePost(ctx, existing, imported, false); err != nil {
+		return err
+	}
+	i.result.Updated = append(i.result.Updated, id)
+	return nil
+}
+
+func (i *postImporter) toPost(ctx context.Context, id string, fm *frontMatter, content string) (*post.Post, error) {
+	categories := make([]post.Category4Post, 0, len(fm.Categories))
+	for _, name := range compactNames(fm.Categories) {
+		c, err := i.category(ctx, name)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "category %q", name)
+		}
+		categories = append(categories, c)
+	}
+	tags := make([]post.Tag4Post, 0, len(fm.Tags))
+	for _, name := range compactNames(fm.Tags) {
+		t, err := i.tag(ctx, name)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "tag %q", name)
+		}
+		tags = append(tags, t)
+	}
+
+	p := &post.Post{
+		PrimaryPost: post.PrimaryPost{
+			Id:           id,
+			Author:       fm.Author,
+			Title:        fm.Title,
+			Summary:      fm.Summary,
+			CoverImg:     fm.Cover,
+			Categories:   categories,
+			Tags:         tags,
+			StickyWeight: fm.StickyWeight,
+		},
+		ExtraPost: post.ExtraPost{
+			Content:          content,
+			MetaDescription:  fm.MetaDescription,
+			MetaKeywords:     fm.MetaKeywords,
+			WordCount:        textutil.WordCount(content),
+			IsDisplayed:      fm.IsDisplayed == nil || *fm.IsDisplayed,
+			IsCommentAllowed: fm.IsCommentAllowed == nil || *fm.IsCommentAllowed,
+		},
+	}
+	if !fm.CreatedAt.IsZero() {
+		p.CreatedAt = fm.CreatedAt.Unix()
+	}
+	if !fm.UpdatedAt.IsZero() {
+		p.UpdatedAt = fm.UpdatedAt.Unix()
+	}
+	return p, nil
+}
+
+// category 按名称查找分类，找不到时按路由查找，仍然找不到则创建
+func (i *postImporter) category(ctx context.Context, name string) (post.Category4Post, error) {
+	if c, ok := i.categories[name]; ok {
+		return c, nil
+	}
+	route := textutil.Slugify(name)
+	if route == "" {
+		return post.Category4Post{}, errors.New("cannot generate a route from the name")
+	}
+	c, err := i.categoryServ.GetCategoryByRoute(ctx, route)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if err = i.categoryServ.AdminCreateCategory(ctx, category.Category{Name: name, Route: route, Enabled: true}); err != nil {
+			return post.Category4Post{}, err
+		}
+		i.result.CategoriesCreated = append(i.result.CategoriesCreated, name)
+		c, err = i.categoryServ.GetCategoryByRoute(ctx, route)
+	}
+	if err != nil {
+		return post.Category4Post{}, err
+	}
+	i.categories[name] = post.Category4Post{Id: c.Id, Name: c.Name}
+	return i.categories[name], nil
+}
+
+// tag 按名称查找标签，找不到时按路由查找，仍然找不到则创建
+func (i *postImporter) tag(ctx context.Context, name string) (post.Tag4Post, error) {
+	if t, ok := i.tags[name]; ok {
+		return t, nil
+	}
+	route := textutil.Slugify(name)
+	if route == "" {
+		return post.Tag4Post{}, errors.New("cannot generate a route from the name")
+	}
+	t, err := i.tagServ.GetTagByRoute(ctx, route)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if err = i.tagServ.AdminCreateTag(ctx, tag.Tag{Name: name, Route: route, Enabled: true}); err != nil {
+			return post.Tag4Post{}, err
+		}
+		i.result.TagsCreated = append(i.result.TagsCreated, name)
+		t, err = i.tagServ.GetTagByRoute(ctx, route)
+	}
+	if err != nil {
+		return post.Tag4Post{}, err
+	}
+	i.tags[name] = post.Tag4Post{Id: t.Id, Name: t.Name}
+	return i.tags[name], nil
+}
+
+// compactNames 去掉空白和重复的名称，保持原有顺序
+func compactNames(names []string) []string {
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// samePost 比较导入会修改的字段，内容没有变化时不需要保存
+func samePost(a, b *post.Post) bool {
+	return a.Author == b.Author && a.Title == b.Title && a.Summary == b.Summary && a.CoverImg == b.CoverImg &&
+		slices.Equal(a.Categories, b.Categories) && slices.Equal(a.Tags, b.Tags) && a.StickyWeight == b.StickyWeight &&
+		a.Content == b.Content && a.MetaDescription == b.MetaDescription && a.MetaKeywords == b.MetaKeywords &&
+		a.IsDisplayed == b.IsDisplayed && a.IsCommentAllowed == b.IsCommentAllowed
+}
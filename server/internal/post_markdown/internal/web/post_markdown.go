@@ -0,0 +1,98 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/fnote/server/internal/post_markdown/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/post_markdown/internal/service"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func NewPostMarkdownHandler(serv service.IPostMarkdownService) *PostMarkdownHandler {
+	return &PostMarkdownHandler{
+		serv: serv,
+	}
+}
+
+type PostMarkdownHandler struct {
+	serv service.IPostMarkdownService
+}
+
+func (h *PostMarkdownHandler) RegisterGinRoutes(engine *gin.Engine) {
+	adminGroup := engine.Group("/admin-api/posts")
+
+	adminGroup.GET("/export", h.AdminExportPosts)
+	adminGroup.POST("/import", apiwrap.Wrap(h.AdminImportPosts))
+}
+
+func (h *PostMarkdownHandler) AdminExportPosts(ctx *gin.Context) {
+	filename := "posts_" + time.Now().Local().Format("2006-01-02_150405") + ".zip"
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	err := h.serv.ExportPosts(ctx, ctx.Writer)
+	if err == nil {
+		return
+	}
+	// 还没有写入响应时可以返回错误信息，否则只能记录日志
+	if !ctx.Writer.Written() {
+		ctx.Writer.Header().Del("Content-Type")
+		ctx.Writer.Header().Del("Content-Disposition")
+		apiwrap.ErrorHandler(ctx, err)
+		return
+	}
+	slog.ErrorContext(ctx, "PostMarkdown: failed to export posts", "error", err)
+	_ = ctx.Error(err)
+}
+
+func (h *PostMarkdownHandler) AdminImportPosts(ctx *gin.Context) (*apiwrap.ResponseBody[ImportResultVO], error) {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, "file is required")
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	result, err := h.serv.ImportPosts(ctx, fileHeader.Filename, f, fileHeader.Size)
+	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedImportFile) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, err.Error())
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(toImportResultVO(result)), nil
+}
+
+func toImportResultVO(result *domain.ImportResult) ImportResultVO {
+	return ImportResultVO{
+		Created:           result.Created,
+		Updated:           result.Updated,
+		Unchanged:         result.Unchanged,
+		CategoriesCreated: result.CategoriesCreated,
+		TagsCreated:       result.TagsCreated,
+		StaticFiles:       result.StaticFiles,
+		Failures: slice.Map(result.Failures, func(_ int, f domain.ImportFailure) ImportFailureVO {
+			return ImportFailureVO{File: f.File, Error: f.Error}
+		}),
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+type ImportResultVO struct {
+	Created           []string          `json:"created"`
+	Updated           []string          `json:"updated"`
+	Unchanged         []string          `json:"unchanged"`
+	CategoriesCreated []string          `json:"categories_created"`
+	TagsCreated       []string          `json:"tags_created"`
+	StaticFiles       int               `json:"static_files"`
+	Failures          []ImportFailureVO `json:"failures"`
+}
+
+type ImportFailureVO struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
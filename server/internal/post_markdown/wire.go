@@ -0,0 +1,40 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build wireinject
+
+package post_markdown
+
+import (
+	"github.com/chenmingyong0423/fnote/server/internal/category"
+	"github.com/chenmingyong0423/fnote/server/internal/file"
+	"github.com/chenmingyong0423/fnote/server/internal/post"
+	"github.com/chenmingyong0423/fnote/server/internal/post_markdown/internal/service"
+	"github.com/chenmingyong0423/fnote/server/internal/post_markdown/internal/web"
+	"github.com/chenmingyong0423/fnote/server/internal/tag"
+	"github.com/google/wire"
+)
+
+var PostMarkdownProviders = wire.NewSet(web.NewPostMarkdownHandler, service.NewPostMarkdownService, wire.Bind(new(service.IPostMarkdownService), new(*service.PostMarkdownService)))
+
+func InitPostMarkdownModule(postModel *post.Module, categoryModel *category.Module, tagModel *tag.Module, fileModel *file.Module) *Module {
+	panic(wire.Build(
+		wire.FieldsOf(new(*post.Module), "Svc"),
+		wire.FieldsOf(new(*category.Module), "Svc"),
+		wire.FieldsOf(new(*tag.Module), "Svc"),
+		wire.FieldsOf(new(*file.Module), "Svc"),
+		PostMarkdownProviders,
+		wire.Struct(new(Module), "Svc", "Hdl"),
+	))
+}
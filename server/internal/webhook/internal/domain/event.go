@@ -0,0 +1,92 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+// 以下为 eventbus 中各主题的事件，只用于解析，不直接推送给接收方
+
+type PostEvent struct {
+	PostId            string   `json:"post_id"`
+	AddedCategoryId   []string `json:"added_category_id,omitempty"`
+	DeletedCategoryId []string `json:"deleted_category_id,omitempty"`
+	AddedTagId        []string `json:"added_tag_id,omitempty"`
+	DeletedTagId      []string `json:"deleted_tag_id,omitempty"`
+	Type              string   `json:"type"`
+}
+
+type LikePostEvent struct {
+	PostId string `json:"post_id"`
+}
+
+type CommentEvent struct {
+	PostId    string   `json:"post_id"`
+	CommentId string   `json:"comment_id"`
+	RepliesId []string `json:"replies_id"`
+	Count     int      `json:"count"`
+	Type      string   `json:"type"`
+}
+
+type CategoryEvent struct {
+	CategoryId string `json:"category_id"`
+	Type       string `json:"type"`
+}
+
+type TagEvent struct {
+	TagId string `json:"tag_id"`
+	Type  string `json:"type"`
+}
+
+type WebsiteVisitEvent struct {
+	Url string `json:"url"`
+}
+
+// 以下为推送给接收方的 Envelope.Data，字段保持稳定，不包含访客的 IP、UA 等信息
+
+type PostData struct {
+	PostId             string   `json:"post_id"`
+	AddedCategoryIds   []string `json:"added_category_ids,omitempty"`
+	DeletedCategoryIds []string `json:"deleted_category_ids,omitempty"`
+	AddedTagIds        []string `json:"added_tag_ids,omitempty"`
+	DeletedTagIds      []string `json:"deleted_tag_ids,omitempty"`
+}
+
+type PostLikeData struct {
+	PostId string `json:"post_id"`
+}
+
+type CommentData struct {
+	PostId    string `json:"post_id"`
+	CommentId string `json:"comment_id"`
+	// 涉及的回复 id
+	ReplyIds []string `json:"reply_ids,omitempty"`
+	// 新增或删除的评论数，包含回复
+	Count int `json:"count"`
+}
+
+type CategoryData struct {
+	CategoryId string `json:"category_id"`
+}
+
+type TagData struct {
+	TagId string `json:"tag_id"`
+}
+
+type WebsiteVisitData struct {
+	Url string `json:"url"`
+}
+
+type PingData struct {
+	WebhookId string   `json:"webhook_id"`
+	Events    []string `json:"events"`
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// 订阅的事件类型，由 eventbus 的主题和事件中的 type 组成
+const (
+	EventPostCreate     = "post.create"
+	EventPostUpdate     = "post.update"
+	EventPostDelete     = "post.delete"
+	EventPostLike       = "post.like"
+	EventCommentCreate  = "comment.create"
+	EventCommentDelete  = "comment.delete"
+	EventCategoryCreate = "category.create"
+	EventCategoryDelete = "category.delete"
+	EventTagCreate      = "tag.create"
+	EventTagDelete      = "tag.delete"
+	EventWebsiteVisit   = "website.visit"
+	// EventPing 手动测试 webhook 时发送的事件，不需要订阅
+	EventPing = "ping"
+	// EventAll 订阅全部事件
+	EventAll = "*"
+)
+
+var EventTypes = []string{
+	EventPostCreate, EventPostUpdate, EventPostDelete, EventPostLike,
+	EventCommentCreate, EventCommentDelete,
+	EventCategoryCreate, EventCategoryDelete,
+	EventTagCreate, EventTagDelete,
+	EventWebsiteVisit,
+}
+
+// Topics eventbus 主题与事件类型前缀的对应关系
+var Topics = map[string]string{
+	"post":          "post",
+	"post-like":     EventPostLike,
+	"comment":       "comment",
+	"category":      "category",
+	"tag":           "tag",
+	"website visit": EventWebsiteVisit,
+}
+
+// EventTypeOf 根据 eventbus 的主题和事件中的 type 得到事件类型，例如 post + create => post.create
+func EventTypeOf(topic, typ string) string {
+	prefix := Topics[topic]
+	if typ == "" || strings.Contains(prefix, ".") {
+		return prefix
+	}
+	return prefix + "." + typ
+}
+
+type Webhook struct {
+	Id      string
+	Name    string
+	Url     string
+	Secret  string
+	Events  []string
+	Enabled bool
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, e := range w.Events {
+		if e == EventAll || e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Envelope 推送的请求体，字段保持稳定，data 为事件类型对应的 PostData、CommentData 等
+type Envelope struct {
+	// 事件 id，重新推送时保持不变，接收方可据此去重
+	Id        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded"
+	// DeliveryStatusFailed 重试次数用尽，不再自动重试
+	DeliveryStatusFailed DeliveryStatus = "failed"
+)
+
+// Delivery 一次推送及其结果
+type Delivery struct {
+	Id        string
+	WebhookId string
+	EventId   string
+	EventType string
+	// 序列化后的 Envelope，每次重试发送相同的内容
+	Payload    string
+	Status     DeliveryStatus
+	Redelivery bool
+	Attempts   int
+	// 下次尝试推送的时间
+	NextAttemptAt time.Time
+	// 最近一次推送的结果
+	Result      DeliveryResult
+	DeliveredAt time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type DeliveryResult struct {
+	StatusCode int
+	// 响应内容，最多保存 1KB
+	ResponseBody string
+	Error        string
+	Duration     time.Duration
+}
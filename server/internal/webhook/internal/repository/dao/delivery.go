@@ -0,0 +1,175 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/bsonx"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/update"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// WebhookDelivery webhook 的推送记录，与发件箱相同：推送前需先抢占租约，
+// 等待重试的记录以 lease_until 作为下次推送的时间
+type WebhookDelivery struct {
+	mongox.Model `bson:",inline"`
+	WebhookId    string    `bson:"webhook_id"`
+	EventId      string    `bson:"event_id"`
+	EventType    string    `bson:"event_type"`
+	Payload      string    `bson:"payload"`
+	Status       string    `bson:"status"`
+	Redelivery   bool      `bson:"redelivery,omitempty"`
+	Attempts     int       `bson:"attempts"`
+	LeaseOwner   string    `bson:"lease_owner,omitempty"`
+	LeaseUntil   time.Time `bson:"lease_until"`
+	// 最近一次推送的结果
+	StatusCode   int       `bson:"status_code,omitempty"`
+	ResponseBody string    `bson:"response_body,omitempty"`
+	LastError    string    `bson:"last_error,omitempty"`
+	Duration     int64     `bson:"duration,omitempty"`
+	DeliveredAt  time.Time `bson:"delivered_at,omitempty"`
+}
+
+// DeliveryResult 一次推送的结果，Duration 单位为毫秒
+type DeliveryResult struct {
+	StatusCode   int
+	ResponseBody string
+	Error        string
+	Duration     int64
+}
+
+type IWebhookDeliveryDao interface {
+	InsertMany(ctx context.Context, deliveries []*WebhookDelivery) error
+	Insert(ctx context.Context, delivery *WebhookDelivery) (string, error)
+	// Claim 领取一条已到推送时间的记录并设置租约
+	Claim(ctx context.Context, owner string, now time.Time, leaseUntil time.Time) (*WebhookDelivery, error)
+	MarkSucceeded(ctx context.Context, id bson.ObjectID, owner string, result DeliveryResult, now time.Time) error
+	// MarkFailed 推送失败时释放租约，status 为 pending 时 retryAt 之后才会被再次领取
+	MarkFailed(ctx context.Context, id bson.ObjectID, owner string, status string, retryAt time.Time, result DeliveryResult, now time.Time) error
+	FindById(ctx context.Context, id bson.ObjectID) (*WebhookDelivery, error)
+	Find(ctx context.Context, filter bson.D, findOptions *options.FindOptionsBuilder) ([]*WebhookDelivery, error)
+	Count(ctx context.Context, filter bson.D) (int64, error)
+	DeleteByWebhookId(ctx context.Context, webhookId string) error
+}
+
+var _ IWebhookDeliveryDao = (*WebhookDeliveryDao)(nil)
+
+func NewWebhookDeliveryDao(db *mongox.Database) *WebhookDeliveryDao {
+	return &WebhookDeliveryDao{coll: mongox.NewCollection[WebhookDelivery](db, "webhook_deliveries")}
+}
+
+type WebhookDeliveryDao struct {
+	coll *mongox.Collection[WebhookDelivery]
+}
+
+func (d *WebhookDeliveryDao) InsertMany(ctx context.Context, deliveries []*WebhookDelivery) error {
+	_, err := d.coll.Creator().InsertMany(ctx, deliveries)
+	if err != nil {
+		return errors.Wrapf(err, "fails to insert webhook deliveries, count=%d", len(deliveries))
+	}
+	return nil
+}
+
+func (d *WebhookDeliveryDao) Insert(ctx context.Context, delivery *WebhookDelivery) (string, error) {
+	result, err := d.coll.Creator().InsertOne(ctx, delivery)
+	if err != nil {
+		return "", errors.Wrapf(err, "fails to insert webhook delivery, webhook_id=%s, event_id=%s", delivery.WebhookId, delivery.EventId)
+	}
+	return result.InsertedID.(bson.ObjectID).Hex(), nil
+}
+
+func (d *WebhookDeliveryDao) Claim(ctx context.Context, owner string, now time.Time, leaseUntil time.Time) (*WebhookDelivery, error) {
+	filter := query.NewBuilder().Eq("status", "pending").Lte("lease_until", now).Build()
+	u := update.NewBuilder().Set("lease_owner", owner).Set("lease_until", leaseUntil).Inc("attempts", 1).Build()
+	delivery, err := d.coll.Finder().Filter(filter).Updates(u).FindOneAndUpdate(ctx,
+		options.FindOneAndUpdate().SetSort(bsonx.M("lease_until", 1)).SetReturnDocument(options.After))
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to claim a webhook delivery, owner=%s", owner)
+	}
+	return delivery, nil
+}
+
+func (d *WebhookDeliveryDao) MarkSucceeded(ctx context.Context, id bson.ObjectID, owner string, result DeliveryResult, now time.Time) error {
+	u := d.resultUpdates(result, now).
+		Set("status", "succeeded").
+		Set("delivered_at", now).
+		Unset("lease_owner").
+		Build()
+	_, err := d.coll.Updater().Filter(query.NewBuilder().Id(id).Eq("lease_owner", owner).Build()).Updates(u).UpdateOne(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to mark the webhook delivery as succeeded, id=%s, owner=%s", id.Hex(), owner)
+	}
+	return nil
+}
+
+func (d *WebhookDeliveryDao) MarkFailed(ctx context.Context, id bson.ObjectID, owner string, status string, retryAt time.Time, result DeliveryResult, now time.Time) error {
+	u := d.resultUpdates(result, now).
+		Set("status", status).
+		Set("lease_until", retryAt).
+		Unset("lease_owner").
+		Build()
+	_, err := d.coll.Updater().Filter(query.NewBuilder().Id(id).Eq("lease_owner", owner).Build()).Updates(u).UpdateOne(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to mark the webhook delivery as failed, id=%s, owner=%s", id.Hex(), owner)
+	}
+	return nil
+}
+
+func (d *WebhookDeliveryDao) resultUpdates(result DeliveryResult, now time.Time) *update.Builder {
+	return update.NewBuilder().
+		Set("status_code", result.StatusCode).
+		Set("response_body", result.ResponseBody).
+		Set("last_error", result.Error).
+		Set("duration", result.Duration).
+		Set("updated_at", now)
+}
+
+func (d *WebhookDeliveryDao) FindById(ctx context.Context, id bson.ObjectID) (*WebhookDelivery, error) {
+	delivery, err := d.coll.Finder().Filter(query.Id(id)).FindOne(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find the webhook delivery, id=%s", id.Hex())
+	}
+	return delivery, nil
+}
+
+func (d *WebhookDeliveryDao) Find(ctx context.Context, filter bson.D, findOptions *options.FindOptionsBuilder) ([]*WebhookDelivery, error) {
+	deliveries, err := d.coll.Finder().Filter(filter).Find(ctx, findOptions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find webhook deliveries, filter=%v", filter)
+	}
+	return deliveries, nil
+}
+
+func (d *WebhookDeliveryDao) Count(ctx context.Context, filter bson.D) (int64, error) {
+	count, err := d.coll.Finder().Filter(filter).Count(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to count webhook deliveries, filter=%v", filter)
+	}
+	return count, nil
+}
+
+func (d *WebhookDeliveryDao) DeleteByWebhookId(ctx context.Context, webhookId string) error {
+	_, err := d.coll.Deleter().Filter(query.Eq("webhook_id", webhookId)).DeleteMany(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to delete webhook deliveries, webhook_id=%s", webhookId)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/update"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type Webhook struct {
+	mongox.Model `bson:",inline"`
+	Name         string   `bson:"name"`
+	Url          string   `bson:"url"`
+	Secret       string   `bson:"secret,omitempty"`
+	Events       []string `bson:"events"`
+	Enabled      bool     `bson:"enabled"`
+}
+
+type IWebhookDao interface {
+	Insert(ctx context.Context, webhook *Webhook) (string, error)
+	// Update 更新 webhook，返回匹配的数量
+	Update(ctx context.Context, webhook *Webhook) (int64, error)
+	DeleteById(ctx context.Context, id bson.ObjectID) (int64, error)
+	FindById(ctx context.Context, id bson.ObjectID) (*Webhook, error)
+	FindAll(ctx context.Context) ([]*Webhook, error)
+	// FindEnabledByEvent 查询已启用且订阅了 eventType 或全部事件的 webhook
+	FindEnabledByEvent(ctx context.Context, eventType string, all string) ([]*Webhook, error)
+}
+
+var _ IWebhookDao = (*WebhookDao)(nil)
+
+func NewWebhookDao(db *mongox.Database) *WebhookDao {
+	return &WebhookDao{coll: mongox.NewCollection[Webhook](db, "webhooks")}
+}
+
+type WebhookDao struct {
+	coll *mongox.Collection[Webhook]
+}
+
+func (d *WebhookDao) Insert(ctx context.Context, webhook *Webhook) (string, error) {
+	result, err := d.coll.Creator().InsertOne(ctx, webhook)
+	if err != nil {
+		return "", errors.Wrapf(err, "fails to insert webhook, name=%s", webhook.Name)
+	}
+	return result.InsertedID.(bson.ObjectID).Hex(), nil
+}
+
+func (d *WebhookDao) Update(ctx context.Context, webhook *Webhook) (int64, error) {
+	u := update.NewBuilder().
+		Set("name", webhook.Name).
+		Set("url", webhook.Url).
+		Set("secret", webhook.Secret).
+		Set("events", webhook.Events).
+		Set("enabled", webhook.Enabled).
+		Set("updated_at", webhook.UpdatedAt).
+		Build()
+	result, err := d.coll.Updater().Filter(query.Id(webhook.ID)).Updates(u).UpdateOne(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to update webhook, id=%s", webhook.ID.Hex())
+	}
+	return result.MatchedCount, nil
+}
+
+func (d *WebhookDao) DeleteById(ctx context.Context, id bson.ObjectID) (int64, error) {
+	result, err := d.coll.Deleter().Filter(query.Id(id)).DeleteOne(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to delete webhook, id=%s", id.Hex())
+	}
+	return result.DeletedCount, nil
+}
+
+func (d *WebhookDao) FindById(ctx context.Context, id bson.ObjectID) (*Webhook, error) {
+	webhook, err := d.coll.Finder().Filter(query.Id(id)).FindOne(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find webhook, id=%s", id.Hex())
+	}
+	return webhook, nil
+}
+
+func (d *WebhookDao) FindAll(ctx context.Context) ([]*Webhook, error) {
+	webhooks, err := d.coll.Finder().Filter(bson.D{}).Find(ctx, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "fails to find webhooks")
+	}
+	return webhooks, nil
+}
+
+func (d *WebhookDao) FindEnabledByEvent(ctx context.Context, eventType string, all string) ([]*Webhook, error) {
+	webhooks, err := d.coll.Finder().Filter(query.NewBuilder().Eq("enabled", true).In("events", eventType, all).Build()).Find(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to find webhooks, event=%s", eventType)
+	}
+	return webhooks, nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/repository/dao"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/bsonx"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type IWebhookDeliveryRepository interface {
+	AddDeliveries(ctx context.Context, deliveries []domain.Delivery, now time.Time) error
+	AddDelivery(ctx context.Context, delivery domain.Delivery, now time.Time) (string, error)
+	ClaimDelivery(ctx context.Context, owner string, now time.Time, leaseUntil time.Time) (*domain.Delivery, error)
+	MarkDeliverySucceeded(ctx context.Context, id string, owner string, result domain.DeliveryResult, now time.Time) error
+	MarkDeliveryFailed(ctx context.Context, id string, owner string, status domain.DeliveryStatus, retryAt time.Time, result domain.DeliveryResult, now time.Time) error
+	FindDeliveryById(ctx context.Context, id string) (*domain.Delivery, error)
+	// FindDeliveries 分页查询 webhook 的推送记录，status 为空时查询全部
+	FindDeliveries(ctx context.Context, webhookId string, status domain.DeliveryStatus, skip, limit int64) ([]domain.Delivery, int64, error)
+	DeleteDeliveriesByWebhookId(ctx context.Context, webhookId string) error
+}
+
+var _ IWebhookDeliveryRepository = (*WebhookDeliveryRepository)(nil)
+
+func NewWebhookDeliveryRepository(dao dao.IWebhookDeliveryDao) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{dao: dao}
+}
+
+type WebhookDeliveryRepository struct {
+	dao dao.IWebhookDeliveryDao
+}
+
+func (r *WebhookDeliveryRepository) AddDeliveries(ctx context.Context, deliveries []domain.Delivery, now time.Time) error {
+	return r.dao.InsertMany(ctx, slice.Map(deliveries, func(_ int, d domain.Delivery) *dao.WebhookDelivery {
+		return r.newDao(d, now)
+	}))
+}
+
+func (r *WebhookDeliveryRepository) AddDelivery(ctx context.Context, delivery domain.Delivery, now time.Time) (string, error) {
+	return r.dao.Insert(ctx, r.newDao(delivery, now))
+}
+
+func (r *WebhookDeliveryRepository) newDao(delivery domain.Delivery, now time.Time) *dao.WebhookDelivery {
+	return &dao.WebhookDelivery{
+		Model:      mongox.Model{CreatedAt: now, UpdatedAt: now},
+		WebhookId:  delivery.WebhookId,
+		EventId:    delivery.EventId,
+		EventType:  delivery.EventType,
+		Payload:    delivery.Payload,
+		Status:     string(domain.DeliveryStatusPending),
+		Redelivery: delivery.Redelivery,
+		LeaseUntil: now,
+	}
+}
+
+func (r *WebhookDeliveryRepository) ClaimDelivery(ctx context.Context, owner string, now time.Time, leaseUntil time.Time) (*domain.Delivery, error) {
+	delivery, err := r.dao.Claim(ctx, owner, now, leaseUntil)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(delivery), nil
+}
+
+func (r *WebhookDeliveryRepository) MarkDeliverySucceeded(ctx context.Context, id string, owner string, result domain.DeliveryResult, now time.Time) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	return r.dao.MarkSucceeded(ctx, objectID, owner, r.toDaoResult(result), now)
+}
+
+func (r *WebhookDeliveryRepository) MarkDeliveryFailed(ctx context.Context, id string, owner string, status domain.DeliveryStatus, retryAt time.Time, result domain.DeliveryResult, now time.Time) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	return r.dao.MarkFailed(ctx, objectID, owner, string(status), retryAt, r.toDaoResult(result), now)
+}
+
+func (r *WebhookDeliveryRepository) FindDeliveryById(ctx context.Context, id string) (*domain.Delivery, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := r.dao.FindById(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(delivery), nil
+}
+
+func (r *WebhookDeliveryRepository) FindDeliveries(ctx context.Context, webhookId string, status domain.DeliveryStatus, skip, limit int64) ([]domain.Delivery, int64, error) {
+	filter := query.NewBuilder().Eq("webhook_id", webhookId)
+	if status != "" {
+		filter.Eq("status", string(status))
+	}
+	cond := filter.Build()
+	count, err := r.dao.Count(ctx, cond)
+	if err != nil {
+		return nil, 0, err
+	}
+	deliveries, err := r.dao.Find(ctx, cond, options.Find().SetSort(bsonx.M("created_at", -1)).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	return slice.Map(deliveries, func(_ int, d *dao.WebhookDelivery) domain.Delivery {
+		return *r.toDomain(d)
+	}), count, nil
+}
+
+func (r *WebhookDeliveryRepository) DeleteDeliveriesByWebhookId(ctx context.Context, webhookId string) error {
+	return r.dao.DeleteByWebhookId(ctx, webhookId)
+}
+
+func (r *WebhookDeliveryRepository) toDaoResult(result domain.DeliveryResult) dao.DeliveryResult {
+	return dao.DeliveryResult{
+		StatusCode:   result.StatusCode,
+		ResponseBody: result.ResponseBody,
+		Error:        result.Error,
+		Duration:     result.Duration.Milliseconds(),
+	}
+}
+
+func (r *WebhookDeliveryRepository) toDomain(delivery *dao.WebhookDelivery) *domain.Delivery {
+	return &domain.Delivery{
+		Id:            delivery.ID.Hex(),
+		WebhookId:     delivery.WebhookId,
+		EventId:       delivery.EventId,
+		EventType:     delivery.EventType,
+		Payload:       delivery.Payload,
+		Status:        domain.DeliveryStatus(delivery.Status),
+		Redelivery:    delivery.Redelivery,
+		Attempts:      delivery.Attempts,
+		NextAttemptAt: delivery.LeaseUntil,
+		Result: domain.DeliveryResult{
+			StatusCode:   delivery.StatusCode,
+			ResponseBody: delivery.ResponseBody,
+			Error:        delivery.LastError,
+			Duration:     time.Duration(delivery.Duration) * time.Millisecond,
+		},
+		DeliveredAt: delivery.DeliveredAt,
+		CreatedAt:   delivery.CreatedAt,
+		UpdatedAt:   delivery.UpdatedAt,
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/repository/dao"
+	"github.com/chenmingyong0423/gkit/slice"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type IWebhookRepository interface {
+	AddWebhook(ctx context.Context, webhook domain.Webhook, now time.Time) (string, error)
+	UpdateWebhook(ctx context.Context, webhook domain.Webhook, now time.Time) (int64, error)
+	DeleteWebhook(ctx context.Context, id string) (int64, error)
+	FindWebhookById(ctx context.Context, id string) (*domain.Webhook, error)
+	FindWebhooks(ctx context.Context) ([]domain.Webhook, error)
+	FindEnabledWebhooksByEvent(ctx context.Context, eventType string) ([]domain.Webhook, error)
+}
+
+var _ IWebhookRepository = (*WebhookRepository)(nil)
+
+func NewWebhookRepository(dao dao.IWebhookDao) *WebhookRepository {
+	return &WebhookRepository{dao: dao}
+}
+
+type WebhookRepository struct {
+	dao dao.IWebhookDao
+}
+
+func (r *WebhookRepository) AddWebhook(ctx context.Context, webhook domain.Webhook, now time.Time) (string, error) {
+	w := r.toDao(webhook)
+	w.CreatedAt, w.UpdatedAt = now, now
+	return r.dao.Insert(ctx, w)
+}
+
+func (r *WebhookRepository) UpdateWebhook(ctx context.Context, webhook domain.Webhook, now time.Time) (int64, error) {
+	id, err := bson.ObjectIDFromHex(webhook.Id)
+	if err != nil {
+		return 0, err
+	}
+	w := r.toDao(webhook)
+	w.ID, w.UpdatedAt = id, now
+	return r.dao.Update(ctx, w)
+}
+
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, id string) (int64, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, err
+	}
+	return r.dao.DeleteById(ctx, objectID)
+}
+
+func (r *WebhookRepository) FindWebhookById(ctx context.Context, id string) (*domain.Webhook, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	webhook, err := r.dao.FindById(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(webhook), nil
+}
+
+func (r *WebhookRepository) FindWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	webhooks, err := r.dao.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomains(webhooks), nil
+}
+
+func (r *WebhookRepository) FindEnabledWebhooksByEvent(ctx context.Context, eventType string) ([]domain.Webhook, error) {
+	webhooks, err := r.dao.FindEnabledByEvent(ctx, eventType, domain.EventAll)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomains(webhooks), nil
+}
+
+func (r *WebhookRepository) toDao(webhook domain.Webhook) *dao.Webhook {
+	return &dao.Webhook{
+		Name:    webhook.Name,
+		Url:     webhook.Url,
+		Secret:  webhook.Secret,
+		Events:  webhook.Events,
+		Enabled: webhook.Enabled,
+	}
+}
+
+func (r *WebhookRepository) toDomains(webhooks []*dao.Webhook) []domain.Webhook {
+	return slice.Map(webhooks, func(_ int, w *dao.Webhook) domain.Webhook {
+		return *r.toDomain(w)
+	})
+}
+
+func (r *WebhookRepository) toDomain(webhook *dao.Webhook) *domain.Webhook {
+	return &domain.Webhook{
+		Id:        webhook.ID.Hex(),
+		Name:      webhook.Name,
+		Url:       webhook.Url,
+		Secret:    webhook.Secret,
+		Events:    webhook.Events,
+		Enabled:   webhook.Enabled,
+		CreatedAt: webhook.CreatedAt,
+		UpdatedAt: webhook.UpdatedAt,
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/leaseworker"
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/netutil"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/domain"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultDeliveryTimeout = 10 * time.Second
+
+	deliveryPollInterval = 5 * time.Second
+	// deliveryLease 领取推送记录后的租约时长，需大于请求的超时时间
+	deliveryLease = 5 * time.Minute
+	// maxResponseBody 推送记录中保存的响应内容长度上限
+	maxResponseBody = 1 << 10
+)
+
+// defaultDeliveryPolicy 默认的推送策略，累计失败 MaxAttempts 次后推送记录进入 failed 状态
+var defaultDeliveryPolicy = leaseworker.Policy{
+	Workers:     2,
+	MaxAttempts: 6,
+	BackoffBase: time.Minute,
+	MaxBackoff:  time.Hour,
+}
+
+func currentDeliveryPolicy() leaseworker.Policy {
+	return leaseworker.LoadPolicy("webhook", defaultDeliveryPolicy)
+}
+
+// currentDeliveryTimeout 推送请求的超时时间
+func currentDeliveryTimeout() time.Duration {
+	timeout := viper.GetDuration("webhook.timeout")
+	if timeout <= 0 || timeout >= deliveryLease {
+		return defaultDeliveryTimeout
+	}
+	return timeout
+}
+
+// newDeliveryClient 推送使用的 http 客户端。响应内容会保存在推送记录中，为避免 webhook 被用来读取内网服务，
+// 只允许连接公网地址，且不跟随重定向，3xx 响应视为推送失败
+func newDeliveryClient(timeout time.Duration) *http.Client {
+	client := netutil.NewPublicHttpClient(timeout)
+	client.CheckRedirect = netutil.NoRedirect
+	return client
+}
+
+// claimedDelivery 领取到的推送记录及本次推送的结果
+type claimedDelivery struct {
+	domain.Delivery
+	result domain.DeliveryResult
+}
+
+// deliveryWorker 逐条领取并推送已到推送时间的记录
+func (s *WebhookService) deliveryWorker() *leaseworker.Worker[claimedDelivery] {
+	return &leaseworker.Worker[claimedDelivery]{
+		Name:         "Webhook",
+		Lease:        deliveryLease,
+		PollInterval: deliveryPollInterval,
+		Policy:       currentDeliveryPolicy,
+		Claim: func(ctx context.Context, owner string, now, leaseUntil time.Time) (*claimedDelivery, error) {
+			delivery, err := s.deliveryRepo.ClaimDelivery(ctx, owner, now, leaseUntil)
+			if err != nil {
+				return nil, err
+			}
+			return &claimedDelivery{Delivery: *delivery}, nil
+		},
+		Handle: func(ctx context.Context, delivery *claimedDelivery) error {
+			delivery.result = s.deliver(ctx, newDeliveryClient(currentDeliveryTimeout()), delivery.Delivery)
+			if delivery.result.Error != "" {
+				return errors.New(delivery.result.Error)
+			}
+			return nil
+		},
+		Succeed: func(ctx context.Context, delivery *claimedDelivery, owner string, now time.Time) error {
+			return s.deliveryRepo.MarkDeliverySucceeded(ctx, delivery.Id, owner, delivery.result, now)
+		},
+		Fail: func(ctx context.Context, delivery *claimedDelivery, owner string, dead bool, retryAt time.Time, _ error, now time.Time) error {
+			status := domain.DeliveryStatusPending
+			if dead {
+				status = domain.DeliveryStatusFailed
+			}
+			return s.deliveryRepo.MarkDeliveryFailed(ctx, delivery.Id, owner, status, retryAt, delivery.result, now)
+		},
+		Id:       func(delivery *claimedDelivery) string { return delivery.Id },
+		Attempts: func(delivery *claimedDelivery) int { return delivery.Attempts },
+	}
+}
+
+// deliver 使用 webhook 最新的地址和密钥推送，非 2xx 响应视为失败
+func (s *WebhookService) deliver(ctx context.Context, client *http.Client, delivery domain.Delivery) domain.DeliveryResult {
+	webhook, err := s.repo.FindWebhookById(ctx, delivery.WebhookId)
+	if err != nil {
+		return domain.DeliveryResult{Error: err.Error()}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return domain.DeliveryResult{Error: err.Error()}
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("User-Agent", "fnote-webhook")
+	req.Header.Set("X-Fnote-Event", delivery.EventType)
+	req.Header.Set("X-Fnote-Delivery", delivery.Id)
+	req.Header.Set("X-Fnote-Timestamp", timestamp)
+	req.Header.Set("X-Fnote-Signature", sign(webhook.Secret, timestamp, delivery.Payload))
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return domain.DeliveryResult{Error: err.Error(), Duration: time.Since(start)}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	result := domain.DeliveryResult{
+		StatusCode:   resp.StatusCode,
+		ResponseBody: strings.ToValidUTF8(string(body), "?"),
+		Duration:     time.Since(start),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	} else if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		result.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return result
+}
+
+// sign 签名为 sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))，
+// 接收方使用 X-Fnote-Timestamp 与原始请求体计算后比较，并拒绝时间相差过大的请求以防重放
+func sign(secret, timestamp, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,179 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/netutil"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/domain"
+)
+
+func TestSign(t *testing.T) {
+	got := sign("secret", "1700000000", `{"id":"1"}`)
+	want := "sha256=086f6aff7bd084c98679825129c5a64dbad88c760016d6d2c0fb123f27951d54"
+	if got != want {
+		t.Fatalf("sign = %s, want %s", got, want)
+	}
+}
+
+// receivedRequest 接收方收到的推送
+type receivedRequest struct {
+	header http.Header
+	body   string
+}
+
+// newReceiver 返回状态码为 status、响应内容为 response 的接收方
+func newReceiver(t *testing.T, status int, response string) (*httptest.Server, func() []receivedRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []receivedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, receivedRequest{header: r.Header.Clone(), body: string(body)})
+		mu.Unlock()
+		if status >= http.StatusMultipleChoices && status < http.StatusBadRequest {
+			w.Header().Set("Location", "/elsewhere")
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []receivedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+// testClient 测试服务监听在回环地址上，使用不限制地址但同样不跟随重定向的客户端
+func testClient(srv *httptest.Server) *http.Client {
+	client := srv.Client()
+	client.CheckRedirect = netutil.NoRedirect
+	return client
+}
+
+func newTestDelivery(t *testing.T, srv *httptest.Server) (*WebhookService, domain.Delivery) {
+	t.Helper()
+	repo := newFakeWebhookRepository(domain.Webhook{Id: "w1", Url: srv.URL + "/hook", Secret: "secret", Enabled: true})
+	s := newTestWebhookService(repo, &fakeDeliveryRepository{})
+	envelope, err := newEnvelope(domain.EventPostLike, domain.PostLikeData{PostId: "p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, domain.Delivery{Id: "d1", WebhookId: "w1", EventId: envelope.Id, EventType: domain.EventPostLike, Payload: envelope.payload}
+}
+
+func TestWebhookService_Deliver(t *testing.T) {
+	srv, received := newReceiver(t, http.StatusNoContent, "")
+	s, delivery := newTestDelivery(t, srv)
+
+	before := time.Now().Unix()
+	result := s.deliver(context.Background(), testClient(srv), delivery)
+	if result.Error != "" || result.StatusCode != http.StatusNoContent {
+		t.Fatalf("result = %+v, want succeeded with 204", result)
+	}
+
+	requests := received()
+	if len(requests) != 1 {
+		t.Fatalf("received %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+	if req.body != delivery.Payload {
+		t.Fatalf("body = %s, want the stored payload %s", req.body, delivery.Payload)
+	}
+	if req.header.Get("Content-Type") != "application/json; charset=utf-8" || req.header.Get("X-Fnote-Event") != domain.EventPostLike || req.header.Get("X-Fnote-Delivery") != "d1" {
+		t.Fatalf("unexpected headers %v", req.header)
+	}
+	timestamp := req.header.Get("X-Fnote-Timestamp")
+	if ts, err := strconv.ParseInt(timestamp, 10, 64); err != nil || ts < before || ts > time.Now().Unix() {
+		t.Fatalf("X-Fnote-Timestamp = %q, want the current unix time", timestamp)
+	}
+	// 接收方的校验方式：HMAC-SHA256(secret, timestamp + "." + body)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(timestamp + "." + req.body))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); req.header.Get("X-Fnote-Signature") != want {
+		t.Fatalf("X-Fnote-Signature = %s, want %s", req.header.Get("X-Fnote-Signature"), want)
+	}
+}
+
+func TestWebhookService_Deliver_Failed(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		response  string
+		wantError string
+		wantBody  string
+	}{
+		{name: "server error", status: http.StatusInternalServerError, response: "oops", wantError: "unexpected status 500", wantBody: "oops"},
+		{name: "client error", status: http.StatusNotFound, response: "not found", wantError: "unexpected status 404", wantBody: "not found"},
+		// 不跟随重定向，避免推送被转发到内网地址
+		{name: "redirect", status: http.StatusFound, wantError: "unexpected status 302"},
+		// 只保存响应开头的内容
+		{name: "large response", status: http.StatusBadGateway, response: strings.Repeat("a", maxResponseBody*2), wantError: "unexpected status 502", wantBody: strings.Repeat("a", maxResponseBody)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, received := newReceiver(t, tt.status, tt.response)
+			s, delivery := newTestDelivery(t, srv)
+
+			result := s.deliver(context.Background(), testClient(srv), delivery)
+			if result.Error != tt.wantError || result.StatusCode != tt.status || result.ResponseBody != tt.wantBody {
+				t.Fatalf("result = %+v, want error %q with status %d", result, tt.wantError, tt.status)
+			}
+			if n := len(received()); n != 1 {
+				t.Fatalf("received %d requests, want 1", n)
+			}
+		})
+	}
+}
+
+func TestWebhookService_Deliver_WebhookDeleted(t *testing.T) {
+	srv, received := newReceiver(t, http.StatusOK, "")
+	s, delivery := newTestDelivery(t, srv)
+	delivery.WebhookId = "deleted"
+
+	if result := s.deliver(context.Background(), testClient(srv), delivery); result.Error == "" {
+		t.Fatalf("result = %+v, want failed", result)
+	}
+	if n := len(received()); n != 0 {
+		t.Fatalf("received %d requests, want 0", n)
+	}
+}
+
+func TestNewDeliveryClient_RejectsNonPublicAddress(t *testing.T) {
+	srv, received := newReceiver(t, http.StatusOK, "")
+	s, delivery := newTestDelivery(t, srv)
+
+	result := s.deliver(context.Background(), newDeliveryClient(time.Second), delivery)
+	if !strings.Contains(result.Error, netutil.ErrNonPublicAddress.Error()) {
+		t.Fatalf("result = %+v, want the loopback address to be rejected", result)
+	}
+	if n := len(received()); n != 0 {
+		t.Fatalf("received %d requests, want 0", n)
+	}
+}
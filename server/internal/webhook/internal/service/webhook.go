@@ -0,0 +1,319 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/repository"
+	"github.com/chenmingyong0423/go-eventbus"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrInvalidWebhookUrl = errors.New("the webhook url must be an absolute http or https url")
+	// ErrDeliveryPending 推送尚未结束，不能重新推送
+	ErrDeliveryPending = errors.New("the delivery is still pending")
+)
+
+type IWebhookService interface {
+	AddWebhook(ctx context.Context, webhook domain.Webhook) (string, error)
+	UpdateWebhook(ctx context.Context, webhook domain.Webhook) error
+	// DeleteWebhook 删除 webhook 及其推送记录
+	DeleteWebhook(ctx context.Context, id string) error
+	GetWebhooks(ctx context.Context) ([]domain.Webhook, error)
+	// PingWebhook 向 webhook 推送一条 ping 事件，不论 webhook 是否启用，返回推送记录的 id
+	PingWebhook(ctx context.Context, id string) (string, error)
+	GetDeliveries(ctx context.Context, webhookId string, status domain.DeliveryStatus, pageNo, pageSize int64) ([]domain.Delivery, int64, error)
+	// Redeliver 以相同的内容和事件 id 新建一条推送记录，返回新记录的 id
+	Redeliver(ctx context.Context, deliveryId string) (string, error)
+}
+
+var _ IWebhookService = (*WebhookService)(nil)
+
+// secretSize 自动生成的签名密钥的随机字节数
+const secretSize = 32
+
+func NewWebhookService(repo repository.IWebhookRepository, deliveryRepo repository.IWebhookDeliveryRepository, eventBus *eventbus.EventBus) *WebhookService {
+	s := &WebhookService{
+		repo:         repo,
+		deliveryRepo: deliveryRepo,
+		eventBus:     eventBus,
+		instanceId:   uuid.NewString(),
+		wake:         make(chan struct{}, 1),
+	}
+	for topic := range domain.Topics {
+		go s.subscribeTopic(topic)
+	}
+	s.deliveryWorker().Start(s.instanceId, s.wake)
+	return s
+}
+
+type WebhookService struct {
+	repo         repository.IWebhookRepository
+	deliveryRepo repository.IWebhookDeliveryRepository
+	eventBus     *eventbus.EventBus
+	instanceId   string
+	// wake 有新的推送记录时唤醒空闲的 worker
+	wake chan struct{}
+}
+
+func (s *WebhookService) AddWebhook(ctx context.Context, webhook domain.Webhook) (string, error) {
+	if !isHttpUrl(webhook.Url) {
+		return "", ErrInvalidWebhookUrl
+	}
+	if webhook.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return "", err
+		}
+		webhook.Secret = secret
+	}
+	return s.repo.AddWebhook(ctx, webhook, time.Now().Local())
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, webhook domain.Webhook) error {
+	if !isHttpUrl(webhook.Url) {
+		return ErrInvalidWebhookUrl
+	}
+	if webhook.Secret == "" {
+		old, err := s.repo.FindWebhookById(ctx, webhook.Id)
+		if err != nil {
+			return err
+		}
+		webhook.Secret = old.Secret
+	}
+	matched, err := s.repo.UpdateWebhook(ctx, webhook, time.Now().Local())
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id string) error {
+	deleted, err := s.repo.DeleteWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return s.deliveryRepo.DeleteDeliveriesByWebhookId(ctx, id)
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	return s.repo.FindWebhooks(ctx)
+}
+
+func (s *WebhookService) PingWebhook(ctx context.Context, id string) (string, error) {
+	webhook, err := s.repo.FindWebhookById(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	envelope, err := newEnvelope(domain.EventPing, domain.PingData{
+		WebhookId: webhook.Id,
+		Events:    webhook.Events,
+	})
+	if err != nil {
+		return "", err
+	}
+	deliveryId, err := s.deliveryRepo.AddDelivery(ctx, domain.Delivery{
+		WebhookId: webhook.Id,
+		EventId:   envelope.Id,
+		EventType: domain.EventPing,
+		Payload:   envelope.payload,
+	}, time.Now().Local())
+	if err != nil {
+		return "", err
+	}
+	s.wakeWorker()
+	return deliveryId, nil
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookId string, status domain.DeliveryStatus, pageNo, pageSize int64) ([]domain.Delivery, int64, error) {
+	return s.deliveryRepo.FindDeliveries(ctx, webhookId, status, (pageNo-1)*pageSize, pageSize)
+}
+
+func (s *WebhookService) Redeliver(ctx context.Context, deliveryId string) (string, error) {
+	delivery, err := s.deliveryRepo.FindDeliveryById(ctx, deliveryId)
+	if err != nil {
+		return "", err
+	}
+	if delivery.Status == domain.DeliveryStatusPending {
+		return "", ErrDeliveryPending
+	}
+	if _, err = s.repo.FindWebhookById(ctx, delivery.WebhookId); err != nil {
+		return "", err
+	}
+	id, err := s.deliveryRepo.AddDelivery(ctx, domain.Delivery{
+		WebhookId:  delivery.WebhookId,
+		EventId:    delivery.EventId,
+		EventType:  delivery.EventType,
+		Payload:    delivery.Payload,
+		Redelivery: true,
+	}, time.Now().Local())
+	if err != nil {
+		return "", err
+	}
+	s.wakeWorker()
+	return id, nil
+}
+
+// subscribeTopic 将 eventbus 中的事件转换为推送记录，由 worker 异步推送，不阻塞事件的发布者
+func (s *WebhookService) subscribeTopic(topic string) {
+	eventChan := s.eventBus.Subscribe(topic)
+	for event := range eventChan {
+		l := slog.Default().With("X-Request-ID", uuid.NewString())
+		if err := s.enqueueEvent(context.Background(), topic, event.Payload); err != nil {
+			l.Error("Webhook: failed to enqueue the event", "topic", topic, "error", err)
+		}
+	}
+}
+
+func (s *WebhookService) enqueueEvent(ctx context.Context, topic string, payload []byte) error {
+	eventType, data, err := eventData(topic, payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal the event")
+	}
+	webhooks, err := s.repo.FindEnabledWebhooksByEvent(ctx, eventType)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+	envelope, err := newEnvelope(eventType, data)
+	if err != nil {
+		return err
+	}
+	deliveries := make([]domain.Delivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, domain.Delivery{
+			WebhookId: webhook.Id,
+			EventId:   envelope.Id,
+			EventType: eventType,
+			Payload:   envelope.payload,
+		})
+	}
+	if err = s.deliveryRepo.AddDeliveries(ctx, deliveries, time.Now().Local()); err != nil {
+		return err
+	}
+	s.wakeWorker()
+	return nil
+}
+
+// eventData 将 eventbus 中的事件转换为推送给接收方的数据，只保留公开的字段
+func eventData(topic string, payload []byte) (string, any, error) {
+	switch topic {
+	case "post":
+		var e domain.PostEvent
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return "", nil, err
+		}
+		return domain.EventTypeOf(topic, e.Type), domain.PostData{
+			PostId:             e.PostId,
+			AddedCategoryIds:   e.AddedCategoryId,
+			DeletedCategoryIds: e.DeletedCategoryId,
+			AddedTagIds:        e.AddedTagId,
+			DeletedTagIds:      e.DeletedTagId,
+		}, nil
+	case "post-like":
+		var e domain.LikePostEvent
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return "", nil, err
+		}
+		return domain.EventTypeOf(topic, ""), domain.PostLikeData{PostId: e.PostId}, nil
+	case "comment":
+		var e domain.CommentEvent
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return "", nil, err
+		}
+		return domain.EventTypeOf(topic, e.Type), domain.CommentData{
+			PostId:    e.PostId,
+			CommentId: e.CommentId,
+			ReplyIds:  e.RepliesId,
+			Count:     e.Count,
+		}, nil
+	case "category":
+		var e domain.CategoryEvent
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return "", nil, err
+		}
+		return domain.EventTypeOf(topic, e.Type), domain.CategoryData{CategoryId: e.CategoryId}, nil
+	case "tag":
+		var e domain.TagEvent
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return "", nil, err
+		}
+		return domain.EventTypeOf(topic, e.Type), domain.TagData{TagId: e.TagId}, nil
+	case "website visit":
+		var e domain.WebsiteVisitEvent
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return "", nil, err
+		}
+		return domain.EventTypeOf(topic, ""), domain.WebsiteVisitData{Url: e.Url}, nil
+	}
+	return "", nil, errors.Errorf("unsupported topic %q", topic)
+}
+
+type envelope struct {
+	domain.Envelope
+	// payload 序列化后的 Envelope
+	payload string
+}
+
+func newEnvelope(eventType string, data any) (*envelope, error) {
+	e := domain.Envelope{
+		Id:        uuid.NewString(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Data:      data,
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the webhook envelope")
+	}
+	return &envelope{Envelope: e, payload: string(payload)}, nil
+}
+
+func (s *WebhookService) wakeWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// newSecret 生成签名密钥
+func newSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate the webhook secret")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func isHttpUrl(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
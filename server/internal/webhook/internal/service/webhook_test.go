@@ -0,0 +1,325 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/repository"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// fakeWebhookRepository 内存中的 webhook
+type fakeWebhookRepository struct {
+	repository.IWebhookRepository
+
+	mu       sync.Mutex
+	webhooks map[string]domain.Webhook
+}
+
+func newFakeWebhookRepository(webhooks ...domain.Webhook) *fakeWebhookRepository {
+	r := &fakeWebhookRepository{webhooks: make(map[string]domain.Webhook)}
+	for _, w := range webhooks {
+		r.webhooks[w.Id] = w
+	}
+	return r
+}
+
+func (r *fakeWebhookRepository) AddWebhook(_ context.Context, webhook domain.Webhook, _ time.Time) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook.Id = strconv.Itoa(len(r.webhooks) + 1)
+	r.webhooks[webhook.Id] = webhook
+	return webhook.Id, nil
+}
+
+func (r *fakeWebhookRepository) UpdateWebhook(_ context.Context, webhook domain.Webhook, _ time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.webhooks[webhook.Id]; !ok {
+		return 0, nil
+	}
+	r.webhooks[webhook.Id] = webhook
+	return 1, nil
+}
+
+func (r *fakeWebhookRepository) FindWebhookById(_ context.Context, id string) (*domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &webhook, nil
+}
+
+func (r *fakeWebhookRepository) FindEnabledWebhooksByEvent(_ context.Context, eventType string) ([]domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]domain.Webhook, 0)
+	for _, webhook := range r.webhooks {
+		if webhook.Enabled && webhook.Subscribes(eventType) {
+			result = append(result, webhook)
+		}
+	}
+	return result, nil
+}
+
+// fakeDeliveryRepository 记录新增的推送记录
+type fakeDeliveryRepository struct {
+	repository.IWebhookDeliveryRepository
+
+	mu         sync.Mutex
+	deliveries []domain.Delivery
+}
+
+func (r *fakeDeliveryRepository) AddDeliveries(_ context.Context, deliveries []domain.Delivery, _ time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries = append(r.deliveries, deliveries...)
+	return nil
+}
+
+func (r *fakeDeliveryRepository) AddDelivery(_ context.Context, delivery domain.Delivery, _ time.Time) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries = append(r.deliveries, delivery)
+	return strconv.Itoa(len(r.deliveries)), nil
+}
+
+func newTestWebhookService(repo repository.IWebhookRepository, deliveryRepo repository.IWebhookDeliveryRepository) *WebhookService {
+	return &WebhookService{repo: repo, deliveryRepo: deliveryRepo, instanceId: "test", wake: make(chan struct{}, 1)}
+}
+
+func TestWebhookService_AddWebhook_GeneratesSecret(t *testing.T) {
+	repo := newFakeWebhookRepository()
+	s := newTestWebhookService(repo, &fakeDeliveryRepository{})
+
+	id, err := s.AddWebhook(context.Background(), domain.Webhook{Name: "hook", Url: "https://example.com/hook", Events: []string{domain.EventAll}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := repo.webhooks[id].Secret
+	if b, err := hex.DecodeString(secret); err != nil || len(b) != secretSize {
+		t.Fatalf("generated secret = %q, want %d random bytes in hex", secret, secretSize)
+	}
+	another, err := s.AddWebhook(context.Background(), domain.Webhook{Name: "hook", Url: "https://example.com/hook", Events: []string{domain.EventAll}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.webhooks[another].Secret == secret {
+		t.Fatal("each webhook should get its own secret")
+	}
+
+	// 指定的密钥原样保存
+	id, err = s.AddWebhook(context.Background(), domain.Webhook{Name: "hook", Url: "https://example.com/hook", Secret: "my-own-secret-value", Events: []string{domain.EventAll}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.webhooks[id].Secret; got != "my-own-secret-value" {
+		t.Fatalf("secret = %q, want the given one", got)
+	}
+}
+
+func TestWebhookService_UpdateWebhook_KeepsSecret(t *testing.T) {
+	repo := newFakeWebhookRepository(domain.Webhook{Id: "1", Name: "hook", Url: "https://example.com/hook", Secret: "old-secret-value"})
+	s := newTestWebhookService(repo, &fakeDeliveryRepository{})
+
+	err := s.UpdateWebhook(context.Background(), domain.Webhook{Id: "1", Name: "renamed", Url: "https://example.com/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.webhooks["1"]; got.Name != "renamed" || got.Secret != "old-secret-value" {
+		t.Fatalf("webhook = %+v, want renamed with the old secret", got)
+	}
+
+	err = s.UpdateWebhook(context.Background(), domain.Webhook{Id: "1", Name: "renamed", Url: "https://example.com/hook", Secret: "new-secret-value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.webhooks["1"].Secret; got != "new-secret-value" {
+		t.Fatalf("secret = %q, want new-secret-value", got)
+	}
+
+	if err = s.UpdateWebhook(context.Background(), domain.Webhook{Id: "2", Url: "https://example.com/hook"}); err != mongo.ErrNoDocuments {
+		t.Fatalf("update a missing webhook: %v, want mongo.ErrNoDocuments", err)
+	}
+}
+
+func TestEventData(t *testing.T) {
+	tests := []struct {
+		name     string
+		topic    string
+		payload  string
+		wantType string
+		wantData string
+	}{
+		{
+			name:     "post create",
+			topic:    "post",
+			payload:  `{"post_id":"p1","added_category_id":["c1"],"added_tag_id":["t1"],"new_file_id":"f1","type":"create"}`,
+			wantType: domain.EventPostCreate,
+			wantData: `{"post_id":"p1","added_category_ids":["c1"],"added_tag_ids":["t1"]}`,
+		},
+		{
+			name:     "post delete",
+			topic:    "post",
+			payload:  `{"post_id":"p1","deleted_category_id":["c1"],"deleted_tag_id":["t1"],"old_file_id":"f1","comment_count":3,"type":"delete"}`,
+			wantType: domain.EventPostDelete,
+			wantData: `{"post_id":"p1","deleted_category_ids":["c1"],"deleted_tag_ids":["t1"]}`,
+		},
+		{
+			name:     "post like",
+			topic:    "post-like",
+			payload:  `{"post_id":"p1"}`,
+			wantType: domain.EventPostLike,
+			wantData: `{"post_id":"p1"}`,
+		},
+		{
+			name:     "comment delete",
+			topic:    "comment",
+			payload:  `{"post_id":"p1","comment_id":"c1","replies_id":["r1","r2"],"count":3,"type":"delete"}`,
+			wantType: domain.EventCommentDelete,
+			wantData: `{"post_id":"p1","comment_id":"c1","reply_ids":["r1","r2"],"count":3}`,
+		},
+		{
+			name:     "category create",
+			topic:    "category",
+			payload:  `{"category_id":"c1","type":"create"}`,
+			wantType: domain.EventCategoryCreate,
+			wantData: `{"category_id":"c1"}`,
+		},
+		{
+			name:     "tag delete",
+			topic:    "tag",
+			payload:  `{"tag_id":"t1","type":"delete"}`,
+			wantType: domain.EventTagDelete,
+			wantData: `{"tag_id":"t1"}`,
+		},
+		{
+			// 访客的 IP、UA 等信息不推送
+			name:     "website visit",
+			topic:    "website visit",
+			payload:  `{"url":"https://example.com/posts/p1","ip":"203.0.113.1","user_agent":"Mozilla/5.0","origin":"https://example.com","referer":"https://search.example.com/?q=secret"}`,
+			wantType: domain.EventWebsiteVisit,
+			wantData: `{"url":"https://example.com/posts/p1"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventType, data, err := eventData(tt.topic, []byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if eventType != tt.wantType {
+				t.Errorf("event type = %s, want %s", eventType, tt.wantType)
+			}
+			envelope, err := newEnvelope(eventType, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]any
+			if err = json.Unmarshal([]byte(envelope.payload), &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 4 || got["id"] != envelope.Id || got["type"] != tt.wantType {
+				t.Fatalf("envelope = %s, want id, type, created_at and data", envelope.payload)
+			}
+			if _, err = time.Parse(time.RFC3339, got["created_at"].(string)); err != nil {
+				t.Errorf("created_at: %v", err)
+			}
+			var wantData any
+			if err = json.Unmarshal([]byte(tt.wantData), &wantData); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got["data"], wantData) {
+				gotData, _ := json.Marshal(got["data"])
+				t.Errorf("data = %s, want %s", gotData, tt.wantData)
+			}
+		})
+	}
+
+	if _, _, err := eventData("unknown", []byte(`{}`)); err == nil {
+		t.Error("unknown topic should return an error")
+	}
+}
+
+func TestWebhookService_EnqueueEvent(t *testing.T) {
+	repo := newFakeWebhookRepository(
+		domain.Webhook{Id: "all", Enabled: true, Events: []string{domain.EventAll}},
+		domain.Webhook{Id: "comment", Enabled: true, Events: []string{domain.EventCommentCreate}},
+		domain.Webhook{Id: "disabled", Enabled: false, Events: []string{domain.EventAll}},
+	)
+	deliveryRepo := &fakeDeliveryRepository{}
+	s := newTestWebhookService(repo, deliveryRepo)
+
+	err := s.enqueueEvent(context.Background(), "post", []byte(`{"post_id":"p1","type":"update"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveryRepo.deliveries) != 1 {
+		t.Fatalf("deliveries = %+v, want one for the webhook subscribed to all events", deliveryRepo.deliveries)
+	}
+	delivery := deliveryRepo.deliveries[0]
+	if delivery.WebhookId != "all" || delivery.EventType != domain.EventPostUpdate {
+		t.Fatalf("delivery = %+v, want post.update for the webhook subscribed to all events", delivery)
+	}
+	var envelope domain.Envelope
+	if err = json.Unmarshal([]byte(delivery.Payload), &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Id != delivery.EventId || envelope.Type != domain.EventPostUpdate {
+		t.Fatalf("envelope = %+v, want the event id and type of the delivery", envelope)
+	}
+
+	// 同一事件推送给多个 webhook 时使用相同的事件 id
+	deliveryRepo.deliveries = nil
+	if err = s.enqueueEvent(context.Background(), "comment", []byte(`{"post_id":"p1","comment_id":"c1","count":1,"type":"create"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveryRepo.deliveries) != 2 || deliveryRepo.deliveries[0].EventId != deliveryRepo.deliveries[1].EventId {
+		t.Fatalf("deliveries = %+v, want two with the same event id", deliveryRepo.deliveries)
+	}
+}
+
+func TestWebhookService_PingWebhook(t *testing.T) {
+	// ping 不要求 webhook 启用或订阅
+	repo := newFakeWebhookRepository(domain.Webhook{Id: "w1", Enabled: false, Events: []string{domain.EventPostLike}})
+	deliveryRepo := &fakeDeliveryRepository{}
+	s := newTestWebhookService(repo, deliveryRepo)
+
+	if _, err := s.PingWebhook(context.Background(), "w1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveryRepo.deliveries) != 1 || deliveryRepo.deliveries[0].EventType != domain.EventPing {
+		t.Fatalf("deliveries = %+v, want one ping", deliveryRepo.deliveries)
+	}
+	var got map[string]any
+	if err := json.Unmarshal([]byte(deliveryRepo.deliveries[0].Payload), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"webhook_id": "w1", "events": []any{domain.EventPostLike}}
+	if got["type"] != domain.EventPing || !reflect.DeepEqual(got["data"], want) {
+		t.Fatalf("envelope = %v, want ping with %v", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+type WebhookRequest struct {
+	Name string `json:"name" binding:"required"`
+	Url  string `json:"url" binding:"required,url"`
+	// 签名密钥，新增时为空则自动生成，修改时为空则保持不变
+	Secret string `json:"secret" binding:"omitempty,min=16"`
+	// 订阅的事件类型，* 表示全部事件
+	Events  []string `json:"events" binding:"required,min=1,dive,oneof=* post.create post.update post.delete post.like comment.create comment.delete category.create category.delete tag.create tag.delete website.visit"`
+	Enabled bool     `json:"enabled"`
+}
+
+type DeliveryPageRequest struct {
+	// 当前页
+	PageNo int64 `form:"pageNo" binding:"required,min=1"`
+	// 每页数量
+	PageSize int64 `form:"pageSize" binding:"required,min=1,max=100"`
+	// 推送状态：pending、succeeded、failed，为空时查询全部
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+type WebhookVO struct {
+	Id        string   `json:"id"`
+	Name      string   `json:"name"`
+	Url       string   `json:"url"`
+	Secret    string   `json:"secret"`
+	Events    []string `json:"events"`
+	Enabled   bool     `json:"enabled"`
+	CreatedAt int64    `json:"created_at"`
+	UpdatedAt int64    `json:"updated_at"`
+}
+
+type DeliveryVO struct {
+	Id            string `json:"id"`
+	WebhookId     string `json:"webhook_id"`
+	EventId       string `json:"event_id"`
+	EventType     string `json:"event_type"`
+	Payload       string `json:"payload"`
+	Status        string `json:"status"`
+	Redelivery    bool   `json:"redelivery"`
+	Attempts      int    `json:"attempts"`
+	NextAttemptAt int64  `json:"next_attempt_at"`
+	StatusCode    int    `json:"status_code,omitempty"`
+	ResponseBody  string `json:"response_body,omitempty"`
+	Error         string `json:"error,omitempty"`
+	// 最近一次推送的耗时，单位为毫秒
+	Duration    int64 `json:"duration"`
+	DeliveredAt int64 `json:"delivered_at,omitempty"`
+	CreatedAt   int64 `json:"created_at"`
+	UpdatedAt   int64 `json:"updated_at"`
+}
+
+type IdVO struct {
+	Id string `json:"id"`
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/web/audit"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/service"
+	"github.com/chenmingyong0423/gkit/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func NewWebhookHandler(serv service.IWebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		serv: serv,
+	}
+}
+
+type WebhookHandler struct {
+	serv service.IWebhookService
+}
+
+func (h *WebhookHandler) RegisterGinRoutes(engine *gin.Engine) {
+	adminGroup := engine.Group("/admin-api/webhooks")
+	adminGroup.GET("", apiwrap.Wrap(h.AdminGetWebhooks))
+	adminGroup.POST("", audit.Redact("url"), apiwrap.WrapWithBody(h.AdminAddWebhook))
+	adminGroup.PUT("/:id", audit.Redact("url"), apiwrap.WrapWithBody(h.AdminUpdateWebhook))
+	adminGroup.DELETE("/:id", apiwrap.Wrap(h.AdminDeleteWebhook))
+	adminGroup.POST("/:id/ping", apiwrap.Wrap(h.AdminPingWebhook))
+	adminGroup.GET("/:id/deliveries", apiwrap.WrapWithBody(h.AdminGetDeliveries))
+
+	deliveryGroup := engine.Group("/admin-api/webhook-deliveries")
+	deliveryGroup.POST("/:id/redeliver", apiwrap.Wrap(h.AdminRedeliver))
+
+	engine.GET("/admin-api/webhook-event-types", apiwrap.Wrap(h.AdminGetEventTypes))
+}
+
+func (h *WebhookHandler) AdminGetWebhooks(ctx *gin.Context) (*apiwrap.ResponseBody[apiwrap.ListVO[WebhookVO]], error) {
+	webhooks, err := h.serv.GetWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewListVO(slice.Map(webhooks, func(_ int, w domain.Webhook) WebhookVO {
+		return WebhookVO{
+			Id:        w.Id,
+			Name:      w.Name,
+			Url:       w.Url,
+			Secret:    w.Secret,
+			Events:    w.Events,
+			Enabled:   w.Enabled,
+			CreatedAt: w.CreatedAt.Unix(),
+			UpdatedAt: w.UpdatedAt.Unix(),
+		}
+	}))), nil
+}
+
+func (h *WebhookHandler) AdminAddWebhook(ctx *gin.Context, req WebhookRequest) (*apiwrap.ResponseBody[IdVO], error) {
+	id, err := h.serv.AddWebhook(ctx, req.toDomain(""))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWebhookUrl) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, err.Error())
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(IdVO{Id: id}), nil
+}
+
+func (h *WebhookHandler) AdminUpdateWebhook(ctx *gin.Context, req WebhookRequest) (*apiwrap.ResponseBody[any], error) {
+	err := h.serv.UpdateWebhook(ctx, req.toDomain(ctx.Param("id")))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWebhookUrl) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, bson.ErrInvalidHex) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "Webhook not found.")
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponse(), nil
+}
+
+func (h *WebhookHandler) AdminDeleteWebhook(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	err := h.serv.DeleteWebhook(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, bson.ErrInvalidHex) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "Webhook not found.")
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponse(), nil
+}
+
+func (h *WebhookHandler) AdminPingWebhook(ctx *gin.Context) (*apiwrap.ResponseBody[IdVO], error) {
+	id, err := h.serv.PingWebhook(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, bson.ErrInvalidHex) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "Webhook not found.")
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(IdVO{Id: id}), nil
+}
+
+func (h *WebhookHandler) AdminGetDeliveries(ctx *gin.Context, req DeliveryPageRequest) (*apiwrap.ResponseBody[*apiwrap.PageVO[DeliveryVO]], error) {
+	deliveries, total, err := h.serv.GetDeliveries(ctx, ctx.Param("id"), domain.DeliveryStatus(req.Status), req.PageNo, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewPageVO(req.PageNo, req.PageSize, total, slice.Map(deliveries, func(_ int, d domain.Delivery) DeliveryVO {
+		return h.toDeliveryVO(d)
+	}))), nil
+}
+
+func (h *WebhookHandler) AdminRedeliver(ctx *gin.Context) (*apiwrap.ResponseBody[IdVO], error) {
+	id, err := h.serv.Redeliver(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, bson.ErrInvalidHex) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusNotFound, "Delivery or webhook not found.")
+		}
+		if errors.Is(err, service.ErrDeliveryPending) {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusConflict, err.Error())
+		}
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(IdVO{Id: id}), nil
+}
+
+func (h *WebhookHandler) AdminGetEventTypes(_ *gin.Context) (*apiwrap.ResponseBody[apiwrap.ListVO[string]], error) {
+	return apiwrap.SuccessResponseWithData(apiwrap.NewListVO(domain.EventTypes)), nil
+}
+
+func (h *WebhookHandler) toDeliveryVO(delivery domain.Delivery) DeliveryVO {
+	vo := DeliveryVO{
+		Id:            delivery.Id,
+		WebhookId:     delivery.WebhookId,
+		EventId:       delivery.EventId,
+		EventType:     delivery.EventType,
+		Payload:       delivery.Payload,
+		Status:        string(delivery.Status),
+		Redelivery:    delivery.Redelivery,
+		Attempts:      delivery.Attempts,
+		NextAttemptAt: delivery.NextAttemptAt.Unix(),
+		StatusCode:    delivery.Result.StatusCode,
+		ResponseBody:  delivery.Result.ResponseBody,
+		Error:         delivery.Result.Error,
+		Duration:      delivery.Result.Duration.Milliseconds(),
+		CreatedAt:     delivery.CreatedAt.Unix(),
+		UpdatedAt:     delivery.UpdatedAt.Unix(),
+	}
+	if !delivery.DeliveredAt.IsZero() {
+		vo.DeliveredAt = delivery.DeliveredAt.Unix()
+	}
+	return vo
+}
+
+func (r WebhookRequest) toDomain(id string) domain.Webhook {
+	return domain.Webhook{
+		Id:      id,
+		Name:    r.Name,
+		Url:     r.Url,
+		Secret:  r.Secret,
+		Events:  r.Events,
+		Enabled: r.Enabled,
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build wireinject
+
+package webhook
+
+import (
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/repository"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/repository/dao"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/service"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook/internal/web"
+	"github.com/chenmingyong0423/go-eventbus"
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/google/wire"
+)
+
+var WebhookProviders = wire.NewSet(web.NewWebhookHandler, service.NewWebhookService, repository.NewWebhookRepository, dao.NewWebhookDao,
+	repository.NewWebhookDeliveryRepository, dao.NewWebhookDeliveryDao,
+	wire.Bind(new(service.IWebhookService), new(*service.WebhookService)),
+	wire.Bind(new(repository.IWebhookRepository), new(*repository.WebhookRepository)),
+	wire.Bind(new(dao.IWebhookDao), new(*dao.WebhookDao)),
+	wire.Bind(new(repository.IWebhookDeliveryRepository), new(*repository.WebhookDeliveryRepository)),
+	wire.Bind(new(dao.IWebhookDeliveryDao), new(*dao.WebhookDeliveryDao)),
+)
+
+func InitWebhookModule(db *mongox.Database, eventBus *eventbus.EventBus) *Module {
+	panic(wire.Build(
+		WebhookProviders,
+		wire.Struct(new(Module), "Svc", "Hdl"),
+	))
+}
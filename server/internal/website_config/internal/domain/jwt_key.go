@@ -0,0 +1,26 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import "time"
+
+// JwtKey jwt 签名密钥
+type JwtKey struct {
+	Kid    string
+	Secret []byte
+	// RetiredAt 密钥被轮换的时间，为 nil 表示当前正在使用的密钥
+	RetiredAt *time.Time
+	CreatedAt time.Time
+}
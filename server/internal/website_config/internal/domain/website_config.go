@@ -119,6 +119,42 @@ type FrontPostCountConfig struct {
 	Count int64 `bson:"count"`
 }
 
+// FeedConfig RSS、Atom 和 JSON Feed 订阅源配置
+type FeedConfig struct {
+	// 是否输出全文，否则只输出摘要
+	FullContent bool `bson:"full_content"`
+	// 订阅源中的文章数量
+	Count int `bson:"count"`
+}
+
+// CommentModerationConfig 评论审核配置，数值为 0 时表示不启用对应的规则
+type CommentModerationConfig struct {
+	// 通过所有过滤器的评论是否直接审核通过，否则仍需人工审核
+	AutoApprove bool `bson:"auto_approve"`
+	// 屏蔽关键词，不区分大小写
+	BlockedKeywords []string `bson:"blocked_keywords"`
+	// 屏蔽的正则表达式
+	BlockedPatterns []string `bson:"blocked_patterns"`
+	// 评论中允许的最大链接数量，超过时需要人工审核
+	MaxLinks int `bson:"max_links"`
+	// 重复内容的检测时间窗口，单位分钟
+	DuplicateWindow int `bson:"duplicate_window"`
+	// 频率限制的时间窗口，单位分钟
+	RateLimitWindow int `bson:"rate_limit_window"`
+	// 时间窗口内同一 IP 最多可提交的评论数量
+	IpRateLimit int `bson:"ip_rate_limit"`
+	// 时间窗口内同一邮箱最多可提交的评论数量
+	EmailRateLimit int `bson:"email_rate_limit"`
+}
+
+// AkismetConfig Akismet 兼容的反垃圾服务配置
+type AkismetConfig struct {
+	Enabled bool `bson:"enabled"`
+	// 接口地址，例如 https://rest.akismet.com/1.1
+	Endpoint string `bson:"endpoint"`
+	ApiKey   string `bson:"api_key"`
+}
+
 type SeoMetaConfig struct {
 	Title                 string `bson:"title"`
 	Description           string `bson:"description"`
@@ -135,6 +171,29 @@ type AdminConfig struct {
 	Password string `bson:"password"`
 }
 
+// TwoFactorConfig 后台用户的两步验证（TOTP）配置，每个后台用户一份
+type TwoFactorConfig struct {
+	UserId  string
+	Enabled bool
+	// Secret 已启用的 TOTP 密钥
+	Secret string
+	// PendingSecret 绑定中的密钥，验证通过后才会启用
+	PendingSecret string
+	// RecoveryCodes 一次性恢复码的 sha256 哈希值
+	RecoveryCodes []string
+	// LastUsedStep 最近一次通过校验的时间步，用于防止验证码被重复使用
+	LastUsedStep   int64
+	FailedAttempts int
+	LockedUntil    int64
+	EnabledAt      int64
+}
+
+// TwoFactorEnrollment 绑定验证器应用所需的信息
+type TwoFactorEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
 type TokenInfo struct {
 	Expiration int64
 	Token      string
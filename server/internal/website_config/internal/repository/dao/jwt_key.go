@@ -0,0 +1,89 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/update"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type IJwtKeyDao interface {
+	FindJwtKeys(ctx context.Context) ([]*JwtKey, error)
+	InsertJwtKey(ctx context.Context, key *JwtKey) error
+	// RetireJwtKeysOlderThan 将比 key 早创建的未轮换密钥标记为已轮换，创建时间相同时按 kid 排序
+	RetireJwtKeysOlderThan(ctx context.Context, key *JwtKey, retiredAt time.Time) error
+	DeleteJwtKeysRetiredBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+type JwtKey struct {
+	mongox.Model `bson:",inline"`
+	Kid          string     `bson:"kid"`
+	Secret       []byte     `bson:"secret"`
+	RetiredAt    *time.Time `bson:"retired_at,omitempty"`
+}
+
+func (d *WebsiteConfigDao) FindJwtKeys(ctx context.Context) ([]*JwtKey, error) {
+	keys, err := d.jwtKeyColl.Finder().Find(ctx, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "kid", Value: -1}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "fails to find jwt keys")
+	}
+	return keys, nil
+}
+
+func (d *WebsiteConfigDao) InsertJwtKey(ctx context.Context, key *JwtKey) error {
+	_, err := d.jwtKeyColl.Creator().InsertOne(ctx, key)
+	if err != nil {
+		return errors.Wrapf(err, "fails to insert jwt key, kid=%s", key.Kid)
+	}
+	return nil
+}
+
+// RetireJwtKeysOlderThan 只轮换比 key 早的密钥，多个实例同时生成密钥时，各实例轮换的结果一致，最终只保留最新的密钥，
+// 不会出现互相轮换对方的密钥导致没有可用密钥的情况
+func (d *WebsiteConfigDao) RetireJwtKeysOlderThan(ctx context.Context, key *JwtKey, retiredAt time.Time) error {
+	_, err := d.jwtKeyColl.Updater().
+		Filter(bson.M{
+			"retired_at": bson.M{"$exists": false},
+			"$or": bson.A{
+				bson.M{"created_at": bson.M{"$lt": key.CreatedAt}},
+				bson.M{"created_at": key.CreatedAt, "kid": bson.M{"$lt": key.Kid}},
+			},
+		}).
+		Updates(update.NewBuilder().Set("retired_at", retiredAt).Set("updated_at", retiredAt).Build()).
+		UpdateMany(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to retire jwt keys older than kid=%s", key.Kid)
+	}
+	return nil
+}
+
+func (d *WebsiteConfigDao) DeleteJwtKeysRetiredBefore(ctx context.Context, t time.Time) (int64, error) {
+	deleteResult, err := d.jwtKeyColl.Deleter().Filter(query.Lt("retired_at", t)).DeleteMany(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to delete jwt keys retired before %v", t)
+	}
+	if deleteResult == nil {
+		return 0, fmt.Errorf("DeleteResult is nil, fails to delete jwt keys retired before %v", t)
+	}
+	return deleteResult.DeletedCount, nil
+}
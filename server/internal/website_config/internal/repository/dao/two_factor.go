@@ -0,0 +1,152 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chenmingyong0423/go-mongox/v2"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/query"
+	"github.com/chenmingyong0423/go-mongox/v2/builder/update"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ITwoFactorDao interface {
+	FindTwoFactorByUserId(ctx context.Context, userId string) (*TwoFactorConfig, error)
+	// SetPendingTotpSecret 保存绑定中的密钥，配置不存在时创建
+	SetPendingTotpSecret(ctx context.Context, userId string, secret string, now time.Time) error
+	// EnableTotp 启用绑定中的密钥，pendingSecret 已被替换时返回 false
+	EnableTotp(ctx context.Context, userId string, pendingSecret string, recoveryCodes []string, step int64, now time.Time) (bool, error)
+	DeleteTwoFactorByUserId(ctx context.Context, userId string) error
+	UpdateRecoveryCodes(ctx context.Context, userId string, recoveryCodes []string) error
+	// ConsumeTotpStep 记录通过校验的时间步，时间步不大于上次记录的值时返回 false
+	ConsumeTotpStep(ctx context.Context, userId string, step int64) (bool, error)
+	// ConsumeRecoveryCode 移除已使用的恢复码，恢复码不存在时返回 false
+	ConsumeRecoveryCode(ctx context.Context, userId string, recoveryCode string) (bool, error)
+	// IncTotpFailedAttempts 累加两步验证失败次数，返回累加后的次数
+	IncTotpFailedAttempts(ctx context.Context, userId string) (int, error)
+	LockTotp(ctx context.Context, userId string, lockedUntil time.Time) error
+}
+
+// TwoFactorConfig defines for the MongoDB Collection "two_factor_configs"
+type TwoFactorConfig struct {
+	mongox.Model   `bson:",inline"`
+	UserId         string     `bson:"user_id"`
+	Enabled        bool       `bson:"enabled"`
+	Secret         string     `bson:"secret,omitempty"`
+	PendingSecret  string     `bson:"pending_secret,omitempty"`
+	RecoveryCodes  []string   `bson:"recovery_codes,omitempty"`
+	LastUsedStep   int64      `bson:"last_used_step"`
+	FailedAttempts int        `bson:"failed_attempts"`
+	LockedUntil    *time.Time `bson:"locked_until,omitempty"`
+	EnabledAt      *time.Time `bson:"enabled_at,omitempty"`
+}
+
+func (d *WebsiteConfigDao) FindTwoFactorByUserId(ctx context.Context, userId string) (*TwoFactorConfig, error) {
+	return d.twoFactorColl.Finder().Filter(query.Eq("user_id", userId)).FindOne(ctx)
+}
+
+func (d *WebsiteConfigDao) SetPendingTotpSecret(ctx context.Context, userId string, secret string, now time.Time) error {
+	_, err := d.twoFactorColl.Updater().Filter(query.Eq("user_id", userId)).
+		Updates(update.NewBuilder().Set("pending_secret", secret).Set("updated_at", now).SetOnInsert("created_at", now).Build()).
+		Upsert(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to set the pending totp secret, userId=%s", userId)
+	}
+	return nil
+}
+
+func (d *WebsiteConfigDao) EnableTotp(ctx context.Context, userId string, pendingSecret string, recoveryCodes []string, step int64, now time.Time) (bool, error) {
+	updateResult, err := d.twoFactorColl.Updater().Filter(query.NewBuilder().Eq("user_id", userId).Eq("pending_secret", pendingSecret).Build()).
+		Updates(update.NewBuilder().
+			Set("enabled", true).
+			Set("secret", pendingSecret).
+			Set("recovery_codes", recoveryCodes).
+			Set("last_used_step", step).
+			Set("failed_attempts", 0).
+			Set("enabled_at", now).
+			Set("updated_at", now).
+			Unset("pending_secret", "locked_until").
+			Build()).
+		UpdateOne(ctx)
+	if err != nil {
+		return false, errors.Wrapf(err, "fails to enable totp, userId=%s", userId)
+	}
+	return updateResult.ModifiedCount > 0, nil
+}
+
+func (d *WebsiteConfigDao) DeleteTwoFactorByUserId(ctx context.Context, userId string) error {
+	_, err := d.twoFactorColl.Deleter().Filter(query.Eq("user_id", userId)).DeleteOne(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to delete the two-factor config, userId=%s", userId)
+	}
+	return nil
+}
+
+func (d *WebsiteConfigDao) UpdateRecoveryCodes(ctx context.Context, userId string, recoveryCodes []string) error {
+	updateResult, err := d.twoFactorColl.Updater().Filter(query.NewBuilder().Eq("user_id", userId).Eq("enabled", true).Build()).
+		Updates(update.NewBuilder().Set("recovery_codes", recoveryCodes).Set("updated_at", time.Now().Local()).Build()).
+		UpdateOne(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to update the recovery codes, userId=%s", userId)
+	}
+	if updateResult.MatchedCount == 0 {
+		return fmt.Errorf("MatchedCount=0, fails to update the recovery codes, userId=%s", userId)
+	}
+	return nil
+}
+
+func (d *WebsiteConfigDao) ConsumeTotpStep(ctx context.Context, userId string, step int64) (bool, error) {
+	updateResult, err := d.twoFactorColl.Updater().Filter(query.NewBuilder().Eq("user_id", userId).Lt("last_used_step", step).Build()).
+		Updates(update.NewBuilder().Set("last_used_step", step).Set("failed_attempts", 0).Build()).
+		UpdateOne(ctx)
+	if err != nil {
+		return false, errors.Wrapf(err, "fails to consume totp step, userId=%s", userId)
+	}
+	return updateResult.ModifiedCount > 0, nil
+}
+
+func (d *WebsiteConfigDao) ConsumeRecoveryCode(ctx context.Context, userId string, recoveryCode string) (bool, error) {
+	updateResult, err := d.twoFactorColl.Updater().Filter(query.NewBuilder().Eq("user_id", userId).Eq("recovery_codes", recoveryCode).Build()).
+		Updates(update.NewBuilder().Pull("recovery_codes", recoveryCode).Set("failed_attempts", 0).Build()).
+		UpdateOne(ctx)
+	if err != nil {
+		return false, errors.Wrapf(err, "fails to consume recovery code, userId=%s", userId)
+	}
+	return updateResult.ModifiedCount > 0, nil
+}
+
+func (d *WebsiteConfigDao) IncTotpFailedAttempts(ctx context.Context, userId string) (int, error) {
+	cfg, err := d.twoFactorColl.Finder().Filter(query.Eq("user_id", userId)).
+		Updates(update.NewBuilder().Inc("failed_attempts", 1).Build()).
+		FindOneAndUpdate(ctx, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err != nil {
+		return 0, errors.Wrapf(err, "fails to increase totp failed attempts, userId=%s", userId)
+	}
+	return cfg.FailedAttempts, nil
+}
+
+func (d *WebsiteConfigDao) LockTotp(ctx context.Context, userId string, lockedUntil time.Time) error {
+	_, err := d.twoFactorColl.Updater().Filter(query.Eq("user_id", userId)).
+		Updates(update.NewBuilder().Set("locked_until", lockedUntil).Set("failed_attempts", 0).Build()).
+		UpdateOne(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to lock totp, userId=%s", userId)
+	}
+	return nil
+}
@@ -40,12 +40,16 @@ type WebsiteConfig struct {
 
 type IWebsiteConfigDao interface {
 	IConfigCheckStateDao
+	IJwtKeyDao
+	ITwoFactorDao
 	FindByTyp(ctx context.Context, typ string) (*WebsiteConfig, error)
 	Increase(ctx context.Context, field string) error
 	GetByTypes(ctx context.Context, types ...string) ([]*WebsiteConfig, error)
 	Decrease(ctx context.Context, field string) error
 	UpdateByConditionAndUpdates(ctx context.Context, cond bson.D, updates bson.D) error
 	UpdatePropsByTyp(ctx context.Context, typ string, cfg any, now time.Time) error
+	// UpsertPropsByTyp 更新配置，配置不存在时创建，用于新增的配置项
+	UpsertPropsByTyp(ctx context.Context, typ string, cfg any, now time.Time) error
 	AddTPSVConfig(ctx context.Context, tpsv domain.TPSV) error
 	DeleteTPSVConfigByKey(ctx context.Context, key string) error
 	UpdatePostIndexProps(ctx context.Context, updates bson.D) error
@@ -59,12 +63,16 @@ func NewWebsiteConfigDao(db *mongox.Database) *WebsiteConfigDao {
 	return &WebsiteConfigDao{
 		coll:                 mongox.NewCollection[WebsiteConfig](db, "configs"),
 		configCheckStateColl: mongox.NewCollection[ConfigCheckState](db, "config_check_states"),
+		jwtKeyColl:           mongox.NewCollection[JwtKey](db, "jwt_keys"),
+		twoFactorColl:        mongox.NewCollection[TwoFactorConfig](db, "two_factor_configs"),
 	}
 }
 
 type WebsiteConfigDao struct {
 	coll                 *mongox.Collection[WebsiteConfig]
 	configCheckStateColl *mongox.Collection[ConfigCheckState]
+	jwtKeyColl           *mongox.Collection[JwtKey]
+	twoFactorColl        *mongox.Collection[TwoFactorConfig]
 }
 
 func (d *WebsiteConfigDao) FindByFilter(ctx context.Context, filter bson.D) (*WebsiteConfig, error) {
@@ -131,6 +139,14 @@ func (d *WebsiteConfigDao) UpdatePropsByTyp(ctx context.Context, typ string, cfg
 	return nil
 }
 
+func (d *WebsiteConfigDao) UpsertPropsByTyp(ctx context.Context, typ string, cfg any, now time.Time) error {
+	_, err := d.coll.Updater().Filter(bsonx.M("typ", typ)).Updates(update.NewBuilder().Set("props", cfg).Set("updated_at", now).SetOnInsert("created_at", now).Build()).Upsert(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fails to upsert %s config, updates=%v", typ, cfg)
+	}
+	return nil
+}
+
 func (d *WebsiteConfigDao) UpdateByConditionAndUpdates(ctx context.Context, cond bson.D, updates bson.D) error {
 	updateOne, err := d.coll.Updater().Filter(cond).Updates(updates).UpdateOne(ctx)
 	if err != nil {
@@ -0,0 +1,67 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/website_config/internal/domain"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config/internal/repository/dao"
+	"github.com/chenmingyong0423/go-mongox/v2"
+)
+
+type IJwtKeyRepository interface {
+	// FindJwtKeys 按创建时间倒序返回所有 jwt 签名密钥，创建时间相同时按 kid 倒序
+	FindJwtKeys(ctx context.Context) ([]domain.JwtKey, error)
+	// RotateJwtKey 保存新的签名密钥，并将比它早创建的未轮换密钥标记为已轮换
+	RotateJwtKey(ctx context.Context, key domain.JwtKey) error
+	DeleteJwtKeysRetiredBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+func (r *WebsiteConfigRepository) FindJwtKeys(ctx context.Context) ([]domain.JwtKey, error) {
+	keys, err := r.dao.FindJwtKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]domain.JwtKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, domain.JwtKey{
+			Kid:       key.Kid,
+			Secret:    key.Secret,
+			RetiredAt: key.RetiredAt,
+			CreatedAt: key.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+func (r *WebsiteConfigRepository) RotateJwtKey(ctx context.Context, key domain.JwtKey) error {
+	// mongodb 中的时间精度为毫秒，比较创建时间前先截断
+	now := time.Now().Local().Truncate(time.Millisecond)
+	jwtKey := &dao.JwtKey{
+		Model:  mongox.Model{CreatedAt: now, UpdatedAt: now},
+		Kid:    key.Kid,
+		Secret: key.Secret,
+	}
+	if err := r.dao.InsertJwtKey(ctx, jwtKey); err != nil {
+		return err
+	}
+	return r.dao.RetireJwtKeysOlderThan(ctx, jwtKey, now)
+}
+
+func (r *WebsiteConfigRepository) DeleteJwtKeysRetiredBefore(ctx context.Context, t time.Time) (int64, error) {
+	return r.dao.DeleteJwtKeysRetiredBefore(ctx, t)
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/website_config/internal/domain"
+)
+
+type ITwoFactorRepository interface {
+	FindTwoFactorByUserId(ctx context.Context, userId string) (*domain.TwoFactorConfig, error)
+	SetPendingTotpSecret(ctx context.Context, userId string, secret string) error
+	EnableTotp(ctx context.Context, userId string, pendingSecret string, recoveryCodes []string, step int64) (bool, error)
+	DeleteTwoFactorByUserId(ctx context.Context, userId string) error
+	UpdateRecoveryCodes(ctx context.Context, userId string, recoveryCodes []string) error
+	ConsumeTotpStep(ctx context.Context, userId string, step int64) (bool, error)
+	ConsumeRecoveryCode(ctx context.Context, userId string, recoveryCode string) (bool, error)
+	IncTotpFailedAttempts(ctx context.Context, userId string) (int, error)
+	LockTotp(ctx context.Context, userId string, lockedUntil time.Time) error
+}
+
+func (r *WebsiteConfigRepository) FindTwoFactorByUserId(ctx context.Context, userId string) (*domain.TwoFactorConfig, error) {
+	cfg, err := r.dao.FindTwoFactorByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	result := &domain.TwoFactorConfig{
+		UserId:         cfg.UserId,
+		Enabled:        cfg.Enabled,
+		Secret:         cfg.Secret,
+		PendingSecret:  cfg.PendingSecret,
+		RecoveryCodes:  cfg.RecoveryCodes,
+		LastUsedStep:   cfg.LastUsedStep,
+		FailedAttempts: cfg.FailedAttempts,
+	}
+	if cfg.LockedUntil != nil {
+		result.LockedUntil = cfg.LockedUntil.Unix()
+	}
+	if cfg.EnabledAt != nil {
+		result.EnabledAt = cfg.EnabledAt.Unix()
+	}
+	return result, nil
+}
+
+func (r *WebsiteConfigRepository) SetPendingTotpSecret(ctx context.Context, userId string, secret string) error {
+	return r.dao.SetPendingTotpSecret(ctx, userId, secret, time.Now().Local())
+}
+
+func (r *WebsiteConfigRepository) EnableTotp(ctx context.Context, userId string, pendingSecret string, recoveryCodes []string, step int64) (bool, error) {
+	return r.dao.EnableTotp(ctx, userId, pendingSecret, recoveryCodes, step, time.Now().Local())
+}
+
+func (r *WebsiteConfigRepository) DeleteTwoFactorByUserId(ctx context.Context, userId string) error {
+	return r.dao.DeleteTwoFactorByUserId(ctx, userId)
+}
+
+func (r *WebsiteConfigRepository) UpdateRecoveryCodes(ctx context.Context, userId string, recoveryCodes []string) error {
+	return r.dao.UpdateRecoveryCodes(ctx, userId, recoveryCodes)
+}
+
+func (r *WebsiteConfigRepository) ConsumeTotpStep(ctx context.Context, userId string, step int64) (bool, error) {
+	return r.dao.ConsumeTotpStep(ctx, userId, step)
+}
+
+func (r *WebsiteConfigRepository) ConsumeRecoveryCode(ctx context.Context, userId string, recoveryCode string) (bool, error) {
+	return r.dao.ConsumeRecoveryCode(ctx, userId, recoveryCode)
+}
+
+func (r *WebsiteConfigRepository) IncTotpFailedAttempts(ctx context.Context, userId string) (int, error) {
+	return r.dao.IncTotpFailedAttempts(ctx, userId)
+}
+
+func (r *WebsiteConfigRepository) LockTotp(ctx context.Context, userId string, lockedUntil time.Time) error {
+	return r.dao.LockTotp(ctx, userId, lockedUntil)
+}
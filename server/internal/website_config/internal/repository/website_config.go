@@ -38,6 +38,8 @@ import (
 
 type IWebsiteConfigRepository interface {
 	IConfigCheckStateRepository
+	IJwtKeyRepository
+	ITwoFactorRepository
 	FindByTyp(ctx context.Context, typ string) (any, error)
 	Increase(ctx context.Context, field string) error
 	FindConfigByTypes(ctx context.Context, types ...string) ([]domain.Config, error)
@@ -49,6 +51,9 @@ type IWebsiteConfigRepository interface {
 	UpdateNoticeConfig(ctx context.Context, noticeCfg *domain.NoticeConfig) error
 	UpdateNoticeConfigEnabled(ctx context.Context, enabled bool) error
 	UpdateFrontPostCountConfig(ctx context.Context, cfg domain.FrontPostCountConfig) error
+	UpdateFeedConfig(ctx context.Context, cfg domain.FeedConfig, now time.Time) error
+	UpdateCommentModerationConfig(ctx context.Context, cfg domain.CommentModerationConfig, now time.Time) error
+	UpdateAkismetConfig(ctx context.Context, cfg domain.AkismetConfig, now time.Time) error
 	AddRecordInWebsiteConfig(ctx context.Context, record string) error
 	DeleteRecordInWebsiteConfig(ctx context.Context, record string) error
 	PushPayInfo(ctx *gin.Context, payInfoConfigElem domain.PayInfoConfigElem) error
@@ -259,6 +264,18 @@ func (r *WebsiteConfigRepository) UpdateFrontPostCountConfig(ctx context.Context
 	)
 }
 
+func (r *WebsiteConfigRepository) UpdateFeedConfig(ctx context.Context, cfg domain.FeedConfig, now time.Time) error {
+	return r.dao.UpsertPropsByTyp(ctx, "feed", cfg, now)
+}
+
+func (r *WebsiteConfigRepository) UpdateCommentModerationConfig(ctx context.Context, cfg domain.CommentModerationConfig, now time.Time) error {
+	return r.dao.UpsertPropsByTyp(ctx, "comment-moderation", cfg, now)
+}
+
+func (r *WebsiteConfigRepository) UpdateAkismetConfig(ctx context.Context, cfg domain.AkismetConfig, now time.Time) error {
+	return r.dao.UpsertPropsByTyp(ctx, "akismet", cfg, now)
+}
+
 func (r *WebsiteConfigRepository) UpdateNoticeConfigEnabled(ctx context.Context, enabled bool) error {
 	return r.dao.UpdateByConditionAndUpdates(
 		ctx,
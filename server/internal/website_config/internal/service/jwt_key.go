@@ -0,0 +1,145 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/jwtutil"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config/internal/domain"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	jwtKeySize             = 32
+	defaultJwtGracePeriod  = 12 * time.Hour
+	jwtKeysRefreshInterval = time.Minute
+)
+
+var ErrJwtKeysManagedByConfig = errors.New("jwt signing keys are managed by the config file")
+
+// JwtKeysManagedByConfig 是否通过配置文件（jwt.secret 或环境变量 JWT_SECRET）指定签名密钥
+func JwtKeysManagedByConfig() bool {
+	return viper.GetString("jwt.secret") != ""
+}
+
+func jwtGracePeriod() time.Duration {
+	if viper.IsSet("jwt.grace_period") {
+		return viper.GetDuration("jwt.grace_period")
+	}
+	return defaultJwtGracePeriod
+}
+
+func (s *WebsiteConfigService) GetJwtKeys(ctx context.Context) ([]domain.JwtKey, error) {
+	if JwtKeysManagedByConfig() {
+		return s.configJwtKeys(), nil
+	}
+	return s.repo.FindJwtKeys(ctx)
+}
+
+// RotateJwtKey 生成新的签名密钥，旧密钥在宽限期内仍可用于校验已签发的 jwt
+func (s *WebsiteConfigService) RotateJwtKey(ctx context.Context) error {
+	if JwtKeysManagedByConfig() {
+		return ErrJwtKeysManagedByConfig
+	}
+	err := s.rotateJwtKey(ctx)
+	if err != nil {
+		return err
+	}
+	return s.LoadJwtKeys(ctx)
+}
+
+func (s *WebsiteConfigService) rotateJwtKey(ctx context.Context) error {
+	secret := make([]byte, jwtKeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return errors.Wrap(err, "fails to generate jwt key")
+	}
+	err := s.repo.RotateJwtKey(ctx, domain.JwtKey{Kid: uuid.NewString(), Secret: secret})
+	if err != nil {
+		return err
+	}
+	// 清理宽限期已过的密钥
+	_, err = s.repo.DeleteJwtKeysRetiredBefore(ctx, time.Now().Local().Add(-jwtGracePeriod()))
+	return err
+}
+
+// LoadJwtKeys 加载签名密钥，数据库中没有可用的密钥时自动生成
+func (s *WebsiteConfigService) LoadJwtKeys(ctx context.Context) error {
+	var keys []domain.JwtKey
+	if JwtKeysManagedByConfig() {
+		keys = s.configJwtKeys()
+	} else {
+		var err error
+		keys, err = s.repo.FindJwtKeys(ctx)
+		if err != nil {
+			return err
+		}
+		if !hasActiveJwtKey(keys) {
+			err = s.rotateJwtKey(ctx)
+			if err != nil {
+				return err
+			}
+			keys, err = s.repo.FindJwtKeys(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	signingKeys := make([]jwtutil.SigningKey, 0, len(keys))
+	for _, key := range keys {
+		signingKeys = append(signingKeys, jwtutil.SigningKey{Id: key.Kid, Secret: key.Secret, RetiredAt: key.RetiredAt})
+	}
+	jwtutil.SetSigningKeys(signingKeys, jwtGracePeriod())
+	return nil
+}
+
+// refreshJwtKeysPeriodically 定期重新加载签名密钥，使多实例部署时其他实例的轮换能够生效
+func (s *WebsiteConfigService) refreshJwtKeysPeriodically() {
+	for {
+		time.Sleep(jwtKeysRefreshInterval)
+		err := s.LoadJwtKeys(context.Background())
+		if err != nil {
+			slog.Default().With("X-Request-ID", uuid.NewString()).Error("JwtKey: failed to refresh jwt keys", "error", err)
+		}
+	}
+}
+
+// configJwtKeys 配置文件中的 jwt.previous_secrets 视为在本次启动时被轮换，宽限期过后不再可用
+func (s *WebsiteConfigService) configJwtKeys() []domain.JwtKey {
+	secret := []byte(viper.GetString("jwt.secret"))
+	keys := []domain.JwtKey{{Kid: jwtutil.KeyIdOf(secret), Secret: secret, CreatedAt: s.startedAt}}
+	for _, previous := range viper.GetStringSlice("jwt.previous_secrets") {
+		if previous == "" {
+			continue
+		}
+		previousSecret := []byte(previous)
+		keys = append(keys, domain.JwtKey{Kid: jwtutil.KeyIdOf(previousSecret), Secret: previousSecret, RetiredAt: &s.startedAt, CreatedAt: s.startedAt})
+	}
+	return keys
+}
+
+func hasActiveJwtKey(keys []domain.JwtKey) bool {
+	for _, key := range keys {
+		if key.RetiredAt == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/totputil"
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config/internal/domain"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	// totpSkew 允许前后各一个时间步（30 秒）的时钟偏差
+	totpSkew = 1
+	// 连续失败 maxTwoFactorAttempts 次后锁定 twoFactorLockDuration
+	maxTwoFactorAttempts  = 5
+	twoFactorLockDuration = 15 * time.Minute
+	recoveryCodeCount     = 10
+	defaultTotpIssuer     = "fnote"
+)
+
+var (
+	ErrInvalidTwoFactorCode = errors.New("two-factor code is incorrect")
+	ErrTwoFactorLocked      = errors.New("too many failed two-factor attempts")
+
+	recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// GetTwoFactorConfig 获取后台用户的两步验证配置，未配置时返回未启用的默认值
+func (s *WebsiteConfigService) GetTwoFactorConfig(ctx context.Context, userId string) (*domain.TwoFactorConfig, error) {
+	cfg, err := s.repo.FindTwoFactorByUserId(ctx, userId)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return &domain.TwoFactorConfig{UserId: userId}, nil
+		}
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// BeginTotpEnrollment 生成新的 TOTP 密钥，需调用 ConfirmTotpEnrollment 验证后才会启用，accountName 为验证器应用中显示的账号
+func (s *WebsiteConfigService) BeginTotpEnrollment(ctx context.Context, userId string, accountName string) (*domain.TwoFactorEnrollment, error) {
+	cfg, err := s.GetTwoFactorConfig(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Enabled {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusConflict, "two-factor authentication is already enabled")
+	}
+	secret, err := totputil.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repo.SetPendingTotpSecret(ctx, userId, secret); err != nil {
+		return nil, err
+	}
+	return &domain.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totputil.ProvisioningURI(s.totpIssuer(ctx), accountName, secret),
+	}, nil
+}
+
+// ConfirmTotpEnrollment 校验验证器应用生成的验证码并启用两步验证，返回明文恢复码（仅此一次）
+func (s *WebsiteConfigService) ConfirmTotpEnrollment(ctx context.Context, userId string, code string) ([]string, error) {
+	cfg, err := s.GetTwoFactorConfig(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Enabled {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusConflict, "two-factor authentication is already enabled")
+	}
+	if cfg.PendingSecret == "" {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, "two-factor enrollment has not been started")
+	}
+	step, ok, err := totputil.Validate(cfg.PendingSecret, code, time.Now(), totpSkew)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.repo.EnableTotp(ctx, userId, cfg.PendingSecret, hashes, step)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusConflict, "two-factor enrollment has been restarted, please scan the new QR code")
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor 关闭两步验证并删除密钥和恢复码
+func (s *WebsiteConfigService) DisableTwoFactor(ctx context.Context, userId string) error {
+	return s.repo.DeleteTwoFactorByUserId(ctx, userId)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部失效
+func (s *WebsiteConfigService) RegenerateRecoveryCodes(ctx context.Context, userId string) ([]string, error) {
+	cfg, err := s.GetTwoFactorConfig(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, "two-factor authentication is not enabled")
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repo.UpdateRecoveryCodes(ctx, userId, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifyTwoFactor 校验验证码或恢复码（recoveryCode 不为空时使用恢复码），连续失败过多时锁定一段时间
+func (s *WebsiteConfigService) VerifyTwoFactor(ctx context.Context, userId string, code, recoveryCode string) error {
+	cfg, err := s.GetTwoFactorConfig(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if !cfg.Enabled {
+		return ErrInvalidTwoFactorCode
+	}
+	now := time.Now()
+	if cfg.LockedUntil > now.Unix() {
+		return ErrTwoFactorLocked
+	}
+	var ok bool
+	if recoveryCode != "" {
+		ok, err = s.repo.ConsumeRecoveryCode(ctx, userId, hashRecoveryCode(recoveryCode))
+	} else {
+		var step int64
+		step, ok, err = totputil.Validate(cfg.Secret, code, now, totpSkew)
+		if err == nil && ok {
+			// 同一验证码只能使用一次
+			ok, err = s.repo.ConsumeTotpStep(ctx, userId, step)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+	attempts, err := s.repo.IncTotpFailedAttempts(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if attempts >= maxTwoFactorAttempts {
+		if err = s.repo.LockTotp(ctx, userId, now.Add(twoFactorLockDuration).Local()); err != nil {
+			return err
+		}
+		slog.Default().WarnContext(ctx, "two-factor verification locked", "userId", userId, "attempts", attempts)
+	}
+	return ErrInvalidTwoFactorCode
+}
+
+// totpIssuer 验证器应用中显示的名称，优先使用站点名称
+func (s *WebsiteConfigService) totpIssuer(ctx context.Context) string {
+	websiteConfig, err := s.GetWebSiteConfig(ctx)
+	if err != nil || strings.TrimSpace(websiteConfig.WebsiteName) == "" {
+		return defaultTotpIssuer
+	}
+	return strings.TrimSpace(websiteConfig.WebsiteName)
+}
+
+// newRecoveryCodes 生成明文恢复码及其哈希值，格式为 xxxxx-xxxxx
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	b := make([]byte, 10)
+	for i := 0; i < recoveryCodeCount; i++ {
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, errors.Wrap(err, "fails to generate recovery code")
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode 恢复码忽略大小写、空格和连字符
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -16,8 +16,12 @@ package service
 
 import (
 	"context"
+	"crypto/subtle"
+	"log/slog"
 	"time"
 
+	"github.com/chenmingyong0423/fnote/server/internal/pkg/passwordutil"
+
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 
@@ -30,6 +34,21 @@ import (
 	"github.com/pkg/errors"
 )
 
+// defaultFeedCount 未配置订阅源时默认输出的文章数量
+const defaultFeedCount = 20
+
+// defaultAkismetEndpoint 未配置接口地址时使用 Akismet 官方的地址
+const defaultAkismetEndpoint = "https://rest.akismet.com/1.1"
+
+// defaultCommentModerationConfig 未配置评论审核时使用的默认规则
+var defaultCommentModerationConfig = domain.CommentModerationConfig{
+	MaxLinks:        2,
+	DuplicateWindow: 24 * 60,
+	RateLimitWindow: 10,
+	IpRateLimit:     5,
+	EmailRateLimit:  5,
+}
+
 type IWebsiteConfigService interface {
 	GetWebSiteConfig(ctx context.Context) (*domain.WebsiteConfig, error)
 	GetEmailConfig(ctx context.Context) (*domain.EmailConfig, error)
@@ -47,6 +66,16 @@ type IWebsiteConfigService interface {
 	UpdateNoticeConfigEnabled(ctx context.Context, enabled bool) error
 	GetFrontPostCountConfig(ctx context.Context) (domain.FrontPostCountConfig, error)
 	UpdateFrontPostCountConfig(ctx context.Context, cfg domain.FrontPostCountConfig) error
+	// GetFeedConfig 获取订阅源配置，未配置时返回默认值
+	GetFeedConfig(ctx context.Context) (domain.FeedConfig, error)
+	UpdateFeedConfig(ctx context.Context, cfg domain.FeedConfig) error
+	// GetCommentModerationConfig 获取评论审核配置，未配置时返回默认值
+	GetCommentModerationConfig(ctx context.Context) (domain.CommentModerationConfig, error)
+	UpdateCommentModerationConfig(ctx context.Context, cfg domain.CommentModerationConfig) error
+	// GetAkismetConfig 获取反垃圾服务配置，未配置时返回未启用的默认值
+	GetAkismetConfig(ctx context.Context) (domain.AkismetConfig, error)
+	// UpdateAkismetConfig cfg.ApiKey 为空时保留已配置的 API Key，启用时必须已配置 API Key
+	UpdateAkismetConfig(ctx context.Context, cfg domain.AkismetConfig) error
 	AddRecordInWebsiteConfig(ctx context.Context, record string) error
 	DeleteRecordInWebsiteConfig(ctx context.Context, record string) error
 	GetPayConfig(ctx context.Context) (domain.PayInfoConfig, error)
@@ -57,6 +86,13 @@ type IWebsiteConfigService interface {
 	UpdateSocialInfo(ctx context.Context, socialInfo domain.SocialInfo) error
 	DeleteSocialInfo(ctx context.Context, id []byte) error
 	GetAdminConfig(ctx context.Context) (*domain.AdminConfig, error)
+	VerifyAdminPassword(ctx context.Context, username, password string) (bool, error)
+	GetTwoFactorConfig(ctx context.Context, userId string) (*domain.TwoFactorConfig, error)
+	BeginTotpEnrollment(ctx context.Context, userId string, accountName string) (*domain.TwoFactorEnrollment, error)
+	ConfirmTotpEnrollment(ctx context.Context, userId string, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userId string) error
+	RegenerateRecoveryCodes(ctx context.Context, userId string) ([]string, error)
+	VerifyTwoFactor(ctx context.Context, userId string, code, recoveryCode string) error
 	InitializeWebsite(ctx context.Context, adminConfig domain.AdminConfig, webSiteConfig domain.WebsiteConfig) error
 	UpdateWebsiteConfig(ctx context.Context, websiteConfig domain.WebsiteConfig, now time.Time) error
 	GetTPSVConfig(ctx context.Context) (*domain.TPSVConfig, error)
@@ -74,18 +110,29 @@ type IWebsiteConfigService interface {
 	GetCommonConfig(ctx context.Context) (*domain.CommonConfig, error)
 	GetConfigHealth(ctx context.Context) (*domain.ConfigHealth, error)
 	UpdateConfigCheckState(ctx context.Context, state domain.ConfigCheckState) error
+	GetJwtKeys(ctx context.Context) ([]domain.JwtKey, error)
+	RotateJwtKey(ctx context.Context) error
 }
 
 var _ IWebsiteConfigService = (*WebsiteConfigService)(nil)
 
+var ErrAkismetApiKeyRequired = errors.New("the akismet api key is required to enable akismet")
+
 func NewWebsiteConfigService(repo repository.IWebsiteConfigRepository) *WebsiteConfigService {
-	return &WebsiteConfigService{
-		repo: repo,
+	s := &WebsiteConfigService{
+		repo:      repo,
+		startedAt: time.Now().Local(),
+	}
+	if err := s.LoadJwtKeys(context.Background()); err != nil {
+		slog.Default().Error("JwtKey: failed to load jwt keys", "error", err)
 	}
+	go s.refreshJwtKeysPeriodically()
+	return s
 }
 
 type WebsiteConfigService struct {
-	repo repository.IWebsiteConfigRepository
+	repo      repository.IWebsiteConfigRepository
+	startedAt time.Time
 }
 
 func (s *WebsiteConfigService) GetCommonConfig(ctx context.Context) (*domain.CommonConfig, error) {
@@ -180,7 +227,12 @@ func (s *WebsiteConfigService) GetTPSVConfig(ctx context.Context) (*domain.TPSVC
 
 func (s *WebsiteConfigService) InitializeWebsite(ctx context.Context, adminConfig domain.AdminConfig, webSiteConfig domain.WebsiteConfig) error {
 	now := time.Now().Local()
-	err := s.UpdateAdminConfig(ctx, adminConfig, now)
+	hashedPassword, err := passwordutil.Hash(adminConfig.Password)
+	if err != nil {
+		return err
+	}
+	adminConfig.Password = hashedPassword
+	err = s.UpdateAdminConfig(ctx, adminConfig, now)
 	if err != nil {
 		return err
 	}
@@ -201,6 +253,35 @@ func (s *WebsiteConfigService) GetAdminConfig(ctx context.Context) (*domain.Admi
 	return cfg, nil
 }
 
+// VerifyAdminPassword 校验管理员账号密码，明文存储的旧密码在校验通过后会被替换为哈希值
+func (s *WebsiteConfigService) VerifyAdminPassword(ctx context.Context, username, password string) (bool, error) {
+	adminConfig, err := s.GetAdminConfig(ctx)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, err
+	}
+	if adminConfig.Username == "" || adminConfig.Password == "" || subtle.ConstantTimeCompare([]byte(adminConfig.Username), []byte(username)) != 1 {
+		return false, nil
+	}
+	if passwordutil.IsHashed(adminConfig.Password) {
+		return passwordutil.Verify(adminConfig.Password, password)
+	}
+	if subtle.ConstantTimeCompare([]byte(adminConfig.Password), []byte(password)) != 1 {
+		return false, nil
+	}
+	hashedPassword, err := passwordutil.Hash(password)
+	if err != nil {
+		return false, err
+	}
+	err = s.UpdateAdminConfig(ctx, domain.AdminConfig{Username: adminConfig.Username, Password: hashedPassword}, time.Now().Local())
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s *WebsiteConfigService) DeleteSocialInfo(ctx context.Context, id []byte) error {
 	return s.repo.DeleteSocialInfo(ctx, id)
 }
@@ -260,6 +341,62 @@ func (s *WebsiteConfigService) GetFrontPostCountConfig(ctx context.Context) (dom
 	return cfg, nil
 }
 
+func (s *WebsiteConfigService) GetFeedConfig(ctx context.Context) (domain.FeedConfig, error) {
+	cfg := domain.FeedConfig{Count: defaultFeedCount}
+	err := s.getConfigAndConvertTo(ctx, "feed", &cfg)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return cfg, err
+	}
+	if cfg.Count <= 0 {
+		cfg.Count = defaultFeedCount
+	}
+	return cfg, nil
+}
+
+func (s *WebsiteConfigService) UpdateFeedConfig(ctx context.Context, cfg domain.FeedConfig) error {
+	return s.repo.UpdateFeedConfig(ctx, cfg, time.Now().Local())
+}
+
+func (s *WebsiteConfigService) GetCommentModerationConfig(ctx context.Context) (domain.CommentModerationConfig, error) {
+	cfg := defaultCommentModerationConfig
+	err := s.getConfigAndConvertTo(ctx, "comment-moderation", &cfg)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func (s *WebsiteConfigService) UpdateCommentModerationConfig(ctx context.Context, cfg domain.CommentModerationConfig) error {
+	return s.repo.UpdateCommentModerationConfig(ctx, cfg, time.Now().Local())
+}
+
+func (s *WebsiteConfigService) GetAkismetConfig(ctx context.Context) (domain.AkismetConfig, error) {
+	cfg := domain.AkismetConfig{Endpoint: defaultAkismetEndpoint}
+	err := s.getConfigAndConvertTo(ctx, "akismet", &cfg)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return cfg, err
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = defaultAkismetEndpoint
+	}
+	return cfg, nil
+}
+
+func (s *WebsiteConfigService) UpdateAkismetConfig(ctx context.Context, cfg domain.AkismetConfig) error {
+	// API Key 不会返回给前端，为空时保留已配置的
+	if cfg.ApiKey == "" {
+		old, err := s.GetAkismetConfig(ctx)
+		if err != nil {
+			return err
+		}
+		cfg.ApiKey = old.ApiKey
+	}
+	if cfg.Enabled && cfg.ApiKey == "" {
+		return ErrAkismetApiKeyRequired
+	}
+	return s.repo.UpdateAkismetConfig(ctx, cfg, time.Now().Local())
+}
+
 func (s *WebsiteConfigService) UpdateNoticeConfigEnabled(ctx context.Context, enabled bool) error {
 	return s.repo.UpdateNoticeConfigEnabled(ctx, enabled)
 }
@@ -0,0 +1,54 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+
+	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
+	"github.com/chenmingyong0423/fnote/server/internal/website_config/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func (h *WebsiteConfigHandler) AdminGetJwtKeys(ctx *gin.Context) (*apiwrap.ResponseBody[apiwrap.ListVO[JwtKeyVO]], error) {
+	keys, err := h.serv.GetJwtKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	managedByConfig := service.JwtKeysManagedByConfig()
+	result := make([]JwtKeyVO, 0, len(keys))
+	for _, key := range keys {
+		vo := JwtKeyVO{
+			Kid:             key.Kid,
+			Active:          key.RetiredAt == nil,
+			ManagedByConfig: managedByConfig,
+			CreatedAt:       key.CreatedAt.Unix(),
+		}
+		if key.RetiredAt != nil {
+			vo.RetiredAt = key.RetiredAt.Unix()
+		}
+		result = append(result, vo)
+	}
+	return apiwrap.SuccessResponseWithData(apiwrap.NewListVO(result)), nil
+}
+
+func (h *WebsiteConfigHandler) AdminRotateJwtKey(ctx *gin.Context) (*apiwrap.ResponseBody[any], error) {
+	err := h.serv.RotateJwtKey(ctx)
+	if errors.Is(err, service.ErrJwtKeysManagedByConfig) {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusConflict, err.Error())
+	}
+	return apiwrap.SuccessResponse(), err
+}
@@ -73,6 +73,29 @@ type UpdateFPCConfigCountReq struct {
 	Count int64 `json:"count" binding:"required"`
 }
 
+type UpdateFeedConfigReq struct {
+	FullContent bool `json:"full_content"`
+	Count       int  `json:"count" binding:"required,min=1,max=100"`
+}
+
+type UpdateCommentModerationConfigReq struct {
+	AutoApprove     bool     `json:"auto_approve"`
+	BlockedKeywords []string `json:"blocked_keywords"`
+	BlockedPatterns []string `json:"blocked_patterns"`
+	MaxLinks        int      `json:"max_links" binding:"min=0"`
+	DuplicateWindow int      `json:"duplicate_window" binding:"min=0"`
+	RateLimitWindow int      `json:"rate_limit_window" binding:"min=0"`
+	IpRateLimit     int      `json:"ip_rate_limit" binding:"min=0"`
+	EmailRateLimit  int      `json:"email_rate_limit" binding:"min=0"`
+}
+
+type UpdateAkismetConfigReq struct {
+	Enabled  bool   `json:"enabled"`
+	Endpoint string `json:"endpoint" binding:"omitempty,url"`
+	// ApiKey 为空时保留已配置的 API Key
+	ApiKey string `json:"api_key"`
+}
+
 type AddRecordInWebsiteConfig struct {
 	Record string `json:"website_record" binding:"required"`
 }
@@ -89,11 +112,6 @@ type SocialInfoReq struct {
 	IsLink      bool   `json:"is_link"`
 }
 
-type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-}
-
 type InitRequest struct {
 	WebsiteName         string `json:"website_name" binding:"required"`
 	WebsiteIcon         string `json:"website_icon" binding:"required"`
@@ -108,15 +108,27 @@ type FrontPostCountConfigVO struct {
 	Count int64 `json:"count"`
 }
 
-type LoginVO struct {
-	AdminInfo  AdminInfoVO `json:"admin_info"`
-	Expiration int64       `json:"expiration"`
-	Token      string      `json:"token"`
+type FeedConfigVO struct {
+	FullContent bool `json:"full_content"`
+	Count       int  `json:"count"`
 }
 
-type AdminInfoVO struct {
-	Username string `json:"username"`
-	Picture  string `json:"picture"`
+type CommentModerationConfigVO struct {
+	AutoApprove     bool     `json:"auto_approve"`
+	BlockedKeywords []string `json:"blocked_keywords"`
+	BlockedPatterns []string `json:"blocked_patterns"`
+	MaxLinks        int      `json:"max_links"`
+	DuplicateWindow int      `json:"duplicate_window"`
+	RateLimitWindow int      `json:"rate_limit_window"`
+	IpRateLimit     int      `json:"ip_rate_limit"`
+	EmailRateLimit  int      `json:"email_rate_limit"`
+}
+
+type AkismetConfigVO struct {
+	Enabled  bool   `json:"enabled"`
+	Endpoint string `json:"endpoint"`
+	// HasApiKey 是否已配置 API Key，API Key 本身不返回
+	HasApiKey bool `json:"has_api_key"`
 }
 
 type TPSVVO struct {
@@ -190,3 +202,12 @@ type ConfigHealthItemVO struct {
 	SnoozedUntil  *int64   `json:"snoozed_until,omitempty"`
 	IgnoredReason string   `json:"ignored_reason,omitempty"`
 }
+
+type JwtKeyVO struct {
+	Kid    string `json:"kid"`
+	Active bool   `json:"active"`
+	// ManagedByConfig 密钥是否由配置文件指定，此时不支持在后台轮换
+	ManagedByConfig bool  `json:"managed_by_config"`
+	CreatedAt       int64 `json:"created_at"`
+	RetiredAt       int64 `json:"retired_at,omitempty"`
+}
@@ -16,7 +16,10 @@ package web
 
 import (
 	"encoding/hex"
+	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,10 +29,6 @@ import (
 
 	"github.com/chenmingyong0423/fnote/server/internal/global"
 
-	"go.mongodb.org/mongo-driver/v2/mongo"
-
-	"github.com/chenmingyong0423/fnote/server/internal/pkg/jwtutil"
-
 	apiwrap "github.com/chenmingyong0423/fnote/server/internal/pkg/web/wrap"
 
 	"github.com/chenmingyong0423/gkit"
@@ -105,6 +104,12 @@ func (h *WebsiteConfigHandler) RegisterGinRoutes(engine *gin.Engine) {
 
 	adminGroup.GET("/front-post-count", apiwrap.Wrap(h.AdminGetFPCConfig))
 	adminGroup.PUT("/front-post-count", apiwrap.WrapWithBody(h.AdminUpdateFPCConfig))
+	adminGroup.GET("/feed", apiwrap.Wrap(h.AdminGetFeedConfig))
+	adminGroup.PUT("/feed", apiwrap.WrapWithBody(h.AdminUpdateFeedConfig))
+	adminGroup.GET("/comment-moderation", apiwrap.Wrap(h.AdminGetCommentModerationConfig))
+	adminGroup.PUT("/comment-moderation", apiwrap.WrapWithBody(h.AdminUpdateCommentModerationConfig))
+	adminGroup.GET("/akismet", apiwrap.Wrap(h.AdminGetAkismetConfig))
+	adminGroup.PUT("/akismet", apiwrap.WrapWithBody(h.AdminUpdateAkismetConfig))
 	adminGroup.GET("/pay", apiwrap.Wrap(h.AdminGetPayConfig))
 	adminGroup.POST("/pay", apiwrap.WrapWithBody(h.AdminAddPayInfo))
 	adminGroup.DELETE("/pay/:name", apiwrap.Wrap(h.AdminDeletePayInfo))
@@ -118,8 +123,9 @@ func (h *WebsiteConfigHandler) RegisterGinRoutes(engine *gin.Engine) {
 	adminGroup.GET("/post-index/:key", apiwrap.Wrap(h.AdminGetPushConfigByKey))
 	adminGroup.PUT("/post-index/:key", apiwrap.WrapWithBody(h.AdminUpdatePushConfigByKey))
 
-	engine.POST("/admin-api/login", apiwrap.WrapWithBody(h.AdminLogin))
-
+	// jwt 签名密钥
+	adminGroup.GET("/jwt/keys", apiwrap.Wrap(h.AdminGetJwtKeys))
+	adminGroup.POST("/jwt/rotate", apiwrap.Wrap(h.AdminRotateJwtKey))
 }
 
 func (h *WebsiteConfigHandler) GetIndexConfig(ctx *gin.Context) (*apiwrap.ResponseBody[IndexConfigVO], error) {
@@ -339,6 +345,95 @@ func (h *WebsiteConfigHandler) AdminUpdateFPCConfig(ctx *gin.Context, req Update
 	})
 }
 
+func (h *WebsiteConfigHandler) AdminGetFeedConfig(ctx *gin.Context) (*apiwrap.ResponseBody[FeedConfigVO], error) {
+	config, err := h.serv.GetFeedConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(FeedConfigVO{
+		FullContent: config.FullContent,
+		Count:       config.Count,
+	}), nil
+}
+
+func (h *WebsiteConfigHandler) AdminUpdateFeedConfig(ctx *gin.Context, req UpdateFeedConfigReq) (*apiwrap.ResponseBody[any], error) {
+	return apiwrap.SuccessResponse(), h.serv.UpdateFeedConfig(ctx, domain.FeedConfig{
+		FullContent: req.FullContent,
+		Count:       req.Count,
+	})
+}
+
+func (h *WebsiteConfigHandler) AdminGetCommentModerationConfig(ctx *gin.Context) (*apiwrap.ResponseBody[CommentModerationConfigVO], error) {
+	config, err := h.serv.GetCommentModerationConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(CommentModerationConfigVO{
+		AutoApprove:     config.AutoApprove,
+		BlockedKeywords: config.BlockedKeywords,
+		BlockedPatterns: config.BlockedPatterns,
+		MaxLinks:        config.MaxLinks,
+		DuplicateWindow: config.DuplicateWindow,
+		RateLimitWindow: config.RateLimitWindow,
+		IpRateLimit:     config.IpRateLimit,
+		EmailRateLimit:  config.EmailRateLimit,
+	}), nil
+}
+
+func (h *WebsiteConfigHandler) AdminUpdateCommentModerationConfig(ctx *gin.Context, req UpdateCommentModerationConfigReq) (*apiwrap.ResponseBody[any], error) {
+	keywords, patterns := trimAndCompact(req.BlockedKeywords), trimAndCompact(req.BlockedPatterns)
+	for _, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, fmt.Sprintf("invalid blocked pattern %q: %v", pattern, err))
+		}
+	}
+	return apiwrap.SuccessResponse(), h.serv.UpdateCommentModerationConfig(ctx, domain.CommentModerationConfig{
+		AutoApprove:     req.AutoApprove,
+		BlockedKeywords: keywords,
+		BlockedPatterns: patterns,
+		MaxLinks:        req.MaxLinks,
+		DuplicateWindow: req.DuplicateWindow,
+		RateLimitWindow: req.RateLimitWindow,
+		IpRateLimit:     req.IpRateLimit,
+		EmailRateLimit:  req.EmailRateLimit,
+	})
+}
+
+func (h *WebsiteConfigHandler) AdminGetAkismetConfig(ctx *gin.Context) (*apiwrap.ResponseBody[AkismetConfigVO], error) {
+	config, err := h.serv.GetAkismetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return apiwrap.SuccessResponseWithData(AkismetConfigVO{
+		Enabled:   config.Enabled,
+		Endpoint:  config.Endpoint,
+		HasApiKey: config.ApiKey != "",
+	}), nil
+}
+
+func (h *WebsiteConfigHandler) AdminUpdateAkismetConfig(ctx *gin.Context, req UpdateAkismetConfigReq) (*apiwrap.ResponseBody[any], error) {
+	err := h.serv.UpdateAkismetConfig(ctx, domain.AkismetConfig{
+		Enabled:  req.Enabled,
+		Endpoint: strings.TrimRight(req.Endpoint, "/"),
+		ApiKey:   strings.TrimSpace(req.ApiKey),
+	})
+	if errors.Is(err, service.ErrAkismetApiKeyRequired) {
+		return nil, apiwrap.NewErrorResponseBody(http.StatusBadRequest, err.Error())
+	}
+	return apiwrap.SuccessResponse(), err
+}
+
+// trimAndCompact 去除首尾空白并丢弃空字符串
+func trimAndCompact(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
 func (h *WebsiteConfigHandler) AdminAddRecordInWebsiteConfig(ctx *gin.Context, req AddRecordInWebsiteConfig) (*apiwrap.ResponseBody[any], error) {
 	return apiwrap.SuccessResponse(), h.serv.AddRecordInWebsiteConfig(ctx, req.Record)
 }
@@ -438,21 +533,6 @@ func (h *WebsiteConfigHandler) AdminDeleteSocialConfig(ctx *gin.Context) (*apiwr
 	return apiwrap.SuccessResponse(), h.serv.DeleteSocialInfo(ctx, id)
 }
 
-func (h *WebsiteConfigHandler) AdminLogin(ctx *gin.Context, req LoginRequest) (*apiwrap.ResponseBody[LoginVO], error) {
-	adminConfig, err := h.serv.GetAdminConfig(ctx)
-	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
-		return nil, err
-	}
-	if adminConfig == nil || adminConfig.Username != req.Username || adminConfig.Password != req.Password {
-		return nil, *apiwrap.NewResponseBody[any](40101, "username or password is incorrect", nil)
-	}
-	jwt, exp, err := jwtutil.GenerateJwt()
-	if err != nil {
-		return nil, err
-	}
-	return apiwrap.SuccessResponseWithData(LoginVO{Token: jwt, Expiration: exp}), nil
-}
-
 func (h *WebsiteConfigHandler) GetInitStatus(_ *gin.Context) (*apiwrap.ResponseBody[map[string]bool], error) {
 	return apiwrap.SuccessResponseWithData(map[string]bool{
 		"initStatus": global.IsWebsiteInitialized(),
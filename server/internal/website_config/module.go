@@ -15,15 +15,26 @@
 package website_config
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/website_config/internal/domain"
 	"github.com/chenmingyong0423/fnote/server/internal/website_config/internal/service"
 	"github.com/chenmingyong0423/fnote/server/internal/website_config/internal/web"
 )
 
 type (
-	Handler = web.WebsiteConfigHandler
-	Service = service.IWebsiteConfigService
-	Module  struct {
+	Handler                 = web.WebsiteConfigHandler
+	Service                 = service.IWebsiteConfigService
+	CommentModerationConfig = domain.CommentModerationConfig
+	AkismetConfig           = domain.AkismetConfig
+	EmailConfig             = domain.EmailConfig
+	TwoFactorConfig         = domain.TwoFactorConfig
+	TwoFactorEnrollment     = domain.TwoFactorEnrollment
+	Module                  struct {
 		Svc Service
 		Hdl *Handler
 	}
 )
+
+var (
+	ErrInvalidTwoFactorCode = service.ErrInvalidTwoFactorCode
+	ErrTwoFactorLocked      = service.ErrTwoFactorLocked
+)
@@ -83,10 +83,12 @@ func initViper(cfgPath string) error {
 
 func bindEnv() error {
 	envBindings := map[string]string{
-		"mongodb.username":    "MONGODB_USERNAME",
-		"mongodb.password":    "MONGODB_PASSWORD",
-		"mongodb.auth_source": "MONGODB_AUTH_SOURCE",
-		"mongodb.database":    "MONGODB_DATABASE",
+		"mongodb.username":             "MONGODB_USERNAME",
+		"mongodb.password":             "MONGODB_PASSWORD",
+		"mongodb.auth_source":          "MONGODB_AUTH_SOURCE",
+		"mongodb.database":             "MONGODB_DATABASE",
+		"jwt.secret":                   "JWT_SECRET",
+		"backup.encryption.passphrase": "BACKUP_PASSPHRASE",
 	}
 
 	for key, env := range envBindings {
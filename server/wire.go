@@ -17,9 +17,13 @@
 package main
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/account"
 	"github.com/chenmingyong0423/fnote/server/internal/aggregate_post"
+	"github.com/chenmingyong0423/fnote/server/internal/akismet"
 	"github.com/chenmingyong0423/fnote/server/internal/asset"
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log"
 	"github.com/chenmingyong0423/fnote/server/internal/backup"
+	"github.com/chenmingyong0423/fnote/server/internal/blog_import"
 	"github.com/chenmingyong0423/fnote/server/internal/category"
 	"github.com/chenmingyong0423/fnote/server/internal/comment"
 	"github.com/chenmingyong0423/fnote/server/internal/count_stats"
@@ -35,9 +39,11 @@ import (
 	"github.com/chenmingyong0423/fnote/server/internal/post_draft"
 	"github.com/chenmingyong0423/fnote/server/internal/post_index"
 	"github.com/chenmingyong0423/fnote/server/internal/post_like"
+	"github.com/chenmingyong0423/fnote/server/internal/post_markdown"
 	"github.com/chenmingyong0423/fnote/server/internal/post_visit"
 	"github.com/chenmingyong0423/fnote/server/internal/tag"
 	"github.com/chenmingyong0423/fnote/server/internal/visit_log"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook"
 	"github.com/chenmingyong0423/fnote/server/internal/website_config"
 	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
@@ -86,10 +92,23 @@ func initializeApp() (*gin.Engine, error) {
 		visit_log.InitVisitLogModule,
 		wire.FieldsOf(new(*visit_log.Module), "Hdl"),
 		message.InitMessageModule,
+		wire.FieldsOf(new(*message.Module), "Hdl"),
 		email.InitEmailModule,
+		wire.FieldsOf(new(*email.Module), "Hdl"),
+		webhook.InitWebhookModule,
+		wire.FieldsOf(new(*webhook.Module), "Hdl"),
+		akismet.InitAkismetModule,
 		backup.InitBackupModule,
 		wire.FieldsOf(new(*backup.Module), "Hdl"),
 		asset.InitAssetModule,
 		wire.FieldsOf(new(*asset.Module), "Hdl"),
+		account.InitAccountModule,
+		wire.FieldsOf(new(*account.Module), "Svc", "Hdl"),
+		audit_log.InitAuditLogModule,
+		wire.FieldsOf(new(*audit_log.Module), "Hdl"),
+		post_markdown.InitPostMarkdownModule,
+		wire.FieldsOf(new(*post_markdown.Module), "Hdl"),
+		blog_import.InitBlogImportModule,
+		wire.FieldsOf(new(*blog_import.Module), "Hdl"),
 	))
 }
@@ -7,9 +7,13 @@
 package main
 
 import (
+	"github.com/chenmingyong0423/fnote/server/internal/account"
 	"github.com/chenmingyong0423/fnote/server/internal/aggregate_post"
+	"github.com/chenmingyong0423/fnote/server/internal/akismet"
 	"github.com/chenmingyong0423/fnote/server/internal/asset"
+	"github.com/chenmingyong0423/fnote/server/internal/audit_log"
 	"github.com/chenmingyong0423/fnote/server/internal/backup"
+	"github.com/chenmingyong0423/fnote/server/internal/blog_import"
 	"github.com/chenmingyong0423/fnote/server/internal/category"
 	"github.com/chenmingyong0423/fnote/server/internal/comment"
 	"github.com/chenmingyong0423/fnote/server/internal/count_stats"
@@ -25,9 +29,11 @@ import (
 	"github.com/chenmingyong0423/fnote/server/internal/post_draft"
 	"github.com/chenmingyong0423/fnote/server/internal/post_index"
 	"github.com/chenmingyong0423/fnote/server/internal/post_like"
+	"github.com/chenmingyong0423/fnote/server/internal/post_markdown"
 	"github.com/chenmingyong0423/fnote/server/internal/post_visit"
 	"github.com/chenmingyong0423/fnote/server/internal/tag"
 	"github.com/chenmingyong0423/fnote/server/internal/visit_log"
+	"github.com/chenmingyong0423/fnote/server/internal/webhook"
 	"github.com/chenmingyong0423/fnote/server/internal/website_config"
 	"github.com/gin-gonic/gin"
 )
@@ -41,16 +47,17 @@ func initializeApp() (*gin.Engine, error) {
 	fileHandler := module.Hdl
 	categoryModule := category.InitCategoryModule(database, eventBus)
 	categoryHandler := categoryModule.Hdl
-	emailModule := email.InitEmailModule(database)
-	message_templateModule := message_template.InitMessageTemplateModule(database)
 	website_configModule := website_config.InitWebsiteConfigModule(database)
-	messageModule := message.InitMessageModule(emailModule, message_templateModule, website_configModule)
+	emailModule := email.InitEmailModule(database, website_configModule)
+	message_templateModule := message_template.InitMessageTemplateModule(database)
+	messageModule := message.InitMessageModule(database, emailModule, message_templateModule, website_configModule)
 	post_likeModule := post_like.InitPostLikeModule(database)
 	postModule := post.InitPostModule(database, website_configModule, post_likeModule, eventBus)
-	commentModule := comment.InitCommentModule(database, messageModule, website_configModule, postModule, eventBus)
+	akismetModule := akismet.InitAkismetModule(website_configModule)
+	commentModule := comment.InitCommentModule(database, messageModule, website_configModule, postModule, akismetModule, eventBus)
 	commentHandler := commentModule.Hdl
 	websiteConfigHandler := website_configModule.Hdl
-	friendModule := friend.InitFriendModule(database, messageModule, website_configModule)
+	friendModule := friend.InitFriendModule(database, messageModule, website_configModule, akismetModule)
 	friendHandler := friendModule.Hdl
 	postHandler := postModule.Hdl
 	visit_logModule := visit_log.InitVisitLogModule(database, eventBus)
@@ -69,7 +76,12 @@ func initializeApp() (*gin.Engine, error) {
 	if err != nil {
 		return nil, err
 	}
-	v2 := ioc.InitMiddlewares(writer, v)
+	accountModule := account.InitAccountModule(database, website_configModule)
+	accountHandler := accountModule.Hdl
+	iAccountService := accountModule.Svc
+	auditLogModule := audit_log.InitAuditLogModule(database)
+	auditLogHandler := auditLogModule.Hdl
+	v2 := ioc.InitMiddlewares(writer, v, accountHandler, iAccountService, auditLogHandler)
 	validators := ioc.InitGinValidators()
 	post_indexModule := post_index.InitPostIndexModule(website_configModule, categoryModule, tagModule, postModule, module)
 	postIndexHandler := post_indexModule.Hdl
@@ -82,7 +94,15 @@ func initializeApp() (*gin.Engine, error) {
 	postVisitHandler := post_visitModule.Hdl
 	assetModule := asset.InitAssetModule(database)
 	assetHandler := assetModule.Hdl
-	engine, err := ioc.NewGinEngine(fileHandler, categoryHandler, commentHandler, websiteConfigHandler, friendHandler, postHandler, visitLogHandler, messageTemplateHandler, tagHandler, dataAnalysisHandler, countStatsHandler, backupHandler, v2, validators, postIndexHandler, postDraftHandler, aggregatePostHandler, postLikeHandler, postVisitHandler, assetHandler)
+	post_markdownModule := post_markdown.InitPostMarkdownModule(postModule, categoryModule, tagModule, module)
+	postMarkdownHandler := post_markdownModule.Hdl
+	blog_importModule := blog_import.InitBlogImportModule(postModule, categoryModule, tagModule, commentModule, module)
+	blogImportHandler := blog_importModule.Hdl
+	messageHandler := messageModule.Hdl
+	emailHandler := emailModule.Hdl
+	webhookModule := webhook.InitWebhookModule(database, eventBus)
+	webhookHandler := webhookModule.Hdl
+	engine, err := ioc.NewGinEngine(fileHandler, categoryHandler, commentHandler, websiteConfigHandler, friendHandler, postHandler, visitLogHandler, messageTemplateHandler, tagHandler, dataAnalysisHandler, countStatsHandler, backupHandler, v2, validators, postIndexHandler, postDraftHandler, aggregatePostHandler, postLikeHandler, postVisitHandler, assetHandler, accountHandler, auditLogHandler, postMarkdownHandler, blogImportHandler, messageHandler, emailHandler, webhookHandler)
 	if err != nil {
 		return nil, err
 	}